@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/output"
+	"github.com/projectdiscovery/tlsx/pkg/report"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// runConvert re-renders saved json results into another output format
+func runConvert(args []string) error {
+	var input, format, destination string
+	options := &clients.Options{}
+
+	flagSet := flag.NewFlagSet("convert", flag.ExitOnError)
+	flagSet.StringVar(&input, "i", "", "json results file to convert")
+	flagSet.StringVar(&format, "of", output.FormatStandard, "output format (standard, json, plain, table, csv, html)")
+	flagSet.StringVar(&destination, "o", output.DestinationStdout, "file to write output to (default stdout)")
+	// display flags are accepted with their long and short names as in a scan
+	display := func(value *bool, name, short, usage string) {
+		flagSet.BoolVar(value, name, false, usage)
+		if short != "" {
+			flagSet.BoolVar(value, short, false, usage)
+		}
+	}
+	display(&options.SAN, "san", "", "display subject alternative names")
+	display(&options.CN, "cn", "", "display subject common names")
+	display(&options.SO, "so", "", "display subject organization name")
+	display(&options.TLSVersion, "tls-version", "tv", "display used tls version")
+	display(&options.Cipher, "cipher", "", "display used cipher")
+	display(&options.Expired, "expired", "ex", "display validity status of certificate")
+	display(&options.SelfSigned, "self-signed", "ss", "display status of self-signed certificate")
+	display(&options.KeyType, "key-type", "kt", "display public key type and size")
+	display(&options.WeakKey, "weak-key", "wk", "display status of weak public key")
+	display(&options.SignatureAlgorithm, "signature-algorithm", "sigalg", "display signature algorithms of the presented chain")
+	display(&options.WeakSignature, "weak-signature", "wsig", "display presented certificates with md5 or sha1 signatures")
+	display(&options.ChainValidation, "chain-validation", "cv", "display chain validation problems")
+	display(&options.HostnameCoverage, "hostname-coverage", "hcov", "display coverage of input hostname by certificate names")
+	display(&options.DefaultCertificate, "default-cert", "dc", "display certificate presented without sni and whether it differs")
+	display(&options.Attribute, "attribute", "", "display only certificate names attributed to the scanned organization")
+	display(&options.Hosting, "hosting", "", "display hosting panel and shared hosting classification of certificate")
+	flagSet.StringVar(&options.Hash, "hash", "", "display certificate fingerprint hashes (md5,sha1,sha256,tlsh)")
+	display(&options.PinSHA256, "pin-sha256", "", "display spki pin-sha256 of certificate")
+	display(&options.JARM, "jarm", "", "display jarm fingerprint of server")
+	display(&options.MatchFingerprint, "match-fingerprint", "mf", "display matched known infrastructure fingerprints")
+	display(&options.OCSP, "ocsp", "", "display stapled ocsp response status of certificate")
+	display(&options.ACME, "acme", "", "display acme tls-alpn-01 challenge endpoints")
+	display(&options.EarlyData, "early-data", "ed", "display tls 1.3 early data acceptance on resumption")
+	display(&options.Renegotiation, "renegotiation", "reneg", "display secure and client-initiated renegotiation support")
+	display(&options.Compression, "compression", "comp", "display tls compression acceptance")
+	display(&options.DHParams, "dh-params", "dhp", "display dh prime size of dhe suites")
+	display(&options.FallbackSCSV, "fallback-scsv", "fscsv", "display tls_fallback_scsv downgrade protection support")
+	display(&options.ExtendedMasterSecret, "extended-master-secret", "ems", "display extended_master_secret support")
+	display(&options.ECH, "ech", "", "display encrypted client hello support")
+	display(&options.PostQuantum, "post-quantum", "pq", "display post-quantum hybrid key exchange support")
+	display(&options.BrokerConfirm, "broker-confirm", "bc", "display confirmed mqtt and amqp brokers")
+	display(&options.HTTP2Confirm, "http2-confirm", "h2", "display confirmed http/2 and grpc services")
+	display(&options.CertificateRequest, "cert-request", "cr", "display client certificate request mode and acceptable ca names")
+	display(&options.CipherEnum, "cipher-enum", "ce", "display accepted cipher suites per tls version")
+	display(&options.GroupEnum, "group-enum", "ge", "display accepted key exchange groups per tls version")
+	display(&options.SignatureEnum, "signature-enum", "sge", "display accepted signature algorithms per tls version")
+	display(&options.ALPNEnum, "alpn-enum", "ae", "display application protocols selected by the server")
+	display(&options.RespOnly, "resp-only", "ro", "display tls response only")
+	display(&options.NoColor, "no-color", "nc", "disable colors in cli output")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if input == "" {
+		return errors.New("no input results file provided")
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		return errors.Wrap(err, "could not open results file")
+	}
+	defer file.Close()
+
+	writer, err := output.NewFormatWriter(format, destination, options)
+	if err != nil {
+		return errors.Wrapf(err, "could not create %s output", format)
+	}
+	var count int
+	err = report.StreamAllResults(file, func(response *clients.Response) {
+		if err := writer.Write(response); err != nil {
+			gologger.Warning().Msgf("Could not write result for %s: %s", response.Host, err)
+			return
+		}
+		count++
+	})
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	gologger.Info().Msgf("Converted %d results to %s", count, format)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ct"
+)
+
+// runCTUpdate downloads the chrome and apple ct log lists used by the
+// ct probe
+func runCTUpdate(args []string) error {
+	var chromeURL, appleURL, chromeOutput, appleOutput string
+	var timeout int
+
+	flagSet := flag.NewFlagSet("ct-update", flag.ExitOnError)
+	flagSet.StringVar(&chromeURL, "url", ct.LogListURL, "url of the chrome log list in v3 schema")
+	flagSet.StringVar(&appleURL, "apple-url", ct.AppleLogListURL, "url of the apple log list in v3 schema")
+	flagSet.StringVar(&chromeOutput, "o", "", "file to write the chrome log list to (default user cache directory)")
+	flagSet.StringVar(&appleOutput, "apple-o", "", "file to write the apple log list to (default user cache directory)")
+	flagSet.IntVar(&timeout, "timeout", 30, "timeout in seconds for the download")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+
+	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	for _, update := range []struct {
+		browser, url, output string
+	}{
+		{ct.LogListChrome, chromeURL, chromeOutput},
+		{ct.LogListApple, appleURL, appleOutput},
+	} {
+		output := update.output
+		if output == "" {
+			var err error
+			if output, err = ct.LogListPath(update.browser); err != nil {
+				return err
+			}
+		}
+		list, err := ct.UpdateLogList(client, update.url, output)
+		if err != nil {
+			return errors.Wrapf(err, "could not update %s log list", update.browser)
+		}
+		gologger.Info().Msgf("Wrote %s log list version %s (%s) with %d logs to %s", update.browser, list.Version, list.Timestamp, list.Len(), output)
+	}
+	return nil
+}
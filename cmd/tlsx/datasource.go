@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/datasource"
+)
+
+// runDatasource serves saved json results as a grafana datasource
+func runDatasource(args []string) error {
+	var input, listen string
+
+	flagSet := flag.NewFlagSet("datasource", flag.ExitOnError)
+	flagSet.StringVar(&input, "i", "", "json results file to serve")
+	flagSet.StringVar(&listen, "listen", "127.0.0.1:8181", "address to listen on")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if input == "" {
+		return errors.New("no input results file provided")
+	}
+
+	gologger.Info().Msgf("Serving results from %s on http://%s", input, listen)
+	return datasource.New(input).ListenAndServe(listen)
+}
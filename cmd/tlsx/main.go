@@ -1,6 +1,10 @@
 package main
 
 import (
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/pkg/errors"
 	"github.com/projectdiscovery/goflags"
 	"github.com/projectdiscovery/gologger"
@@ -13,7 +17,26 @@ var (
 	options = &clients.Options{}
 )
 
+// subcommands are the commands which can be run instead of a scan
+var subcommands = map[string]func(args []string) error{
+	"report":      runReport,
+	"datasource":  runDatasource,
+	"shared-keys": runSharedKeys,
+	"ct-update":   runCTUpdate,
+	"convert":     runConvert,
+	"watch":       runWatch,
+	"snapshot":    runSnapshot,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			if err := subcommand(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not run %s: %s", os.Args[1], err)
+			}
+			return
+		}
+	}
 	if err := process(); err != nil {
 		gologger.Fatal().Msgf("Could not process: %s", err)
 	}
@@ -30,7 +53,21 @@ func process() error {
 	if runner == nil {
 		return nil
 	}
+	// outputs are only committed on close, so close on interrupt as well
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		gologger.Info().Msgf("Interrupted, writing outputs")
+		signal.Stop(signals)
+		if err := runner.Close(); err != nil {
+			gologger.Error().Msgf("Could not close runner: %s", err)
+		}
+		os.Exit(1)
+	}()
 	if err := runner.Execute(); err != nil {
+		// outputs written before the error are kept
+		_ = runner.Close()
 		return errors.Wrap(err, "could not execute runner")
 	}
 	if err := runner.Close(); err != nil {
@@ -46,12 +83,28 @@ func readFlags() error {
 	flagSet.CreateGroup("input", "Input",
 		flagSet.StringSliceVarP(&options.Inputs, "host", "u", []string{}, "target host to scan (-u INPUT1,INPUT2)", goflags.CommaSeparatedStringSliceOptions),
 		flagSet.StringVarP(&options.InputList, "list", "l", "", "target list to scan (-l INPUT_FILE)"),
+		flagSet.BoolVar(&options.DryRun, "dry-run", false, "display the final target list and count without connecting"),
+		flagSet.StringVar(&options.Sample, "sample", "", "scan a sample of targets as a percentage (1%) or interval (1/100)"),
+		flagSet.IntVar(&options.SampleSeed, "sample-seed", 1, "seed for deterministic target sampling"),
+		flagSet.BoolVar(&options.Shuffle, "shuffle", false, "scan targets in random order spreading each /24 (/56 for ipv6) over the scan (buffers all targets in memory)"),
+		flagSet.IntVar(&options.ShuffleSeed, "shuffle-seed", 1, "seed for deterministic target shuffling"),
+		flagSet.StringVar(&options.RetryFile, "retry-file", "", "retry file of failed targets from a previous scan to scan"),
 		flagSet.StringSliceVarP(&options.Ports, "port", "p", nil, "target port to connect (default 443)", goflags.FileCommaSeparatedStringSliceOptions),
+		flagSet.StringVarP(&options.DiscoverPorts, "discover-ports", "dp", "", "probe a port list on each responsive host to discover companion tls services (top100-tls)"),
 	)
 
 	flagSet.CreateGroup("scan-mode", "SCAN-MODE",
-		flagSet.StringVarP(&options.ScanMode, "scan-mode", "sm", "", "tls connection mode to use (ctls, ztls, auto) (default ctls)"),
-		flagSet.BoolVarP(&options.CertsOnly, "pre-handshake", "ps", false, "enable pre-handshake tls connection (early termination) using ztls"),
+		flagSet.StringVarP(&options.ScanMode, "scan-mode", "sm", "", "tls connection mode to use (ctls, ztls, openssl, quic, auto) (default ctls)"),
+		flagSet.StringVar(&options.OpenSSLBinary, "openssl-binary", "", "path to openssl binary for openssl scan mode (default openssl)"),
+		flagSet.BoolVarP(&options.CertsOnly, "pre-handshake", "ps", false, "enable pre-handshake tls connection (early termination after certificate message)"),
+		flagSet.BoolVar(&options.QUIC, "quic", false, "probe tls over quic (http/3) on udp port"),
+		flagSet.BoolVarP(&options.CheckOnly, "check-only", "co", false, "only check if host speaks tls (no certificate parsing)"),
+		flagSet.StringVar(&options.StartTLS, "starttls", "", "starttls protocol to negotiate before handshake (smtp,ftp,imap,pop3,ldap,xmpp,postgres,mysql,rdp)"),
+		flagSet.BoolVarP(&options.AutoStartTLS, "auto-starttls", "ast", false, "negotiate starttls protocol detected from port (21,25,587,143,110,389,5222,5432,3306,3389)"),
+		flagSet.StringSliceVarP(&options.StartTLSPorts, "starttls-port", "stp", nil, "starttls protocol to negotiate for a port (-stp 2525:smtp,3390:rdp)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.BoolVarP(&options.DetectService, "detect-service", "ds", false, "detect plaintext service from banner for failed tls handshakes"),
+		flagSet.BoolVarP(&options.DetectDuplicates, "detect-duplicates", "dd", false, "flag serial numbers and public keys shared across unrelated subjects"),
+		flagSet.BoolVarP(&options.SNIMatrix, "sni-matrix", "snim", false, "write certificate fingerprint per sni record for ips tested with multiple snis"),
 	)
 
 	flagSet.CreateGroup("probes", "Probes",
@@ -61,31 +114,129 @@ func readFlags() error {
 		flagSet.BoolVarP(&options.TLSVersion, "tls-version", "tv", false, "display used tls version"),
 		flagSet.BoolVar(&options.Cipher, "cipher", false, "display used cipher"),
 		flagSet.BoolVarP(&options.Expired, "expired", "ex", false, "display validity status of certificate"),
+		flagSet.StringVarP(&options.ExpiringWithin, "expiring-within", "ew", "", "display certificates expiring within window (30d, 2w, 12h)"),
+		flagSet.BoolVarP(&options.ExpiringOnly, "expiring-only", "eo", false, "only write results expiring within window or expired"),
 		flagSet.BoolVarP(&options.SelfSigned, "self-signed", "ss", false, "display status of self-signed certificate"),
-		flagSet.StringVar(&options.Hash, "hash", "", "display certificate fingerprint hashes (md5,sha1,sha256)"),
+		flagSet.BoolVarP(&options.KeyType, "key-type", "kt", false, "display public key type and size (RSA-2048, ECDSA-P256, Ed25519)"),
+		flagSet.BoolVarP(&options.WeakKey, "weak-key", "wk", false, "display status of weak public key (rsa < 2048, ecdsa < 256)"),
+		flagSet.BoolVarP(&options.WeakKeyOnly, "weak-key-only", "wko", false, "only write results with weak public keys"),
+		flagSet.BoolVarP(&options.SignatureAlgorithm, "signature-algorithm", "sigalg", false, "display signature algorithms of the presented chain"),
+		flagSet.BoolVarP(&options.WeakSignature, "weak-signature", "wsig", false, "display presented certificates with md5 or sha1 signatures"),
+		flagSet.BoolVarP(&options.ChainValidation, "chain-validation", "cv", false, "display chain validation problems (untrusted-root, incomplete-chain, expired, wrong-order, hostname-mismatch)"),
+		flagSet.BoolVar(&options.AIAFetch, "aia-fetch", false, "fetch missing issuers of incomplete chains from aia ca issuers urls with chain-validation"),
+		flagSet.StringSliceVar(&options.Revocation, "revocation", nil, "display revocation status of certificate queried from its issuer (ocsp,crl)", goflags.NormalizedStringSliceOptions),
+		flagSet.StringVar(&options.CRLCacheDir, "crl-cache-dir", "", "directory to cache downloaded crls in (default user cache directory)"),
+		flagSet.BoolVar(&options.CT, "ct", false, "display signed certificate timestamps and chrome/apple ct policy compliance"),
+		flagSet.BoolVar(&options.CTSearch, "ct-search", false, "display other certificates recently logged in ct for the hostname (crt.sh)"),
+		flagSet.StringVar(&options.CTSearchURL, "ct-search-url", "", "crt.sh compatible json search url the hostname is appended to (default https://crt.sh/?output=json&q=)"),
+		flagSet.IntVar(&options.CTSearchDays, "ct-search-days", 30, "number of days before the scan to search logged certificates in"),
+		flagSet.StringVar(&options.CTLogList, "ct-log-list", "", "chrome ct log list file in v3 schema (default list downloaded by ct-update or embedded)"),
+		flagSet.StringVar(&options.CTAppleLogList, "ct-apple-log-list", "", "apple ct log list file in v3 schema (default list downloaded by ct-update or embedded)"),
+		flagSet.BoolVarP(&options.HostnameCoverage, "hostname-coverage", "hcov", false, "display coverage of input hostname by certificate names (san, wildcard-san, cn, none)"),
+		flagSet.BoolVarP(&options.DefaultCertificate, "default-cert", "dc", false, "display certificate presented without sni and whether it differs"),
+		flagSet.BoolVar(&options.Attribute, "attribute", false, "display only certificate names attributed to the scanned organization (scope, whois, ct logs)"),
+		flagSet.StringSliceVarP(&options.AttributionScope, "attribute-scope", "as", nil, "in scope domains for name attribution (file or comma separated)", goflags.FileCommaSeparatedStringSliceOptions),
+		flagSet.BoolVar(&options.Hosting, "hosting", false, "display hosting panel (cpanel, plesk, directadmin, webmin) and shared hosting classification of certificate"),
+		flagSet.StringVar(&options.Hash, "hash", "", "display certificate fingerprint hashes (md5,sha1,sha256,tlsh)"),
+		flagSet.BoolVar(&options.PinSHA256, "pin-sha256", false, "display spki pin-sha256 of certificate"),
+		flagSet.BoolVar(&options.JARM, "jarm", false, "display jarm fingerprint of server (10 handshakes)"),
+		flagSet.BoolVarP(&options.MatchFingerprint, "match-fingerprint", "mf", false, "display matched known infrastructure fingerprints"),
+		flagSet.BoolVar(&options.OCSP, "ocsp", false, "display stapled ocsp response status of certificate"),
+		flagSet.BoolVar(&options.ACME, "acme", false, "display acme tls-alpn-01 challenge endpoints"),
+		flagSet.BoolVarP(&options.EarlyData, "early-data", "ed", false, "display tls 1.3 early data (0-rtt) acceptance on resumption (requires openssl)"),
+		flagSet.BoolVarP(&options.Compression, "compression", "comp", false, "display tls compression acceptance (crime)"),
+		flagSet.BoolVarP(&options.DHParams, "dh-params", "dhp", false, "display dh prime size of dhe suites (logjam)"),
+		flagSet.BoolVarP(&options.FallbackSCSV, "fallback-scsv", "fscsv", false, "display tls_fallback_scsv downgrade protection support"),
+		flagSet.BoolVar(&options.SIPSRV, "sip-srv", false, "scan sips servers of domain inputs from _sips._tcp srv records and validate sip domain certificates"),
+		flagSet.BoolVarP(&options.CertificateRequest, "cert-request", "cr", false, "display client certificate request mode and acceptable ca names"),
+		flagSet.BoolVarP(&options.HTTP2Confirm, "http2-confirm", "h2", false, "confirm http/2 and grpc services negotiating h2 with a connection preface and grpc request"),
+		flagSet.BoolVarP(&options.BrokerConfirm, "broker-confirm", "bc", false, "confirm mqtt (8883) and amqp (5671) brokers with a protocol opening after the handshake"),
+		flagSet.BoolVarP(&options.PostQuantum, "post-quantum", "pq", false, "display post-quantum hybrid key exchange support (X25519MLKEM768, X25519Kyber768)"),
+		flagSet.BoolVar(&options.ECH, "ech", false, "display encrypted client hello support from https dns records and grease ech tolerance"),
+		flagSet.BoolVarP(&options.ExtendedMasterSecret, "extended-master-secret", "ems", false, "display extended_master_secret support of tls 1.0 - 1.2"),
+		flagSet.BoolVarP(&options.Renegotiation, "renegotiation", "reneg", false, "display secure and client-initiated renegotiation support (requires openssl)"),
+		flagSet.BoolVarP(&options.CipherEnum, "cipher-enum", "ce", false, "enumerate accepted cipher suites per tls version with server preference order"),
+		flagSet.BoolVarP(&options.GroupEnum, "group-enum", "ge", false, "enumerate accepted key exchange groups per tls version with server preference order"),
+		flagSet.BoolVarP(&options.SignatureEnum, "signature-enum", "sge", false, "enumerate accepted signature algorithms per tls version with server preference order"),
+		flagSet.BoolVarP(&options.ALPNEnum, "alpn-enum", "ae", false, "enumerate application protocols selected by the server (-alpn or default list)"),
+	)
+
+	flagSet.CreateGroup("vulnerabilities", "Vulnerabilities",
+		flagSet.BoolVar(&options.Heartbleed, "heartbleed", false, "check for heartbleed (cve-2014-0160) on tls 1.0-1.2"),
+		flagSet.BoolVar(&options.ROBOT, "robot", false, "check for robot rsa padding oracle on servers offering rsa key exchange"),
+		flagSet.BoolVar(&options.Ticketbleed, "ticketbleed", false, "check for ticketbleed memory leak in session ids of resumed session tickets"),
+		flagSet.BoolVar(&options.DROWN, "drown", false, "check for drown exposure of servers supporting sslv2"),
+		flagSet.BoolVar(&options.POODLE, "poodle", false, "check for poodle exposure of servers negotiating cbc suites over sslv3"),
+		flagSet.BoolVar(&options.FREAK, "freak", false, "check for freak exposure of servers accepting export grade rsa or dhe suites"),
 	)
 
 	flagSet.CreateGroup("configs", "Configurations",
 		flagSet.StringVar(&cfgFile, "config", "", "path to the tlsx configuration file"),
 		flagSet.StringSliceVarP(&options.Resolvers, "resolvers", "r", nil, "list of resolvers to use", goflags.FileCommaSeparatedStringSliceOptions),
+		flagSet.StringVar(&options.Proxy, "proxy", "", "http proxy to tunnel connections through with basic, ntlm or negotiate auth (http://[domain\\user:pass@]host:port)"),
 		flagSet.StringVarP(&options.CACertificate, "cacert", "cc", "", "client certificate authority file"),
+		flagSet.StringVar(&options.CAFile, "ca-file", "", "pem bundle or der file of roots to validate chains against instead of system roots"),
+		flagSet.StringVar(&options.CADir, "ca-dir", "", "directory of root files to validate chains against instead of system roots"),
+		flagSet.StringSliceVarP(&options.TrustStoreFiles, "trust-store", "ts", nil, "named trust store (mozilla=roots.pem, java=dir, system) to report chain trust in, one per store", goflags.StringSliceOptions),
+		flagSet.StringVar(&options.ClientCertificate, "client-cert", "", "client certificate pem file for mtls"),
+		flagSet.StringVar(&options.ClientKey, "client-key", "", "client certificate private key pem file for mtls"),
+		flagSet.StringVar(&options.ClientPKCS12, "client-pkcs12", "", "pkcs12 client certificate file for mtls"),
+		flagSet.StringVar(&options.ClientPKCS12Password, "client-pkcs12-password", "", "password of the pkcs12 client certificate file"),
+		flagSet.StringVar(&options.ClientPKCS11, "client-pkcs11", "", "pkcs11 uri of hardware token client certificate for mtls"),
+		flagSet.StringVar(&options.ClientCertStore, "client-cert-store", "", "windows certificate store client certificate for mtls ([location/]store/thumbprint)"),
+		flagSet.StringVar(&options.CACertStore, "cacert-store", "", "windows certificate store to load trust anchors from ([location/]store)"),
 		flagSet.StringSliceVarP(&options.Ciphers, "cipher-input", "ci", nil, "ciphers to use with tls connection", goflags.FileCommaSeparatedStringSliceOptions),
-		flagSet.StringVar(&options.ServerName, "sni", "", "tls sni hostname to use"),
+		flagSet.StringSliceVar(&options.ALPN, "alpn", nil, "application protocols to offer with tls connection (h2,http/1.1,h3)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringSliceVar(&options.ServerNames, "sni", nil, "tls sni hostnames to use, one connection per sni (file or comma separated)", goflags.FileCommaSeparatedStringSliceOptions),
 		flagSet.StringVar(&options.MinVersion, "min-version", "", "minimum tls version to accept (ssl30,tls10,tls11,tls12,tls13)"),
 		flagSet.StringVar(&options.MaxVersion, "max-version", "", "maximum tls version to accept (ssl30,tls10,tls11,tls12,tls13)"),
-		flagSet.BoolVarP(&options.TLSChain, "tls-chain", "tc", false, "display tls chain in json output"),
+		flagSet.BoolVarP(&options.TLSChain, "tls-chain", "tc", false, "display presented tls chain including leaf in json output"),
+		flagSet.StringVarP(&options.ChainVerbosity, "tls-chain-verbosity", "tcv", "", "verbosity of tls chain entries (full, brief)"),
 		flagSet.BoolVar(&options.VerifyServerCertificate, "verify-cert", false, "enable verification of server certificate"),
+		flagSet.StringVarP(&options.ValidationTime, "validation-time", "vt", "", "date to evaluate certificate validity at (2006-01-02 or rfc3339)"),
+		flagSet.BoolVarP(&options.CaptureHello, "capture-hello", "ch", false, "capture raw client and server hello in json output"),
+		flagSet.StringVarP(&options.FingerprintFile, "fingerprint-db", "fdb", "", "custom fingerprint database file to match with"),
+		flagSet.StringVar(&options.RootStoreFile, "root-store", "", "pem file of hypothetical root store to report endpoints which would become untrusted"),
+		flagSet.StringVar(&options.OIDFile, "oid-file", "", "json file mapping private oids to names for policies and extensions"),
+	)
+
+	flagSet.CreateGroup("metrics", "Metrics",
+		flagSet.StringVar(&options.StatsdAddress, "statsd", "", "statsd/dogstatsd address to emit scan metrics to (host:port)"),
+		flagSet.StringVar(&options.StatsdPrefix, "statsd-prefix", "tlsx", "prefix for emitted statsd metrics"),
+		flagSet.StringSliceVar(&options.StatsdTags, "statsd-tags", nil, "dogstatsd tags to add to emitted metrics (env:prod)", goflags.CommaSeparatedStringSliceOptions),
 	)
 
 	flagSet.CreateGroup("optimizations", "OPTIMIZATIONS",
 		flagSet.IntVarP(&options.Concurrency, "concurrency", "c", 300, "number of concurrent threads to process"),
+		flagSet.IntVarP(&options.MaxPerSubnet, "max-per-subnet", "mps", 0, "max number of concurrent connections per /24 (/56 for ipv6) subnet"),
 		flagSet.IntVar(&options.Timeout, "timeout", 5, "tls connection timeout in seconds"),
+		flagSet.StringVarP(&options.PreviousResults, "previous-results", "pr", "", "json results file from a previous scan to compare with"),
+		flagSet.BoolVarP(&options.RescanChangedOnly, "rescan-changed-only", "rco", false, "only run full scan for hosts whose certificate, chain, version, cipher or jarm changed since previous results"),
+		flagSet.BoolVar(&options.Preflight, "preflight", false, "validate dns, tls egress and clock skew before scanning"),
+		flagSet.StringVar(&options.PreflightTarget, "preflight-target", "www.cloudflare.com:443", "known-good host:port contacted for preflight checks (dns, one handshake and one https request)"),
 	)
 
 	flagSet.CreateGroup("output", "Output",
 		flagSet.StringVarP(&options.OutputFile, "output", "o", "", "file to write output to"),
+		flagSet.StringVar(&options.RetryOutput, "retry-output", "", "file to write failed targets with error categories to for retrying"),
+		flagSet.StringVarP(&options.InputReport, "input-report", "ir", "", "file to write skipped invalid input lines to as jsonl (default stderr)"),
+		flagSet.StringSliceVarP(&options.OutputTargets, "output-target", "ot", nil, "additional output as [policy:]format:destination (json:stdout, csv:inventory.csv, fail:json:https://hook), policy continue or fail", goflags.StringSliceOptions),
+		flagSet.BoolVar(&options.NoStdout, "no-stdout", false, "do not write results to stdout when writing to other outputs"),
+		flagSet.StringVar(&options.AuditCSV, "audit-csv", "", "file to write auditor certificate inventory csv to"),
+		flagSet.StringVarP(&options.ChainBundleDir, "chain-bundle-dir", "cbd", "", "directory to write one pem bundle per unique chain with a host index to"),
+		flagSet.StringVar(&options.ReportPDF, "report-pdf", "", "file to write pdf executive summary to at scan end"),
+		flagSet.StringVar(&options.ReportLocale, "report-locale", "", "locale (de, es, fr) or message catalog file to render pdf report with"),
+		flagSet.IntVar(&options.FlushEvery, "flush-every", 0, "flush stdout and output pipes every n results (default flushes stdout per result)"),
+		flagSet.BoolVar(&options.Unbuffered, "unbuffered", false, "flush stdout and output pipes after every result"),
+		flagSet.BoolVar(&options.Lock, "lock", false, "use a lock file to prevent concurrent scans writing the same output"),
+		flagSet.StringVar(&options.Package, "package", "", "zip archive to package results, stats, report and chain bundles into at scan end"),
+		flagSet.BoolVarP(&options.RemediationHints, "remediation-hints", "rh", false, "include findings with remediation hints in json output"),
+		flagSet.StringVarP(&options.RemediationFile, "remediation-file", "rf", "", "custom remediation hints file to use"),
+		flagSet.BoolVarP(&options.ExcludeHosting, "exclude-hosting", "eh", false, "exclude results with hosting panel or shared hosting certificates from output"),
 		flagSet.BoolVarP(&options.JSON, "json", "j", false, "display json format output"),
 		flagSet.BoolVarP(&options.RespOnly, "resp-only", "ro", false, "display tls response only"),
+		flagSet.BoolVar(&options.Plain, "plain", false, "display plain key=value output without colors"),
+		flagSet.BoolVar(&options.Table, "table", false, "display standard output aligned in columns"),
 		flagSet.BoolVar(&options.Silent, "silent", false, "display silent output"),
 		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable colors in cli output"),
 		flagSet.BoolVarP(&options.Verbose, "verbose", "v", false, "display verbose output"),
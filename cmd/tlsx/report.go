@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/findings"
+	"github.com/projectdiscovery/tlsx/pkg/report"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// runReport renders a pdf executive summary from saved json results
+func runReport(args []string) error {
+	var input, output, remediationFile, validationTime, expiringWithin, locale string
+
+	flagSet := flag.NewFlagSet("report", flag.ExitOnError)
+	flagSet.StringVar(&input, "i", "", "json results file to render report from")
+	flagSet.StringVar(&output, "o", "report.pdf", "pdf file to write report to")
+	flagSet.StringVar(&remediationFile, "remediation-file", "", "custom remediation hints file to use")
+	flagSet.StringVar(&validationTime, "validation-time", "", "date to evaluate certificate validity at (2006-01-02 or rfc3339)")
+	flagSet.StringVar(&expiringWithin, "expiring-within", "", "window to list certificates as expiring in (e.g. 14d, 2w, default 30d)")
+	flagSet.StringVar(&locale, "locale", "", "locale (de, es, fr) or message catalog file to render report with")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if input == "" {
+		return errors.New("no input results file provided")
+	}
+
+	var validationAt time.Time
+	if validationTime != "" {
+		parsed, err := clients.ParseValidationTime(validationTime)
+		if err != nil {
+			return errors.Wrap(err, "invalid validation-time")
+		}
+		validationAt = parsed
+	}
+
+	var window time.Duration
+	if expiringWithin != "" {
+		parsed, err := clients.ParseWindow(expiringWithin)
+		if err != nil {
+			return errors.Wrap(err, "invalid expiring-within")
+		}
+		window = parsed
+	}
+
+	reportLocale, err := report.LoadLocale(locale)
+	if err != nil {
+		return err
+	}
+	results, err := report.ReadResults(input)
+	if err != nil {
+		return err
+	}
+	database, err := findings.New(remediationFile)
+	if err != nil {
+		return err
+	}
+	if err := report.WritePDFFile(output, report.Summarize(results, database, validationAt, window), reportLocale); err != nil {
+		return err
+	}
+	gologger.Info().Msgf("Wrote report for %d results to %s", len(results), output)
+	return nil
+}
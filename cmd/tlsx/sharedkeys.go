@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/report"
+)
+
+// runSharedKeys reports public keys served by distinct organizations
+// from saved json results
+func runSharedKeys(args []string) error {
+	var input string
+	var threshold int
+	var jsonOutput bool
+
+	flagSet := flag.NewFlagSet("shared-keys", flag.ExitOnError)
+	flagSet.StringVar(&input, "i", "", "json results file to aggregate keys from")
+	flagSet.IntVar(&threshold, "threshold", report.DefaultSharedKeyThreshold, "minimum distinct organizations serving a key")
+	flagSet.BoolVar(&jsonOutput, "json", false, "display json format output")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if input == "" {
+		return errors.New("no input results file provided")
+	}
+
+	results, err := report.ReadResults(input)
+	if err != nil {
+		return err
+	}
+	sharedKeys := report.SharedKeys(results, threshold)
+	for _, key := range sharedKeys {
+		if jsonOutput {
+			data, err := jsoniter.Marshal(key)
+			if err != nil {
+				return errors.Wrap(err, "could not marshal shared key")
+			}
+			fmt.Fprintln(os.Stdout, string(data))
+			continue
+		}
+		line := fmt.Sprintf("%s [%d hosts] [%d organizations] [%d ranges]", key.PinSHA256, key.Hosts, len(key.Organizations), len(key.Ranges))
+		if len(key.Organizations) > 0 {
+			line += " [" + strings.Join(key.Organizations, ",") + "]"
+		}
+		fmt.Fprintln(os.Stdout, line)
+	}
+	gologger.Info().Msgf("Found %d shared keys in %d results", len(sharedKeys), len(results))
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/internal/runner"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/revocation"
+)
+
+// runSnapshot runs every probe against one endpoint and packages the
+// results, chains and report into an evidence bundle
+func runSnapshot(args []string) error {
+	var bundle string
+	snapshotOptions := &clients.Options{
+		Concurrency:  1,
+		JSON:         true,
+		NoStdout:     true,
+		ErrorResults: true,
+
+		SO:                   true,
+		TLSVersion:           true,
+		Cipher:               true,
+		Expired:              true,
+		SelfSigned:           true,
+		TLSChain:             true,
+		ChainValidation:      true,
+		AIAFetch:             true,
+		Revocation:           []string{revocation.MethodOCSP, revocation.MethodCRL},
+		CT:                   true,
+		HostnameCoverage:     true,
+		Hosting:              true,
+		DefaultCertificate:   true,
+		Hash:                 "md5,sha1,sha256",
+		PinSHA256:            true,
+		CaptureHello:         true,
+		JARM:                 true,
+		MatchFingerprint:     true,
+		DetectService:        true,
+		AutoStartTLS:         true,
+		ACME:                 true,
+		OCSP:                 true,
+		Compression:          true,
+		DHParams:             true,
+		FallbackSCSV:         true,
+		ExtendedMasterSecret: true,
+		ECH:                  true,
+		PostQuantum:          true,
+		BrokerConfirm:        true,
+		HTTP2Confirm:         true,
+		CertificateRequest:   true,
+		CipherEnum:           true,
+		GroupEnum:            true,
+		SignatureEnum:        true,
+		ALPNEnum:             true,
+		Heartbleed:           true,
+		ROBOT:                true,
+		Ticketbleed:          true,
+		DROWN:                true,
+		POODLE:               true,
+		FREAK:                true,
+		RemediationHints:     true,
+	}
+
+	// the endpoint may be given before or after the flags
+	var address string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		address, args = args[0], args[1:]
+	}
+	flagSet := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	flagSet.StringVar(&bundle, "o", "", "zip archive to write the evidence bundle to (default snapshot-host-port.zip)")
+	flagSet.StringVar(&snapshotOptions.ServerName, "sni", "", "tls sni hostname to use")
+	flagSet.IntVar(&snapshotOptions.Timeout, "timeout", 10, "tls connection timeout in seconds")
+	flagSet.StringVar(&snapshotOptions.OpenSSLBinary, "openssl-binary", "", "path to openssl binary for early data and renegotiation probes (default openssl)")
+	flagSet.BoolVar(&snapshotOptions.CTSearch, "ct-search", false, "search ct logs for other certificates of the hostname")
+	flagSet.BoolVar(&snapshotOptions.Verbose, "v", false, "display verbose output")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if address == "" {
+		address = flagSet.Arg(0)
+	}
+	if address == "" {
+		return errors.New("no endpoint provided")
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "443"
+	}
+	snapshotOptions.Inputs = []string{net.JoinHostPort(host, port)}
+	snapshotOptions.CTSearchDays = 30
+	if bundle == "" {
+		bundle = "snapshot-" + strings.NewReplacer(":", "-", "[", "", "]", "").Replace(host) + "-" + port + ".zip"
+	}
+
+	// early data and renegotiation are probed with the openssl binary
+	binary := snapshotOptions.OpenSSLBinary
+	if binary == "" {
+		binary = "openssl"
+	}
+	if _, err := exec.LookPath(binary); err == nil {
+		snapshotOptions.EarlyData = true
+		snapshotOptions.Renegotiation = true
+	} else {
+		gologger.Warning().Msgf("Skipping early data and renegotiation probes: %s", err)
+	}
+
+	directory, err := os.MkdirTemp("", "tlsx-snapshot-*")
+	if err != nil {
+		return errors.Wrap(err, "could not create snapshot directory")
+	}
+	defer os.RemoveAll(directory)
+	snapshotOptions.OutputFile = filepath.Join(directory, "results.jsonl")
+	snapshotOptions.ChainBundleDir = filepath.Join(directory, "chains")
+	snapshotOptions.ReportPDF = filepath.Join(directory, "report.pdf")
+	snapshotOptions.Package = bundle
+
+	snapshotRunner, err := runner.New(snapshotOptions)
+	if err != nil {
+		return errors.Wrap(err, "could not create runner")
+	}
+	if err := snapshotRunner.Execute(); err != nil {
+		_ = snapshotRunner.Close()
+		return errors.Wrap(err, "could not execute runner")
+	}
+	if err := snapshotRunner.Close(); err != nil {
+		return errors.Wrap(err, "could not close runner")
+	}
+	if snapshotRunner.Errors() > 0 {
+		return errors.Errorf("could not connect to %s, wrote error result to %s", snapshotOptions.Inputs[0], bundle)
+	}
+	gologger.Info().Msgf("Wrote snapshot of %s to %s", snapshotOptions.Inputs[0], bundle)
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/watch"
+)
+
+// runWatch repeatedly handshakes one endpoint logging changes of its
+// certificate, chain, version, cipher, ja3s and jarm
+func runWatch(args []string) error {
+	var interval time.Duration
+	var count int
+	var jsonOutput bool
+	options := &clients.Options{CaptureHello: true}
+
+	// the endpoint may be given before or after the flags
+	var address string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		address, args = args[0], args[1:]
+	}
+	flagSet := flag.NewFlagSet("watch", flag.ExitOnError)
+	flagSet.DurationVar(&interval, "interval", time.Minute, "interval between handshakes")
+	flagSet.IntVar(&count, "count", 0, "number of handshakes to do (default until stopped)")
+	flagSet.StringVar(&options.ScanMode, "sm", "", "tls connection mode to use (ctls, ztls, openssl, quic, auto) (default ctls)")
+	flagSet.StringVar(&options.ServerName, "sni", "", "tls sni hostname to use")
+	flagSet.IntVar(&options.Timeout, "timeout", 5, "tls connection timeout in seconds")
+	flagSet.BoolVar(&options.JARM, "jarm", true, "watch jarm fingerprint of server (10 handshakes)")
+	flagSet.BoolVar(&jsonOutput, "json", false, "display json format output")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if address == "" {
+		address = flagSet.Arg(0)
+	}
+	if address == "" {
+		return errors.New("no endpoint provided")
+	}
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "443"
+	}
+
+	dialerOpts := fastdialer.DefaultOptions
+	dialerOpts.WithDialerHistory = true
+	dialerOpts.DialerTimeout = time.Duration(options.Timeout) * time.Second
+	fastDialer, err := fastdialer.NewDialer(dialerOpts)
+	if err != nil {
+		return errors.Wrap(err, "could not create dialer")
+	}
+	defer fastDialer.Close()
+	options.Fastdialer = fastDialer
+
+	service, err := tlsx.New(options)
+	if err != nil {
+		return err
+	}
+	gologger.Info().Msgf("Watching %s every %s", net.JoinHostPort(host, port), interval)
+	watch.Watch(service, host, port, interval, count, func(event *watch.Event) {
+		if jsonOutput {
+			data, err := jsoniter.Marshal(event)
+			if err != nil {
+				gologger.Warning().Msgf("Could not marshal watch event: %s", err)
+				return
+			}
+			fmt.Fprintln(os.Stdout, string(data))
+			return
+		}
+		fmt.Fprintln(os.Stdout, formatWatchEvent(event))
+	})
+	return nil
+}
+
+// formatWatchEvent formats the state of an initial event or the changes
+// of later events
+func formatWatchEvent(event *watch.Event) string {
+	builder := &strings.Builder{}
+	builder.WriteString(event.Timestamp.Format(time.RFC3339))
+	builder.WriteString(" ")
+	builder.WriteString(net.JoinHostPort(event.Host, event.Port))
+
+	state := event.State
+	if event.Initial {
+		if state.Error != "" {
+			fmt.Fprintf(builder, " [handshake-failed: %s]", state.Error)
+			return builder.String()
+		}
+		fmt.Fprintf(builder, " [initial] [%s: %s] [%s] [chain: %d] [%s] [%s]", watch.FieldCertificate, state.Certificate, state.SubjectCN, len(state.Chain), state.Version, state.Cipher)
+		if state.JA3S != "" {
+			fmt.Fprintf(builder, " [%s: %s]", watch.FieldJA3S, state.JA3S)
+		}
+		if state.JARM != "" {
+			fmt.Fprintf(builder, " [%s: %s]", watch.FieldJARM, state.JARM)
+		}
+		return builder.String()
+	}
+	for _, change := range event.Changes {
+		switch {
+		case change.Field == watch.FieldError && change.New == "":
+			builder.WriteString(" [recovered]")
+		case change.Field == watch.FieldError:
+			fmt.Fprintf(builder, " [handshake-failed: %s]", change.New)
+		case change.Field == watch.FieldChain:
+			fmt.Fprintf(builder, " [%s: %s -> %s]", change.Field, formatWatchChain(change.Old), formatWatchChain(change.New))
+		default:
+			fmt.Fprintf(builder, " [%s: %s -> %s]", change.Field, change.Old, change.New)
+		}
+		if change.Field == watch.FieldCertificate {
+			fmt.Fprintf(builder, " [%s] [not-after: %s]", state.SubjectCN, state.NotAfter.UTC().Format(time.RFC3339))
+		}
+	}
+	return builder.String()
+}
+
+// formatWatchChain shortens the comma separated chain fingerprints of a change
+func formatWatchChain(chain string) string {
+	if chain == "" {
+		return "none"
+	}
+	fingerprints := strings.Split(chain, ",")
+	for i, fingerprint := range fingerprints {
+		if len(fingerprint) > 16 {
+			fingerprints[i] = fingerprint[:16]
+		}
+	}
+	return strings.Join(fingerprints, ",")
+}
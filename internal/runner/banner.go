@@ -7,6 +7,10 @@ import (
 	"github.com/projectdiscovery/fileutil"
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/tlsx/pkg/output"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/revocation"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
 )
 
 var banner = fmt.Sprintf(`  
@@ -19,29 +23,205 @@ var banner = fmt.Sprintf(`
 
 var version = "v0.0.1"
 
+// certificateProbeSpecified returns true if any probe of the presented
+// certificate or of the server fingerprint is enabled.
+func certificateProbeSpecified(options *clients.Options) bool {
+	return options.SO || options.Expired || options.ExpiringWithin != "" || options.SelfSigned || options.KeyType || options.WeakKey || options.SignatureAlgorithm || options.WeakSignature || options.ChainValidation || len(options.Revocation) > 0 || options.CT || options.CTSearch || options.HostnameCoverage || options.Hosting || options.DefaultCertificate || options.Hash != "" || options.PinSHA256 || options.JARM || options.MatchFingerprint || options.OCSP
+}
+
+// connectionProbeSpecified returns true if any probe making its own
+// connections to the server is enabled.
+func connectionProbeSpecified(options *clients.Options) bool {
+	return options.ACME || options.EarlyData || options.Renegotiation || options.Compression || options.DHParams || options.FallbackSCSV || options.ExtendedMasterSecret || options.ECH || options.PostQuantum || options.BrokerConfirm || options.HTTP2Confirm || options.CertificateRequest || options.Heartbleed || options.ROBOT || options.Ticketbleed || options.DROWN || options.POODLE || options.FREAK || options.CipherEnum || options.GroupEnum || options.SignatureEnum || options.ALPNEnum
+}
+
 // validateOptions validates the provided options for crawler
 func (r *Runner) validateOptions() error {
 	r.hasStdin = fileutil.HasStdin()
+	if len(r.options.ServerNames) == 1 {
+		r.options.ServerName = r.options.ServerNames[0]
+	}
 
-	probeSpecified := r.options.SO || r.options.TLSVersion || r.options.Cipher || r.options.Expired || r.options.SelfSigned || r.options.Hash != ""
+	probeSpecified := r.options.TLSVersion || r.options.Cipher || certificateProbeSpecified(r.options) || connectionProbeSpecified(r.options)
 	if r.options.RespOnly && probeSpecified {
 		return errors.New("resp-only flag can only be used with san and cn flags")
 	}
 	if (r.options.SAN || r.options.CN) && probeSpecified {
 		return errors.New("san or cn flag cannot be used with other probes")
 	}
-	if !r.hasStdin && len(r.options.Inputs) == 0 && r.options.InputList == "" {
+	if !r.hasStdin && len(r.options.Inputs) == 0 && r.options.InputList == "" && r.options.RetryFile == "" {
 		return errors.New("no input provided for enumeration")
 	}
 	if len(r.options.Ports) == 0 {
 		// Append port 443 for default ports
 		r.options.Ports = append(r.options.Ports, "443")
 	}
-	if r.options.CertsOnly && !(r.options.ScanMode == "ztls" || r.options.ScanMode == "auto") {
-		return errors.New("scan-mode must be ztls or auto with certs-only option")
+	if r.options.CertsOnly && !(r.options.ScanMode == "" || r.options.ScanMode == "ctls" || r.options.ScanMode == "ztls" || r.options.ScanMode == "auto") {
+		return errors.New("scan-mode must be ctls, ztls or auto with certs-only option")
+	}
+	if r.options.CertsOnly && r.options.OCSP {
+		return errors.New("ocsp flag cannot be used with certs-only as the handshake ends before the stapled response")
+	}
+	if r.options.CertsOnly && r.options.ScanMode == "" {
+		r.options.ScanMode = "auto" // ztls is used for servers ctls cannot connect to
+	}
+	if r.options.QUIC && !(r.options.ScanMode == "" || r.options.ScanMode == "quic") {
+		return errors.New("scan-mode must be quic with quic option")
+	}
+	if r.options.QUIC {
+		r.options.ScanMode = "quic" // force setting quic scan mode when using quic
+	}
+	if r.options.ScanMode == "quic" && (r.options.CheckOnly || r.options.DiscoverPorts != "" || r.options.SIPSRV || r.options.DefaultCertificate || r.options.StartTLS != "" || r.options.AutoStartTLS || len(r.options.StartTLSPorts) > 0 || r.options.Proxy != "" || r.options.CaptureHello || connectionProbeSpecified(r.options)) {
+		return errors.New("quic scan mode cannot be used with check-only, discover-ports, sip-srv, default-cert, starttls, proxy, capture-hello, acme, early-data, renegotiation, compression, vulnerability or enumeration flags")
+	}
+	if r.options.CheckOnly && (r.options.SAN || r.options.CN || r.options.WeakKeyOnly || r.options.Attribute || r.options.TLSChain || r.options.CaptureHello || r.options.RootStoreFile != "" || r.options.DetectDuplicates || r.options.SNIMatrix || r.options.ChainBundleDir != "" || certificateProbeSpecified(r.options) || connectionProbeSpecified(r.options)) {
+		return errors.New("check-only flag can only be used with tls-version and cipher probes")
+	}
+	if r.options.RemediationFile != "" && !r.options.RemediationHints && r.options.ReportPDF == "" {
+		return errors.New("remediation-file flag can only be used with remediation-hints or report-pdf flags")
+	}
+	if r.options.AIAFetch && !r.options.ChainValidation {
+		return errors.New("aia-fetch flag can only be used with chain-validation flag")
+	}
+	if len(r.options.TrustStoreFiles) > 0 && !r.options.ChainValidation {
+		return errors.New("trust-store flag can only be used with chain-validation flag")
+	}
+	var crlRevocation bool
+	for _, method := range r.options.Revocation {
+		if !revocation.IsSupported(method) {
+			return fmt.Errorf("unsupported revocation method: %s", method)
+		}
+		crlRevocation = crlRevocation || method == revocation.MethodCRL
+	}
+	if r.options.CRLCacheDir != "" && !crlRevocation {
+		return errors.New("crl-cache-dir flag can only be used with crl revocation method")
+	}
+	if r.options.CTLogList != "" && !r.options.CT {
+		return errors.New("ct-log-list flag can only be used with ct flag")
+	}
+	if r.options.CTAppleLogList != "" && !r.options.CT {
+		return errors.New("ct-apple-log-list flag can only be used with ct flag")
+	}
+	if r.options.CTSearchURL != "" && !r.options.CTSearch && !r.options.Attribute {
+		return errors.New("ct-search-url flag can only be used with ct-search or attribute flag")
+	}
+	if r.options.CTSearch && r.options.CTSearchDays <= 0 {
+		return errors.New("ct-search-days must be greater than 0")
+	}
+	var otherTarget bool
+	for _, value := range r.options.OutputTargets {
+		target, err := output.ParseTarget(value)
+		if err != nil {
+			return err
+		}
+		if target.Destination == output.DestinationStdout && r.options.NoStdout {
+			return errors.New("no-stdout flag cannot be used with stdout output targets")
+		}
+		otherTarget = otherTarget || target.Destination != output.DestinationStdout
+	}
+	if r.options.NoStdout && !otherTarget && r.options.OutputFile == "" && r.options.AuditCSV == "" && r.options.ChainBundleDir == "" && r.options.ReportPDF == "" && r.options.Package == "" {
+		return errors.New("no-stdout flag can only be used with output, output-target, audit-csv, chain-bundle-dir, report-pdf or package flags")
+	}
+	if r.options.Plain && (r.options.JSON || r.options.RespOnly) {
+		return errors.New("plain flag cannot be used with json or resp-only flags")
+	}
+	if r.options.Table && (r.options.JSON || r.options.Plain) {
+		return errors.New("table flag cannot be used with json or plain flags")
+	}
+	if r.options.ReportLocale != "" && r.options.ReportPDF == "" {
+		return errors.New("report-locale flag can only be used with report-pdf flag")
+	}
+	if r.options.ChainVerbosity != "" && !r.options.TLSChain {
+		return errors.New("tls-chain-verbosity flag can only be used with tls-chain flag")
+	}
+	if r.options.ChainVerbosity != "" && r.options.ChainVerbosity != clients.ChainVerbosityFull && r.options.ChainVerbosity != clients.ChainVerbosityBrief {
+		return fmt.Errorf("unsupported tls-chain-verbosity value: %s", r.options.ChainVerbosity)
+	}
+	if len(r.options.AttributionScope) > 0 && !r.options.Attribute {
+		return errors.New("attribute-scope flag can only be used with attribute flag")
+	}
+	if r.options.FingerprintFile != "" && !r.options.MatchFingerprint {
+		return errors.New("fingerprint-db flag can only be used with match-fingerprint flag")
+	}
+	if (r.options.ClientCertificate != "" || r.options.ClientPKCS12 != "" || r.options.ClientPKCS11 != "" || r.options.ClientCertStore != "" || r.options.CACertStore != "") && !(r.options.ScanMode == "" || r.options.ScanMode == "ctls") {
+		return errors.New("client-cert, client-pkcs12, client-pkcs11, client-cert-store and cacert-store flags can only be used with ctls scan mode")
+	}
+	if (r.options.ClientCertificate != "") != (r.options.ClientKey != "") {
+		return errors.New("client-cert and client-key flags must be used together")
+	}
+	if r.options.ClientPKCS12Password != "" && r.options.ClientPKCS12 == "" {
+		return errors.New("client-pkcs12-password flag can only be used with client-pkcs12 flag")
+	}
+	identities := 0
+	for _, value := range []string{r.options.ClientCertificate, r.options.ClientPKCS12, r.options.ClientPKCS11, r.options.ClientCertStore} {
+		if value != "" {
+			identities++
+		}
+	}
+	if identities > 1 {
+		return errors.New("client-cert, client-pkcs12, client-pkcs11 and client-cert-store flags cannot be used together")
+	}
+	if r.options.ExpiringWithin != "" {
+		window, err := clients.ParseWindow(r.options.ExpiringWithin)
+		if err != nil {
+			return errors.Wrap(err, "invalid expiring-within")
+		}
+		r.options.ExpiringWindow = window
+	}
+	if r.options.ExpiringOnly && r.options.ExpiringWithin == "" {
+		return errors.New("expiring-only flag can only be used with expiring-within flag")
+	}
+	if r.options.ValidationTime != "" {
+		validationAt, err := clients.ParseValidationTime(r.options.ValidationTime)
+		if err != nil {
+			return errors.Wrap(err, "invalid validation-time")
+		}
+		r.options.ValidationAt = validationAt
+	}
+	if r.options.Proxy != "" && r.options.ScanMode == "openssl" {
+		return errors.New("proxy flag cannot be used with openssl scan mode")
+	}
+	if r.options.Proxy != "" && (r.options.EarlyData || r.options.Renegotiation) {
+		return errors.New("proxy flag cannot be used with early-data or renegotiation flags")
+	}
+	if r.options.StartTLS != "" && !starttls.IsSupported(r.options.StartTLS) {
+		return fmt.Errorf("unsupported starttls protocol: %s", r.options.StartTLS)
+	}
+	for _, mapping := range r.options.StartTLSPorts {
+		_, protocol, ok := starttls.ParsePortMapping(mapping)
+		if !ok {
+			return fmt.Errorf("invalid starttls port mapping: %s", mapping)
+		}
+		if !starttls.IsSupported(protocol) {
+			return fmt.Errorf("unsupported starttls protocol: %s", protocol)
+		}
+	}
+	if r.options.RescanChangedOnly && r.options.PreviousResults == "" {
+		return errors.New("rescan-changed-only flag can only be used with previous-results flag")
+	}
+	if _, ok := discoveryPortLists[r.options.DiscoverPorts]; r.options.DiscoverPorts != "" && !ok {
+		return fmt.Errorf("unsupported discover-ports list: %s", r.options.DiscoverPorts)
+	}
+	if r.options.MaxPerSubnet < 0 {
+		return errors.New("max-per-subnet must not be negative")
+	}
+	if r.options.FlushEvery < 0 {
+		return errors.New("flush-every must not be negative")
+	}
+	if r.options.Unbuffered && r.options.FlushEvery != 0 {
+		return errors.New("unbuffered and flush-every flags cannot be used together")
+	}
+	if r.options.Lock && r.options.OutputFile == "" && r.options.Package == "" {
+		return errors.New("lock flag can only be used with output or package flags")
+	}
+	if r.options.RetryOutput != "" && r.options.RetryOutput == r.options.RetryFile {
+		return errors.New("retry-output file must differ from retry-file")
+	}
+	if r.options.InputReport != "" && (r.options.InputReport == r.options.InputList || r.options.InputReport == r.options.OutputFile) {
+		return errors.New("input-report file must differ from list and output files")
 	}
-	if r.options.CertsOnly {
-		r.options.ScanMode = "ztls" // force setting ztls when using certs-only
+	if r.options.Package != "" && (r.options.Package == r.options.OutputFile || r.options.Package == r.options.AuditCSV || r.options.Package == r.options.ReportPDF) {
+		return errors.New("package file must differ from output, audit-csv and report-pdf files")
 	}
 	if r.options.Verbose {
 		gologger.DefaultLogger.SetMaxLevel(levels.LevelVerbose)
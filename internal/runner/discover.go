@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"net"
+	"sync"
+)
+
+// discoveryPortLists is the list of named port lists for companion port discovery
+var discoveryPortLists = map[string][]string{
+	"top100-tls": {
+		"443", "444", "448", "465", "563", "585", "614", "636", "684", "695",
+		"853", "898", "902", "989", "990", "992", "993", "994", "995", "1311",
+		"1443", "2083", "2087", "2096", "2221", "2252", "2376", "2443", "2484", "3269",
+		"3443", "3871", "4064", "4343", "4433", "4443", "4444", "4843", "5001", "5061",
+		"5223", "5443", "5671", "5986", "6443", "6514", "6619", "6679", "6697", "7002",
+		"7443", "7473", "7677", "8001", "8006", "8010", "8081", "8083", "8089", "8172",
+		"8243", "8333", "8443", "8444", "8531", "8834", "8843", "8883", "8888", "8889",
+		"9001", "9043", "9089", "9091", "9093", "9243", "9443", "9444", "9999", "10000",
+		"10250", "10443", "11443", "12443", "13443", "15443", "16993", "18091", "18092", "20443",
+		"25565", "30443", "32443", "44443", "50443", "55443", "60443", "61616", "61617", "64443",
+	},
+}
+
+// discoverer queues the ports of a port list for each responsive host
+// once, skipping the addresses already scanned.
+type discoverer struct {
+	ports []string
+	// tasks is the queue of discovered addresses to scan
+	tasks chan taskInput
+
+	mutex   sync.Mutex
+	hosts   map[string]struct{}
+	scanned map[string]struct{}
+}
+
+// newDiscoverer creates a discoverer for ports queueing up to size tasks
+func newDiscoverer(ports []string, size int) *discoverer {
+	return &discoverer{
+		ports:   ports,
+		tasks:   make(chan taskInput, size),
+		hosts:   make(map[string]struct{}),
+		scanned: make(map[string]struct{}),
+	}
+}
+
+// claim marks the address of task as scanned, returning false if it
+// was scanned before.
+func (d *discoverer) claim(task taskInput) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	address := net.JoinHostPort(task.host, task.port)
+	if _, ok := d.scanned[address]; ok {
+		return false
+	}
+	d.scanned[address] = struct{}{}
+	return true
+}
+
+// discover queues the ports of the host of a responsive task if the
+// host was not discovered before.
+func (d *discoverer) discover(task taskInput) {
+	d.mutex.Lock()
+	if _, ok := d.hosts[task.host]; ok {
+		d.mutex.Unlock()
+		return
+	}
+	d.hosts[task.host] = struct{}{}
+	var tasks []taskInput
+	for _, port := range d.ports {
+		if _, ok := d.scanned[net.JoinHostPort(task.host, port)]; !ok {
+			tasks = append(tasks, taskInput{host: task.host, port: port, discovered: true})
+		}
+	}
+	d.mutex.Unlock()
+
+	for _, task := range tasks {
+		d.tasks <- task
+	}
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// dryRun parses and expands inputs printing every unique target which
+// would be scanned along with the target count.
+func (r *Runner) dryRun() error {
+	inputs := make(chan taskInput, r.options.Concurrency)
+	done := make(chan struct{})
+
+	var total, unique int
+	go func() {
+		defer close(done)
+
+		stdout := bufio.NewWriter(os.Stdout)
+		defer stdout.Flush()
+
+		seen := make(map[string]struct{})
+		for task := range inputs {
+			total++
+			address := task.Address()
+			if _, ok := seen[address]; ok {
+				continue
+			}
+			seen[address] = struct{}{}
+			unique++
+			_, _ = stdout.WriteString(address)
+			_ = stdout.WriteByte('\n')
+		}
+	}()
+	err := r.normalizeAndQueueInputs(inputs)
+	close(inputs)
+	<-done
+	if err != nil {
+		return err
+	}
+
+	if r.sampler != nil {
+		gologger.Info().Msgf("Sampled %d of %d targets", r.sampler.sampled, r.sampler.queued)
+	}
+	if r.inputReport.skipped > 0 {
+		gologger.Info().Msgf("Skipped %d invalid input lines", r.inputReport.skipped)
+	}
+	gologger.Info().Msgf("Dry run: %d unique targets of %d queued", unique, total)
+	return nil
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+)
+
+// List of reasons input lines are skipped for
+const (
+	skipInvalidCIDR    = "invalid-cidr"
+	skipInvalidURL     = "invalid-url"
+	skipInvalidAddress = "invalid-address"
+	skipInvalidPort    = "invalid-port"
+)
+
+// inputError is an error parsing an input line along with the reason
+// the line is skipped.
+type inputError struct {
+	reason string
+	err    error
+}
+
+func (e *inputError) Error() string {
+	return e.err.Error()
+}
+
+// newInputError returns a new input error for reason
+func newInputError(reason string, err error) error {
+	return &inputError{reason: reason, err: err}
+}
+
+// skippedInput is an input line skipped as it could not be parsed
+type skippedInput struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Input  string `json:"input"`
+	Reason string `json:"reason"`
+	Error  string `json:"error"`
+}
+
+// inputReport reports skipped input lines as json lines to a file,
+// or to stderr when no file is specified.
+type inputReport struct {
+	file    *os.File
+	writer  *bufio.Writer
+	skipped int
+}
+
+// newInputReport creates a new input report writing to file if not empty
+func newInputReport(file string) (*inputReport, error) {
+	report := &inputReport{}
+	if file == "" {
+		return report, nil
+	}
+	output, err := os.Create(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create input report file")
+	}
+	report.file = output
+	report.writer = bufio.NewWriter(output)
+	return report, nil
+}
+
+// skip reports an input line of source skipped because of err
+func (i *inputReport) skip(source string, line int, input string, err error) {
+	i.skipped++
+
+	entry := &skippedInput{Source: source, Line: line, Input: input, Reason: skipInvalidAddress, Error: err.Error()}
+	var inputErr *inputError
+	if errors.As(err, &inputErr) {
+		entry.Reason = inputErr.reason
+	}
+	if i.writer == nil {
+		gologger.Error().Msgf("Skipped input %s (%s:%d): %s: %s", input, source, line, entry.Reason, entry.Error)
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_, _ = i.writer.Write(data)
+	_ = i.writer.WriteByte('\n')
+}
+
+// close flushes and closes the input report file
+func (i *inputReport) close() error {
+	if i.file == nil {
+		return nil
+	}
+	if err := i.writer.Flush(); err != nil {
+		_ = i.file.Close()
+		return err
+	}
+	return i.file.Close()
+}
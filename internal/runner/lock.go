@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+)
+
+// List of lock acquisition settings
+const (
+	// lockAttempts is the number of attempts to acquire a lock which is
+	// stale or whose pid cannot be read
+	lockAttempts = 5
+	// lockRetryDelay is the delay before reading a lock again whose pid
+	// cannot be read
+	lockRetryDelay = 100 * time.Millisecond
+)
+
+// lockFile is a lock file preventing concurrent scans from writing
+// the same output.
+type lockFile struct {
+	path string
+}
+
+// acquireLock creates the lock file for an output path. Locks left
+// behind by processes which are no longer running are taken over.
+func acquireLock(output string) (*lockFile, error) {
+	absolute, err := filepath.Abs(output)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get output path")
+	}
+	path := absolute + ".lock"
+
+	for attempt := 0; attempt < lockAttempts; attempt++ {
+		err := createLock(path)
+		if err == nil {
+			return &lockFile{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrap(err, "could not create lock file")
+		}
+
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read lock file")
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			// the lock is held by a process not writing its pid yet
+			time.Sleep(lockRetryDelay)
+			continue
+		}
+		if processExists(pid) {
+			return nil, fmt.Errorf("output %s is locked by running process %d (%s)", output, pid, path)
+		}
+		gologger.Info().Msgf("Removing stale lock file %s", path)
+		if err := removeStaleLock(path, pid); err != nil {
+			return nil, errors.Wrap(err, "could not remove stale lock file")
+		}
+	}
+	return nil, fmt.Errorf("could not acquire lock file %s, remove it if no scan is running", path)
+}
+
+// removeStaleLock removes the lock of a process which is no longer running.
+// The lock is moved away before its pid is read again, so a lock created
+// by another process taking it over meanwhile is put back instead.
+func removeStaleLock(path string, pid int) error {
+	moved := path + ".stale." + strconv.Itoa(os.Getpid())
+	if err := os.Rename(path, moved); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(moved)
+
+	data, err := os.ReadFile(moved)
+	if err != nil {
+		return err
+	}
+	if current, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && current == pid {
+		return nil
+	}
+	if err := os.Link(moved, path); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+// createLock writes the pid of the process to a temporary file which is
+// linked to path, so the lock file never exists without the pid.
+func createLock(path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = fmt.Fprintf(file, "%d\n", os.Getpid())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Link(file.Name(), path); err != nil {
+		if linkErr, ok := err.(*os.LinkError); ok && os.IsExist(linkErr.Err) {
+			return os.ErrExist
+		}
+		return err
+	}
+	return nil
+}
+
+// release removes the lock file
+func (l *lockFile) release() error {
+	return os.Remove(l.path)
+}
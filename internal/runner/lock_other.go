@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import "syscall"
+
+// processExists returns true if a process with pid is running
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package runner
+
+import "golang.org/x/sys/windows"
+
+// stillActive is the exit code of processes which have not exited
+const stillActive = 259
+
+// processExists returns true if a process with pid is running
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// access is denied for processes of other users
+		return err == windows.ERROR_ACCESS_DENIED
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
@@ -0,0 +1,179 @@
+package runner
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/output/stats"
+)
+
+// scanStats contains counters of a scan included in packages. The
+// atomically updated counters come first to be 64-bit aligned on 32-bit
+// platforms, as the first field of the runner.
+type scanStats struct {
+	Targets               uint64    `json:"targets"`
+	Results               uint64    `json:"results"`
+	Errors                uint64    `json:"errors"`
+	CryptoTLSConnections  uint64    `json:"crypto-tls-connections,omitempty"`
+	ZcryptoTLSConnections uint64    `json:"zcrypto-tls-connections,omitempty"`
+	Started               time.Time `json:"started"`
+	Finished              time.Time `json:"finished"`
+	Duration              string    `json:"duration"`
+}
+
+// packageMetadata describes the scan and contents of a package
+type packageMetadata struct {
+	Version  string            `json:"version"`
+	Command  []string          `json:"command"`
+	ScanMode string            `json:"scan-mode,omitempty"`
+	Files    map[string]string `json:"files"`
+}
+
+// createPackageResults creates a temporary results file for packages
+// when no output file is specified.
+func (r *Runner) createPackageResults() error {
+	file, err := os.CreateTemp("", "tlsx-results-*")
+	if err != nil {
+		return errors.Wrap(err, "could not create temporary results file")
+	}
+	_ = file.Close()
+	r.options.OutputFile = file.Name()
+	r.packageResults = file.Name()
+	return nil
+}
+
+// writePackage writes the scan artifacts to a single zip archive.
+// It must be called after the output and retry writers are closed.
+func (r *Runner) writePackage() (err error) {
+	if r.packageResults != "" {
+		defer os.Remove(r.packageResults)
+	}
+
+	file, err := os.Create(r.options.Package)
+	if err != nil {
+		return errors.Wrap(err, "could not create package file")
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = errors.Wrap(closeErr, "could not close package file")
+		}
+	}()
+	archive := zip.NewWriter(file)
+
+	metadata := packageMetadata{
+		Version: version,
+		Command: os.Args,
+		Files:   make(map[string]string),
+	}
+	results := "results.txt"
+	if r.options.JSON {
+		results = "results.jsonl"
+	}
+	if r.packageResults == "" {
+		results = filepath.Base(r.options.OutputFile)
+	}
+	artifacts := []struct {
+		kind, name, file string
+	}{
+		{"results", results, r.options.OutputFile},
+		{"audit-csv", filepath.Base(r.options.AuditCSV), r.options.AuditCSV},
+		{"report-pdf", filepath.Base(r.options.ReportPDF), r.options.ReportPDF},
+		{"retry", filepath.Base(r.options.RetryOutput), r.options.RetryOutput},
+		{"retry-input", "input-" + filepath.Base(r.options.RetryFile), r.options.RetryFile},
+		{"input-report", filepath.Base(r.options.InputReport), r.options.InputReport},
+	}
+	for _, artifact := range artifacts {
+		if artifact.file == "" {
+			continue
+		}
+		if err := addFile(archive, artifact.name, artifact.file); err != nil {
+			return errors.Wrapf(err, "could not add %s to package", artifact.kind)
+		}
+		metadata.Files[artifact.kind] = artifact.name
+	}
+	if r.options.ChainBundleDir != "" {
+		if err := addDirectory(archive, "chains", r.options.ChainBundleDir); err != nil {
+			return errors.Wrap(err, "could not add chain bundles to package")
+		}
+		metadata.Files["chain-bundles"] = "chains/"
+	}
+
+	// workers have finished so counters can be read directly
+	scanStats := r.stats
+	scanStats.Duration = scanStats.Finished.Sub(scanStats.Started).String()
+	if r.options.ScanMode == "auto" {
+		scanStats.CryptoTLSConnections = stats.LoadCryptoTLSConnections()
+		scanStats.ZcryptoTLSConnections = stats.LoadZcryptoTLSConnections()
+	}
+	if err := addJSON(archive, "stats.json", scanStats); err != nil {
+		return errors.Wrap(err, "could not add stats to package")
+	}
+	metadata.Files["stats"] = "stats.json"
+	metadata.ScanMode = r.options.ScanMode
+	if err := addJSON(archive, "metadata.json", metadata); err != nil {
+		return errors.Wrap(err, "could not add metadata to package")
+	}
+
+	if err := archive.Close(); err != nil {
+		return errors.Wrap(err, "could not write package")
+	}
+	return nil
+}
+
+// addFile copies a file to the archive with name
+func addFile(archive *zip.Writer, name, file string) error {
+	input, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	info, err := input.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, input)
+	return err
+}
+
+// addDirectory copies the regular files of a directory to the archive
+// under prefix.
+func addDirectory(archive *zip.Writer, prefix, directory string) error {
+	return filepath.Walk(directory, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		relative, err := filepath.Rel(directory, file)
+		if err != nil {
+			return err
+		}
+		return addFile(archive, path.Join(prefix, filepath.ToSlash(relative)), file)
+	})
+}
+
+// addJSON writes value as indented json to the archive with name
+func addJSON(archive *zip.Writer, name string, value interface{}) error {
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// maxClockSkew is the maximum clock difference with the preflight
+// target before certificate validity checks become unreliable.
+const maxClockSkew = 5 * time.Minute
+
+// preflight validates egress before scanning by resolving the
+// preflight target, doing a handshake with it and checking clock skew.
+func (r *Runner) preflight() error {
+	host, port, err := net.SplitHostPort(r.options.PreflightTarget)
+	if err != nil {
+		return errors.Wrap(err, "invalid preflight target")
+	}
+
+	switch {
+	case r.options.ProxyDialer != nil:
+		gologger.Info().Msgf("Preflight dns: skipped, %s is resolved by proxy", host)
+	case iputil.IsIP(host):
+		gologger.Info().Msgf("Preflight dns: skipped, %s is an ip address", host)
+	default:
+		dnsData, err := r.fastDialer.GetDNSData(host)
+		if err != nil {
+			return errors.Wrapf(err, "dns: could not resolve %s, check network or -resolvers", host)
+		}
+		addresses := append(dnsData.A, dnsData.AAAA...)
+		if len(addresses) == 0 {
+			return fmt.Errorf("dns: no addresses found for %s, check network or -resolvers", host)
+		}
+		gologger.Info().Msgf("Preflight dns: resolved %s to %s", host, addresses[0])
+	}
+
+	// A single handshake with the connection settings of the scan surfaces
+	// proxy and scan mode issues, without probes or protocol specific steps
+	// which do not apply to the target.
+	preflightOptions := r.options.HandshakeOptions()
+	preflightOptions.ServerName = ""
+	preflightOptions.StartTLS = ""
+	preflightOptions.StartTLSPorts = nil
+	preflightOptions.AutoStartTLS = false
+	service, err := tlsx.New(preflightOptions)
+	if err != nil {
+		return errors.Wrap(err, "could not create preflight service")
+	}
+	response, err := service.Connect(host, port)
+	if err != nil {
+		return fmt.Errorf("tls: could not handshake with %s, check egress firewall or -proxy: %v", r.options.PreflightTarget, err)
+	}
+	gologger.Info().Msgf("Preflight tls: handshake with %s succeeded (%s)", r.options.PreflightTarget, response.Version)
+
+	skew, err := r.clockSkew(host, port)
+	if err != nil {
+		return errors.Wrap(err, "clock: could not get server time")
+	}
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("clock: local clock is off by %s, certificate validity results would be unreliable", skew.Round(time.Second))
+	}
+	gologger.Info().Msgf("Preflight clock: skew %s", skew.Round(time.Second))
+	return nil
+}
+
+// clockSkew returns the difference between local time and the
+// Date header returned by the https server at host:port.
+func (r *Runner) clockSkew(host, port string) (time.Duration, error) {
+	timeout := time.Duration(r.options.Timeout) * time.Second
+	httpClient := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return clients.Dial(ctx, r.options, address)
+			},
+			// verification is skipped as a skewed clock makes it fail
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	started := time.Now()
+	resp, err := httpClient.Head("https://" + net.JoinHostPort(host, port))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, errors.Wrap(err, "could not parse date header")
+	}
+	// the date header has second precision, compare with request midpoint
+	local := started.Add(time.Since(started) / 2)
+	return local.Sub(date), nil
+}
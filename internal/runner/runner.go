@@ -5,8 +5,10 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -14,20 +16,71 @@ import (
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/gologger/formatter"
 	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/iputil"
 	"github.com/projectdiscovery/mapcidr"
+	"github.com/projectdiscovery/tlsx/pkg/duplicates"
+	"github.com/projectdiscovery/tlsx/pkg/findings"
+	"github.com/projectdiscovery/tlsx/pkg/metrics"
 	"github.com/projectdiscovery/tlsx/pkg/output"
 	"github.com/projectdiscovery/tlsx/pkg/output/stats"
+	"github.com/projectdiscovery/tlsx/pkg/plaintext"
+	"github.com/projectdiscovery/tlsx/pkg/report"
+	"github.com/projectdiscovery/tlsx/pkg/retry"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/jarm"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/proxy"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/sip"
 )
 
 // Runner is a client for running the enumeration process
 type Runner struct {
+	// stats contains scan counters written to packages, it is the first
+	// field so its atomic counters are 64-bit aligned on 32-bit platforms.
+	stats scanStats
+
 	hasStdin     bool
+	closeOnce    sync.Once
+	closeErr     error
 	outputWriter output.Writer
 	tlsxService  *tlsx.Service
-	fastDialer   *fastdialer.Dialer
-	options      *clients.Options
+	// sniServices is the service of each server name when connecting
+	// with multiple server names.
+	sniServices   map[string]*tlsx.Service
+	fastDialer    *fastdialer.Dialer
+	metricsClient *metrics.Client
+	findings      *findings.Database
+	duplicates    *duplicates.Tracker
+	sniMatrix     *sniMatrix
+	retryWriter   *retry.Writer
+	lock          *lockFile
+	sampler       *sampler
+	shuffler      *shuffler
+	subnetLimiter *subnetLimiter
+	discoverer    *discoverer
+	inputReport   *inputReport
+	options       *clients.Options
+
+	// outputErr is the first error of an output with the fail policy,
+	// inputs are no longer processed once it is set.
+	outputErr     error
+	outputErrOnce sync.Once
+	outputFailed  uint32
+
+	// checkService is a single handshake service used to detect
+	// changed hosts with rescan-changed-only.
+	checkService   *tlsx.Service
+	previousStates map[string]*handshakeState
+
+	// reportSummarizer aggregates results for the pdf report as they
+	// are written, so memory stays flat on large scans.
+	reportSummarizer *report.Summarizer
+	reportMutex      sync.Mutex
+	reportLocale     *report.Locale
+
+	// packageResults is the temporary results file created for
+	// packages when no output file is specified.
+	packageResults string
 }
 
 // New creates a new runner from provided configuration options
@@ -49,6 +102,25 @@ func New(options *clients.Options) (*Runner, error) {
 	if err := runner.validateOptions(); err != nil {
 		return nil, errors.Wrap(err, "could not validate options")
 	}
+	if options.Sample != "" {
+		sampler, err := newSampler(options.Sample, options.SampleSeed)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create sampler")
+		}
+		runner.sampler = sampler
+	}
+	if options.Shuffle {
+		runner.shuffler = newShuffler(options.ShuffleSeed)
+	}
+	inputReport, err := newInputReport(options.InputReport)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create input report")
+	}
+	runner.inputReport = inputReport
+	if options.DryRun {
+		// dry runs only parse inputs without connecting or writing output
+		return runner, nil
+	}
 
 	dialerOpts := fastdialer.DefaultOptions
 	dialerOpts.WithDialerHistory = true
@@ -64,6 +136,53 @@ func New(options *clients.Options) (*Runner, error) {
 	runner.fastDialer = fastDialer
 	runner.options.Fastdialer = fastDialer
 
+	if options.MaxPerSubnet > 0 {
+		// hostnames are resolved by the proxy and limited by name
+		dialer := fastDialer
+		if options.Proxy != "" {
+			dialer = nil
+		}
+		runner.subnetLimiter = newSubnetLimiter(options.MaxPerSubnet, dialer)
+	}
+
+	if options.Proxy != "" {
+		proxyDialer, err := proxy.New(options.Proxy, fastDialer)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create proxy dialer")
+		}
+		runner.options.ProxyDialer = proxyDialer
+	}
+
+	oidRegistry, err := clients.NewOIDRegistry(options.OIDFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create oid registry")
+	}
+	runner.options.OIDRegistry = oidRegistry
+
+	if options.RootStoreFile != "" {
+		rootStore, err := clients.NewRootStore(options.RootStoreFile, options)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create root store simulation")
+		}
+		runner.options.RootStore = rootStore
+	}
+
+	if options.Lock {
+		output := options.OutputFile
+		if output == "" {
+			output = options.Package
+		}
+		lock, err := acquireLock(output)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not acquire lock")
+		}
+		runner.lock = lock
+	}
+	if options.Package != "" && options.OutputFile == "" {
+		if err := runner.createPackageResults(); err != nil {
+			return nil, errors.Wrap(err, "could not setup package")
+		}
+	}
 	outputWriter, err := output.New(options)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create output writer")
@@ -75,19 +194,123 @@ func New(options *clients.Options) (*Runner, error) {
 		return nil, errors.Wrap(err, "could not create tlsx client")
 	}
 	runner.tlsxService = tlsxService
+	if len(options.ServerNames) > 1 {
+		runner.sniServices = make(map[string]*tlsx.Service, len(options.ServerNames))
+		for _, name := range options.ServerNames {
+			sniOptions := *options
+			sniOptions.ServerName = name
+			service, err := tlsx.New(&sniOptions)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not create tlsx client for sni %s", name)
+			}
+			runner.sniServices[name] = service
+		}
+	}
+
+	if options.ReportPDF != "" {
+		if runner.reportLocale, err = report.LoadLocale(options.ReportLocale); err != nil {
+			return nil, errors.Wrap(err, "could not load report locale")
+		}
+	}
+	if options.RemediationHints || options.ReportPDF != "" {
+		database, err := findings.New(options.RemediationFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create remediation database")
+		}
+		runner.findings = database
+	}
+	if options.ReportPDF != "" {
+		runner.reportSummarizer = report.NewSummarizer(runner.findings, options.ValidationAt, options.ExpiringWindow)
+	}
+	if options.DetectDuplicates {
+		runner.duplicates = duplicates.New()
+	}
+	if options.SNIMatrix {
+		runner.sniMatrix = newSNIMatrix()
+	}
+	if options.DiscoverPorts != "" {
+		runner.discoverer = newDiscoverer(discoveryPortLists[options.DiscoverPorts], options.Concurrency)
+	}
+	if options.RetryOutput != "" {
+		retryWriter, err := retry.NewWriter(options.RetryOutput)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create retry writer")
+		}
+		runner.retryWriter = retryWriter
+	}
+	if options.RescanChangedOnly {
+		if err := runner.setupChangeCheck(); err != nil {
+			return nil, errors.Wrap(err, "could not setup rescan changed only")
+		}
+	}
+	if options.StatsdAddress != "" {
+		metricsClient, err := metrics.New(options.StatsdAddress, options.StatsdPrefix, options.StatsdTags)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create metrics client")
+		}
+		runner.metricsClient = metricsClient
+	}
 	return runner, nil
 }
 
+// Errors returns the number of inputs which failed tls handshake
+func (r *Runner) Errors() uint64 {
+	return atomic.LoadUint64(&r.stats.Errors)
+}
+
 // Close closes the runner releasing resources
 func (r *Runner) Close() error {
-	_ = r.outputWriter.Close()
+	r.closeOnce.Do(func() {
+		r.closeErr = r.close()
+	})
+	return r.closeErr
+}
+
+// close writes the outputs and releases the resources of the runner
+func (r *Runner) close() (err error) {
+	if r.lock != nil {
+		// the lock is released even if writing the outputs fails
+		defer func() {
+			if releaseErr := r.lock.release(); releaseErr != nil && err == nil {
+				err = errors.Wrap(releaseErr, "could not release lock")
+			}
+		}()
+	}
+	if err := r.inputReport.close(); err != nil {
+		return errors.Wrap(err, "could not write input report")
+	}
+	if r.options.DryRun {
+		return nil
+	}
+	outputErr := r.outputWriter.Close()
+	var retryErr error
+	if r.retryWriter != nil {
+		retryErr = r.retryWriter.Close()
+	}
 	r.fastDialer.Close()
-	return nil
+	if r.metricsClient != nil {
+		_ = r.metricsClient.Close()
+	}
+	if r.options.Package != "" {
+		if err := r.writePackage(); err != nil {
+			return errors.Wrap(err, "could not write package")
+		}
+	}
+	if retryErr != nil {
+		return errors.Wrap(retryErr, "could not write retry output")
+	}
+	return outputErr
 }
 
 type taskInput struct {
 	host string
 	port string
+	// discovered is true for companion ports of responsive hosts
+	discovered bool
+	// sipDomain is the sip domain the sips server was looked up for
+	sipDomain string
+	// sni is the server name to connect with if multiple are specified
+	sni string
 }
 
 func (t taskInput) Address() string {
@@ -96,6 +319,17 @@ func (t taskInput) Address() string {
 
 // Execute executes the main data collection loop
 func (r *Runner) Execute() error {
+	if r.options.DryRun {
+		return r.dryRun()
+	}
+	if r.options.Preflight {
+		if err := r.preflight(); err != nil {
+			return errors.Wrap(err, "preflight failed")
+		}
+	}
+	started := time.Now()
+	r.stats.Started = started
+
 	// Create the worker goroutines for processing
 	inputs := make(chan taskInput, r.options.Concurrency)
 	wg := &sync.WaitGroup{}
@@ -104,6 +338,13 @@ func (r *Runner) Execute() error {
 		wg.Add(1)
 		go r.processInputElementWorker(inputs, wg)
 	}
+	discoveryWg := &sync.WaitGroup{}
+	if r.discoverer != nil {
+		for i := 0; i < r.options.Concurrency; i++ {
+			discoveryWg.Add(1)
+			go r.processDiscoveredWorker(discoveryWg)
+		}
+	}
 	// Queue inputs
 	if err := r.normalizeAndQueueInputs(inputs); err != nil {
 		gologger.Error().Msgf("Could not normalize queue inputs: %s", err)
@@ -111,12 +352,54 @@ func (r *Runner) Execute() error {
 
 	close(inputs)
 	wg.Wait()
+	if r.discoverer != nil {
+		close(r.discoverer.tasks)
+		discoveryWg.Wait()
+	}
+	r.stats.Finished = time.Now()
+
+	if r.sniMatrix != nil && r.outputErr == nil {
+		for _, response := range r.sniMatrix.responses() {
+			if !r.writeOutput(response) {
+				break
+			}
+		}
+	}
+	if r.outputErr != nil {
+		return errors.Wrap(r.outputErr, "scan aborted")
+	}
+
+	if r.sampler != nil {
+		gologger.Info().Msgf("Sampled %d of %d targets", r.sampler.sampled, r.sampler.queued)
+	}
+	if r.inputReport.skipped > 0 {
+		gologger.Info().Msgf("Skipped %d invalid input lines", r.inputReport.skipped)
+	}
+
+	if r.metricsClient != nil {
+		r.metricsClient.Count("scans", 1)
+		r.metricsClient.Timing("scan.duration", time.Since(started))
+	}
+
+	if r.duplicates != nil {
+		for _, duplicate := range r.duplicates.Duplicates() {
+			gologger.Info().Msgf("Duplicate %s %s presented by unrelated subjects: %s", duplicate.Type, duplicate.Value, strings.Join(duplicate.Hosts, ", "))
+		}
+	}
 
 	// Print the stats if auto fallback mode is used
 	if r.options.ScanMode == "auto" {
 		gologger.Info().Msgf("Connections made using crypto/tls: %d", stats.LoadCryptoTLSConnections())
 		gologger.Info().Msgf("Connections made using zcrypto/tls: %d", stats.LoadZcryptoTLSConnections())
 	}
+	if r.reportSummarizer != nil {
+		r.reportMutex.Lock()
+		summary := r.reportSummarizer.Summary()
+		r.reportMutex.Unlock()
+		if err := report.WritePDFFile(r.options.ReportPDF, summary, r.reportLocale); err != nil {
+			return errors.Wrap(err, "could not write pdf report")
+		}
+	}
 	return nil
 }
 
@@ -125,27 +408,270 @@ func (r *Runner) processInputElementWorker(inputs chan taskInput, wg *sync.WaitG
 	defer wg.Done()
 
 	for task := range inputs {
+		if r.discoverer == nil {
+			r.processTask(task)
+			continue
+		}
+		r.discoverer.claim(task)
+		// ports are discovered after releasing the subnet limit as
+		// discovered addresses are limited by the same subnet
+		if r.processTask(task) {
+			r.discoverer.discover(task)
+		}
+	}
+}
+
+// processDiscoveredWorker processes discovered companion ports
+func (r *Runner) processDiscoveredWorker(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for task := range r.discoverer.tasks {
+		if r.discoverer.claim(task) {
+			r.processTask(task)
+		}
+	}
+}
+
+// processTask processes a task within the subnet limit, returning true
+// if the target responded.
+func (r *Runner) processTask(task taskInput) bool {
+	if r.subnetLimiter == nil {
+		return r.processInputElement(task)
+	}
+	subnet := r.subnetLimiter.acquire(task.host)
+	defer r.subnetLimiter.release(subnet)
+	return r.processInputElement(task)
+}
+
+// processInputElement processes a single input, returning true if the
+// target responded.
+func (r *Runner) processInputElement(task taskInput) bool {
+	if atomic.LoadUint32(&r.outputFailed) == 1 {
+		return false
+	}
+	if r.options.Verbose {
+		gologger.Info().Msgf("Processing input %s:%s", task.host, task.port)
+	}
+	// discovered ports are only counted if they respond
+	if !task.discovered {
+		r.countTarget()
+	}
+	if r.checkService != nil && !r.hasChanged(task) {
 		if r.options.Verbose {
-			gologger.Info().Msgf("Processing input %s:%s", task.host, task.port)
+			gologger.Info().Msgf("Skipping unchanged input %s", task.Address())
+		}
+		return false
+	}
+	service := r.tlsxService
+	if task.sni != "" {
+		service = r.sniServices[task.sni]
+	}
+	response, err := service.Connect(task.host, task.port)
+	if err != nil && task.discovered {
+		gologger.Verbose().Msgf("No tls service discovered on %s: %s", task.Address(), err)
+		return false
+	}
+	if task.discovered {
+		r.countTarget()
+	}
+	if err != nil {
+		gologger.Warning().Msgf("Could not connect input %s: %s", task.Address(), err)
+		atomic.AddUint64(&r.stats.Errors, 1)
+		if r.retryWriter != nil {
+			if err := r.retryWriter.Write(task.host, task.port, err); err != nil {
+				gologger.Warning().Msgf("Could not write retry entry %s: %s", task.Address(), err)
+			}
+		}
+		if r.metricsClient != nil {
+			r.metricsClient.Count("errors", 1)
+		}
+		if r.options.DetectService || r.options.ErrorResults {
+			r.writeErrorResponse(task, err)
 		}
-		response, err := r.tlsxService.Connect(task.host, task.port)
+		return false
+	}
+	if r.metricsClient != nil {
+		r.metricsClient.Result(response)
+	}
+	if response == nil {
+		return false
+	}
+	if r.options.ExcludeHosting && response.Hosting != nil {
+		gologger.Verbose().Msgf("Excluding hosting result %s", task.Address())
+		return true
+	}
+	if r.options.ExpiringOnly && !response.Expiring && !response.Expired {
+		gologger.Verbose().Msgf("Excluding not expiring result %s", task.Address())
+		return true
+	}
+	if r.options.WeakKeyOnly && !response.WeakKey {
+		gologger.Verbose().Msgf("Excluding result without weak key %s", task.Address())
+		return true
+	}
+	response.Discovered = task.discovered
+	if task.sipDomain != "" && len(response.RawChain) > 0 {
+		if response.SIPDomain, err = sip.CheckDomain(task.sipDomain, response.RawChain[0]); err != nil {
+			gologger.Verbose().Msgf("Could not check sip domain for %s: %s", task.Address(), err)
+		}
+	}
+	atomic.AddUint64(&r.stats.Results, 1)
+	var members []duplicates.Member
+	if r.duplicates != nil {
+		response.Duplicates, members = r.duplicates.Observe(response)
+	}
+	if r.sniMatrix != nil {
+		r.sniMatrix.observe(response, response.SNI)
+	}
+	if r.options.RemediationHints {
+		response.Findings = r.findings.Findings(response, clients.Now(r.options), r.options.ExpiringWindow)
+	}
+	r.writeOutput(response)
+	if r.reportSummarizer != nil {
+		r.reportMutex.Lock()
+		r.reportSummarizer.Add(response)
+		r.reportMutex.Unlock()
+	}
+	// hosts written before their value became shared are flagged again
+	for _, member := range members {
+		flagged := &clients.Response{Timestamp: time.Now(), Host: member.Host, Port: member.Port, Duplicates: member.Duplicates}
+		if r.options.RemediationHints {
+			flagged.Findings = r.findings.Findings(flagged, clients.Now(r.options), r.options.ExpiringWindow)
+		}
+		r.writeOutput(flagged)
+	}
+	return true
+}
+
+// writeOutput writes a response to the outputs, returning false if an
+// output with the fail policy failed and the scan has to stop.
+func (r *Runner) writeOutput(response *clients.Response) bool {
+	err := r.outputWriter.Write(response)
+	if err == nil {
+		return true
+	}
+	r.outputErrOnce.Do(func() {
+		r.outputErr = err
+		atomic.StoreUint32(&r.outputFailed, 1)
+	})
+	return false
+}
+
+// countTarget counts a processed target
+func (r *Runner) countTarget() {
+	atomic.AddUint64(&r.stats.Targets, 1)
+	if r.metricsClient != nil {
+		r.metricsClient.Count("targets", 1)
+	}
+}
+
+// writeErrorResponse writes the error of an input which failed tls
+// handshake as a response, with the detected plaintext service if
+// service detection is enabled. Without error results only inputs with
+// a detected service are written.
+func (r *Runner) writeErrorResponse(task taskInput, connectErr error) {
+	response := &clients.Response{
+		Timestamp: time.Now(),
+		Host:      task.host,
+		Port:      task.port,
+		Error:     errors.Cause(connectErr).Error(),
+	}
+	if r.options.DetectService {
+		result, err := plaintext.Detect(r.options, task.Address(), time.Duration(r.options.Timeout)*time.Second)
 		if err != nil {
-			gologger.Warning().Msgf("Could not connect input %s: %s", task.Address(), err)
+			gologger.Verbose().Msgf("Could not detect service for %s: %s", task.Address(), err)
+			if !r.options.ErrorResults {
+				return
+			}
+		} else {
+			response.Service, response.Banner = result.Service, result.Banner
+		}
+	}
+	r.writeOutput(response)
+}
+
+// handshakeState is the state of a host compared with rescan-changed-only
+type handshakeState struct {
+	certificate string
+	version     string
+	cipher      string
+	// chain is the list of sha256 fingerprints of the presented chain,
+	// nil if previous results were written without -tls-chain
+	chain []string
+	// jarm is the jarm fingerprint, empty if previous results were
+	// written without -jarm
+	jarm string
+}
+
+// setupChangeCheck loads the handshake states from previous results
+// and creates the service used for single handshake change checks.
+func (r *Runner) setupChangeCheck() error {
+	results, err := report.ReadResults(r.options.PreviousResults)
+	if err != nil {
+		return errors.Wrap(err, "could not read previous results")
+	}
+	r.previousStates = make(map[string]*handshakeState, len(results))
+	for _, result := range results {
+		if result.FingerprintHash.SHA256 == "" {
 			continue
 		}
-		if response != nil {
-			if err := r.outputWriter.Write(response); err != nil {
-				gologger.Warning().Msgf("Could not write output %s: %s", task.Address(), err)
+		state := &handshakeState{certificate: result.FingerprintHash.SHA256, version: result.Version, cipher: result.Cipher, jarm: result.JARM}
+		for _, cert := range result.Chain {
+			state.chain = append(state.chain, cert.FingerprintHash.SHA256)
+		}
+		r.previousStates[net.JoinHostPort(result.Host, result.Port)] = state
+	}
+
+	// a full handshake in the scan mode without probes negotiates the same
+	// version and cipher as the scan, which early termination would not report.
+	checkService, err := tlsx.New(r.options.HandshakeOptions())
+	if err != nil {
+		return errors.Wrap(err, "could not create check service")
+	}
+	r.checkService = checkService
+	return nil
+}
+
+// hasChanged returns true if the certificate, version, cipher, chain or
+// jarm fingerprint for the input differs from previous results or could not be compared.
+func (r *Runner) hasChanged(task taskInput) bool {
+	previous, ok := r.previousStates[task.Address()]
+	if !ok {
+		return true
+	}
+	response, err := r.checkService.Connect(task.host, task.port)
+	if err != nil {
+		return true
+	}
+	if response.FingerprintHash.SHA256 != previous.certificate || response.Version != previous.version || response.Cipher != previous.cipher {
+		return true
+	}
+	if previous.chain != nil {
+		if len(response.RawChain) != len(previous.chain) {
+			return true
+		}
+		for i, raw := range response.RawChain {
+			if clients.SHA256Fingerprint(raw) != previous.chain[i] {
+				return true
 			}
 		}
 	}
+	if previous.jarm != "" {
+		fingerprint, err := jarm.Fingerprint(r.options, task.host, task.port)
+		if err != nil || fingerprint != previous.jarm {
+			return true
+		}
+	}
+	return false
 }
 
 // normalizeAndQueueInputs normalizes the inputs and queues them for execution
 func (r *Runner) normalizeAndQueueInputs(inputs chan taskInput) error {
+	if r.shuffler != nil {
+		defer r.shuffler.flush(inputs)
+	}
 	// Process Normal Inputs
-	for _, text := range r.options.Inputs {
-		r.processInputItem(text, inputs)
+	for i, text := range r.options.Inputs {
+		r.processInputLine("input", i+1, text, inputs)
 	}
 
 	if r.options.InputList != "" {
@@ -156,71 +682,149 @@ func (r *Runner) normalizeAndQueueInputs(inputs chan taskInput) error {
 		defer file.Close()
 
 		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			text := scanner.Text()
-			if text != "" {
-				r.processInputItem(text, inputs)
-			}
+		for line := 1; scanner.Scan(); line++ {
+			r.processInputLine(r.options.InputList, line, scanner.Text(), inputs)
+		}
+	}
+	if r.options.RetryFile != "" {
+		entries, err := retry.Read(r.options.RetryFile)
+		if err != nil {
+			return errors.Wrap(err, "could not read retry file")
+		}
+		for _, entry := range entries {
+			r.queue(inputs, taskInput{host: entry.Host, port: entry.Port})
 		}
 	}
 	if r.hasStdin {
 		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			text := scanner.Text()
-			if text != "" {
-				r.processInputItem(text, inputs)
-			}
+		for line := 1; scanner.Scan(); line++ {
+			r.processInputLine("stdin", line, scanner.Text(), inputs)
 		}
 	}
 	return nil
 }
 
+// processInputLine processes an input line of source reporting it if skipped
+func (r *Runner) processInputLine(source string, line int, text string, inputs chan taskInput) {
+	if text == "" {
+		return
+	}
+	if err := r.processInputItem(text, inputs); err != nil {
+		r.inputReport.skip(source, line, text, err)
+	}
+}
+
 // processInputItem processes a single input item
-func (r *Runner) processInputItem(input string, inputs chan taskInput) {
+func (r *Runner) processInputItem(input string, inputs chan taskInput) error {
 	// CIDR input
 	if _, ipRange, _ := net.ParseCIDR(input); ipRange != nil {
 		cidrInputs, err := mapcidr.IPAddressesAsStream(input)
 		if err != nil {
-			gologger.Error().Msgf("Could not parse cidr %s: %s", input, err)
-			return
+			return newInputError(skipInvalidCIDR, err)
 		}
 		for cidr := range cidrInputs {
 			for _, port := range r.options.Ports {
-				inputs <- taskInput{host: cidr, port: port}
+				r.queue(inputs, taskInput{host: cidr, port: port})
 			}
 		}
+		return nil
+	}
+	if index := strings.Index(input, "/"); index != -1 && net.ParseIP(input[:index]) != nil {
+		_, _, err := net.ParseCIDR(input)
+		return newInputError(skipInvalidCIDR, err)
+	}
+
+	// Normal input
+	host, customPort, err := r.getHostPortFromInput(input)
+	if err != nil {
+		return err
+	}
+	if r.options.SIPSRV && !iputil.IsIP(host) {
+		r.queueSIPTargets(host, customPort, inputs)
+		return nil
+	}
+	if customPort == "" {
+		for _, port := range r.options.Ports {
+			r.queue(inputs, taskInput{host: host, port: port})
+		}
 	} else {
-		// Normal input
-		host, customPort := r.getHostPortFromInput(input)
-		if customPort == "" {
-			for _, port := range r.options.Ports {
-				inputs <- taskInput{host: host, port: port}
-			}
-		} else {
-			inputs <- taskInput{host: host, port: customPort}
+		r.queue(inputs, taskInput{host: host, port: customPort})
+	}
+	return nil
+}
+
+// queueSIPTargets queues the sips servers of a domain from its srv records,
+// or the domain itself on the specified or default sips port without them.
+func (r *Runner) queueSIPTargets(domain, port string, inputs chan taskInput) {
+	if port == "" {
+		targets, err := sip.LookupTargets(r.options, domain)
+		if err != nil {
+			gologger.Verbose().Msgf("Could not lookup sips servers for %s: %s", domain, err)
+		}
+		for _, target := range targets {
+			r.queue(inputs, taskInput{host: target.Host, port: target.Port, sipDomain: domain})
+		}
+		if len(targets) > 0 {
+			return
+		}
+		port = sip.DefaultPort
+	}
+	r.queue(inputs, taskInput{host: domain, port: port, sipDomain: domain})
+}
+
+// queue queues a task for execution if it is part of the sample, or
+// buffers it to be queued in shuffled order.
+func (r *Runner) queue(inputs chan taskInput, task taskInput) {
+	if len(r.sniServices) > 0 && task.sni == "" {
+		// every server name is connected with separately
+		for _, name := range r.options.ServerNames {
+			task.sni = name
+			r.queue(inputs, task)
 		}
+		return
+	}
+	if r.sampler != nil && !r.sampler.keep(task.Address()) {
+		return
 	}
+	if r.shuffler != nil {
+		r.shuffler.add(task)
+		return
+	}
+	inputs <- task
 }
 
 // getHostPortFromInput returns host and optionally port from input.
 // If no ports are found, port field is left blank and user specified ports
 // are used.
-func (r *Runner) getHostPortFromInput(input string) (string, string) {
+func (r *Runner) getHostPortFromInput(input string) (string, string, error) {
 	host := input
 
 	if strings.Contains(input, "://") {
-		if parsed, err := url.Parse(input); err != nil {
-			return "", ""
-		} else {
-			host = parsed.Host
+		parsed, err := url.Parse(input)
+		if err != nil {
+			return "", "", newInputError(skipInvalidURL, err)
 		}
-	}
-	if strings.Contains(host, ":") {
-		if host, port, err := net.SplitHostPort(host); err != nil {
-			return "", ""
-		} else {
-			return host, port
+		if parsed.Host == "" {
+			return "", "", newInputError(skipInvalidURL, errors.New("url has no host"))
 		}
+		host = parsed.Host
+	}
+	if !strings.Contains(host, ":") {
+		return host, "", nil
+	}
+	// bare ipv6 addresses are used with the specified ports
+	if ip := net.ParseIP(host); ip != nil {
+		return host, "", nil
+	}
+	host, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return "", "", newInputError(skipInvalidAddress, err)
+	}
+	if host == "" {
+		return "", "", newInputError(skipInvalidAddress, errors.New("missing host"))
+	}
+	if value, err := strconv.Atoi(port); err != nil || value < 1 || value > 65535 {
+		return "", "", newInputError(skipInvalidPort, errors.Errorf("invalid port %q", port))
 	}
-	return host, ""
+	return host, port, nil
 }
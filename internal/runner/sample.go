@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// sampler selects a subset of targets for quick estimation runs
+type sampler struct {
+	// threshold selects targets whose hash is below it out of sampleScale
+	threshold uint64
+	// every selects every nth target when non-zero
+	every   uint64
+	seed    int64
+	counter uint64
+
+	queued  uint64
+	sampled uint64
+}
+
+// sampleScale is the resolution of percentage based sampling
+const sampleScale = 1000000
+
+// newSampler creates a sampler from a rate such as 1% or 1/100
+func newSampler(rate string, seed int) (*sampler, error) {
+	s := &sampler{seed: int64(seed)}
+	switch {
+	case strings.HasSuffix(rate, "%"):
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(rate, "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid sample percentage: %s", rate)
+		}
+		s.threshold = uint64(percent / 100 * sampleScale)
+	case strings.HasPrefix(rate, "1/"):
+		every, err := strconv.ParseUint(strings.TrimPrefix(rate, "1/"), 10, 64)
+		if err != nil || every == 0 {
+			return nil, fmt.Errorf("invalid sample interval: %s", rate)
+		}
+		s.every = every
+	default:
+		return nil, errors.New("sample must be a percentage (1%) or an interval (1/100)")
+	}
+	return s, nil
+}
+
+// keep returns true if the target address is part of the sample.
+// Percentage sampling hashes the address with the seed so the same
+// targets are selected regardless of input order, while interval
+// sampling selects every nth target starting at an offset from the seed.
+func (s *sampler) keep(address string) bool {
+	s.queued++
+	var selected bool
+	if s.every > 0 {
+		selected = (s.counter+uint64(s.seed))%s.every == 0
+		s.counter++
+	} else {
+		hash := fnv.New64a()
+		_ = binary.Write(hash, binary.BigEndian, s.seed)
+		_, _ = hash.Write([]byte(address))
+		selected = hash.Sum64()%sampleScale < s.threshold
+	}
+	if selected {
+		s.sampled++
+	}
+	return selected
+}
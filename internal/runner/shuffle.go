@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// shuffler buffers targets and orders them randomly, spreading the
+// targets of each subnet evenly over the scan so that no subnet receives
+// a burst of connections.
+type shuffler struct {
+	random *rand.Rand
+	// subnets is the list of buffered targets by subnet
+	subnets map[string][]taskInput
+	// order is the list of subnets in the order first seen
+	order []string
+}
+
+// newShuffler creates a shuffler ordering targets using seed
+func newShuffler(seed int) *shuffler {
+	return &shuffler{random: rand.New(rand.NewSource(int64(seed))), subnets: make(map[string][]taskInput)}
+}
+
+// add buffers a target
+func (s *shuffler) add(task taskInput) {
+	subnet := targetSubnet(task.host)
+	if _, ok := s.subnets[subnet]; !ok {
+		s.order = append(s.order, subnet)
+	}
+	s.subnets[subnet] = append(s.subnets[subnet], task)
+}
+
+// flush queues the buffered targets to inputs. Targets of a subnet with
+// n targets are shuffled and placed at a random offset within successive
+// 1/n slices of the scan, which weights subnets by their size.
+func (s *shuffler) flush(inputs chan taskInput) {
+	type positionedTask struct {
+		position float64
+		task     taskInput
+	}
+	var tasks []positionedTask
+	for _, subnet := range s.order {
+		targets := s.subnets[subnet]
+		s.random.Shuffle(len(targets), func(i, j int) {
+			targets[i], targets[j] = targets[j], targets[i]
+		})
+		for i, task := range targets {
+			position := (float64(i) + s.random.Float64()) / float64(len(targets))
+			tasks = append(tasks, positionedTask{position: position, task: task})
+		}
+	}
+	s.subnets, s.order = nil, nil
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].position < tasks[j].position
+	})
+	for _, task := range tasks {
+		inputs <- task.task
+	}
+}
+
+// targetSubnet returns the subnet of ip hosts (clients.Subnet),
+// hostnames are their own subnet.
+func targetSubnet(host string) string {
+	if subnet := clients.Subnet(host); subnet != nil {
+		return subnet.String()
+	}
+	return strings.ToLower(host)
+}
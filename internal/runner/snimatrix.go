@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// sniMatrix collects the certificates presented for each server name
+// by the same ip and port, such as virtual hosts sharing an address.
+type sniMatrix struct {
+	mutex sync.Mutex
+	// addresses is the leaf fingerprint of each server name by address
+	addresses map[string]map[string]string
+}
+
+// newSNIMatrix returns a new empty sni matrix
+func newSNIMatrix() *sniMatrix {
+	return &sniMatrix{addresses: make(map[string]map[string]string)}
+}
+
+// observe records the certificate of a response for the server name
+// sent, responses without a server name are ignored.
+func (m *sniMatrix) observe(response *clients.Response, serverName string) {
+	if serverName == "" && !iputil.IsIP(response.Host) {
+		serverName = response.Host
+	}
+	ip := response.IP
+	if ip == "" {
+		ip = response.Host
+	}
+	if serverName == "" || !iputil.IsIP(ip) || response.FingerprintHash.SHA256 == "" {
+		return
+	}
+	address := net.JoinHostPort(ip, response.Port)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	names, ok := m.addresses[address]
+	if !ok {
+		names = make(map[string]string)
+		m.addresses[address] = names
+	}
+	names[serverName] = response.FingerprintHash.SHA256
+}
+
+// responses returns a matrix record for every address tested with
+// multiple server names, sorted by address and server name.
+func (m *sniMatrix) responses() []*clients.Response {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	addresses := make([]string, 0, len(m.addresses))
+	for address, names := range m.addresses {
+		if len(names) > 1 {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+
+	responses := make([]*clients.Response, 0, len(addresses))
+	for _, address := range addresses {
+		ip, port, _ := net.SplitHostPort(address)
+		response := &clients.Response{Timestamp: time.Now(), Host: ip, IP: ip, Port: port}
+		for name, fingerprint := range m.addresses[address] {
+			response.SNIMatrix = append(response.SNIMatrix, clients.SNIMatrixEntry{SNI: name, FingerprintSHA256: fingerprint})
+		}
+		sort.Slice(response.SNIMatrix, func(i, j int) bool {
+			return response.SNIMatrix[i].SNI < response.SNIMatrix[j].SNI
+		})
+		responses = append(responses, response)
+	}
+	return responses
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// subnetLimiter limits the number of targets of a subnet processed at
+// the same time independent of the global concurrency.
+type subnetLimiter struct {
+	max    int
+	dialer *fastdialer.Dialer
+
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	inFlight map[string]int
+}
+
+// newSubnetLimiter creates a limiter allowing max targets per subnet.
+// Hostnames are resolved with dialer if not nil to find their subnet.
+func newSubnetLimiter(max int, dialer *fastdialer.Dialer) *subnetLimiter {
+	limiter := &subnetLimiter{max: max, dialer: dialer, inFlight: make(map[string]int)}
+	limiter.cond = sync.NewCond(&limiter.mutex)
+	return limiter
+}
+
+// acquire blocks until the subnet of host is below the limit and
+// returns the subnet to release once the target is processed.
+func (l *subnetLimiter) acquire(host string) string {
+	subnet := l.subnet(host)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for l.inFlight[subnet] >= l.max {
+		l.cond.Wait()
+	}
+	l.inFlight[subnet]++
+	return subnet
+}
+
+// release marks a target of subnet as processed
+func (l *subnetLimiter) release(subnet string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.inFlight[subnet]--; l.inFlight[subnet] <= 0 {
+		delete(l.inFlight, subnet)
+	}
+	l.cond.Broadcast()
+}
+
+// subnet returns the subnet of ip hosts (clients.Subnet). Hostnames are limited by their first resolved address,
+// or by name if they cannot be resolved.
+func (l *subnetLimiter) subnet(host string) string {
+	if !iputil.IsIP(host) && l.dialer != nil {
+		if dnsData, err := l.dialer.GetDNSData(host); err == nil {
+			if addresses := append(dnsData.A, dnsData.AAAA...); len(addresses) > 0 {
+				host = addresses[0]
+			}
+		}
+	}
+	if subnet := clients.Subnet(host); subnet != nil {
+		return subnet.String()
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,246 @@
+// Package datasource implements a http server exposing saved scan
+// results using the grafana simple-json datasource contract.
+//
+// The results file is read on every request so results appended by
+// running scans are reflected without restarting the server.
+package datasource
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/report"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// tableTarget is the target name returning results as a table
+const tableTarget = "certificates"
+
+// metricTargets are the time series targets supported by the datasource
+var metricTargets = map[string]func(*clients.Response) bool{
+	"results":     func(r *clients.Response) bool { return true },
+	"expired":     func(r *clients.Response) bool { return r.Expired },
+	"self-signed": func(r *clients.Response) bool { return r.SelfSigned },
+	"weak-cipher": func(r *clients.Response) bool { return clients.IsWeakCipher(r.Cipher) },
+	"tls10":       func(r *clients.Response) bool { return r.Version == "tls10" },
+	"tls11":       func(r *clients.Response) bool { return r.Version == "tls11" },
+	"tls12":       func(r *clients.Response) bool { return r.Version == "tls12" },
+	"tls13":       func(r *clients.Response) bool { return r.Version == "tls13" },
+}
+
+// Server is a grafana datasource server for a results file
+type Server struct {
+	file string
+}
+
+// New creates a new datasource server for a json lines results file
+func New(file string) *Server {
+	return &Server{file: file}
+}
+
+// ListenAndServe listens on address and serves datasource requests
+func (s *Server) ListenAndServe(address string) error {
+	return http.ListenAndServe(address, s.Handler())
+}
+
+// Handler returns the http handler for the datasource
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleHealth)
+	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/query", s.handleQuery)
+	mux.HandleFunc("/annotations", s.handleAnnotations)
+	mux.HandleFunc("/results", s.handleResults)
+	return mux
+}
+
+type queryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	IntervalMs int64 `json:"intervalMs"`
+	Targets    []struct {
+		Target string `json:"target"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+}
+
+type timeSeries struct {
+	Target     string     `json:"target"`
+	Datapoints [][2]int64 `json:"datapoints"`
+}
+
+type tableColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type table struct {
+	Type    string          `json:"type"`
+	Columns []tableColumn   `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// handleHealth responds to the datasource connection test
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleSearch returns the list of available targets
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	targets := make([]string, 0, len(metricTargets)+1)
+	for target := range metricTargets {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	targets = append(targets, tableTarget)
+	writeJSON(w, targets)
+}
+
+// handleAnnotations returns no annotations as none are supported
+func (s *Server) handleAnnotations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, []interface{}{})
+}
+
+// handleResults returns all results as a json array for use
+// with the infinity datasource.
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	results, err := report.ReadResults(s.file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if results == nil {
+		results = []*clients.Response{}
+	}
+	writeJSON(w, results)
+}
+
+// handleQuery returns time series or table data for requested targets
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	request := &queryRequest{}
+	if err := jsoniter.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, errors.Wrap(err, "could not decode query").Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := report.ReadResults(s.file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	results = filterRange(results, request.Range.From, request.Range.To)
+
+	interval := request.IntervalMs
+	if interval <= 0 {
+		interval = int64(time.Minute / time.Millisecond)
+	}
+	response := make([]interface{}, 0, len(request.Targets))
+	for _, target := range request.Targets {
+		if target.Target == tableTarget || target.Type == "table" {
+			response = append(response, buildTable(results))
+			continue
+		}
+		matcher, ok := metricTargets[target.Target]
+		if !ok {
+			continue
+		}
+		response = append(response, buildTimeSeries(target.Target, results, matcher, interval))
+	}
+	writeJSON(w, response)
+}
+
+// filterRange returns results with timestamp in the range if it is specified
+func filterRange(results []*clients.Response, from, to time.Time) []*clients.Response {
+	if from.IsZero() && to.IsZero() {
+		return results
+	}
+	filtered := results[:0]
+	for _, result := range results {
+		if !from.IsZero() && result.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && result.Timestamp.After(to) {
+			continue
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+// buildTimeSeries buckets matching results into interval sized datapoints
+func buildTimeSeries(target string, results []*clients.Response, matcher func(*clients.Response) bool, interval int64) timeSeries {
+	buckets := make(map[int64]int64)
+	for _, result := range results {
+		if result.Timestamp.IsZero() || !matcher(result) {
+			continue
+		}
+		timestamp := result.Timestamp.UnixNano() / int64(time.Millisecond)
+		buckets[timestamp-timestamp%interval]++
+	}
+	series := timeSeries{Target: target, Datapoints: make([][2]int64, 0, len(buckets))}
+	for timestamp, value := range buckets {
+		series.Datapoints = append(series.Datapoints, [2]int64{value, timestamp})
+	}
+	sort.Slice(series.Datapoints, func(i, j int) bool {
+		return series.Datapoints[i][1] < series.Datapoints[j][1]
+	})
+	return series
+}
+
+// buildTable returns results as a table of certificate details
+func buildTable(results []*clients.Response) table {
+	data := table{
+		Type: "table",
+		Columns: []tableColumn{
+			{Text: "Time", Type: "time"},
+			{Text: "Host", Type: "string"},
+			{Text: "Port", Type: "string"},
+			{Text: "Version", Type: "string"},
+			{Text: "Cipher", Type: "string"},
+			{Text: "Subject CN", Type: "string"},
+			{Text: "Issuer CN", Type: "string"},
+			{Text: "Not After", Type: "time"},
+			{Text: "Expired", Type: "string"},
+			{Text: "Self Signed", Type: "string"},
+			{Text: "Subject AN", Type: "string"},
+		},
+		Rows: make([][]interface{}, 0, len(results)),
+	}
+	for _, result := range results {
+		data.Rows = append(data.Rows, []interface{}{
+			result.Timestamp.UnixNano() / int64(time.Millisecond),
+			result.Host,
+			result.Port,
+			result.Version,
+			result.Cipher,
+			result.SubjectCN,
+			result.IssuerCN,
+			result.NotAfter.UnixNano() / int64(time.Millisecond),
+			boolString(result.Expired),
+			boolString(result.SelfSigned),
+			strings.Join(result.SubjectAN, ","),
+		})
+	}
+	return data
+}
+
+func boolString(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = jsoniter.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,295 @@
+// Package duplicates implements detection of certificate serial numbers
+// and public keys shared across unrelated subjects within a scan, which
+// indicates cloned appliances or broken key generation.
+package duplicates
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of duplicate types detected for responses
+const (
+	TypeSerial    = "serial"
+	TypePublicKey = "public-key"
+)
+
+// Tracker tracks serial numbers and public keys seen during a scan
+type Tracker struct {
+	mutex   sync.Mutex
+	serials map[string]*group
+	keys    map[string]*group
+}
+
+// group is the set of subjects sharing a serial number or public key
+type group struct {
+	value string
+	// subjects maps each subject to the addresses presenting it
+	subjects map[string][]string
+	// organizations and ranges are the distinct subject organizations
+	// and ip ranges presenting the value
+	organizations map[string]struct{}
+	ranges        map[string]struct{}
+	// shared is true once unrelated subjects present the value
+	shared bool
+}
+
+// Member is a host observed before the value it presents became shared
+// with an unrelated subject, along with the duplicates it is flagged for.
+type Member struct {
+	Host       string
+	Port       string
+	Duplicates []clients.Duplicate
+}
+
+// New creates a new duplicate tracker
+func New() *Tracker {
+	return &Tracker{serials: make(map[string]*group), keys: make(map[string]*group)}
+}
+
+// Observe records the leaf certificate of a response and returns the
+// duplicates shared with unrelated subjects observed earlier. The hosts
+// observed before a value became shared are returned as members to be
+// flagged as well, once per value.
+func (t *Tracker) Observe(response *clients.Response) ([]clients.Duplicate, []Member) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	serial, key, subject := t.add(response)
+	var duplicates []clients.Duplicate
+	var members []Member
+	flag := func(g *group, duplicateType string) {
+		duplicate := g.duplicate(subject)
+		if duplicate == nil {
+			return
+		}
+		duplicate.Type = duplicateType
+		duplicates = append(duplicates, *duplicate)
+		if g.shared {
+			return
+		}
+		g.shared = true
+		// only the first subject was observed before the value was shared
+		for other, addresses := range g.subjects {
+			if other == subject {
+				continue
+			}
+			earlier := g.duplicate(other)
+			earlier.Type = duplicateType
+			for _, address := range addresses {
+				members = addMember(members, address, *earlier)
+			}
+		}
+	}
+	if serial != nil {
+		flag(serial, TypeSerial)
+	}
+	if key != nil {
+		flag(key, TypePublicKey)
+	}
+	return duplicates, members
+}
+
+// addMember adds a duplicate to the member of address
+func addMember(members []Member, address string, duplicate clients.Duplicate) []Member {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return members
+	}
+	for i := range members {
+		if members[i].Host == host && members[i].Port == port {
+			members[i].Duplicates = append(members[i].Duplicates, duplicate)
+			return members
+		}
+	}
+	return append(members, Member{Host: host, Port: port, Duplicates: []clients.Duplicate{duplicate}})
+}
+
+// Add records the leaf certificate of a response without looking up duplicates
+func (t *Tracker) Add(response *clients.Response) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.add(response)
+}
+
+// add records the leaf certificate of a response returning the serial
+// and key groups of the certificate if any, and its subject.
+func (t *Tracker) add(response *clients.Response) (*group, *group, string) {
+	cert := response.CertificateResponse
+	subject := cert.SubjectDN
+	if subject == "" {
+		subject = cert.SubjectCN
+	}
+	address := net.JoinHostPort(response.Host, response.Port)
+
+	var serial, key *group
+	if cert.Serial != "" {
+		// serial numbers are only unique per issuer
+		serial = observe(t.serials, cert.IssuerDN+"/"+cert.Serial, cert.Serial, subject, address)
+	}
+	if cert.PinSHA256 != "" {
+		key = observe(t.keys, cert.PinSHA256, cert.PinSHA256, subject, address)
+		for _, organization := range subjectOrganizations(&cert) {
+			key.organizations[organization] = struct{}{}
+		}
+		ip := response.IP
+		if ip == "" {
+			ip = response.Host
+		}
+		if subnet := clients.Subnet(ip); subnet != nil {
+			key.ranges[subnet.String()] = struct{}{}
+		}
+	}
+	return serial, key, subject
+}
+
+// subjectOrganizations returns the subject organizations of cert, or the
+// registered domain of the common name for certificates without one.
+func subjectOrganizations(cert *clients.CertificateResponse) []string {
+	if len(cert.SubjectOrg) > 0 {
+		return cert.SubjectOrg
+	}
+	if name := strings.TrimPrefix(clients.NormalizeName(cert.SubjectCN), "*."); name != "" {
+		return []string{clients.RegisteredDomain(name)}
+	}
+	return nil
+}
+
+// observe adds a subject to the group for key returning the group
+func observe(groups map[string]*group, key, value, subject, address string) *group {
+	g, ok := groups[key]
+	if !ok {
+		g = &group{
+			value:         value,
+			subjects:      make(map[string][]string),
+			organizations: make(map[string]struct{}),
+			ranges:        make(map[string]struct{}),
+		}
+		groups[key] = g
+	}
+	g.subjects[subject] = append(g.subjects[subject], address)
+	return g
+}
+
+// duplicate returns a duplicate with the addresses of subjects of the
+// group other than subject, nil if there are none.
+func (g *group) duplicate(subject string) *clients.Duplicate {
+	var hosts []string
+	for other, addresses := range g.subjects {
+		if other != subject {
+			hosts = append(hosts, addresses...)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	sort.Strings(hosts)
+	return &clients.Duplicate{Value: g.value, Hosts: hosts}
+}
+
+// Duplicates returns every serial number and public key shared by
+// unrelated subjects with all addresses presenting them.
+func (t *Tracker) Duplicates() []clients.Duplicate {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var duplicates []clients.Duplicate
+	collect := func(groups map[string]*group, duplicateType string) {
+		for _, g := range groups {
+			if len(g.subjects) < 2 {
+				continue
+			}
+			duplicate := clients.Duplicate{Type: duplicateType, Value: g.value}
+			for _, addresses := range g.subjects {
+				duplicate.Hosts = append(duplicate.Hosts, addresses...)
+			}
+			sort.Strings(duplicate.Hosts)
+			duplicates = append(duplicates, duplicate)
+		}
+	}
+	collect(t.serials, TypeSerial)
+	collect(t.keys, TypePublicKey)
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Type != duplicates[j].Type {
+			return duplicates[i].Type < duplicates[j].Type
+		}
+		return duplicates[i].Value < duplicates[j].Value
+	})
+	return duplicates
+}
+
+// SharedKey is a public key served by distinct organizations
+type SharedKey struct {
+	// PinSHA256 is the spki pin-sha256 of the shared key
+	PinSHA256 string `json:"pin-sha256"`
+	// Hosts is the number of endpoints serving the key
+	Hosts int `json:"hosts"`
+	// Subjects is the list of distinct certificate subjects using the key
+	Subjects []string `json:"subjects"`
+	// Organizations is the list of distinct subject organizations using the
+	// key, the registered domain of the common name for certificates without one
+	Organizations []string `json:"organizations,omitempty"`
+	// Ranges is the list of distinct ip ranges (clients.Subnet) serving the key
+	Ranges []string `json:"ranges,omitempty"`
+	// Addresses is the list of endpoints serving the key
+	Addresses []string `json:"addresses"`
+}
+
+// SharedKeys returns the public keys served by at least threshold distinct
+// organizations sorted by the spread of the key. Keys of one organization
+// served from many ranges, as by cdns and load balancers, are not shared.
+func (t *Tracker) SharedKeys(threshold int) []SharedKey {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var shared []SharedKey
+	for _, g := range t.keys {
+		if len(g.organizations) < threshold {
+			continue
+		}
+		subjects := make(map[string]struct{})
+		addresses := make(map[string]struct{})
+		for subject, subjectAddresses := range g.subjects {
+			subjects[subject] = struct{}{}
+			for _, address := range subjectAddresses {
+				addresses[address] = struct{}{}
+			}
+		}
+		shared = append(shared, SharedKey{
+			PinSHA256:     g.value,
+			Hosts:         len(addresses),
+			Subjects:      sortedSet(subjects),
+			Organizations: sortedSet(g.organizations),
+			Ranges:        sortedSet(g.ranges),
+			Addresses:     sortedSet(addresses),
+		})
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		spreadI := len(shared[i].Organizations) + len(shared[i].Ranges)
+		spreadJ := len(shared[j].Organizations) + len(shared[j].Ranges)
+		if spreadI != spreadJ {
+			return spreadI > spreadJ
+		}
+		if shared[i].Hosts != shared[j].Hosts {
+			return shared[i].Hosts > shared[j].Hosts
+		}
+		return shared[i].PinSHA256 < shared[j].PinSHA256
+	})
+	return shared
+}
+
+func sortedSet(values map[string]struct{}) []string {
+	keys := make([]string, 0, len(values))
+	for value := range values {
+		if strings.TrimSpace(value) != "" {
+			keys = append(keys, value)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package findings
+
+import (
+	"strings"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// appliance is a management appliance recognized by the factory
+// certificate it generates on first boot.
+type appliance struct {
+	// id is the finding reported for the factory certificate
+	id string
+	// ports is the list of management ports of the appliance
+	ports map[string]struct{}
+	// factory returns true if cert is the factory certificate
+	factory func(cert *clients.CertificateResponse) bool
+}
+
+// appliances is the list of appliances checked for factory certificates
+var appliances = []appliance{
+	{
+		id:    VCenterDefaultCertificate,
+		ports: map[string]struct{}{"443": {}, "5480": {}, "9443": {}},
+		factory: func(cert *clients.CertificateResponse) bool {
+			// certificates issued by the default vmca root or the esxi default certificate
+			return strings.Contains(cert.IssuerDN, "OU=VMware Engineering") || strings.Contains(cert.SubjectDN, "OU=VMware ESX Server Default Certificate")
+		},
+	},
+	{
+		id:    IDRACDefaultCertificate,
+		ports: map[string]struct{}{"443": {}},
+		factory: func(cert *clients.CertificateResponse) bool {
+			return cert.SelfSigned && containsString(cert.SubjectOrg, "Dell Inc.") && strings.Contains(cert.SubjectDN, "OU=Remote Access Group")
+		},
+	},
+	{
+		id:    ILODefaultCertificate,
+		ports: map[string]struct{}{"443": {}},
+		factory: func(cert *clients.CertificateResponse) bool {
+			return cert.IssuerCN == "Default Issuer (Do not trust)"
+		},
+	},
+	{
+		id:    NetScalerDefaultCertificate,
+		ports: map[string]struct{}{"443": {}, "3008": {}},
+		factory: func(cert *clients.CertificateResponse) bool {
+			return containsString(cert.SubjectOrg, "Citrix ANG") && strings.HasPrefix(cert.SubjectCN, "default ")
+		},
+	},
+}
+
+// detectAppliances returns the findings for factory certificates of
+// appliances served on their management ports.
+func detectAppliances(response *clients.Response) []string {
+	var ids []string
+	for _, appliance := range appliances {
+		if _, ok := appliance.ports[response.Port]; !ok {
+			continue
+		}
+		if appliance.factory(&response.CertificateResponse) {
+			ids = append(ids, appliance.id)
+		}
+	}
+	return ids
+}
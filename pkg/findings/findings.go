@@ -0,0 +1,318 @@
+// Package findings implements detection of security findings for tls
+// responses along with remediation hints for each finding type.
+package findings
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/duplicates"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/validation"
+)
+
+//go:embed remediations.json
+var defaultRemediations []byte
+
+// List of finding identifiers detected for responses
+const (
+	ExpiredCertificate    = "expired-certificate"
+	ExpiringCertificate   = "expiring-certificate"
+	SelfSignedCertificate = "self-signed-certificate"
+	WeakCipher            = "weak-cipher"
+	LegacyTLSVersion      = "legacy-tls-version"
+	WeakRSAKey            = "weak-rsa-key"
+	WeakECDSAKey          = "weak-ecdsa-key"
+	WeakDSAKey            = "weak-dsa-key"
+	DuplicateSerial       = "duplicate-serial"
+	DuplicatePublicKey    = "duplicate-public-key"
+	WeakSignature         = "weak-signature-algorithm"
+	EarlyDataAccepted     = "early-data-accepted"
+	InsecureRenegotiation = "insecure-renegotiation"
+	ClientRenegotiation   = "client-initiated-renegotiation"
+	MustStapleNotStapled  = "must-staple-not-stapled"
+	TLSCompression        = "tls-compression"
+	WeakDHParams          = "weak-dh-params"
+	MissingFallbackSCSV   = "missing-fallback-scsv"
+	MissingEMS            = "missing-extended-master-secret"
+	ADMissingServerAuth   = "ad-missing-server-auth-eku"
+	ADMissingDCHostname   = "ad-missing-dc-hostname-san"
+	ADExpiredCertificate  = "ad-expired-dc-certificate"
+
+	VCenterDefaultCertificate   = "vcenter-default-certificate"
+	IDRACDefaultCertificate     = "idrac-default-certificate"
+	ILODefaultCertificate       = "ilo-default-certificate"
+	NetScalerDefaultCertificate = "netscaler-default-certificate"
+
+	SIPDomainMismatch = "sip-domain-mismatch"
+
+	UntrustedRoot       = "untrusted-root"
+	IncompleteChain     = "incomplete-chain"
+	ExpiredIntermediate = "expired-intermediate"
+	ChainWrongOrder     = "chain-wrong-order"
+	HostnameMismatch    = "hostname-mismatch"
+
+	RevokedCertificate = "revoked-certificate"
+	CTNotCompliant     = "ct-not-compliant"
+)
+
+// ExpiringWindow is the default window in which certificates are reported
+// as expiring
+const ExpiringWindow = 30 * 24 * time.Hour
+
+// Database is a database of remediation hints for finding types
+type Database struct {
+	hints map[string]*clients.Finding
+	order []string
+}
+
+// New creates a new database from the embedded remediation hints
+// and optionally a user supplied file overriding them.
+func New(file string) (*Database, error) {
+	database := &Database{hints: make(map[string]*clients.Finding)}
+	if err := database.load(defaultRemediations); err != nil {
+		return nil, errors.Wrap(err, "could not load embedded remediations")
+	}
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read remediation file")
+		}
+		if err := database.load(data); err != nil {
+			return nil, errors.Wrap(err, "could not load remediation file")
+		}
+	}
+	return database, nil
+}
+
+// load parses a json list of hints, overriding non-empty fields of
+// hints already present in the database.
+func (d *Database) load(data []byte) error {
+	var hints []*clients.Finding
+	if err := json.Unmarshal(data, &hints); err != nil {
+		return err
+	}
+	for _, hint := range hints {
+		if hint.ID == "" {
+			return errors.New("remediation without id specified")
+		}
+		existing, ok := d.hints[hint.ID]
+		if !ok {
+			d.hints[hint.ID] = hint
+			d.order = append(d.order, hint.ID)
+			continue
+		}
+		if hint.Title != "" {
+			existing.Title = hint.Title
+		}
+		if hint.Remediation != "" {
+			existing.Remediation = hint.Remediation
+		}
+		if len(hint.References) > 0 {
+			existing.References = hint.References
+		}
+	}
+	return nil
+}
+
+// Finding returns the finding with remediation hints for an identifier
+func (d *Database) Finding(id string) clients.Finding {
+	if hint, ok := d.hints[id]; ok {
+		return *hint
+	}
+	return clients.Finding{ID: id, Title: id}
+}
+
+// Findings returns the findings with remediation hints for a response at
+// time now, certificates expiring within window are reported as expiring.
+func (d *Database) Findings(response *clients.Response, now time.Time, window time.Duration) []clients.Finding {
+	ids := Detect(response, now, window)
+	if len(ids) == 0 {
+		return nil
+	}
+	findings := make([]clients.Finding, 0, len(ids))
+	for _, id := range ids {
+		findings = append(findings, d.Finding(id))
+	}
+	return findings
+}
+
+// Detect returns the identifiers of findings for a response at time now,
+// certificates expiring within window or ExpiringWindow if zero are
+// reported as expiring.
+func Detect(response *clients.Response, now time.Time, window time.Duration) []string {
+	var ids []string
+	if window <= 0 {
+		window = ExpiringWindow
+	}
+
+	cert := response.CertificateResponse
+	if !cert.NotAfter.IsZero() || cert.Expired {
+		remaining := cert.NotAfter.Sub(now)
+		switch {
+		// expired domain controller certificates have their own finding
+		case (cert.Expired || remaining <= 0) && response.ActiveDirectory:
+			ids = append(ids, ADExpiredCertificate)
+		case cert.Expired || remaining <= 0:
+			ids = append(ids, ExpiredCertificate)
+		case remaining <= window:
+			ids = append(ids, ExpiringCertificate)
+		}
+	}
+	if cert.SelfSigned {
+		ids = append(ids, SelfSignedCertificate)
+	}
+	if clients.IsWeakCipher(response.Cipher) {
+		ids = append(ids, WeakCipher)
+	}
+	switch response.Version {
+	case "ssl30", "tls10", "tls11":
+		ids = append(ids, LegacyTLSVersion)
+	}
+	if clients.IsWeakKey(cert.KeyAlgorithm, cert.KeySize) {
+		switch cert.KeyAlgorithm {
+		case "RSA":
+			ids = append(ids, WeakRSAKey)
+		case "ECDSA":
+			ids = append(ids, WeakECDSAKey)
+		case "DSA":
+			ids = append(ids, WeakDSAKey)
+		}
+	}
+	if hasWeakSignature(response) {
+		ids = append(ids, WeakSignature)
+	}
+	if cert.MustStaple && cert.OCSP != nil && !cert.OCSP.Stapled {
+		ids = append(ids, MustStapleNotStapled)
+	}
+	if response.EarlyData != nil && response.EarlyData.Accepted {
+		ids = append(ids, EarlyDataAccepted)
+	}
+	if response.Compression != "" {
+		ids = append(ids, TLSCompression)
+	}
+	if response.DHParams != nil && response.DHParams.Logjam {
+		ids = append(ids, WeakDHParams)
+	}
+	if response.FallbackSCSV != nil && !response.FallbackSCSV.Supported {
+		ids = append(ids, MissingFallbackSCSV)
+	}
+	if response.ExtendedMasterSecret != nil && !response.ExtendedMasterSecret.Negotiated {
+		ids = append(ids, MissingEMS)
+	}
+	if response.Renegotiation != nil {
+		if !response.Renegotiation.SecureRenegotiation {
+			ids = append(ids, InsecureRenegotiation)
+		}
+		if response.Renegotiation.ClientInitiated {
+			ids = append(ids, ClientRenegotiation)
+		}
+	}
+	if response.ActiveDirectory {
+		ids = append(ids, detectAD(response)...)
+	}
+	ids = append(ids, detectAppliances(response)...)
+	if response.SIPDomain != nil && !response.SIPDomain.Matched {
+		ids = append(ids, SIPDomainMismatch)
+	}
+	if response.ChainValidation != nil {
+		ids = append(ids, detectChainProblems(response.ChainValidation)...)
+	}
+	if (response.Revocation != nil && response.Revocation.Revoked) || (response.OCSP != nil && response.OCSP.Status == "revoked") {
+		ids = append(ids, RevokedCertificate)
+	}
+	if response.CT != nil && (response.CT.Chrome == "not-compliant" || response.CT.Apple == "not-compliant") {
+		ids = append(ids, CTNotCompliant)
+	}
+	// vulnerabilities are reported under their check identifier
+	ids = append(ids, response.Vulnerabilities...)
+	for _, duplicate := range response.Duplicates {
+		switch duplicate.Type {
+		case duplicates.TypeSerial:
+			ids = append(ids, DuplicateSerial)
+		case duplicates.TypePublicKey:
+			ids = append(ids, DuplicatePublicKey)
+		}
+	}
+	return ids
+}
+
+// detectAD returns the findings for domain controller certificates. Clients
+// binding to domain controllers require the server authentication usage
+// and the dns name of the controller in the subject alternative names.
+func detectAD(response *clients.Response) []string {
+	var ids []string
+	if len(response.ExtKeyUsage) > 0 && !containsString(response.ExtKeyUsage, clients.ExtKeyUsageServerAuth) && !containsString(response.ExtKeyUsage, clients.ExtKeyUsageAny) {
+		ids = append(ids, ADMissingServerAuth)
+	}
+	coverage := response.HostnameCoverage
+	if coverage == nil && !iputil.IsIP(response.Host) {
+		coverage = clients.HostnameCoverage(response.Host, &response.CertificateResponse)
+	}
+	if coverage != nil && coverage.Coverage != clients.CoverageSAN && coverage.Coverage != clients.CoverageWildcardSAN {
+		ids = append(ids, ADMissingDCHostname)
+	}
+	return ids
+}
+
+// chainProblemFindings is the finding of each chain validation problem
+// type, expired leaf certificates are reported as expired certificates.
+var chainProblemFindings = map[string]string{
+	validation.ProblemUntrustedRoot:    UntrustedRoot,
+	validation.ProblemIncompleteChain:  IncompleteChain,
+	validation.ProblemWrongOrder:       ChainWrongOrder,
+	validation.ProblemHostnameMismatch: HostnameMismatch,
+}
+
+// detectChainProblems returns the findings for chain validation problems
+func detectChainProblems(chainValidation *clients.ChainValidationResponse) []string {
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, problem := range chainValidation.Problems {
+		id, ok := chainProblemFindings[problem.Type]
+		if problem.Type == validation.ProblemExpired && problem.Index > 0 {
+			id, ok = ExpiredIntermediate, true
+		}
+		if _, found := seen[id]; !ok || found {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWeakSignature returns true if the certificate chain is signed with
+// sha1 or md5, or the server accepts such handshake signatures.
+func hasWeakSignature(response *clients.Response) bool {
+	// self-signed roots are trusted by presence and not by their signature
+	if !response.SelfSigned && clients.IsWeakSignatureAlgorithm(response.SignatureAlgorithm) {
+		return true
+	}
+	for _, cert := range response.Chain {
+		if !cert.SelfSigned && clients.IsWeakSignatureAlgorithm(cert.SignatureAlgorithm) {
+			return true
+		}
+	}
+	for _, enum := range response.SignatureEnum {
+		for _, algorithm := range enum.Algorithms {
+			if clients.IsWeakSignatureAlgorithm(algorithm) {
+				return true
+			}
+		}
+	}
+	return false
+}
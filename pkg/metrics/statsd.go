@@ -0,0 +1,100 @@
+// Package metrics implements emission of scan metrics to a
+// statsd or dogstatsd compatible collector over udp.
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// Client is a statsd metrics client
+type Client struct {
+	conn   net.Conn
+	prefix string
+	tags   string
+}
+
+// New creates a new statsd client sending to address. Tags are
+// appended in dogstatsd format to every metric if specified.
+func New(address, prefix string, tags []string) (*Client, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create statsd connection")
+	}
+	client := &Client{conn: conn, prefix: strings.TrimSuffix(prefix, ".")}
+	if len(tags) > 0 {
+		client.tags = strings.Join(tags, ",")
+	}
+	return client, nil
+}
+
+// Count increments a counter metric by value
+func (c *Client) Count(name string, value int64, tags ...string) {
+	c.send(name, fmt.Sprintf("%d|c", value), tags)
+}
+
+// Gauge sets a gauge metric to value
+func (c *Client) Gauge(name string, value float64, tags ...string) {
+	c.send(name, fmt.Sprintf("%g|g", value), tags)
+}
+
+// Timing records a timing metric for duration
+func (c *Client) Timing(name string, duration time.Duration, tags ...string) {
+	c.send(name, fmt.Sprintf("%d|ms", duration.Milliseconds()), tags)
+}
+
+// Result emits counters for a scan result and its findings
+func (c *Client) Result(response *clients.Response) {
+	version := "version:" + response.Version
+	c.Count("results", 1, version)
+
+	if response.Expired {
+		c.Count("findings", 1, "finding:expired")
+	}
+	if response.SelfSigned {
+		c.Count("findings", 1, "finding:self-signed")
+	}
+	if clients.IsWeakCipher(response.Cipher) {
+		c.Count("findings", 1, "finding:weak-cipher")
+	}
+	switch response.Version {
+	case "ssl30", "tls10", "tls11":
+		c.Count("findings", 1, "finding:legacy-version")
+	}
+}
+
+// Close closes the statsd connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// send writes a single metric packet ignoring any errors as
+// metrics emission is best-effort.
+func (c *Client) send(name, value string, tags []string) {
+	builder := &strings.Builder{}
+	if c.prefix != "" {
+		builder.WriteString(c.prefix)
+		builder.WriteString(".")
+	}
+	builder.WriteString(name)
+	builder.WriteString(":")
+	builder.WriteString(value)
+
+	allTags := c.tags
+	if len(tags) > 0 {
+		if allTags != "" {
+			allTags += ","
+		}
+		allTags += strings.Join(tags, ",")
+	}
+	if allTags != "" {
+		builder.WriteString("|#")
+		builder.WriteString(allTags)
+	}
+	_, _ = c.conn.Write([]byte(builder.String()))
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// atomicFile is a file written to a temporary file in the same directory
+// which replaces the destination only when committed, so crashes never
+// leave a partially written destination file. Destinations which are not
+// regular files such as devices, pipes and symlinks are written in place,
+// and stdout is written without being closed.
+type atomicFile struct {
+	*os.File
+	path   string
+	direct bool
+}
+
+// createAtomicFile creates a new atomic file for a destination path
+func createAtomicFile(path string) (*atomicFile, error) {
+	if path == DestinationStdout {
+		return &atomicFile{File: os.Stdout, path: path, direct: true}, nil
+	}
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &atomicFile{File: file, path: path, direct: true}, nil
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: file, path: path}, nil
+}
+
+// Commit syncs the temporary file to disk and renames it to the destination
+func (f *atomicFile) Commit() error {
+	if f.direct {
+		return f.closeDirect()
+	}
+	err := f.Chmod(0644)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.File.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+// Abort closes and removes the temporary file leaving the destination as is
+func (f *atomicFile) Abort() {
+	if f.direct {
+		_ = f.closeDirect()
+		return
+	}
+	_ = f.File.Close()
+	_ = os.Remove(f.Name())
+}
+
+// closeDirect closes a destination written in place other than stdout
+func (f *atomicFile) closeDirect() error {
+	if f.File == os.Stdout {
+		return nil
+	}
+	return f.File.Close()
+}
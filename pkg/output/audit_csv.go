@@ -0,0 +1,98 @@
+package output
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// auditCSVHeaders are the columns written to the audit inventory csv
+var auditCSVHeaders = []string{
+	"system",
+	"common_name",
+	"sans",
+	"issuer",
+	"key_algorithm",
+	"key_size",
+	"signature_algorithm",
+	"not_before",
+	"not_after",
+	"tls_versions",
+	"weak_ciphers",
+}
+
+// auditCSVWriter writes certificate inventory rows for auditors
+type auditCSVWriter struct {
+	file   *atomicFile
+	writer *csv.Writer
+	mutex  sync.Mutex
+}
+
+// newAuditCSVWriter creates a new audit csv writer for a file
+func newAuditCSVWriter(file string) (*auditCSVWriter, error) {
+	output, err := createAtomicFile(file)
+	if err != nil {
+		return nil, err
+	}
+	writer := csv.NewWriter(output)
+	if err := writer.Write(auditCSVHeaders); err != nil {
+		output.Abort()
+		return nil, err
+	}
+	return &auditCSVWriter{file: output, writer: writer}, nil
+}
+
+// Write writes an inventory row for the response, failed handshakes and
+// sni matrix records have no certificate and are skipped.
+func (w *auditCSVWriter) Write(event *clients.Response) error {
+	if event.Error != "" || len(event.SNIMatrix) > 0 {
+		return nil
+	}
+	cert := event.CertificateResponse
+
+	weakCiphers := "N"
+	if clients.IsWeakCipher(event.Cipher) {
+		weakCiphers = "Y"
+	}
+	var keySize string
+	if cert.KeySize > 0 {
+		keySize = strconv.Itoa(cert.KeySize)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.writer.Write([]string{
+		event.Host + ":" + event.Port,
+		cert.SubjectCN,
+		strings.Join(cert.SubjectAN, ";"),
+		cert.IssuerDN,
+		cert.KeyAlgorithm,
+		keySize,
+		cert.SignatureAlgorithm,
+		formatAuditTime(cert.NotBefore),
+		formatAuditTime(cert.NotAfter),
+		event.Version,
+		weakCiphers,
+	})
+}
+
+// Close flushes and renames the underlying file in place
+func (w *auditCSVWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Abort()
+		return err
+	}
+	return w.file.Commit()
+}
+
+func formatAuditTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.UTC().Format("2006-01-02")
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// chainBundleIndexFile is the name of the index file mapping hosts to chains
+const chainBundleIndexFile = "index.jsonl"
+
+// chainBundleWriter writes one pem bundle per unique presented chain
+// along with an index mapping each host to its chain identifier.
+type chainBundleWriter struct {
+	dir     string
+	index   *fileWriter
+	written map[string]struct{}
+	mutex   sync.Mutex
+}
+
+// chainBundleIndexEntry is a line of the chain bundle index file
+type chainBundleIndexEntry struct {
+	Host    string `json:"host"`
+	IP      string `json:"ip,omitempty"`
+	Port    string `json:"port"`
+	ChainID string `json:"chain-id"`
+}
+
+// newChainBundleWriter creates a new chain bundle writer for a directory
+func newChainBundleWriter(dir string) (*chainBundleWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	index, err := newFileOutputWriter(filepath.Join(dir, chainBundleIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	return &chainBundleWriter{dir: dir, index: index, written: make(map[string]struct{})}, nil
+}
+
+// chainBundleID returns the identifier of a raw chain which is the
+// sha256 hash of the concatenated der certificates.
+func chainBundleID(raw [][]byte) string {
+	hash := sha256.New()
+	for _, cert := range raw {
+		_, _ = hash.Write(cert)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Write writes the bundle for the chain of a response if it was not
+// written yet and adds the host to the index.
+func (w *chainBundleWriter) Write(event *clients.Response) error {
+	if event.ChainID == "" {
+		return nil
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, ok := w.written[event.ChainID]; !ok {
+		if err := w.writeBundle(event.ChainID, event.RawChain); err != nil {
+			return err
+		}
+		w.written[event.ChainID] = struct{}{}
+	}
+	data, err := jsoniter.Marshal(&chainBundleIndexEntry{Host: event.Host, IP: event.IP, Port: event.Port, ChainID: event.ChainID})
+	if err != nil {
+		return err
+	}
+	return w.index.Write(data)
+}
+
+// writeBundle writes a pem bundle of raw certificates unless a bundle
+// for the chain already exists in the directory from a previous scan.
+func (w *chainBundleWriter) writeBundle(id string, raw [][]byte) error {
+	path := filepath.Join(w.dir, id+".pem")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	file, err := createAtomicFile(path)
+	if err != nil {
+		return err
+	}
+	for _, cert := range raw {
+		if err := pem.Encode(file, &pem.Block{Type: "CERTIFICATE", Bytes: cert}); err != nil {
+			file.Abort()
+			return err
+		}
+	}
+	return file.Commit()
+}
+
+// Close closes the index file
+func (w *chainBundleWriter) Close() error {
+	return w.index.Close()
+}
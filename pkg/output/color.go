@@ -0,0 +1,187 @@
+package output
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of escape codes of output colors, matching the codes written by aurora
+const (
+	colorRed           = "\x1b[31m"
+	colorGreen         = "\x1b[32m"
+	colorYellow        = "\x1b[33m"
+	colorBlue          = "\x1b[34m"
+	colorMagenta       = "\x1b[35m"
+	colorCyan          = "\x1b[36m"
+	colorBrightYellow  = "\x1b[93m"
+	colorBrightMagenta = "\x1b[95m"
+	colorBrightCyan    = "\x1b[96m"
+	colorReset         = "\x1b[0m"
+)
+
+// renderer is a buffer standard output is rendered into either with
+// colors for the terminal or without for files.
+type renderer struct {
+	bytes.Buffer
+	color bool
+
+	// recordFields records the fields written for table output
+	recordFields bool
+	fields       []tableField
+}
+
+// rendererPool is the pool of renderers results are formatted into
+var rendererPool = sync.Pool{
+	New: func() interface{} {
+		return &renderer{}
+	},
+}
+
+// maxPooledBuffer is the capacity above which renderers are not pooled
+// so that a few large results do not keep memory alive.
+const maxPooledBuffer = 64 * 1024
+
+func getRenderer(color bool) *renderer {
+	r := rendererPool.Get().(*renderer)
+	r.Reset()
+	r.color = color
+	r.recordFields = false
+	return r
+}
+
+func putRenderer(r *renderer) {
+	if r.Cap() <= maxPooledBuffer {
+		rendererPool.Put(r)
+	}
+}
+
+// Reset empties the buffer and the fields recorded
+func (r *renderer) Reset() {
+	r.Buffer.Reset()
+	r.fields = r.fields[:0]
+}
+
+// field starts the bracketed field name, recording it for table output
+func (r *renderer) field(name string) {
+	if r.recordFields {
+		r.fields = append(r.fields, tableField{name: name, offset: r.Len()})
+	}
+	r.WriteString(" [")
+}
+
+// colorStart writes the escape code of color if colors are enabled
+func (r *renderer) colorStart(color string) {
+	if r.color {
+		r.WriteString(color)
+	}
+}
+
+// colorEnd writes the reset escape code if colors are enabled
+func (r *renderer) colorEnd() {
+	if r.color {
+		r.WriteString(colorReset)
+	}
+}
+
+// writeColored writes the concatenated values in color
+func (r *renderer) writeColored(color string, values ...string) {
+	r.colorStart(color)
+	for _, value := range values {
+		r.WriteString(value)
+	}
+	r.colorEnd()
+}
+
+// writeColoredJoined writes the comma separated values in color
+func (r *renderer) writeColoredJoined(color string, values []string) {
+	r.colorStart(color)
+	for i, value := range values {
+		if i > 0 {
+			r.WriteByte(',')
+		}
+		r.WriteString(value)
+	}
+	r.colorEnd()
+}
+
+// writeColoredUpper writes the ascii upper case value in color
+func (r *renderer) writeColoredUpper(color string, value string) {
+	r.colorStart(color)
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		r.WriteByte(c)
+	}
+	r.colorEnd()
+}
+
+// writeInt writes the decimal value without allocating
+func (r *renderer) writeInt(value int) {
+	var scratch [20]byte
+	r.Write(strconv.AppendInt(scratch[:0], int64(value), 10))
+}
+
+// writeRevocation writes the revocation status reported with a method
+func (r *renderer) writeRevocation(method, status string, expired bool, err string) {
+	r.field("revocation")
+	r.WriteString("revocation: ")
+	r.WriteString(method)
+	r.WriteString(" ")
+	switch {
+	case err != "":
+		r.writeColored(colorYellow, "error")
+	case status == "good":
+		r.writeColored(colorGreen, status)
+	default:
+		r.writeColored(colorRed, status)
+	}
+	if expired {
+		r.writeColored(colorRed, " expired")
+	}
+	r.WriteString("]")
+}
+
+// writeCTStatus writes the compliance status with a ct policy
+func (r *renderer) writeCTStatus(status string) {
+	switch status {
+	case "compliant":
+		r.writeColored(colorGreen, status)
+	case "not-compliant":
+		r.writeColored(colorRed, status)
+	default:
+		r.writeColored(colorYellow, status)
+	}
+}
+
+// writeTrustStores writes the names of the stores trusting the chain, or
+// not trusting it if trusted is false, skipping an empty list.
+func (r *renderer) writeTrustStores(stores []clients.TrustStoreResult, trusted bool) {
+	label, color := "untrusted: ", colorRed
+	if trusted {
+		label, color = "trusted: ", colorGreen
+	}
+	var written bool
+	for _, store := range stores {
+		if store.Trusted != trusted {
+			continue
+		}
+		if !written {
+			r.field(label[:len(label)-2])
+			r.colorStart(color)
+			r.WriteString(label)
+			written = true
+		} else {
+			r.WriteString(",")
+		}
+		r.WriteString(store.Name)
+	}
+	if written {
+		r.colorEnd()
+		r.WriteString("]")
+	}
+}
@@ -1,19 +1,17 @@
 package output
 
-import (
-	"bufio"
-	"os"
-)
+import "bufio"
 
-// fileWriter is a concurrent file based output writer.
+// fileWriter is a concurrent file based output writer. Output is
+// written to a temporary file which replaces the file on close.
 type fileWriter struct {
-	file   *os.File
+	file   *atomicFile
 	writer *bufio.Writer
 }
 
 // NewFileOutputWriter creates a new buffered writer for a file
 func newFileOutputWriter(file string) (*fileWriter, error) {
-	output, err := os.Create(file)
+	output, err := createAtomicFile(file)
 	if err != nil {
 		return nil, err
 	}
@@ -30,10 +28,16 @@ func (w *fileWriter) Write(data []byte) error {
 	return err
 }
 
-// Close closes the underlying writer flushing everything to disk
+// Flush writes buffered output to the underlying file
+func (w *fileWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+// Close flushes everything to disk and renames the file in place
 func (w *fileWriter) Close() error {
-	w.writer.Flush()
-	//nolint:errcheck // we don't care whether sync failed or succeeded.
-	w.file.Sync()
-	return w.file.Close()
+	if err := w.writer.Flush(); err != nil {
+		w.file.Abort()
+		return err
+	}
+	return w.file.Commit()
 }
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"bufio"
+	"html"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// htmlHeader starts the html results document and its table
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>tlsx results</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+.status { color: #b00; }
+</style>
+</head>
+<body>
+<table>
+<tr><th>Host</th><th>IP</th><th>Version</th><th>Cipher</th><th>Subject CN</th><th>SANs</th><th>Issuer</th><th>Not After</th><th>Status</th></tr>
+`
+
+// htmlFooter ends the html results document
+const htmlFooter = `</table>
+</body>
+</html>
+`
+
+// htmlWriter writes results as rows of a html table document
+type htmlWriter struct {
+	file   *atomicFile
+	writer *bufio.Writer
+	mutex  sync.Mutex
+}
+
+// newHTMLWriter creates a new html writer for a file
+func newHTMLWriter(file string) (*htmlWriter, error) {
+	output, err := createAtomicFile(file)
+	if err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(output)
+	if _, err := writer.WriteString(htmlHeader); err != nil {
+		output.Abort()
+		return nil, err
+	}
+	return &htmlWriter{file: output, writer: writer}, nil
+}
+
+// Write writes a table row for the response, sni matrix records have
+// no certificate and are skipped.
+func (w *htmlWriter) Write(event *clients.Response) error {
+	if len(event.SNIMatrix) > 0 {
+		return nil
+	}
+	cert := event.CertificateResponse
+
+	var status []string
+	switch {
+	case event.Error != "":
+		status = append(status, event.Error)
+	case event.Service != "":
+		status = append(status, "no-tls", event.Service)
+	}
+	if cert.Expired {
+		status = append(status, "expired")
+	}
+	if cert.NotYetValid {
+		status = append(status, "not-yet-valid")
+	}
+	if cert.Expiring {
+		status = append(status, "expiring")
+	}
+	if cert.SelfSigned {
+		status = append(status, "self-signed")
+	}
+	if event.ChainValidation != nil && !event.ChainValidation.Valid {
+		status = append(status, "invalid-chain")
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.writer.WriteString("<tr>")
+	for _, value := range []string{
+		event.Host + ":" + event.Port,
+		event.IP,
+		event.Version,
+		event.Cipher,
+		cert.SubjectCN,
+		strings.Join(cert.SubjectAN, "\n"),
+		cert.IssuerDN,
+		formatAuditTime(cert.NotAfter),
+	} {
+		w.writer.WriteString("<td>")
+		w.writer.WriteString(strings.ReplaceAll(html.EscapeString(value), "\n", "<br>"))
+		w.writer.WriteString("</td>")
+	}
+	w.writer.WriteString(`<td class="status">`)
+	w.writer.WriteString(html.EscapeString(strings.Join(status, ", ")))
+	_, err := w.writer.WriteString("</td></tr>\n")
+	return err
+}
+
+// Close writes the end of the document and renames the file in place
+func (w *htmlWriter) Close() error {
+	w.writer.WriteString(htmlFooter)
+	if err := w.writer.Flush(); err != nil {
+		w.file.Abort()
+		return err
+	}
+	return w.file.Commit()
+}
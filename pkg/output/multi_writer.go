@@ -0,0 +1,68 @@
+package output
+
+import (
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// MultiWriter writes events to multiple outputs handling the write
+// errors of each output with its own policy.
+type MultiWriter struct {
+	outputs []policyWriter
+	// chainIDs enables setting the chain identifier of events before
+	// they are written, as written by chain bundles.
+	chainIDs bool
+}
+
+// policyWriter is an output writer with its error policy
+type policyWriter struct {
+	name   string
+	writer Writer
+	policy string
+}
+
+func (m *MultiWriter) add(name string, writer Writer, policy string) {
+	m.outputs = append(m.outputs, policyWriter{name: name, writer: writer, policy: policy})
+}
+
+// Write writes the event to every output. Errors of outputs with the
+// continue policy are logged, the first error of an output with the fail
+// policy is returned once the event was written to all outputs.
+func (m *MultiWriter) Write(event *clients.Response) error {
+	if m.chainIDs && len(event.RawChain) > 0 {
+		event.ChainID = chainBundleID(event.RawChain)
+	}
+	var failed error
+	for _, output := range m.outputs {
+		err := output.writer.Write(event)
+		if err == nil {
+			continue
+		}
+		if output.policy == PolicyFail && failed == nil {
+			failed = errors.Wrapf(err, "could not write %s output", output.name)
+			continue
+		}
+		gologger.Warning().Msgf("Could not write %s output for %s:%s: %s", output.name, event.Host, event.Port, err)
+	}
+	return failed
+}
+
+// Close closes every output handling errors with the policy of each
+// output as for writes, the first error of an output with the fail
+// policy is returned.
+func (m *MultiWriter) Close() error {
+	var failed error
+	for _, output := range m.outputs {
+		err := output.writer.Close()
+		if err == nil {
+			continue
+		}
+		if output.policy == PolicyFail && failed == nil {
+			failed = errors.Wrapf(err, "could not close %s output", output.name)
+			continue
+		}
+		gologger.Warning().Msgf("Could not close %s output: %s", output.name, err)
+	}
+	return failed
+}
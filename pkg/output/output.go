@@ -1,14 +1,15 @@
 package output
 
 import (
+	"bufio"
 	"bytes"
+	"fmt"
 	"os"
-	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
-	"github.com/logrusorgru/aurora"
 	"github.com/pkg/errors"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
 )
@@ -21,47 +22,178 @@ type Writer interface {
 	Write(*clients.Response) error
 }
 
-var decolorizerRegex = regexp.MustCompile(`\x1B\[[0-9;]*[a-zA-Z]`)
+// destination is where a standard writer writes formatted events to
+type destination interface {
+	// Write writes a formatted event followed by a newline
+	Write(data []byte) error
+	Flush() error
+	Close() error
+}
+
+// stdoutBufferSize is the size of the buffer for stdout output
+const stdoutBufferSize = 64 * 1024
+
+// stdoutWriter is a buffered destination writing to stdout
+type stdoutWriter struct {
+	writer *bufio.Writer
+}
+
+func newStdoutWriter() *stdoutWriter {
+	return &stdoutWriter{writer: bufio.NewWriterSize(os.Stdout, stdoutBufferSize)}
+}
 
-// StandardWriter is an standard output writer structure
+func (w *stdoutWriter) Write(data []byte) error {
+	if _, err := w.writer.Write(data); err != nil {
+		return err
+	}
+	return w.writer.WriteByte('\n')
+}
+
+func (w *stdoutWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+func (w *stdoutWriter) Close() error {
+	return w.writer.Flush()
+}
+
+// StandardWriter is an output writer writing events in a format to
+// stdout, a file or a webhook.
 type StandardWriter struct {
-	json        bool
-	aurora      aurora.Aurora
-	outputFile  *fileWriter
+	format      string
+	color       bool
+	destination destination
 	outputMutex *sync.Mutex
 
+	// flushEvery is the number of results after which the destination
+	// is flushed. Zero flushes stdout after every result and files when
+	// their buffer is full.
+	flushEvery int
+	pending    int
+
+	// hashes is the list of fingerprint hashes displayed in standard output
+	hashes []string
+	// table buffers standard output to align it into columns, nil if disabled
+	table *tableLayout
+
 	options *clients.Options
 }
 
-// New returns a new output writer instance
+// New returns a new output writer for the output targets of options
 func New(options *clients.Options) (Writer, error) {
-	var outputFile *fileWriter
-	if options.OutputFile != "" {
-		output, err := newFileOutputWriter(options.OutputFile)
+	targets, err := Targets(options)
+	if err != nil {
+		return nil, err
+	}
+	writer := &MultiWriter{}
+	for _, target := range targets {
+		targetWriter, err := newTargetWriter(target, options)
 		if err != nil {
-			return nil, errors.Wrap(err, "could not create output file")
+			_ = writer.Close()
+			return nil, errors.Wrapf(err, "could not create %s output", target)
+		}
+		writer.add(target.String(), targetWriter, target.Policy)
+	}
+	if options.ChainBundleDir != "" {
+		chainBundle, err := newChainBundleWriter(options.ChainBundleDir)
+		if err != nil {
+			_ = writer.Close()
+			return nil, errors.Wrap(err, "could not create chain bundle directory")
+		}
+		writer.chainIDs = true
+		writer.add("chain bundle", chainBundle, PolicyContinue)
+	}
+	return writer, nil
+}
+
+// NewFormatWriter returns a writer of events in format to destination,
+// the formats being those of output targets and table.
+func NewFormatWriter(format, destination string, options *clients.Options) (Writer, error) {
+	if format == FormatTable {
+		if destination != DestinationStdout {
+			return nil, fmt.Errorf("table output can only be written to stdout")
 		}
-		outputFile = output
+		tableOptions := *options
+		tableOptions.Table = true
+		options = &tableOptions
+		format = FormatStandard
 	}
+	target, err := ParseTarget(format + ":" + destination)
+	if err != nil {
+		return nil, err
+	}
+	return newTargetWriter(target, options)
+}
+
+// newTargetWriter creates the writer of an output target
+func newTargetWriter(target *Target, options *clients.Options) (Writer, error) {
+	switch target.Format {
+	case FormatCSV:
+		return newAuditCSVWriter(target.Destination)
+	case FormatHTML:
+		return newHTMLWriter(target.Destination)
+	}
+	var output destination
+	switch {
+	case target.Destination == DestinationStdout:
+		output = newStdoutWriter()
+	case target.IsWebhook():
+		output = newWebhookWriter(target.Destination, target.Format, target.Policy)
+	default:
+		file, err := newFileOutputWriter(target.Destination)
+		if err != nil {
+			return nil, err
+		}
+		output = file
+	}
+	return newStandardWriter(target, output, options), nil
+}
+
+// newStandardWriter creates a writer of events in the target format,
+// only standard output to stdout is colored and aligned as a table.
+func newStandardWriter(target *Target, output destination, options *clients.Options) *StandardWriter {
 	writer := &StandardWriter{
-		json:        options.JSON,
-		aurora:      aurora.NewAurora(!options.NoColor),
-		outputFile:  outputFile,
+		format:      target.Format,
+		color:       target.Format == FormatStandard && target.Destination == DestinationStdout && !options.NoColor,
+		destination: output,
 		outputMutex: &sync.Mutex{},
+		flushEvery:  options.FlushEvery,
 		options:     options,
 	}
-	return writer, nil
+	if options.Unbuffered {
+		writer.flushEvery = 1
+	}
+	if options.Hash != "" {
+		writer.hashes = strings.Split(options.Hash, ",")
+	}
+	if options.Table && target.Format == FormatStandard && target.Destination == DestinationStdout {
+		writer.table = newTableLayout()
+	}
+	return writer
 }
 
-// Write writes the event to file and/or screen.
+// Write writes the event to the destination in the writer format
 func (w *StandardWriter) Write(event *clients.Response) error {
 	var data []byte
 	var err error
 
-	if w.json {
+	switch w.format {
+	case FormatJSON:
 		data, err = w.formatJSON(event)
-	} else {
-		data, err = w.formatStandard(event)
+	case FormatPlain:
+		data, err = w.formatPlain(event)
+	default:
+		builder := getRenderer(w.color)
+		defer putRenderer(builder)
+		builder.recordFields = w.table != nil
+		w.formatStandard(builder, event)
+		data = builder.Bytes()
+		if w.table != nil {
+			w.outputMutex.Lock()
+			w.table.add(bytes.TrimSuffix(data, []byte("\n")), builder.fields)
+			w.outputMutex.Unlock()
+			return nil
+		}
 	}
 	if err != nil {
 		return errors.Wrap(err, "could not format output")
@@ -71,103 +203,635 @@ func (w *StandardWriter) Write(event *clients.Response) error {
 	w.outputMutex.Lock()
 	defer w.outputMutex.Unlock()
 
-	_, _ = os.Stdout.Write(data)
-	_, _ = os.Stdout.Write([]byte("\n"))
-	if w.outputFile != nil {
-		if !w.json {
-			data = decolorizerRegex.ReplaceAll(data, []byte(""))
-		}
-		if writeErr := w.outputFile.Write(data); writeErr != nil {
-			return errors.Wrap(err, "could not write to output")
-		}
+	if err := w.destination.Write(data); err != nil {
+		return errors.Wrap(err, "could not write to output")
+	}
+	if err := w.flush(); err != nil {
+		return errors.Wrap(err, "could not flush output")
 	}
 	return nil
 }
 
-// Close closes the output writer
+// flush flushes the destination as configured with flushEvery
+func (w *StandardWriter) flush() error {
+	if w.flushEvery == 0 {
+		if _, ok := w.destination.(*stdoutWriter); ok {
+			return w.destination.Flush()
+		}
+		return nil
+	}
+	w.pending++
+	if w.pending < w.flushEvery {
+		return nil
+	}
+	w.pending = 0
+	return w.destination.Flush()
+}
+
+// Close flushes and closes the destination
 func (w *StandardWriter) Close() error {
-	var err error
-	if w.outputFile != nil {
-		err = w.outputFile.Close()
+	w.outputMutex.Lock()
+	defer w.outputMutex.Unlock()
+
+	// table output is aligned once the widths of all results are known
+	if w.table != nil {
+		builder := getRenderer(w.color)
+		defer putRenderer(builder)
+		if err := w.table.render(builder, w.destination.Write); err != nil {
+			_ = w.destination.Close()
+			return errors.Wrap(err, "could not write to output")
+		}
 	}
-	return err
+	return w.destination.Close()
 }
 
 // formatJSON formats the output for json based formatting
 func (w *StandardWriter) formatJSON(output *clients.Response) ([]byte, error) {
+	if w.options.CheckOnly {
+		return jsoniter.Marshal(&checkResponse{
+			Timestamp: output.Timestamp,
+			Host:      output.Host,
+			IP:        output.IP,
+			Port:      output.Port,
+			TLS:       output.Error == "",
+			Version:   output.Version,
+			Cipher:    output.Cipher,
+			Service:   output.Service,
+			Banner:    output.Banner,
+		})
+	}
 	return jsoniter.Marshal(output)
 }
 
-// formatStandard formats the output for standard client formatting
-func (w *StandardWriter) formatStandard(output *clients.Response) ([]byte, error) {
-	builder := &bytes.Buffer{}
+// checkResponse is the compact json record for check-only mode
+type checkResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+	Host      string    `json:"host"`
+	IP        string    `json:"ip,omitempty"`
+	Port      string    `json:"port"`
+	TLS       bool      `json:"tls"`
+	Version   string    `json:"tls-version,omitempty"`
+	Cipher    string    `json:"cipher,omitempty"`
+	Service   string    `json:"service,omitempty"`
+	Banner    string    `json:"banner,omitempty"`
+}
 
+// formatStandard formats the output for standard client formatting
+// into builder, which is expected to be empty.
+func (w *StandardWriter) formatStandard(builder *renderer, output *clients.Response) {
 	if !w.options.RespOnly {
 		builder.WriteString(output.Host)
 		builder.WriteString(":")
 		builder.WriteString(output.Port)
+		// results of multiple server names are told apart by the sni
+		if len(w.options.ServerNames) > 1 && output.SNI != "" {
+			builder.field("sni")
+			builder.writeColored(colorCyan, "sni: ", output.SNI)
+			builder.WriteString("]")
+		}
 	}
-	outputPrefix := builder.String()
-	builder.Reset()
-
-	cert := output.CertificateResponse
+	// the prefix is repeated from the start of the buffer, which is not
+	// modified by later writes even if the buffer grows.
+	outputPrefix := builder.Bytes()
 
-	var names []string
-	if w.options.SAN {
-		names = append(names, cert.SubjectAN...)
+	if output.Service != "" {
+		builder.field("no-tls")
+		builder.writeColored(colorRed, "no-tls")
+		builder.WriteString("]")
+		builder.field("service")
+		builder.writeColored(colorYellow, output.Service)
+		builder.WriteString("]")
+		return
+	}
+	if output.Error != "" {
+		builder.field("handshake-failed")
+		builder.writeColored(colorRed, "handshake-failed: ", output.Error)
+		builder.WriteString("]")
+		return
 	}
-	if w.options.CN {
-		names = append(names, cert.SubjectCN)
+
+	if len(output.SNIMatrix) > 0 {
+		builder.field("sni-matrix")
+		builder.writeColored(colorYellow, "sni-matrix")
+		builder.WriteString("]")
+		for _, entry := range output.SNIMatrix {
+			builder.field("sni-matrix: " + entry.SNI)
+			builder.writeColored(colorCyan, entry.SNI)
+			builder.WriteString(": ")
+			builder.writeColored(colorBrightMagenta, entry.FingerprintSHA256)
+			builder.WriteString("]")
+		}
+		return
 	}
-	uniqueNames := uniqueNormalizeCertNames(names)
-	if len(uniqueNames) > 0 {
-		for _, name := range uniqueNames {
+
+	cert := &output.CertificateResponse
+	if w.options.SAN || w.options.CN {
+		var names []string
+		if w.options.SAN {
+			names = append(names, cert.SubjectAN...)
+		}
+		if w.options.CN {
+			names = append(names, cert.SubjectCN)
+		}
+		uniqueNames := uniqueNormalizeCertNames(names)
+		if w.options.Attribute {
+			uniqueNames = attributedNames(uniqueNames, output.Attribution)
+		}
+		if len(uniqueNames) == 0 {
+			builder.Reset()
+		}
+		for i, name := range uniqueNames {
+			// each name is written on its own line after the prefix
+			if i > 0 {
+				builder.Write(outputPrefix)
+			}
 			if w.options.RespOnly {
 				builder.WriteString(name)
 				builder.WriteString("\n")
 			} else {
-				builder.WriteString(outputPrefix)
-				builder.WriteString(" [")
-				builder.WriteString(w.aurora.Cyan(name).String())
+				builder.field("names")
+				builder.writeColored(colorCyan, name)
 				builder.WriteString("]\n")
 			}
 		}
 	}
-
-	if !w.options.SAN && !w.options.CN {
-		builder.WriteString(outputPrefix)
+	if output.Discovered {
+		builder.field("discovered")
+		builder.writeColored(colorYellow, "discovered")
+		builder.WriteString("]")
+	}
+	if output.SIPDomain != nil {
+		builder.field("sip-domain")
+		if output.SIPDomain.Matched {
+			builder.writeColored(colorGreen, "sip-domain: ", output.SIPDomain.Domain)
+		} else {
+			builder.writeColored(colorRed, "sip-domain-mismatch: ", output.SIPDomain.Domain)
+		}
+		builder.WriteString("]")
+	}
+	if output.ClientCertificate {
+		builder.field("client-cert")
+		builder.writeColored(colorCyan, "client-cert")
+		builder.WriteString("]")
 	}
 	if w.options.SO && len(cert.SubjectOrg) > 0 {
-		builder.WriteString(" [")
-		builder.WriteString(w.aurora.BrightYellow(strings.Join(cert.SubjectOrg, ",")).String())
+		builder.field("so")
+		builder.writeColoredJoined(colorBrightYellow, cert.SubjectOrg)
 		builder.WriteString("]")
 	}
 	if w.options.TLSVersion {
-		builder.WriteString(" [")
-		builder.WriteString(w.aurora.Blue(strings.ToUpper(output.Version)).String())
+		builder.field("tls-version")
+		builder.writeColoredUpper(colorBlue, output.Version)
 		builder.WriteString("]")
 	}
 	if w.options.Cipher {
-		builder.WriteString(" [")
-		builder.WriteString(w.aurora.Green(output.Cipher).String())
+		builder.field("cipher")
+		builder.writeColored(colorGreen, output.Cipher)
 		builder.WriteString("]")
 	}
 	if w.options.Expired && cert.Expired {
-		builder.WriteString(" [")
-		builder.WriteString(w.aurora.Red("expired").String())
+		builder.field("expired")
+		builder.writeColored(colorRed, "expired")
+		builder.WriteString("]")
+	}
+	if w.options.Expired && cert.NotYetValid {
+		builder.field("not-yet-valid")
+		builder.writeColored(colorRed, "not-yet-valid")
+		builder.WriteString("]")
+	}
+	if cert.Expiring {
+		builder.field("expiring")
+		builder.colorStart(colorYellow)
+		builder.WriteString("expiring: ")
+		// certificates expiring within a day show the remaining hours
+		remaining := cert.NotAfter.Sub(clients.Now(w.options))
+		if remaining < 24*time.Hour {
+			builder.writeInt(int(remaining.Hours()))
+			builder.WriteString("h")
+		} else {
+			builder.writeInt(int(remaining.Hours() / 24))
+			builder.WriteString("d")
+		}
+		builder.colorEnd()
 		builder.WriteString("]")
 	}
 	if w.options.SelfSigned && cert.SelfSigned {
-		builder.WriteString(" [")
-		builder.WriteString(w.aurora.Yellow("self-signed").String())
+		builder.field("self-signed")
+		builder.writeColored(colorYellow, "self-signed")
 		builder.WriteString("]")
 	}
-	if w.options.Hash != "" {
-		hashOpts := strings.Split(w.options.Hash, ",")
-
-		for _, hash := range hashOpts {
+	if w.options.KeyType && cert.KeyType != "" {
+		builder.field("key-type")
+		builder.writeColored(colorCyan, cert.KeyType)
+		builder.WriteString("]")
+	}
+	if w.options.WeakKey && cert.WeakKey {
+		builder.field("weak-key")
+		builder.writeColored(colorRed, "weak-key")
+		builder.WriteString("]")
+	}
+	if w.options.SignatureAlgorithm && len(output.Chain) > 0 {
+		builder.field("sig")
+		builder.colorStart(colorCyan)
+		builder.WriteString("sig: ")
+		for i, chainCert := range output.Chain {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString(chainCert.SignatureAlgorithm)
+		}
+		builder.colorEnd()
+		builder.WriteString("]")
+	}
+	if w.options.WeakSignature {
+		for i, chainCert := range output.Chain {
+			// self-signed roots are trusted by presence and not by their signature
+			if chainCert.SelfSigned || !clients.IsWeakSignatureAlgorithm(chainCert.SignatureAlgorithm) {
+				continue
+			}
+			subject := chainCert.SubjectCN
+			if subject == "" {
+				subject = chainCert.SubjectDN
+			}
+			builder.field("weak-signature")
+			builder.colorStart(colorRed)
+			builder.WriteString("weak-signature: #")
+			builder.writeInt(i)
+			if subject != "" {
+				builder.WriteString(" ")
+				builder.WriteString(subject)
+			}
+			builder.colorEnd()
+			builder.WriteString("]")
+		}
+	}
+	if w.options.ChainValidation && output.ChainValidation != nil {
+		if output.ChainValidation.Valid {
+			builder.field("valid-chain")
+			builder.writeColored(colorGreen, "valid-chain")
+			builder.WriteString("]")
+		}
+		// the store is only shown if custom roots are trusted
+		if store := output.ChainValidation.TrustStore; store != "" && store != clients.TrustStoreSystem {
+			builder.field("trust-store")
+			builder.writeColored(colorCyan, "trust-store: ", store)
+			builder.WriteString("]")
+		}
+		if stores := output.ChainValidation.TrustStores; len(stores) > 0 {
+			builder.writeTrustStores(stores, true)
+			builder.writeTrustStores(stores, false)
+		}
+		for _, problem := range output.ChainValidation.Problems {
+			builder.field(problem.Type)
+			builder.colorStart(colorRed)
+			builder.WriteString(problem.Type)
+			builder.WriteString(": #")
+			builder.writeInt(problem.Index)
+			builder.colorEnd()
+			builder.WriteString("]")
+		}
+		if output.ChainValidation.AIAResolvable {
+			builder.field("aia-resolvable")
+			builder.writeColored(colorYellow, "aia-resolvable")
+			builder.WriteString("]")
+		}
+	}
+	if revocation := output.Revocation; revocation != nil {
+		if ocsp := revocation.OCSP; ocsp != nil {
+			builder.writeRevocation("ocsp", ocsp.Status, ocsp.Expired, ocsp.Error)
+		}
+		if crl := revocation.CRL; crl != nil {
+			builder.writeRevocation("crl", crl.Status, crl.Expired, crl.Error)
+		}
+	}
+	if ct := output.CT; ct != nil {
+		builder.field("ct")
+		builder.WriteString("ct: ")
+		builder.writeInt(len(ct.SCTs))
+		builder.WriteString(" scts]")
+		var invalid int
+		for _, sct := range ct.SCTs {
+			if sct.Signature == "invalid" {
+				invalid++
+			}
+		}
+		if invalid > 0 {
+			builder.field("sct-invalid")
+			builder.colorStart(colorRed)
+			builder.WriteString("sct-invalid: ")
+			builder.writeInt(invalid)
+			builder.colorEnd()
+			builder.WriteString("]")
+		}
+		builder.field("ct-chrome")
+		builder.WriteString("ct-chrome: ")
+		builder.writeCTStatus(ct.Chrome)
+		builder.WriteString("]")
+		builder.field("ct-apple")
+		builder.WriteString("ct-apple: ")
+		builder.writeCTStatus(ct.Apple)
+		builder.WriteString("]")
+	}
+	if search := output.CTSearch; search != nil {
+		builder.field("ct-search")
+		builder.WriteString("ct-search: ")
+		builder.writeInt(len(search.Certificates))
+		builder.WriteString(" certs]")
+		if search.OtherIssuers > 0 {
+			builder.field("ct-other-issuer")
+			builder.colorStart(colorRed)
+			builder.WriteString("ct-other-issuer: ")
+			builder.writeInt(search.OtherIssuers)
+			builder.colorEnd()
+			builder.WriteString("]")
+		}
+	}
+	if w.options.HostnameCoverage && output.HostnameCoverage != nil {
+		coverage := output.HostnameCoverage
+		builder.field("coverage")
+		builder.WriteString("coverage: ")
+		switch coverage.Coverage {
+		case clients.CoverageSAN:
+			builder.writeColored(colorGreen, coverage.Coverage)
+		case clients.CoverageNone:
+			builder.writeColored(colorRed, coverage.Coverage)
+		default:
+			builder.writeColored(colorYellow, coverage.Coverage)
+		}
+		builder.WriteString("]")
+		if len(coverage.UnrelatedNames) > 0 {
+			builder.field("unrelated")
+			builder.WriteString("unrelated: ")
+			builder.writeColoredJoined(colorYellow, coverage.UnrelatedNames)
+			builder.WriteString("]")
+		}
+	}
+	if w.options.DefaultCertificate && output.DefaultCertificate != nil {
+		defaultCert := output.DefaultCertificate
+		builder.field("default-cert")
+		switch {
+		case defaultCert.Rejected:
+			builder.writeColored(colorGreen, "sni-required")
+		case defaultCert.Differs:
+			names := uniqueNormalizeCertNames(append([]string{defaultCert.Certificate.SubjectCN}, defaultCert.Certificate.SubjectAN...))
+			builder.WriteString("default-cert: ")
+			builder.writeColoredJoined(colorYellow, names)
+		default:
+			builder.writeColored(colorGreen, "default-cert-same")
+		}
+		builder.WriteString("]")
+	}
+	if w.options.Hosting && output.Hosting != nil {
+		if output.Hosting.Panel != "" {
+			builder.field("hosting")
+			builder.WriteString("hosting: ")
+			builder.writeColored(colorYellow, output.Hosting.Panel)
+			builder.WriteString("]")
+		}
+		if output.Hosting.Shared {
+			builder.field("shared-hosting")
+			builder.writeColored(colorYellow, "shared-hosting")
+			builder.WriteString("]")
+		}
+	}
+	if w.options.Attribute && !w.options.SAN && !w.options.CN && output.Attribution != nil {
+		builder.field("attributed")
+		builder.WriteString("attributed: ")
+		builder.colorStart(colorCyan)
+		builder.writeInt(len(output.Attribution.Attributed))
+		builder.WriteString("/")
+		builder.writeInt(len(output.Attribution.Names))
+		builder.colorEnd()
+		builder.WriteString("]")
+	}
+	if w.options.OCSP && cert.OCSP != nil {
+		switch {
+		case cert.OCSP.Stapled && cert.OCSP.Status != "":
+			builder.field("ocsp")
+			builder.WriteString("ocsp: ")
+			if cert.OCSP.Status == "good" && !cert.OCSP.Expired {
+				builder.writeColored(colorGreen, cert.OCSP.Status)
+			} else {
+				builder.writeColored(colorRed, cert.OCSP.Status)
+			}
+			if cert.OCSP.Expired {
+				builder.writeColored(colorRed, " expired")
+			}
+			builder.WriteString("]")
+		case cert.OCSP.Stapled:
+			builder.field("ocsp")
+			builder.WriteString("ocsp: ")
+			builder.writeColored(colorRed, "invalid")
+			builder.WriteString("]")
+		case cert.MustStaple:
+			builder.field("must-staple")
+			builder.writeColored(colorRed, "must-staple-not-stapled")
+			builder.WriteString("]")
+		}
+	}
+	if w.options.ACME && output.ACME {
+		builder.field("acme")
+		builder.writeColored(colorBrightYellow, "acme-tls/1")
+		builder.WriteString("]")
+	}
+	if w.options.EarlyData && output.EarlyData != nil && output.EarlyData.Accepted {
+		builder.field("0-rtt")
+		builder.writeColored(colorRed, "0-rtt")
+		builder.WriteString("]")
+	}
+	if w.options.Compression && output.Compression != "" {
+		builder.field("compression")
+		builder.WriteString("compression: ")
+		builder.writeColored(colorRed, output.Compression)
+		builder.WriteString("]")
+	}
+	if w.options.DHParams && output.DHParams != nil {
+		builder.field("dh")
+		builder.WriteString("dh: ")
+		if output.DHParams.Logjam {
+			builder.colorStart(colorRed)
+		} else {
+			builder.colorStart(colorGreen)
+		}
+		builder.writeInt(output.DHParams.Bits)
+		builder.colorEnd()
+		if output.DHParams.CommonPrime != "" {
+			builder.WriteString(" ")
+			builder.writeColored(colorYellow, output.DHParams.CommonPrime)
+		}
+		builder.WriteString("]")
+		if output.DHParams.Logjam {
+			builder.field("logjam")
+			builder.writeColored(colorRed, "logjam")
+			builder.WriteString("]")
+		}
+	}
+	if w.options.FallbackSCSV && output.FallbackSCSV != nil {
+		builder.field("fallback-scsv")
+		if output.FallbackSCSV.Supported {
+			builder.writeColored(colorGreen, "fallback-scsv")
+		} else {
+			builder.writeColored(colorRed, "no-fallback-scsv")
+		}
+		builder.WriteString("]")
+	}
+	if w.options.ExtendedMasterSecret && output.ExtendedMasterSecret != nil {
+		builder.field("ems")
+		switch {
+		case output.ExtendedMasterSecret.Required:
+			builder.writeColored(colorGreen, "ems-required")
+		case output.ExtendedMasterSecret.Negotiated:
+			builder.writeColored(colorGreen, "ems")
+		default:
+			builder.writeColored(colorRed, "no-ems")
+		}
+		builder.WriteString("]")
+	}
+	if w.options.CertificateRequest && output.CertificateRequest != nil {
+		builder.field("cert-request")
+		if output.CertificateRequest.Required {
+			builder.writeColored(colorYellow, "client-cert-required")
+		} else {
+			builder.writeColored(colorCyan, "client-cert-requested")
+		}
+		builder.WriteString("]")
+	}
+	if w.options.HTTP2Confirm && output.HTTP2 != nil {
+		builder.field("http2")
+		switch {
+		case output.HTTP2.GRPC:
+			builder.writeColored(colorGreen, "grpc")
+		case output.HTTP2.Confirmed:
+			builder.writeColored(colorGreen, "h2")
+		default:
+			builder.writeColored(colorYellow, "h2-unconfirmed")
+		}
+		builder.WriteString("]")
+	}
+	if w.options.BrokerConfirm && output.Broker != nil {
+		builder.field("broker")
+		if output.Broker.Confirmed {
+			builder.writeColored(colorGreen, output.Broker.Protocol)
+		} else {
+			builder.writeColored(colorYellow, output.Broker.Protocol, "-unconfirmed")
+		}
+		builder.WriteString("]")
+	}
+	if w.options.PostQuantum && output.PostQuantum != nil {
+		builder.field("post-quantum")
+		if output.PostQuantum.Hybrid {
+			builder.writeColored(colorGreen, "pq: ", output.PostQuantum.Negotiated)
+		} else {
+			builder.writeColored(colorYellow, "no-pq")
+		}
+		builder.WriteString("]")
+	}
+	if w.options.ECH && output.ECH != nil {
+		builder.field("ech")
+		switch {
+		case output.ECH.Supported:
+			builder.writeColored(colorGreen, "ech")
+		case output.ECH.Configured:
+			builder.writeColored(colorRed, "ech-rejected")
+		default:
+			builder.writeColored(colorYellow, "no-ech")
+		}
+		builder.WriteString("]")
+		if !output.ECH.GreaseTolerated {
+			builder.field("grease-ech")
+			builder.writeColored(colorRed, "grease-ech-intolerant")
+			builder.WriteString("]")
+		}
+	}
+	for _, vulnerability := range output.Vulnerabilities {
+		builder.field(vulnerability)
+		builder.writeColored(colorRed, vulnerability)
+		builder.WriteString("]")
+	}
+	if w.options.Renegotiation && output.Renegotiation != nil {
+		if !output.Renegotiation.SecureRenegotiation {
+			builder.field("renegotiation")
+			builder.writeColored(colorRed, "insecure-renegotiation")
+			builder.WriteString("]")
+		}
+		if output.Renegotiation.ClientInitiated {
+			builder.field("client-renegotiation")
+			builder.writeColored(colorRed, "client-renegotiation")
+			builder.WriteString("]")
+		}
+	}
+	if len(w.options.ALPN) > 0 && output.ALPN != "" {
+		builder.field("alpn")
+		builder.writeColored(colorMagenta, output.ALPN)
+		builder.WriteString("]")
+	}
+	if w.options.ALPNEnum && len(output.ALPNEnum) > 0 {
+		builder.field("alpn-enum")
+		builder.WriteString("alpn: ")
+		builder.writeColoredJoined(colorMagenta, output.ALPNEnum)
+		builder.WriteString("]")
+	}
+	if w.options.CipherEnum {
+		for _, enum := range output.CipherEnum {
+			builder.field("cipher-enum: " + enum.Version)
+			builder.writeColoredUpper(colorBlue, enum.Version)
+			if enum.ServerPreference {
+				builder.WriteString(" server-order")
+			}
+			builder.WriteString(": ")
+			builder.writeColoredJoined(colorGreen, enum.Ciphers)
+			builder.WriteString("]")
+		}
+	}
+	if w.options.GroupEnum {
+		for _, enum := range output.GroupEnum {
+			builder.field("group-enum: " + enum.Version)
+			builder.writeColoredUpper(colorBlue, enum.Version)
+			if enum.ServerPreference {
+				builder.WriteString(" server-order")
+			}
+			builder.WriteString(": ")
+			builder.writeColoredJoined(colorGreen, enum.Groups)
+			builder.WriteString("]")
+		}
+	}
+	if w.options.SignatureEnum {
+		for _, enum := range output.SignatureEnum {
+			builder.field("signature-enum: " + enum.Version)
+			builder.writeColoredUpper(colorBlue, enum.Version)
+			if enum.ServerPreference {
+				builder.WriteString(" server-order")
+			}
+			builder.WriteString(": ")
+			builder.writeColoredJoined(colorGreen, enum.Algorithms)
+			builder.WriteString("]")
+		}
+	}
+	for _, duplicate := range output.Duplicates {
+		builder.field("duplicate-" + duplicate.Type)
+		builder.writeColored(colorRed, "duplicate-", duplicate.Type)
+		builder.WriteString("]")
+	}
+	if output.RootStore != nil && output.RootStore.Untrusted {
+		builder.field("untrusted-in-root-store")
+		builder.writeColored(colorRed, "untrusted-in-root-store")
+		builder.WriteString("]")
+	}
+	if w.options.JARM && output.JARM != "" {
+		builder.field("jarm")
+		builder.WriteString("jarm: ")
+		builder.writeColored(colorBrightMagenta, output.JARM)
+		builder.WriteString("]")
+	}
+	if w.options.MatchFingerprint && len(output.Fingerprints) > 0 {
+		builder.field("fingerprints")
+		builder.writeColoredJoined(colorBrightCyan, output.Fingerprints)
+		builder.WriteString("]")
+	}
+	if len(w.hashes) > 0 {
+		for _, hash := range w.hashes {
 			var value string
-			builder.WriteString(" [")
+			builder.field(hash)
 			switch hash {
 			case "md5":
 				value = cert.FingerprintHash.MD5
@@ -175,14 +839,39 @@ func (w *StandardWriter) formatStandard(output *clients.Response) ([]byte, error
 				value = cert.FingerprintHash.SHA1
 			case "sha256":
 				value = cert.FingerprintHash.SHA256
+			case "tlsh":
+				value = cert.FingerprintHash.TLSH
 			}
-			builder.WriteString(w.aurora.BrightMagenta(value).String())
+			builder.writeColored(colorBrightMagenta, value)
 			builder.WriteString("]")
 		}
 	}
 
-	outputdata := builder.Bytes()
-	return outputdata, nil
+	if w.options.PinSHA256 && cert.PinSHA256 != "" {
+		builder.field("pin-sha256")
+		builder.writeColored(colorBrightMagenta, cert.PinSHA256)
+		builder.WriteString("]")
+	}
+
+}
+
+// attributedNames returns the names attributed to the scanned organization
+// with high confidence.
+func attributedNames(names []string, attribution *clients.AttributionResponse) []string {
+	if attribution == nil {
+		return nil
+	}
+	attributed := make(map[string]struct{}, len(attribution.Attributed))
+	for _, name := range attribution.Attributed {
+		attributed[name] = struct{}{}
+	}
+	var results []string
+	for _, name := range names {
+		if _, ok := attributed[strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")]; ok {
+			results = append(results, name)
+		}
+	}
+	return results
 }
 
 // uniqueNormalizeCertNames removes *. wildcards from cert alternative
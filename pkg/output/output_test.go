@@ -0,0 +1,79 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// benchmarkResponse returns a response with the fields commonly displayed
+func benchmarkResponse() *clients.Response {
+	notBefore := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &clients.Response{
+		Timestamp: notBefore,
+		Host:      "www.example.com",
+		IP:        "93.184.216.34",
+		Port:      "443",
+		Version:   "tls13",
+		Cipher:    "TLS_AES_128_GCM_SHA256",
+		ALPN:      "h2",
+		JARM:      "27d40d40d29d40d1dc42d43d00041d4689ee210389f4f6b4b5b1b93f92252d",
+		CertificateResponse: clients.CertificateResponse{
+			NotBefore:          notBefore,
+			NotAfter:           notBefore.Add(90 * 24 * time.Hour),
+			SubjectDN:          "CN=www.example.com, O=Example Inc",
+			SubjectCN:          "www.example.com",
+			SubjectOrg:         []string{"Example Inc"},
+			SubjectAN:          []string{"www.example.com", "example.com", "api.example.com", "cdn.example.com", "mail.example.com"},
+			IssuerDN:           "CN=Example Issuing CA, O=Example CA",
+			IssuerCN:           "Example Issuing CA",
+			IssuerOrg:          []string{"Example CA"},
+			KeyAlgorithm:       "RSA",
+			KeySize:            2048,
+			KeyType:            "RSA-2048",
+			SignatureAlgorithm: "SHA256-RSA",
+			Serial:             "0A:1B:2C:3D:4E:5F",
+			FingerprintHash: clients.CertificateResponseFingerprintHash{
+				MD5:    "5ab45f0e2fa9c1a2b3c4d5e6f7a8b9c0",
+				SHA1:   "0f3e2d1c0b5a69788796a5b4c3d2e1f0a9b8c7d6",
+				SHA256: "79e78878b2cbfe0ae1b51d4cc3a1fae5d81a7e1fc2f6c4e8d5d1b1e1f2a3b4c5",
+			},
+		},
+	}
+}
+
+func BenchmarkFormatStandard(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		color   bool
+		options clients.Options
+	}{
+		{"default", false, clients.Options{}},
+		{"default-color", true, clients.Options{}},
+		{"san", false, clients.Options{SAN: true}},
+		{"cn-so", false, clients.Options{CN: true, SO: true}},
+		{"version-cipher", true, clients.Options{TLSVersion: true, Cipher: true}},
+		{"status", true, clients.Options{Expired: true, SelfSigned: true, KeyType: true, WeakKey: true}},
+		{"hash", false, clients.Options{Hash: "md5,sha1,sha256"}},
+		{"all", true, clients.Options{SO: true, TLSVersion: true, Cipher: true, Expired: true, SelfSigned: true, KeyType: true, WeakKey: true, SignatureAlgorithm: true, Hash: "sha256", JARM: true}},
+		{"resp-only", false, clients.Options{RespOnly: true, SAN: true}},
+	}
+	response := benchmarkResponse()
+	for _, benchmark := range benchmarks {
+		benchmark := benchmark
+		b.Run(benchmark.name, func(b *testing.B) {
+			writer := &StandardWriter{format: FormatStandard, color: benchmark.color, options: &benchmark.options}
+			if benchmark.options.Hash != "" {
+				writer.hashes = strings.Split(benchmark.options.Hash, ",")
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				builder := getRenderer(writer.color)
+				writer.formatStandard(builder, response)
+				putRenderer(builder)
+			}
+		})
+	}
+}
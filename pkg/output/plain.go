@@ -0,0 +1,195 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// plainField is a labeled value of plain output
+type plainField struct {
+	key   string
+	value string
+	// list is true for values joined from a list whose items are
+	// already quoted
+	list bool
+}
+
+// plainDisplayFields is the list of certificate and handshake fields
+// selected by display flags. With any display flag given only the selected
+// fields are written as in standard output, fields with a nil selector
+// are only written without display flags.
+var plainDisplayFields = map[string]func(*clients.Options) bool{
+	"tls-version":         func(o *clients.Options) bool { return o.TLSVersion },
+	"cipher":              func(o *clients.Options) bool { return o.Cipher },
+	"subject-cn":          func(o *clients.Options) bool { return o.CN },
+	"subject-an":          func(o *clients.Options) bool { return o.SAN },
+	"subject-org":         func(o *clients.Options) bool { return o.SO },
+	"expired":             func(o *clients.Options) bool { return o.Expired },
+	"not-yet-valid":       func(o *clients.Options) bool { return o.Expired },
+	"expiring":            func(o *clients.Options) bool { return o.Expired },
+	"not-after":           func(o *clients.Options) bool { return o.Expired },
+	"self-signed":         func(o *clients.Options) bool { return o.SelfSigned },
+	"key-algorithm":       func(o *clients.Options) bool { return o.KeyType },
+	"key-size":            func(o *clients.Options) bool { return o.KeyType },
+	"key-type":            func(o *clients.Options) bool { return o.KeyType },
+	"weak-key":            func(o *clients.Options) bool { return o.WeakKey },
+	"signature-algorithm": func(o *clients.Options) bool { return o.SignatureAlgorithm || o.WeakSignature },
+	"fingerprint-hash":    func(o *clients.Options) bool { return o.Hash != "" },
+	"pin-sha256":          func(o *clients.Options) bool { return o.PinSHA256 },
+	"not-before":          nil,
+	"subject-dn":          nil,
+	"issuer-dn":           nil,
+	"issuer-cn":           nil,
+	"issuer-org":          nil,
+	"emails":              nil,
+	"serial":              nil,
+	"policies":            nil,
+	"extensions":          nil,
+	"ext-key-usage":       nil,
+	"must-staple":         nil,
+	"tls-connection":      nil,
+}
+
+// hasDisplayFlags returns true if a flag selecting displayed fields is given
+func hasDisplayFlags(options *clients.Options) bool {
+	for _, selected := range plainDisplayFields {
+		if selected != nil && selected(options) {
+			return true
+		}
+	}
+	return false
+}
+
+// plainFieldSelected returns true if the flattened field key is written
+func (w *StandardWriter) plainFieldSelected(key string, display bool) bool {
+	name, sub := key, ""
+	if index := strings.IndexByte(key, '.'); index != -1 {
+		name, sub = key[:index], key[index+1:]
+	}
+	selected, ok := plainDisplayFields[name]
+	if !ok || !display {
+		return true
+	}
+	if selected == nil || !selected(w.options) {
+		return false
+	}
+	if name == "fingerprint-hash" && sub != "" {
+		for _, hash := range w.hashes {
+			if hash == sub {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// formatPlain formats the output as labeled key=value pairs without
+// colors, using the json fields with dotted keys for nested values.
+// Display flags select the written fields as in standard output.
+func (w *StandardWriter) formatPlain(output *clients.Response) ([]byte, error) {
+	data, err := w.formatJSON(output)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields []plainField
+	if err := flattenJSON(decoder, "", &fields); err != nil {
+		return nil, err
+	}
+
+	display := hasDisplayFlags(w.options)
+	builder := &bytes.Buffer{}
+	for _, field := range fields {
+		if !w.plainFieldSelected(field.key, display) {
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(field.key)
+		builder.WriteString("=")
+		if field.list {
+			builder.WriteString(field.value)
+		} else {
+			builder.WriteString(quotePlainValue(field.value, false))
+		}
+	}
+	return builder.Bytes(), nil
+}
+
+// flattenJSON appends the fields of the next json value to fields.
+// Lists of plain values are joined with commas quoting values containing
+// commas, other lists are flattened with the index as key.
+func flattenJSON(decoder *json.Decoder, key string, fields *[]plainField) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		if token == nil {
+			return nil
+		}
+		switch value := token.(type) {
+		case string:
+			*fields = append(*fields, plainField{key: key, value: value})
+		case bool:
+			*fields = append(*fields, plainField{key: key, value: strconv.FormatBool(value)})
+		case json.Number:
+			*fields = append(*fields, plainField{key: key, value: value.String()})
+		}
+		return nil
+	}
+
+	var children []plainField
+	for index := 0; decoder.More(); index++ {
+		childKey := strconv.Itoa(index)
+		if delim == '{' {
+			name, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			childKey, _ = name.(string)
+		}
+		if key != "" {
+			childKey = key + "." + childKey
+		}
+		if err := flattenJSON(decoder, childKey, &children); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	if delim == '[' && len(children) > 0 {
+		values := make([]string, 0, len(children))
+		for index, child := range children {
+			if child.key != key+"."+strconv.Itoa(index) {
+				values = nil
+				break
+			}
+			values = append(values, quotePlainValue(child.value, true))
+		}
+		if values != nil {
+			children = []plainField{{key: key, value: strings.Join(values, ","), list: true}}
+		}
+	}
+	*fields = append(*fields, children...)
+	return nil
+}
+
+// quotePlainValue quotes values which would break key=value parsing,
+// and for list items values which would break splitting at commas.
+func quotePlainValue(value string, item bool) string {
+	if value == "" || strings.ContainsAny(value, " \t\"=\\") || (item && strings.Contains(value, ",")) || strconv.Quote(value) != `"`+value+`"` {
+		return strconv.Quote(value)
+	}
+	return value
+}
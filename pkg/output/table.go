@@ -0,0 +1,194 @@
+package output
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf8"
+)
+
+// tableMaxFieldWidth is the width above which fields do not widen their
+// column so that a single long value does not misalign other results.
+const tableMaxFieldWidth = 48
+
+// tableField is a bracketed field written to a renderer, recorded at the
+// offset of the space preceding it.
+type tableField struct {
+	name   string
+	offset int
+}
+
+// tableCell is the rendered value of a named column of a row
+type tableCell struct {
+	name  string
+	value []byte
+}
+
+// tableLayout buffers lines of standard output and aligns them into a
+// column for the host and each named field once all results are written,
+// so that fields shown only for some hosts keep their own column.
+type tableLayout struct {
+	rows    [][]tableCell
+	columns []string
+	widths  map[string]int
+}
+
+func newTableLayout() *tableLayout {
+	return &tableLayout{widths: make(map[string]int)}
+}
+
+// add buffers each line of data as a row, naming its columns after the
+// fields recorded while rendering data.
+func (t *tableLayout) add(data []byte, fields []tableField) {
+	names := make(map[int]string, len(fields))
+	for _, field := range fields {
+		names[field.offset] = field.name
+	}
+
+	var first []string
+	var lineStart int
+	for lineStart <= len(data) {
+		line := data[lineStart:]
+		if index := bytes.IndexByte(line, '\n'); index != -1 {
+			line = line[:index]
+		}
+		starts := splitColumns(line)
+		row := make([]tableCell, 0, len(starts))
+		occurrences := make(map[string]int, len(starts))
+		var lineNames []string
+		for i, start := range starts {
+			end := len(line)
+			if i+1 < len(starts) {
+				end = starts[i+1] - 1
+			}
+			name, ok := names[lineStart+start-1]
+			switch {
+			case i == 0:
+				name = "host"
+			case ok:
+			case i < len(first):
+				// fields of the prefix repeated on each line of names
+				name = first[i]
+			default:
+				name = "#" + strconv.Itoa(i)
+			}
+			lineNames = append(lineNames, name)
+			occurrences[name]++
+			if count := occurrences[name]; count > 1 {
+				name += "#" + strconv.Itoa(count)
+			}
+			value := append([]byte(nil), line[start:end]...)
+			row = append(row, tableCell{name: name, value: value})
+			if width := visibleWidth(value); width > t.widths[name] && width <= tableMaxFieldWidth {
+				t.widths[name] = width
+			}
+		}
+		if first == nil {
+			first = lineNames
+		}
+		t.addColumns(row)
+		t.rows = append(t.rows, row)
+		lineStart += len(line) + 1
+	}
+}
+
+// addColumns inserts the columns of row not seen yet after the column
+// preceding them in the row, keeping columns in the order fields are written.
+func (t *tableLayout) addColumns(row []tableCell) {
+	position := -1
+	for _, cell := range row {
+		index := -1
+		for i, column := range t.columns {
+			if column == cell.name {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			index = position + 1
+			t.columns = append(t.columns, "")
+			copy(t.columns[index+1:], t.columns[index:])
+			t.columns[index] = cell.name
+		}
+		position = index
+	}
+}
+
+// render writes each buffered row with its fields padded to the width of
+// their column and absent fields left blank.
+func (t *tableLayout) render(builder *renderer, write func([]byte) error) error {
+	for _, row := range t.rows {
+		builder.Reset()
+		cells := make(map[string][]byte, len(row))
+		for _, cell := range row {
+			cells[cell.name] = cell.value
+		}
+		written := 0
+		for _, column := range t.columns {
+			if written == len(row) {
+				break
+			}
+			value, ok := cells[column]
+			if ok {
+				written++
+			}
+			builder.Write(value)
+			if written == len(row) {
+				break
+			}
+			for padding := t.widths[column] - visibleWidth(value); padding > 0; padding-- {
+				builder.WriteByte(' ')
+			}
+			builder.WriteString("  ")
+		}
+		if err := write(builder.Bytes()); err != nil {
+			return err
+		}
+	}
+	t.rows = nil
+	return nil
+}
+
+// splitColumns returns the offsets of the host and each bracketed field
+// of a line, separated by a space outside of brackets.
+func splitColumns(line []byte) []int {
+	starts := []int{0}
+	var depth int
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\x1b':
+			i += escapeLength(line[i:]) - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ' ':
+			if depth == 0 && i+1 < len(line) && line[i+1] == '[' {
+				starts = append(starts, i+1)
+			}
+		}
+	}
+	return starts
+}
+
+// visibleWidth returns the number of characters of data without escape codes
+func visibleWidth(data []byte) int {
+	var width int
+	for i := 0; i < len(data); {
+		if data[i] == '\x1b' {
+			i += escapeLength(data[i:])
+			continue
+		}
+		_, size := utf8.DecodeRune(data[i:])
+		i += size
+		width++
+	}
+	return width
+}
+
+// escapeLength returns the length of the color escape code data starts with
+func escapeLength(data []byte) int {
+	if index := bytes.IndexByte(data, 'm'); index != -1 {
+		return index + 1
+	}
+	return len(data)
+}
@@ -0,0 +1,109 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of output target formats
+const (
+	FormatStandard = "standard"
+	FormatJSON     = "json"
+	FormatPlain    = "plain"
+	// FormatCSV is the auditor inventory csv, not posted to webhooks
+	FormatCSV = "csv"
+	// FormatHTML is a html results table, not posted to webhooks
+	FormatHTML = "html"
+	// FormatTable is standard output aligned into columns, only written
+	// to stdout by the convert command
+	FormatTable = "table"
+)
+
+// List of error policies of output targets
+const (
+	// PolicyContinue logs write errors and continues the scan
+	PolicyContinue = "continue"
+	// PolicyFail aborts the scan on the first write error
+	PolicyFail = "fail"
+)
+
+// DestinationStdout is the destination of targets written to stdout
+const DestinationStdout = "stdout"
+
+// Target is an output destination with its format and error policy
+type Target struct {
+	Format      string
+	Destination string
+	Policy      string
+}
+
+// ParseTarget parses an output target of the form [policy:]format:destination
+// where destination is stdout, a file or a http(s) webhook url.
+func ParseTarget(value string) (*Target, error) {
+	target := &Target{Policy: PolicyContinue}
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) == 2 && (parts[0] == PolicyContinue || parts[0] == PolicyFail) {
+		target.Policy = parts[0]
+		parts = strings.SplitN(parts[1], ":", 2)
+	}
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid output target: %s", value)
+	}
+	target.Format, target.Destination = parts[0], parts[1]
+	switch target.Format {
+	case FormatStandard, FormatJSON, FormatPlain:
+	case FormatCSV, FormatHTML:
+		if target.IsWebhook() {
+			return nil, fmt.Errorf("%s output target can only be written to a file or stdout: %s", target.Format, value)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output target format: %s", target.Format)
+	}
+	return target, nil
+}
+
+// IsWebhook returns true if the target posts events to a url
+func (t *Target) IsWebhook() bool {
+	return strings.HasPrefix(t.Destination, "http://") || strings.HasPrefix(t.Destination, "https://")
+}
+
+// String returns the target as format:destination
+func (t *Target) String() string {
+	return t.Format + ":" + t.Destination
+}
+
+// Targets returns the output targets of options. The output of -output,
+// -audit-csv and stdout in the selected format are written with the
+// continue policy, stdout is replaced by output targets writing to it.
+func Targets(options *clients.Options) ([]*Target, error) {
+	format := FormatStandard
+	switch {
+	case options.JSON:
+		format = FormatJSON
+	case options.Plain:
+		format = FormatPlain
+	}
+
+	var targets []*Target
+	var stdoutTarget bool
+	for _, value := range options.OutputTargets {
+		target, err := ParseTarget(value)
+		if err != nil {
+			return nil, err
+		}
+		stdoutTarget = stdoutTarget || target.Destination == DestinationStdout
+		targets = append(targets, target)
+	}
+	if !stdoutTarget && !options.NoStdout {
+		targets = append(targets, &Target{Format: format, Destination: DestinationStdout, Policy: PolicyContinue})
+	}
+	if options.OutputFile != "" {
+		targets = append(targets, &Target{Format: format, Destination: options.OutputFile, Policy: PolicyContinue})
+	}
+	if options.AuditCSV != "" {
+		targets = append(targets, &Target{Format: FormatCSV, Destination: options.AuditCSV, Policy: PolicyContinue})
+	}
+	return targets, nil
+}
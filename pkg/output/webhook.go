@@ -0,0 +1,157 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// webhookQueueSize is the number of events queued for posting before
+	// further events are dropped
+	webhookQueueSize = 1024
+	// webhookTimeout is the timeout of a single webhook request
+	webhookTimeout = 10 * time.Second
+)
+
+// webhookWriter is a destination posting each formatted event to a url.
+// Events are posted by a worker so slow endpoints do not block the scan,
+// and post errors are returned by the next write. With the fail policy
+// events are posted synchronously so no result is lost unnoticed. The
+// first post error is kept and returned again by close.
+type webhookWriter struct {
+	url         string
+	contentType string
+	client      *http.Client
+	synchronous bool
+	queue       chan []byte
+	done        chan struct{}
+
+	mutex   sync.Mutex
+	err     error
+	pending error
+	dropped int
+}
+
+func newWebhookWriter(url, format, policy string) *webhookWriter {
+	contentType := "text/plain; charset=utf-8"
+	if format == FormatJSON {
+		contentType = "application/json"
+	}
+	writer := &webhookWriter{
+		url:         url,
+		contentType: contentType,
+		client:      &http.Client{Timeout: webhookTimeout},
+		synchronous: policy == PolicyFail,
+	}
+	if !writer.synchronous {
+		writer.queue = make(chan []byte, webhookQueueSize)
+		writer.done = make(chan struct{})
+		go writer.work()
+	}
+	return writer
+}
+
+// work posts queued events until the queue is closed
+func (w *webhookWriter) work() {
+	defer close(w.done)
+	for data := range w.queue {
+		if err := w.post(data); err != nil {
+			w.setError(err)
+		}
+	}
+}
+
+func (w *webhookWriter) post(data []byte) error {
+	resp, err := w.client.Post(w.url, w.contentType, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected webhook status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Write posts an event with the fail policy, or queues it returning an
+// error if the queue is full along with the error of a previous post.
+func (w *webhookWriter) Write(data []byte) error {
+	if w.synchronous {
+		if err := w.firstError(); err != nil {
+			return err
+		}
+		if err := w.post(data); err != nil {
+			w.setError(err)
+			return err
+		}
+		return nil
+	}
+	// data is reused by the caller once written
+	event := make([]byte, len(data))
+	copy(event, data)
+	select {
+	case w.queue <- event:
+	default:
+		w.mutex.Lock()
+		w.dropped++
+		w.mutex.Unlock()
+		return errors.Errorf("dropped webhook event for %s, queue is full", w.url)
+	}
+	return w.takeError()
+}
+
+// setError records a post error, keeping the first one
+func (w *webhookWriter) setError(err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err == nil {
+		w.err = err
+	}
+	w.pending = err
+}
+
+// takeError returns and clears the error of a previous post not returned yet
+func (w *webhookWriter) takeError() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	err := w.pending
+	w.pending = nil
+	return err
+}
+
+// firstError returns the first post error
+func (w *webhookWriter) firstError() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.err
+}
+
+func (w *webhookWriter) Flush() error {
+	return nil
+}
+
+// Close waits for queued events to be posted and returns the first post
+// error, or an error if events were dropped.
+func (w *webhookWriter) Close() error {
+	if !w.synchronous {
+		close(w.queue)
+		<-w.done
+	}
+	if err := w.firstError(); err != nil {
+		return err
+	}
+	if w.dropped > 0 {
+		return errors.Errorf("dropped %d webhook events for %s", w.dropped, w.url)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package plaintext implements plaintext service detection for ports
+// which do not complete a tls handshake.
+package plaintext
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// maxBannerLength is the maximum number of banner bytes read
+const maxBannerLength = 512
+
+// waitTimeout is the time to wait for a server initiated banner
+// before sending a http request to elicit a response.
+const waitTimeout = 2 * time.Second
+
+// httpRequest is sent to services which do not speak first
+var httpRequest = []byte("GET / HTTP/1.0\r\n\r\n")
+
+// Result is the result of a plaintext service detection
+type Result struct {
+	// Service is the detected service name
+	Service string
+	// Banner is the first line of the plaintext response
+	Banner string
+}
+
+// Detect connects to address and classifies the plaintext service from
+// its banner. Services which do not send a banner are probed with http.
+func Detect(options *clients.Options, address string, timeout time.Duration) (*Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := clients.Dial(ctx, options, address)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial address")
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	data, err := read(conn, minTime(time.Now().Add(waitTimeout), deadline))
+	if len(data) == 0 {
+		if _, err = conn.Write(httpRequest); err != nil {
+			return nil, errors.Wrap(err, "could not write http request")
+		}
+		data, err = read(conn, deadline)
+	}
+	if len(data) == 0 {
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read banner")
+		}
+		return nil, errors.New("no banner received")
+	}
+	return &Result{Service: Classify(data), Banner: firstLine(data)}, nil
+}
+
+// Classify returns the service name for a plaintext banner
+func Classify(data []byte) string {
+	line := firstLine(data)
+	upper := strings.ToUpper(line)
+	switch {
+	case strings.HasPrefix(line, "SSH-"):
+		return "ssh"
+	case strings.HasPrefix(line, "HTTP/"):
+		return "http"
+	case strings.HasPrefix(line, "220") && (strings.Contains(upper, "SMTP") || strings.Contains(upper, "MAIL")):
+		return "smtp"
+	case strings.HasPrefix(line, "220") && strings.Contains(upper, "FTP"):
+		return "ftp"
+	case strings.HasPrefix(line, "220"):
+		return "smtp"
+	case strings.HasPrefix(line, "* OK"):
+		return "imap"
+	case strings.HasPrefix(line, "+OK"):
+		return "pop3"
+	case strings.HasPrefix(line, "RFB "):
+		return "vnc"
+	case strings.HasPrefix(line, "-ERR") || strings.HasPrefix(line, "+PONG"):
+		return "redis"
+	case len(data) > 5 && data[4] == 0x0a && bytes.IndexByte(data[5:], 0) > 0:
+		// mysql handshake v10 packet
+		return "mysql"
+	}
+	return "unknown"
+}
+
+func read(conn net.Conn, deadline time.Time) ([]byte, error) {
+	_ = conn.SetReadDeadline(deadline)
+	buffer := make([]byte, maxBannerLength)
+	n, err := conn.Read(buffer)
+	return buffer[:n], err
+}
+
+func firstLine(data []byte) string {
+	if index := bytes.IndexAny(data, "\r\n"); index != -1 {
+		data = data[:index]
+	}
+	return strings.ToValidUTF8(string(data), "")
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.IsZero() || a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+//go:embed locales/*.json
+var catalogs embed.FS
+
+// Locale is a message catalog with date and number formats used to
+// render reports. Messages are keyed by their english text, which is
+// used for messages missing from the catalog.
+type Locale struct {
+	// DateFormat is the go time layout of dates
+	DateFormat string `json:"date-format"`
+	// DateTimeFormat is the go time layout of dates with time
+	DateTimeFormat string `json:"date-time-format"`
+	// ThousandsSeparator is the separator of digit groups in numbers
+	ThousandsSeparator string `json:"thousands-separator"`
+	// Messages is the list of translated messages by english text
+	Messages map[string]string `json:"messages"`
+	// Findings is the list of translated findings by finding id
+	Findings map[string]LocaleFinding `json:"findings"`
+}
+
+// LocaleFinding is the translated title and remediation of a finding
+type LocaleFinding struct {
+	Title       string `json:"title"`
+	Remediation string `json:"remediation"`
+}
+
+// DefaultLocale is the english locale reports are rendered with by default
+var DefaultLocale = &Locale{
+	DateFormat:         "2006-01-02",
+	DateTimeFormat:     "2006-01-02 15:04 MST",
+	ThousandsSeparator: ",",
+}
+
+// LoadLocale returns the locale of an embedded catalog name (de, es, fr)
+// or a json catalog file, and the default locale if value is empty.
+func LoadLocale(value string) (*Locale, error) {
+	if value == "" || value == "en" {
+		return DefaultLocale, nil
+	}
+	data, err := catalogs.ReadFile(path.Join("locales", value+".json"))
+	if err != nil {
+		if data, err = ioutil.ReadFile(value); err != nil {
+			return nil, errors.Errorf("unsupported report locale: %s", value)
+		}
+	}
+	locale := &Locale{}
+	if err := json.Unmarshal(data, locale); err != nil {
+		return nil, errors.Wrap(err, "could not decode locale catalog")
+	}
+	if locale.DateFormat == "" {
+		locale.DateFormat = DefaultLocale.DateFormat
+	}
+	if locale.DateTimeFormat == "" {
+		locale.DateTimeFormat = DefaultLocale.DateTimeFormat
+	}
+	return locale, nil
+}
+
+// Message returns the translated message formatted with args
+func (l *Locale) Message(message string, args ...interface{}) string {
+	if translated, ok := l.Messages[message]; ok && translated != "" {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Finding returns finding with the translated title and remediation,
+// the english text is kept for fields missing from the catalog.
+func (l *Locale) Finding(finding clients.Finding) clients.Finding {
+	translated, ok := l.Findings[finding.ID]
+	if !ok {
+		return finding
+	}
+	if translated.Title != "" {
+		finding.Title = translated.Title
+	}
+	if translated.Remediation != "" {
+		finding.Remediation = translated.Remediation
+	}
+	return finding
+}
+
+// Date returns value formatted as a date in utc
+func (l *Locale) Date(value time.Time) string {
+	return value.UTC().Format(l.DateFormat)
+}
+
+// DateTime returns value formatted as a date with time in utc
+func (l *Locale) DateTime(value time.Time) string {
+	return value.UTC().Format(l.DateTimeFormat)
+}
+
+// Number returns value with digit groups separated
+func (l *Locale) Number(value int) string {
+	digits := strconv.Itoa(value)
+	sign := ""
+	if value < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if l.ThousandsSeparator == "" || len(digits) <= 3 {
+		return sign + digits
+	}
+	groups := make([]string, 0, len(digits)/3+1)
+	first := len(digits) % 3
+	if first > 0 {
+		groups = append(groups, digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		groups = append(groups, digits[i:i+3])
+	}
+	return sign + strings.Join(groups, l.ThousandsSeparator)
+}
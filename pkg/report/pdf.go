@@ -0,0 +1,310 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	pageMargin   = 50.0
+	chartLabelW  = 140.0
+	chartBarMaxW = 300.0
+
+	// remediationLineLength is the wrap length of remediation text
+	remediationLineLength = 95
+)
+
+var (
+	colorText   = [3]float64{0.15, 0.15, 0.15}
+	colorAccent = [3]float64{0.16, 0.38, 0.71}
+	colorRisk   = [3]float64{0.80, 0.22, 0.18}
+	colorMuted  = [3]float64{0.45, 0.45, 0.45}
+)
+
+// WritePDF renders an executive summary pdf report to writer with the
+// labels, findings, dates and numbers of locale, or DefaultLocale if nil.
+func WritePDF(writer io.Writer, summary *Summary, locale *Locale) error {
+	if locale == nil {
+		locale = DefaultLocale
+	}
+	// risks are labeled with the english titles of their findings
+	remediations := make([]clients.Finding, 0, len(summary.Remediations))
+	titles := make(map[string]string, len(summary.Remediations))
+	for _, finding := range summary.Remediations {
+		translated := locale.Finding(finding)
+		remediations = append(remediations, translated)
+		titles[finding.Title] = translated.Title
+	}
+	risks := make([]Count, 0, len(summary.Risks))
+	for _, risk := range summary.Risks {
+		if title, ok := titles[risk.Label]; ok {
+			risk.Label = title
+		}
+		risks = append(risks, risk)
+	}
+
+	doc := &pdfDocument{}
+	layout := &pdfLayout{doc: doc, locale: locale}
+	layout.newPage()
+
+	layout.heading(locale.Message("TLS Executive Summary"), 22)
+	layout.line(locale.Message("Generated %s", locale.DateTime(summary.Generated)), 10, false, colorMuted)
+	if !summary.ValidationTime.IsZero() {
+		layout.line(locale.Message("Certificate validity evaluated as of %s", locale.DateTime(summary.ValidationTime)), 10, false, colorMuted)
+	}
+	layout.space(10)
+	layout.line(locale.Message("Endpoints scanned: %s", locale.Number(summary.Total)), 12, true, colorText)
+	layout.line(locale.Message("Findings: %s", locale.Number(sumCounts(summary.Risks))), 12, true, colorText)
+	layout.space(16)
+
+	layout.heading(locale.Message("TLS Version Distribution"), 14)
+	layout.barChart(summary.Versions, colorAccent)
+	layout.space(12)
+
+	layout.heading(locale.Message("Certificate Expiry"), 14)
+	layout.barChart(summary.Expiry, colorAccent)
+	layout.space(12)
+
+	layout.heading(locale.Message("Top Risks"), 14)
+	if len(summary.Risks) == 0 {
+		layout.line(locale.Message("No risks identified."), 11, false, colorText)
+	} else {
+		layout.barChart(risks, colorRisk)
+	}
+	layout.space(12)
+
+	if len(summary.Expiring) > 0 {
+		layout.heading(locale.Message("Certificates Expiring Within %d Days", WindowDays(summary.ExpiringWindow)), 14)
+		for _, cert := range summary.Expiring {
+			entry := fmt.Sprintf("%s  %s  %s", locale.Date(cert.NotAfter), cert.Address, cert.Subject)
+			layout.line(entry, 10, false, colorText)
+		}
+		layout.space(12)
+	}
+
+	if len(summary.SharedKeys) > 0 {
+		layout.heading(locale.Message("Keys Shared Across Organizations"), 14)
+		for _, key := range summary.SharedKeys {
+			entry := locale.Message("%s  %s hosts  %s organizations  %s ranges", key.PinSHA256, locale.Number(key.Hosts), locale.Number(len(key.Organizations)), locale.Number(len(key.Ranges)))
+			layout.line(entry, 10, false, colorRisk)
+		}
+		layout.space(12)
+	}
+
+	if len(remediations) > 0 {
+		layout.heading(locale.Message("Remediation"), 14)
+		for _, finding := range remediations {
+			layout.line(finding.Title, 11, true, colorText)
+			for _, text := range wrapText(finding.Remediation, remediationLineLength) {
+				layout.line(text, 10, false, colorText)
+			}
+			for _, reference := range finding.References {
+				layout.line(reference, 9, false, colorMuted)
+			}
+			layout.space(6)
+		}
+	}
+	return doc.writeTo(writer)
+}
+
+// wrapText splits text into lines of at most length characters
+func wrapText(text string, length int) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		if current != "" && len(current)+1+len(word) > length {
+			lines = append(lines, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func sumCounts(counts []Count) int {
+	var total int
+	for _, count := range counts {
+		total += count.Value
+	}
+	return total
+}
+
+// pdfLayout is a top-to-bottom flowing layout over pdf pages
+type pdfLayout struct {
+	doc    *pdfDocument
+	locale *Locale
+	y      float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.doc.addPage()
+	l.y = pageHeight - pageMargin
+}
+
+// ensure starts a new page if height does not fit on the current one
+func (l *pdfLayout) ensure(height float64) {
+	if l.y-height < pageMargin {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) space(height float64) {
+	l.y -= height
+}
+
+func (l *pdfLayout) heading(value string, size float64) {
+	l.ensure(size + 8)
+	l.y -= size
+	l.doc.text(pageMargin, l.y, size, true, colorText, value)
+	l.y -= 8
+}
+
+func (l *pdfLayout) line(value string, size float64, bold bool, color [3]float64) {
+	l.ensure(size + 4)
+	l.y -= size
+	l.doc.text(pageMargin, l.y, size, bold, color, value)
+	l.y -= 4
+}
+
+// barChart draws a horizontal bar chart of counts
+func (l *pdfLayout) barChart(counts []Count, color [3]float64) {
+	var max int
+	for _, count := range counts {
+		if count.Value > max {
+			max = count.Value
+		}
+	}
+	for _, count := range counts {
+		l.ensure(18)
+		l.y -= 14
+		l.doc.text(pageMargin, l.y+2, 10, false, colorText, l.locale.Message(count.Label, count.Args...))
+
+		width := 1.0
+		if max > 0 && count.Value > 0 {
+			width = chartBarMaxW * float64(count.Value) / float64(max)
+		}
+		l.doc.rect(pageMargin+chartLabelW, l.y, width, 11, color)
+		l.doc.text(pageMargin+chartLabelW+width+6, l.y+2, 10, false, colorMuted, l.locale.Number(count.Value))
+		l.y -= 4
+	}
+}
+
+// pdfDocument is a minimal pdf writer supporting text with the
+// standard helvetica fonts and filled rectangles.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+}
+
+func (d *pdfDocument) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *pdfDocument) current() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *pdfDocument) text(x, y, size float64, bold bool, color [3]float64, value string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current(), "BT %.3f %.3f %.3f rg /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", color[0], color[1], color[2], font, size, x, y, escapePDFString(value))
+}
+
+func (d *pdfDocument) rect(x, y, width, height float64, color [3]float64) {
+	fmt.Fprintf(d.current(), "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", color[0], color[1], color[2], x, y, width, height)
+}
+
+// writeTo writes the document objects, cross reference table and trailer
+func (d *pdfDocument) writeTo(writer io.Writer) error {
+	buffer := &bytes.Buffer{}
+	var offsets []int
+
+	writeObject := func(body string) {
+		offsets = append(offsets, buffer.Len())
+		fmt.Fprintf(buffer, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buffer.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts, followed by
+	// a page and content stream object pair for every page.
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+i*2))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xrefOffset := buffer.Len()
+	fmt.Fprintf(buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	_, err := buffer.WriteTo(writer)
+	return err
+}
+
+// winAnsiCharacters is the list of winansi encoded characters outside
+// of latin-1 used by localized reports.
+var winAnsiCharacters = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '–': 0x96, '—': 0x97,
+}
+
+// escapePDFString escapes a value for use in a pdf literal string.
+// Latin-1 characters are written as winansi octal escapes, other
+// characters outside of printable ascii are replaced.
+func escapePDFString(value string) string {
+	builder := &strings.Builder{}
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(r)
+		case winAnsiCharacters[r] != 0:
+			fmt.Fprintf(builder, "\\%03o", winAnsiCharacters[r])
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(builder, "\\%03o", r)
+		case r < 32 || r > 126:
+			builder.WriteRune('?')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// WritePDFFile renders an executive summary pdf report to a file
+func WritePDFFile(file string, summary *Summary, locale *Locale) error {
+	output, err := os.Create(file)
+	if err != nil {
+		return errors.Wrap(err, "could not create report file")
+	}
+	if err := WritePDF(output, summary, locale); err != nil {
+		output.Close()
+		return errors.Wrap(err, "could not write report")
+	}
+	return output.Close()
+}
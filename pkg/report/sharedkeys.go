@@ -0,0 +1,23 @@
+package report
+
+import (
+	"github.com/projectdiscovery/tlsx/pkg/duplicates"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// DefaultSharedKeyThreshold is the default number of distinct organizations
+// a key must be served by to be reported as shared.
+const DefaultSharedKeyThreshold = 2
+
+// SharedKey is a public key served by distinct organizations
+type SharedKey = duplicates.SharedKey
+
+// SharedKeys aggregates results by spki hash and returns the keys served
+// by at least threshold distinct organizations sorted by the spread of the key.
+func SharedKeys(results []*clients.Response, threshold int) []SharedKey {
+	tracker := duplicates.New()
+	for _, result := range results {
+		tracker.Add(result)
+	}
+	return tracker.SharedKeys(threshold)
+}
@@ -0,0 +1,268 @@
+// Package report implements generation of executive summary reports
+// from tlsx scan results.
+package report
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/duplicates"
+	"github.com/projectdiscovery/tlsx/pkg/findings"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// maxExpiringEntries is the maximum number of expiring certificates listed
+const maxExpiringEntries = 15
+
+// maxSharedKeyEntries is the maximum number of shared keys listed
+const maxSharedKeyEntries = 15
+
+// Summary is an aggregated summary of scan results
+type Summary struct {
+	// Generated is the time the summary was generated at
+	Generated time.Time
+	// ValidationTime is the time validity was evaluated at if not generated time
+	ValidationTime time.Time
+	// ExpiringWindow is the window in which certificates are listed as expiring
+	ExpiringWindow time.Duration
+	// Total is the total number of results
+	Total int
+	// Versions is the number of results per tls version
+	Versions []Count
+	// Expiry is the number of certificates per expiry bucket
+	Expiry []Count
+	// Risks is the number of results per risk sorted by count
+	Risks []Count
+	// Expiring is a list of certificates expiring soonest
+	Expiring []ExpiringCertificate
+	// Remediations is the list of remediation hints for risks
+	Remediations []clients.Finding
+	// SharedKeys is a list of keys served by distinct organizations
+	SharedKeys []SharedKey
+}
+
+// Count is a labeled counter value, the label is formatted with args
+type Count struct {
+	Label string
+	Args  []interface{}
+	Value int
+}
+
+// ExpiringCertificate is a certificate which expires within the window
+type ExpiringCertificate struct {
+	Address  string
+	Subject  string
+	NotAfter time.Time
+}
+
+// ReadResults reads json lines scan results from a file
+func ReadResults(file string) ([]*clients.Response, error) {
+	input, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open results file")
+	}
+	defer input.Close()
+
+	return DecodeResults(input)
+}
+
+// DecodeResults decodes json lines scan results from a reader.
+// Results for hosts which did not complete tls handshake are skipped.
+func DecodeResults(reader io.Reader) ([]*clients.Response, error) {
+	var results []*clients.Response
+	err := StreamResults(reader, func(response *clients.Response) {
+		results = append(results, response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// StreamResults decodes json lines scan results from a reader calling
+// fn for each result without keeping them in memory. Results for hosts
+// which did not complete tls handshake are skipped.
+func StreamResults(reader io.Reader, fn func(*clients.Response)) error {
+	return StreamAllResults(reader, func(response *clients.Response) {
+		if response.Error == "" {
+			fn(response)
+		}
+	})
+}
+
+// StreamAllResults decodes json lines scan results from a reader calling
+// fn for each result including hosts which did not complete tls handshake.
+func StreamAllResults(reader io.Reader, fn func(*clients.Response)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		response := &clients.Response{}
+		if err := jsoniter.Unmarshal([]byte(line), response); err != nil {
+			return errors.Wrap(err, "could not decode result")
+		}
+		fn(response)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "could not read results")
+	}
+	return nil
+}
+
+// Summarize creates a summary from a list of scan results using
+// remediation hints from database for the identified risks. Certificate
+// validity is evaluated at validationTime, or the current time if zero,
+// and certificates expiring within window are listed as expiring.
+func Summarize(results []*clients.Response, database *findings.Database, validationTime time.Time, window time.Duration) *Summary {
+	summarizer := NewSummarizer(database, validationTime, window)
+	for _, result := range results {
+		summarizer.Add(result)
+	}
+	return summarizer.Summary()
+}
+
+// Summarizer aggregates scan results one at a time into a summary,
+// so that results do not need to be kept in memory.
+type Summarizer struct {
+	database   *findings.Database
+	now        time.Time
+	summary    *Summary
+	sharedKeys *duplicates.Tracker
+
+	versions map[string]int
+	risks    map[string]int
+	riskIDs  map[string]string
+
+	expired, expiringSoon, expiringQuarter, valid int
+}
+
+// NewSummarizer creates a summarizer using remediation hints from database
+// and evaluating certificate validity at validationTime, or the current
+// time if zero. Certificates expiring within window, or the default
+// findings window if zero, are listed as expiring.
+func NewSummarizer(database *findings.Database, validationTime time.Time, window time.Duration) *Summarizer {
+	if window <= 0 {
+		window = findings.ExpiringWindow
+	}
+	now := time.Now()
+	summarizer := &Summarizer{
+		database:   database,
+		now:        now,
+		summary:    &Summary{Generated: now, ExpiringWindow: window},
+		sharedKeys: duplicates.New(),
+		versions:   make(map[string]int),
+		risks:      make(map[string]int),
+		riskIDs:    make(map[string]string),
+	}
+	if !validationTime.IsZero() {
+		summarizer.now = validationTime
+		summarizer.summary.ValidationTime = validationTime
+	}
+	return summarizer
+}
+
+// Add aggregates a scan result into the summary
+func (s *Summarizer) Add(result *clients.Response) {
+	s.summary.Total++
+	version := strings.ToUpper(result.Version)
+	if version == "" {
+		version = "UNKNOWN"
+	}
+	s.versions[version]++
+
+	cert := result.CertificateResponse
+	remaining := cert.NotAfter.Sub(s.now)
+	switch {
+	case cert.NotAfter.IsZero():
+		// results without a certificate have no expiry
+	case cert.Expired || remaining <= 0:
+		s.expired++
+	case remaining <= s.summary.ExpiringWindow:
+		s.expiringSoon++
+		s.summary.Expiring = append(s.summary.Expiring, ExpiringCertificate{
+			Address:  result.Host + ":" + result.Port,
+			Subject:  cert.SubjectCN,
+			NotAfter: cert.NotAfter,
+		})
+		// only the soonest expiring certificates are kept
+		if len(s.summary.Expiring) > 2*maxExpiringEntries {
+			s.truncateExpiring()
+		}
+	case remaining <= 3*s.summary.ExpiringWindow:
+		s.expiringQuarter++
+	default:
+		s.valid++
+	}
+
+	for _, id := range findings.Detect(result, s.now, s.summary.ExpiringWindow) {
+		title := s.database.Finding(id).Title
+		s.risks[title]++
+		s.riskIDs[title] = id
+	}
+	s.sharedKeys.Add(result)
+}
+
+// Summary returns the summary of the results added
+func (s *Summarizer) Summary() *Summary {
+	summary := s.summary
+	summary.Versions = sortedCounts(s.versions)
+	days := WindowDays(summary.ExpiringWindow)
+	summary.Expiry = []Count{
+		{Label: "Expired", Value: s.expired},
+		{Label: "< %d days", Args: []interface{}{days}, Value: s.expiringSoon},
+		{Label: "%d-%d days", Args: []interface{}{days, 3 * days}, Value: s.expiringQuarter},
+		{Label: "> %d days", Args: []interface{}{3 * days}, Value: s.valid},
+	}
+	summary.Risks = sortedCounts(s.risks)
+	summary.Remediations = nil
+	for _, risk := range summary.Risks {
+		summary.Remediations = append(summary.Remediations, s.database.Finding(s.riskIDs[risk.Label]))
+	}
+	s.truncateExpiring()
+	summary.SharedKeys = s.sharedKeys.SharedKeys(DefaultSharedKeyThreshold)
+	if len(summary.SharedKeys) > maxSharedKeyEntries {
+		summary.SharedKeys = summary.SharedKeys[:maxSharedKeyEntries]
+	}
+	return summary
+}
+
+// WindowDays returns the number of days of an expiring window rounded up
+func WindowDays(window time.Duration) int {
+	day := 24 * time.Hour
+	return int((window + day - 1) / day)
+}
+
+// truncateExpiring keeps the soonest expiring certificates of the summary
+func (s *Summarizer) truncateExpiring() {
+	expiring := s.summary.Expiring
+	sort.Slice(expiring, func(i, j int) bool {
+		return expiring[i].NotAfter.Before(expiring[j].NotAfter)
+	})
+	if len(expiring) > maxExpiringEntries {
+		s.summary.Expiring = expiring[:maxExpiringEntries]
+	}
+}
+
+// sortedCounts returns counts sorted by value descending then label
+func sortedCounts(values map[string]int) []Count {
+	counts := make([]Count, 0, len(values))
+	for label, value := range values {
+		counts = append(counts, Count{Label: label, Value: value})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Value == counts[j].Value {
+			return counts[i].Label < counts[j].Label
+		}
+		return counts[i].Value > counts[j].Value
+	})
+	return counts
+}
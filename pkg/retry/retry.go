@@ -0,0 +1,147 @@
+// Package retry implements persistence of permanently failed targets
+// so that they can be re-attempted later, for example from a different
+// network.
+package retry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+)
+
+// List of error categories for failed targets
+const (
+	CategoryDNS         = "dns"
+	CategoryTimeout     = "timeout"
+	CategoryConnect     = "connect"
+	CategoryRefused     = "refused"
+	CategoryReset       = "reset"
+	CategoryUnreachable = "unreachable"
+	CategoryStartTLS    = "starttls"
+	CategoryHandshake   = "handshake"
+	CategoryOther       = "other"
+)
+
+// Entry is a failed target written to a retry file
+type Entry struct {
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Category string `json:"category"`
+	Error    string `json:"error"`
+}
+
+// categoryPatterns maps error message fragments to categories for tls
+// and starttls failures, which are not exposed as typed errors.
+var categoryPatterns = []struct {
+	category string
+	patterns []string
+}{
+	{CategoryStartTLS, []string{"starttls"}},
+	{CategoryHandshake, []string{"handshake", "tls:", "alert", "certificate"}},
+}
+
+// Categorize returns the category of a connection error
+func Categorize(err error) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout(), errors.Is(err, context.DeadlineExceeded):
+		return CategoryTimeout
+	case errors.As(err, &dnsErr), errors.Is(err, fastdialer.ResolveHostError), errors.Is(err, fastdialer.NoAddressFoundError):
+		return CategoryDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return CategoryRefused
+	// the dialer does not expose why connecting to addresses failed
+	case errors.Is(err, fastdialer.CouldNotConnectError):
+		return CategoryConnect
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return CategoryReset
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTDOWN):
+		return CategoryUnreachable
+	}
+	message := strings.ToLower(err.Error())
+	for _, category := range categoryPatterns {
+		for _, pattern := range category.patterns {
+			if strings.Contains(message, pattern) {
+				return category.category
+			}
+		}
+	}
+	return CategoryOther
+}
+
+// Writer writes failed targets to a retry file
+type Writer struct {
+	mutex  sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// NewWriter creates a new retry file writer
+func NewWriter(file string) (*Writer, error) {
+	output, err := os.Create(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create retry file")
+	}
+	return &Writer{file: output, writer: bufio.NewWriter(output)}, nil
+}
+
+// Write writes a failed target with the category of its error
+func (w *Writer) Write(host, port string, err error) error {
+	data, marshalErr := json.Marshal(&Entry{Host: host, Port: port, Category: Categorize(err), Error: errors.Cause(err).Error()})
+	if marshalErr != nil {
+		return marshalErr
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, err := w.writer.Write(data); err != nil {
+		return err
+	}
+	return w.writer.WriteByte('\n')
+}
+
+// Close flushes and closes the retry file
+func (w *Writer) Close() error {
+	if err := w.writer.Flush(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Read reads the failed targets from a retry file
+func Read(file string) ([]Entry, error) {
+	input, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open retry file")
+	}
+	defer input.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, errors.Wrapf(err, "could not parse retry file line %d", line)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read retry file")
+	}
+	return entries, nil
+}
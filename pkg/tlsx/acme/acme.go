@@ -0,0 +1,50 @@
+// Package acme implements detection of ACME tls-alpn-01 challenge
+// endpoints by negotiating the acme-tls/1 application protocol.
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// ALPNProtocol is the application protocol used by tls-alpn-01 challenges
+const ALPNProtocol = "acme-tls/1"
+
+// Probe returns true if the server negotiates the acme-tls/1 protocol
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc8737
+func Probe(options *clients.Options, hostname, port string) (bool, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return false, errors.Wrap(err, "could not dial address")
+	}
+	defer rawConn.Close()
+
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{ALPNProtocol},
+		ServerName:         options.ServerName,
+	}
+	if config.ServerName == "" && !iputil.IsIP(hostname) {
+		config.ServerName = hostname
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		// servers without pending challenges reject the protocol
+		return false, nil
+	}
+	return conn.ConnectionState().NegotiatedProtocol == ALPNProtocol, nil
+}
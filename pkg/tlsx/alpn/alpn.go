@@ -0,0 +1,73 @@
+// Package alpn implements enumeration of the application protocols
+// selected by a server during alpn negotiation.
+package alpn
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+)
+
+// DefaultProtocols is the list of application protocols enumerated
+// unless a custom list is specified.
+var DefaultProtocols = []string{"h2", "http/1.1", "http/1.0", "spdy/3.1", "spdy/3", "spdy/2", "h2c", "grpc-exp", "dot", "imap", "pop3", "managesieve", "ftp", "xmpp-client", "xmpp-server", "mqtt", "coap", "stun.turn", "webrtc", "sunrpc", "postgresql"}
+
+// Enumerate returns the protocols selected by the server when offered
+// alone. Protocols from options are enumerated if specified.
+func Enumerate(options *clients.Options, hostname, port string) ([]string, error) {
+	protocols := DefaultProtocols
+	if len(options.ALPN) > 0 {
+		protocols = options.ALPN
+	}
+	var selected []string
+	for _, protocol := range protocols {
+		ok, err := negotiate(options, hostname, port, protocol)
+		if err != nil {
+			return selected, err
+		}
+		if ok {
+			selected = append(selected, protocol)
+		}
+	}
+	return selected, nil
+}
+
+// negotiate returns true if the server selects protocol
+func negotiate(options *clients.Options, hostname, port, protocol string) (bool, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return false, errors.Wrap(err, "could not dial address")
+	}
+	defer rawConn.Close()
+
+	if err := starttls.Negotiate(ctx, rawConn, starttls.Protocol(options, port), starttls.ServerName(options, hostname)); err != nil {
+		return false, errors.Wrap(err, "could not negotiate starttls")
+	}
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{protocol},
+		ServerName:         options.ServerName,
+	}
+	if config.ServerName == "" && !iputil.IsIP(hostname) {
+		config.ServerName = hostname
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		// strict servers reject unknown protocols with an alert
+		return false, nil
+	}
+	return conn.ConnectionState().NegotiatedProtocol == protocol, nil
+}
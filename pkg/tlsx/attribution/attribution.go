@@ -0,0 +1,237 @@
+// Package attribution scores how likely the names of a certificate
+// belong to the organization of the scanned hostname.
+package attribution
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ct"
+)
+
+// Threshold is the score from which names are attributed with high confidence
+const Threshold = 0.5
+
+// List of attribution reasons
+const (
+	// ReasonScope is used when the registered domain of the name is in scope
+	ReasonScope = "scope"
+	// ReasonOrganization is used when the whois registrant organization
+	// of the name matches the organization of the scope
+	ReasonOrganization = "organization"
+	// ReasonCT is used when the name was issued together with in scope
+	// names in certificate transparency logs
+	ReasonCT = "ct"
+)
+
+// scores is the score added for each reason. Certificate transparency
+// history alone is not enough for attribution as cdn certificates are
+// shared by unrelated customers.
+var scores = map[string]float64{
+	ReasonScope:        0.6,
+	ReasonOrganization: 0.5,
+	ReasonCT:           0.3,
+}
+
+// maxCTLookups is the number of concurrent certificate transparency lookups
+const maxCTLookups = 4
+
+// defaultLookupTimeout is the timeout of whois and certificate
+// transparency lookups if no timeout is specified
+const defaultLookupTimeout = 10 * time.Second
+
+// Attributor scores certificate names caching whois and certificate
+// transparency lookups between responses.
+type Attributor struct {
+	options  *clients.Options
+	scope    []string
+	timeout  time.Duration
+	ctClient *ct.SearchClient
+	// ctSlots bounds the number of concurrent ct lookups
+	ctSlots chan struct{}
+
+	mutex         sync.Mutex
+	organizations map[string]string
+	ctLookups     map[string]*ctLookup
+}
+
+// ctLookup is the certificate transparency lookup of a registered domain
+// shared by the names and hosts of the domain
+type ctLookup struct {
+	once  sync.Once
+	names []string
+}
+
+// New creates an attributor with the attribution scope of options
+func New(options *clients.Options) *Attributor {
+	attributor := &Attributor{
+		options:       options,
+		timeout:       time.Duration(options.Timeout) * time.Second,
+		ctSlots:       make(chan struct{}, maxCTLookups),
+		organizations: make(map[string]string),
+		ctLookups:     make(map[string]*ctLookup),
+	}
+	if attributor.timeout == 0 {
+		attributor.timeout = defaultLookupTimeout
+	}
+	for _, domain := range options.AttributionScope {
+		if domain = clients.NormalizeName(domain); domain != "" {
+			attributor.scope = append(attributor.scope, clients.RegisteredDomain(domain))
+		}
+	}
+	attributor.ctClient = ct.NewSearchClient(options, attributor.timeout)
+	return attributor
+}
+
+// Attribute scores the names of cert for the scanned hostname, which is
+// empty for ip inputs. Nil is returned if there is no scope to score with.
+func (a *Attributor) Attribute(hostname string, cert *clients.CertificateResponse) *clients.AttributionResponse {
+	scope := make(map[string]struct{})
+	for _, domain := range a.scope {
+		scope[domain] = struct{}{}
+	}
+	if hostname = clients.NormalizeName(hostname); hostname != "" {
+		scope[clients.RegisteredDomain(hostname)] = struct{}{}
+	}
+	if len(scope) == 0 {
+		return nil
+	}
+
+	response := &clients.AttributionResponse{}
+	var organizations []string
+	seen := make(map[string]struct{})
+	for _, name := range append([]string{cert.SubjectCN}, cert.SubjectAN...) {
+		name = strings.TrimPrefix(clients.NormalizeName(name), "*.")
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		attributed := clients.AttributedName{Name: name}
+		domain := clients.RegisteredDomain(name)
+		if _, ok := scope[domain]; ok {
+			attributed.Reasons = append(attributed.Reasons, ReasonScope)
+		} else {
+			// organizations of the scope are only looked up if needed
+			if organizations == nil {
+				organizations = a.scopeOrganizations(cert, scope)
+			}
+			if organization := a.organization(domain); organization != "" && containsOrganization(organizations, organization) {
+				attributed.Reasons = append(attributed.Reasons, ReasonOrganization)
+			}
+			if a.sharesCTHistory(domain, scope) {
+				attributed.Reasons = append(attributed.Reasons, ReasonCT)
+			}
+		}
+		for _, reason := range attributed.Reasons {
+			attributed.Score += scores[reason]
+		}
+		if attributed.Score > 1 {
+			attributed.Score = 1
+		}
+		response.Names = append(response.Names, attributed)
+		if attributed.Score >= Threshold {
+			response.Attributed = append(response.Attributed, name)
+		}
+	}
+	return response
+}
+
+// scopeOrganizations returns the organizations of the scope, which are
+// the subject organization of cert and the whois registrant organization
+// of the scope domains.
+func (a *Attributor) scopeOrganizations(cert *clients.CertificateResponse, scope map[string]struct{}) []string {
+	organizations := []string{}
+	for _, organization := range cert.SubjectOrg {
+		if organization = normalizeOrganization(organization); organization != "" {
+			organizations = append(organizations, organization)
+		}
+	}
+	for domain := range scope {
+		if organization := a.organization(domain); organization != "" {
+			organizations = append(organizations, organization)
+		}
+	}
+	return organizations
+}
+
+// organization returns the normalized whois registrant organization
+// of domain, empty if it is unknown or redacted.
+func (a *Attributor) organization(domain string) string {
+	a.mutex.Lock()
+	organization, ok := a.organizations[domain]
+	a.mutex.Unlock()
+	if ok {
+		return organization
+	}
+
+	organization, err := a.whoisOrganization(domain)
+	if err != nil {
+		gologger.Verbose().Msgf("Could not lookup whois organization for %s: %s", domain, err)
+	}
+	organization = normalizeOrganization(organization)
+	a.mutex.Lock()
+	a.organizations[domain] = organization
+	a.mutex.Unlock()
+	return organization
+}
+
+// sharesCTHistory returns true if a name of the registered domain was
+// logged in a certificate together with a name of the scope. Lookups
+// are done once per domain, concurrent lookups of a domain wait for
+// the first one.
+func (a *Attributor) sharesCTHistory(domain string, scope map[string]struct{}) bool {
+	a.mutex.Lock()
+	lookup, ok := a.ctLookups[domain]
+	if !ok {
+		lookup = &ctLookup{}
+		a.ctLookups[domain] = lookup
+	}
+	a.mutex.Unlock()
+	lookup.once.Do(func() {
+		a.ctSlots <- struct{}{}
+		defer func() { <-a.ctSlots }()
+
+		var err error
+		if lookup.names, err = a.ctClient.Names(domain); err != nil {
+			gologger.Verbose().Msgf("Could not lookup certificate transparency logs for %s: %s", domain, err)
+		}
+	})
+	for _, logged := range lookup.names {
+		if _, ok := scope[clients.RegisteredDomain(strings.TrimPrefix(clients.NormalizeName(logged), "*."))]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// organizationSuffix matches legal form suffixes of organization names
+var organizationSuffix = regexp.MustCompile(`\b(inc|incorporated|llc|ltd|limited|corp|corporation|co|company|gmbh|ag|sa|sas|bv|nv|plc|pty|srl|oy|ab|as)$`)
+
+// nonAlphanumeric matches the characters ignored in organization names
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9 ]+`)
+
+// normalizeOrganization returns organization in lowercase without
+// punctuation and legal form suffixes.
+func normalizeOrganization(organization string) string {
+	organization = nonAlphanumeric.ReplaceAllString(strings.ToLower(organization), "")
+	organization = strings.Join(strings.Fields(organization), " ")
+	return strings.TrimSpace(organizationSuffix.ReplaceAllString(organization, ""))
+}
+
+// containsOrganization returns true if organizations contains organization
+func containsOrganization(organizations []string, organization string) bool {
+	for _, value := range organizations {
+		if value == organization {
+			return true
+		}
+	}
+	return false
+}
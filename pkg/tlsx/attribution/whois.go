@@ -0,0 +1,122 @@
+package attribution
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+const (
+	// whoisRootServer is the whois server referring to tld servers
+	whoisRootServer = "whois.iana.org"
+	whoisPort       = "43"
+	// maxWhoisResponse is the maximum size of whois responses read
+	maxWhoisResponse = 1 << 20
+)
+
+// organizationFields is the list of whois fields of registrant organizations
+var organizationFields = []string{"registrant organization", "registrant organisation", "registrant", "org", "organization"}
+
+// redactedValues is the list of values used by redacted whois fields
+var redactedValues = []string{"redacted", "privacy", "proxy", "protected", "not disclosed", "withheld", "n/a"}
+
+// whoisOrganization returns the registrant organization of domain
+// following the referral of the root server and the registrar server
+// of thin registries, empty if it is redacted.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc3912
+func (a *Attributor) whoisOrganization(domain string) (string, error) {
+	index := strings.LastIndex(domain, ".")
+	if index < 0 {
+		return "", nil
+	}
+	response, err := a.whoisQuery(whoisRootServer, domain[index+1:])
+	if err != nil {
+		return "", err
+	}
+	server := whoisField(response, "refer")
+	if server == "" {
+		return "", nil
+	}
+	if response, err = a.whoisQuery(server, domain); err != nil {
+		return "", err
+	}
+	if registrar := whoisServerName(whoisField(response, "registrar whois server")); registrar != "" && !strings.EqualFold(registrar, server) {
+		if registrarResponse, err := a.whoisQuery(registrar, domain); err == nil {
+			response = registrarResponse
+		}
+	}
+
+	for _, field := range organizationFields {
+		value := whoisField(response, field)
+		if value == "" {
+			continue
+		}
+		lower := strings.ToLower(value)
+		for _, redacted := range redactedValues {
+			if strings.Contains(lower, redacted) {
+				return "", nil
+			}
+		}
+		return value, nil
+	}
+	return "", nil
+}
+
+// whoisQuery sends query to the whois server returning the response
+func (a *Attributor) whoisQuery(server, query string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	conn, err := clients.Dial(ctx, a.options, net.JoinHostPort(server, whoisPort))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to whois server")
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(a.timeout))
+
+	if _, err := io.WriteString(conn, query+"\r\n"); err != nil {
+		return nil, errors.Wrap(err, "could not send whois query")
+	}
+	response, err := ioutil.ReadAll(io.LimitReader(conn, maxWhoisResponse))
+	if err != nil && len(response) == 0 {
+		return nil, errors.Wrap(err, "could not read whois response")
+	}
+	return response, nil
+}
+
+// whoisField returns the first value of the named field of response
+func whoisField(response []byte, name string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(response))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		index := strings.Index(line, ":")
+		if index <= 0 || !strings.EqualFold(strings.TrimSpace(line[:index]), name) {
+			continue
+		}
+		if value := strings.TrimSpace(line[index+1:]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// whoisServerName returns the host of a whois server value which may
+// be given as an url.
+func whoisServerName(value string) string {
+	if index := strings.Index(value, "://"); index >= 0 {
+		value = value[index+3:]
+	}
+	if index := strings.IndexAny(value, "/:"); index >= 0 {
+		value = value[:index]
+	}
+	return value
+}
@@ -0,0 +1,130 @@
+// Package broker implements tls probing of message brokers, offering the
+// application protocols they expect and confirming the broker protocol
+// after the handshake.
+package broker
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of supported broker protocols
+const (
+	MQTT = "mqtt"
+	AMQP = "amqp"
+)
+
+// portToProtocol is the list of broker protocols of tls ports
+var portToProtocol = map[string]string{
+	"8883": MQTT,
+	"5671": AMQP,
+}
+
+// protocolALPN is the list of application protocols offered to brokers
+var protocolALPN = map[string][]string{
+	MQTT: {"mqtt"},
+}
+
+// mqttConnect is a mqtt 3.1.1 CONNECT packet with a clean session
+// and the tlsx client identifier.
+var mqttConnect = []byte{0x10, 0x10, 0x00, 0x04, 'M', 'Q', 'T', 'T', 0x04, 0x02, 0x00, 0x3c, 0x00, 0x04, 't', 'l', 's', 'x'}
+
+// amqpHeader is the amqp 0-9-1 protocol header
+var amqpHeader = []byte{'A', 'M', 'Q', 'P', 0x00, 0x00, 0x09, 0x01}
+
+// Protocol returns the broker protocol of a port, empty if there is none
+func Protocol(port string) string {
+	return portToProtocol[port]
+}
+
+// ALPN returns the application protocols to offer to the broker of a port
+func ALPN(port string) []string {
+	return protocolALPN[Protocol(port)]
+}
+
+// Confirm makes a handshake with the broker of a port and returns true
+// if the server answers the protocol opening as a broker would. Servers
+// refusing the client, such as with a mqtt CONNACK return code, are
+// still confirmed.
+func Confirm(options *clients.Options, hostname, port string) (bool, error) {
+	protocol := Protocol(port)
+	if protocol == "" {
+		return false, nil
+	}
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return false, errors.Wrap(err, "could not dial address")
+	}
+	defer rawConn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = rawConn.SetDeadline(deadline)
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         options.ALPN,
+		ServerName:         options.ServerName,
+	}
+	if len(config.NextProtos) == 0 {
+		config.NextProtos = protocolALPN[protocol]
+	}
+	if config.ServerName == "" && !iputil.IsIP(hostname) {
+		config.ServerName = hostname
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return false, errors.Wrap(err, "could not do handshake")
+	}
+
+	switch protocol {
+	case MQTT:
+		return confirmMQTT(conn)
+	case AMQP:
+		return confirmAMQP(conn)
+	}
+	return false, nil
+}
+
+// confirmMQTT returns true if the server answers a CONNECT with a CONNACK
+func confirmMQTT(conn net.Conn) (bool, error) {
+	if _, err := conn.Write(mqttConnect); err != nil {
+		return false, errors.Wrap(err, "could not write mqtt connect")
+	}
+	response := make([]byte, 4)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return false, nil
+	}
+	return response[0] == 0x20 && response[1] == 0x02, nil
+}
+
+// confirmAMQP returns true if the server answers the protocol header with
+// a Connection.Start method frame, or with the header of the protocol
+// version it supports as amqp 1.0 brokers do.
+func confirmAMQP(conn net.Conn) (bool, error) {
+	if _, err := conn.Write(amqpHeader); err != nil {
+		return false, errors.Wrap(err, "could not write amqp header")
+	}
+	response := make([]byte, 11)
+	n, _ := io.ReadFull(conn, response)
+	response = response[:n]
+	if n >= 8 && bytes.HasPrefix(response, []byte("AMQP")) {
+		return true, nil
+	}
+	// method frame on channel 0 with the connection class and start method
+	return n == 11 && response[0] == 0x01 && response[1] == 0x00 && response[2] == 0x00 && bytes.Equal(response[7:11], []byte{0x00, 0x0a, 0x00, 0x0a}), nil
+}
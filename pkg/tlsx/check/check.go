@@ -0,0 +1,199 @@
+// Package check implements a cheap tls liveness check which only
+// verifies whether a service speaks tls without completing the handshake.
+package check
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+)
+
+const (
+	recordTypeAlert          = 21
+	recordTypeHandshake      = 22
+	handshakeTypeServerHello = 2
+	maxRecordLength          = 16384 + 2048
+)
+
+// Client is a tls liveness checking client
+type Client struct {
+	dialer  *fastdialer.Dialer
+	options *clients.Options
+}
+
+// New creates a new tls liveness checking client
+func New(options *clients.Options) (*Client, error) {
+	return &Client{dialer: options.Fastdialer, options: options}, nil
+}
+
+// cipherSuites is the list of cipher suites offered in the ClientHello
+var cipherSuites = []uint16{
+	tls.TLS_AES_128_GCM_SHA256,
+	tls.TLS_AES_256_GCM_SHA384,
+	tls.TLS_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	tls.TLS_RSA_WITH_RC4_128_SHA,
+}
+
+// Connect checks whether a host speaks tls returning a compact response
+func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
+	address := net.JoinHostPort(hostname, port)
+
+	ctx := context.Background()
+	if c.options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	conn, err := clients.Dial(ctx, c.options, address)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial address")
+	}
+	defer conn.Close()
+
+	if err := starttls.Negotiate(ctx, conn, starttls.Protocol(c.options, port), starttls.ServerName(c.options, hostname)); err != nil {
+		return nil, errors.Wrap(err, "could not negotiate starttls")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	var resolvedIP string
+	if !iputil.IsIP(hostname) {
+		resolvedIP = c.dialer.GetDialedIP(hostname)
+	}
+
+	serverName := c.options.ServerName
+	if serverName == "" && !iputil.IsIP(hostname) {
+		serverName = hostname
+	}
+	hello, err := buildClientHello(serverName)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not build client hello")
+	}
+	if _, err := conn.Write(hello); err != nil {
+		return nil, errors.Wrap(err, "could not write client hello")
+	}
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, errors.Wrap(err, "could not read server response")
+	}
+	length := int(binary.BigEndian.Uint16(header[3:5]))
+	if (header[0] != recordTypeHandshake && header[0] != recordTypeAlert) || header[1] != 3 || length > maxRecordLength {
+		return nil, errors.New("service does not speak tls")
+	}
+
+	response := &clients.Response{
+		Timestamp: time.Now(),
+		Host:      hostname,
+		IP:        resolvedIP,
+		Port:      port,
+	}
+	if header[0] == recordTypeAlert {
+		// server rejected the hello but still speaks tls
+		return response, nil
+	}
+
+	record := make([]byte, length)
+	if _, err := io.ReadFull(conn, record); err != nil {
+		return response, nil
+	}
+	if len(record) < 4 || record[0] != handshakeTypeServerHello {
+		return response, nil
+	}
+	messageLength := int(record[1])<<16 | int(record[2])<<8 | int(record[3])
+	if len(record) < 4+messageLength {
+		return response, nil
+	}
+	serverHello := clients.ParseHelloMessage(record[:4+messageLength])
+	response.Version = serverHelloVersion(serverHello)
+	if len(serverHello.CipherSuites) > 0 {
+		response.Cipher = tls.CipherSuiteName(serverHello.CipherSuites[0])
+	}
+	return response, nil
+}
+
+// serverHelloVersion returns the negotiated version string for a server hello
+func serverHelloVersion(hello *clients.HelloMessage) string {
+	for _, extension := range hello.Extensions {
+		if extension.Name == "supported_versions" {
+			return "tls13"
+		}
+	}
+	switch hello.Version {
+	case tls.VersionSSL30:
+		return "ssl30"
+	case tls.VersionTLS10:
+		return "tls10"
+	case tls.VersionTLS11:
+		return "tls11"
+	case tls.VersionTLS12:
+		return "tls12"
+	}
+	return ""
+}
+
+// buildClientHello builds a ClientHello record offering tls 1.0 to 1.3
+func buildClientHello(serverName string) ([]byte, error) {
+	random := make([]byte, 32+32+32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	var extensions []byte
+	if serverName != "" {
+		extensions = clients.AppendExtension(extensions, 0, clients.ServerNameExtension(serverName))
+	}
+	// supported_groups: x25519, secp256r1, secp384r1
+	extensions = clients.AppendExtension(extensions, 10, []byte{0, 6, 0, 29, 0, 23, 0, 24})
+	// ec_point_formats: uncompressed
+	extensions = clients.AppendExtension(extensions, 11, []byte{1, 0})
+	// signature_algorithms
+	extensions = clients.AppendExtension(extensions, 13, []byte{0, 16, 4, 3, 5, 3, 8, 4, 8, 5, 4, 1, 5, 1, 2, 1, 2, 3})
+	// supported_versions: tls13, tls12, tls11, tls10
+	extensions = clients.AppendExtension(extensions, 43, []byte{8, 3, 4, 3, 3, 3, 2, 3, 1})
+	// key_share: x25519 with a random key
+	keyShare := append([]byte{0, 36, 0, 29, 0, 32}, random[64:96]...)
+	extensions = clients.AppendExtension(extensions, 51, keyShare)
+	// renegotiation_info
+	extensions = clients.AppendExtension(extensions, 65281, []byte{0})
+
+	body := []byte{3, 3}
+	body = append(body, random[:32]...)
+	body = append(body, 32)
+	body = append(body, random[32:64]...)
+	body = append(body, clients.Uint16Bytes(len(cipherSuites)*2)...)
+	for _, suite := range cipherSuites {
+		body = append(body, clients.Uint16Bytes(int(suite))...)
+	}
+	body = append(body, 1, 0)
+	body = append(body, clients.Uint16Bytes(len(extensions))...)
+	body = append(body, extensions...)
+
+	return clients.HandshakeRecord(tls.VersionTLS10, clients.HandshakeMessage(1, body)), nil
+}
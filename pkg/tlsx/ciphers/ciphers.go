@@ -0,0 +1,269 @@
+// Package ciphers implements enumeration of the cipher suites and key
+// exchange groups accepted by a server along with its preference ordering.
+package ciphers
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+	ztls "github.com/zmap/zcrypto/tls"
+)
+
+// maxOfferedSuites is the number of suites offered in a single hello
+// as some servers reject hello messages with very long suite lists.
+const maxOfferedSuites = 128
+
+// versions is the list of enumerated protocol versions
+var versions = []struct {
+	name  string
+	value uint16
+}{
+	{"ssl30", tls.VersionSSL30},
+	{"tls10", tls.VersionTLS10},
+	{"tls11", tls.VersionTLS11},
+	{"tls12", tls.VersionTLS12},
+	{"tls13", tls.VersionTLS13},
+}
+
+// tls13Suites is the list of cipher suites defined for tls 1.3
+var tls13Suites = []uint16{0x1301, 0x1302, 0x1303, 0x1304, 0x1305}
+
+var (
+	legacySuites     []uint16
+	legacySuitesOnce sync.Once
+)
+
+// candidateSuites returns the suites enumerated for a protocol version.
+// Suites before tls 1.3 are every suite known to zcrypto excluding the
+// signaling values.
+func candidateSuites(version uint16) []uint16 {
+	if version == tls.VersionTLS13 {
+		return tls13Suites
+	}
+	legacySuitesOnce.Do(func() {
+		for value := 0; value <= 0xffff; value++ {
+			suite := uint16(value)
+			if suite == ztls.TLS_RENEGO_PROTECTION_REQUEST || suite == ztls.TLS_FALLBACK_SCSV || suite>>8 == 0x13 {
+				continue
+			}
+			if ztls.CipherSuite(suite).String() != "unknown" {
+				legacySuites = append(legacySuites, suite)
+			}
+		}
+	})
+	return legacySuites
+}
+
+// suiteName returns the name of a cipher suite
+func suiteName(suite uint16) string {
+	if name := ztls.CipherSuite(suite).String(); name != "unknown" {
+		return name
+	}
+	return tls.CipherSuiteName(suite)
+}
+
+// enumerator enumerates cipher suites and groups of a single host
+type enumerator struct {
+	options    *clients.Options
+	hostname   string
+	port       string
+	serverName string
+}
+
+func newEnumerator(options *clients.Options, hostname, port string) *enumerator {
+	e := &enumerator{options: options, hostname: hostname, port: port, serverName: options.ServerName}
+	if e.serverName == "" && !iputil.IsIP(hostname) {
+		e.serverName = hostname
+	}
+	return e
+}
+
+// negotiateFunc offers values returning the value selected by the server.
+// False is returned if the server rejected all of the offered values.
+type negotiateFunc func(values []uint16) (uint16, bool, error)
+
+// Enumerate returns the cipher suites accepted by the server for every
+// supported protocol version ordered by server preference if enforced.
+func Enumerate(options *clients.Options, hostname, port string) ([]clients.CipherEnumResponse, error) {
+	e := newEnumerator(options, hostname, port)
+
+	var responses []clients.CipherEnumResponse
+	var lastErr error
+	for _, version := range versions {
+		version := version
+		negotiate := func(suites []uint16) (uint16, bool, error) {
+			return e.negotiateSuite(version.value, suites)
+		}
+		accepted, err := acceptedValues(candidateSuites(version.value), negotiate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(accepted) == 0 {
+			continue
+		}
+		ordered, serverPreference, err := orderValues(accepted, negotiate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		response := clients.CipherEnumResponse{Version: version.name, ServerPreference: serverPreference}
+		for _, suite := range ordered {
+			response.Ciphers = append(response.Ciphers, suiteName(suite))
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return responses, nil
+}
+
+// acceptedValues returns every candidate accepted by the server by
+// repeatedly offering the candidates which were not selected yet.
+func acceptedValues(candidates []uint16, negotiate negotiateFunc) ([]uint16, error) {
+	var accepted []uint16
+	for start := 0; start < len(candidates); start += maxOfferedSuites {
+		end := start + maxOfferedSuites
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		remaining := append([]uint16(nil), candidates[start:end]...)
+		for len(remaining) > 0 {
+			value, ok, err := negotiate(remaining)
+			if err != nil {
+				return accepted, err
+			}
+			if !ok {
+				break
+			}
+			if !containsValue(remaining, value) {
+				return accepted, errors.Errorf("server selected unoffered value %#04x", value)
+			}
+			accepted = append(accepted, value)
+			remaining = removeValue(remaining, value)
+		}
+	}
+	return accepted, nil
+}
+
+// orderValues returns the accepted values in server preference order if
+// the server enforces its own preference, otherwise in the order found.
+func orderValues(accepted []uint16, negotiate negotiateFunc) ([]uint16, bool, error) {
+	if len(accepted) < 2 {
+		return accepted, false, nil
+	}
+	reversed := make([]uint16, len(accepted))
+	for i, value := range accepted {
+		reversed[len(accepted)-1-i] = value
+	}
+	first, ok, err := negotiate(accepted)
+	if err != nil || !ok {
+		return accepted, false, err
+	}
+	second, ok, err := negotiate(reversed)
+	if err != nil || !ok {
+		return accepted, false, err
+	}
+	if first != second {
+		// server follows the client preference
+		return accepted, false, nil
+	}
+
+	ordered := []uint16{first}
+	remaining := removeValue(append([]uint16(nil), accepted...), first)
+	for len(remaining) > 1 {
+		value, ok, err := negotiate(remaining)
+		if err != nil {
+			return accepted, true, err
+		}
+		if !ok || !containsValue(remaining, value) {
+			return accepted, true, errors.New("server rejected previously accepted values")
+		}
+		ordered = append(ordered, value)
+		remaining = removeValue(remaining, value)
+	}
+	return append(ordered, remaining...), true, nil
+}
+
+// negotiateSuite offers suites for a version returning the suite selected
+// by the server. False is returned if the server rejected the hello or
+// negotiated a different version.
+func (e *enumerator) negotiateSuite(version uint16, suites []uint16) (uint16, bool, error) {
+	var suite uint16
+	var ok bool
+	err := e.exchange(&clientHello{version: version, suites: suites, groups: defaultGroups, keyShare: true, serverName: e.serverName}, func(reader *handshakeReader) error {
+		hello, err := reader.serverHello(version)
+		if err != nil || hello == nil {
+			return err
+		}
+		suite, ok = hello.suite, true
+		return nil
+	})
+	return suite, ok, err
+}
+
+// exchange connects to the host, writes hello and calls read with a
+// reader for the handshake messages sent by the server.
+func (e *enumerator) exchange(hello *clientHello, read func(reader *handshakeReader) error) error {
+	data, err := hello.marshal()
+	if err != nil {
+		return errors.Wrap(err, "could not build client hello")
+	}
+	return e.send(data, read)
+}
+
+// send writes a client hello record to a new connection and reads the
+// response with read.
+func (e *enumerator) send(data []byte, read func(reader *handshakeReader) error) error {
+	ctx := context.Background()
+	if e.options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(e.options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	conn, err := clients.Dial(ctx, e.options, net.JoinHostPort(e.hostname, e.port))
+	if err != nil {
+		return errors.Wrap(err, "could not dial address")
+	}
+	defer conn.Close()
+
+	if err := starttls.Negotiate(ctx, conn, starttls.Protocol(e.options, e.port), starttls.ServerName(e.options, e.hostname)); err != nil {
+		return errors.Wrap(err, "could not negotiate starttls")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(data); err != nil {
+		return errors.Wrap(err, "could not write client hello")
+	}
+	return read(&handshakeReader{conn: conn})
+}
+
+func removeValue(values []uint16, value uint16) []uint16 {
+	filtered := values[:0]
+	for _, v := range values {
+		if v != value {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+func containsValue(values []uint16, value uint16) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package ciphers
+
+import (
+	"crypto/tls"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// compressionVersions is the list of versions probed for compression
+// from the highest as tls 1.3 removed compression.
+var compressionVersions = []uint16{tls.VersionTLS12, tls.VersionTLS11, tls.VersionTLS10, tls.VersionSSL30}
+
+// compressionNames is the list of names of tls compression methods
+var compressionNames = map[byte]string{
+	compressionDeflate: "deflate",
+}
+
+// DetectCompression returns the tls compression method selected by the
+// server when offering deflate compression, which makes it vulnerable to
+// the crime attack. Empty string is returned if compression is refused.
+func DetectCompression(options *clients.Options, hostname, port string) (string, error) {
+	e := newEnumerator(options, hostname, port)
+
+	var lastErr error
+	for _, version := range compressionVersions {
+		version := version
+		var hello *serverHello
+		err := e.exchange(&clientHello{version: version, suites: compressionSuites(), groups: defaultGroups, serverName: e.serverName, compressionMethods: []byte{compressionDeflate, compressionNull}}, func(reader *handshakeReader) error {
+			var err error
+			hello, err = reader.serverHello(version)
+			return err
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if hello == nil {
+			continue
+		}
+		if hello.compression == compressionNull {
+			return "", nil
+		}
+		if name, ok := compressionNames[hello.compression]; ok {
+			return name, nil
+		}
+		return "unknown", nil
+	}
+	return "", lastErr
+}
+
+// compressionSuites returns the commonly deployed suites known to crypto/tls
+// which are offered when probing for compression.
+func compressionSuites() []uint16 {
+	var suites []uint16
+	for _, list := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range list {
+			if suite.ID>>8 != 0x13 {
+				suites = append(suites, suite.ID)
+			}
+		}
+	}
+	return suites
+}
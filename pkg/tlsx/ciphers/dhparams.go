@@ -0,0 +1,136 @@
+package ciphers
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// minDHBits is the minimum size of dh primes not exposed to logjam
+const minDHBits = 2048
+
+// dhVersions is the list of versions probed for dhe key exchange
+var dhVersions = []uint16{tls.VersionTLS12, tls.VersionTLS11, tls.VersionTLS10}
+
+// commonPrimes is the list of widely shared dh primes in hex encoding,
+// which are the targets of precomputation attacks.
+var commonPrimes = []struct {
+	name  string
+	prime string
+}{
+	{"rfc2409-768", "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f14374fe1356d6d51c245e485b576625e7ec6f44c42e9a63a3620ffffffffffffffff"},
+	{"rfc2409-1024", "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f14374fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7edee386bfb5a899fa5ae9f24117c4b1fe649286651ece65381ffffffffffffffff"},
+	{"rfc3526-1536", "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f14374fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7edee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf0598da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb9ed529077096966d670c354e4abc9804f1746c08ca237327ffffffffffffffff"},
+	{"rfc3526-2048", "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f14374fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7edee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf0598da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3be39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf6955817183995497cea956ae515d2261898fa051015728e5a8aacaa68ffffffffffffffff"},
+	{"rfc7919-ffdhe2048", "ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617ad3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797abc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f619172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005c58ef1837d1683b2c6f34a26c1b2effa886b423861285c97ffffffffffffffff"},
+}
+
+// DetectDHParams returns the dh parameters sent by the server when
+// offering only dhe suites, including export grade ones. Primes under
+// 2048 bits are flagged as exposed to logjam. Nil is returned if dhe
+// suites are refused.
+//
+// follows: https://weakdh.org/
+func DetectDHParams(options *clients.Options, hostname, port string) (*clients.DHParamsResponse, error) {
+	e := newEnumerator(options, hostname, port)
+	suites := dheCandidateSuites()
+
+	var lastErr error
+	for _, version := range dhVersions {
+		version := version
+		var response *clients.DHParamsResponse
+		err := e.exchange(&clientHello{version: version, suites: suites, groups: defaultGroups, serverName: e.serverName}, func(reader *handshakeReader) error {
+			hello, err := reader.serverHello(version)
+			if err != nil || hello == nil {
+				return err
+			}
+			for {
+				message, err := reader.next()
+				if err != nil {
+					return errors.Wrap(err, "could not read server handshake")
+				}
+				if message == nil || message[0] == handshakeTypeServerHelloDone {
+					return nil
+				}
+				if message[0] == handshakeTypeServerKeyExchange {
+					prime := serverKeyExchangePrime(message)
+					if prime == nil {
+						return errors.New("invalid server key exchange")
+					}
+					response = newDHParamsResponse(suiteName(hello.suite), prime)
+					return nil
+				}
+			}
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if response != nil {
+			return response, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// newDHParamsResponse returns the response for a dh prime
+func newDHParamsResponse(cipher string, prime []byte) *clients.DHParamsResponse {
+	// leading zero bytes are not part of the prime size
+	for len(prime) > 0 && prime[0] == 0 {
+		prime = prime[1:]
+	}
+	bits := len(prime) * 8
+	if len(prime) > 0 {
+		for mask := byte(0x80); prime[0]&mask == 0; mask >>= 1 {
+			bits--
+		}
+	}
+	return &clients.DHParamsResponse{
+		Cipher:      cipher,
+		Bits:        bits,
+		CommonPrime: commonPrimeName(prime),
+		Logjam:      bits < minDHBits,
+	}
+}
+
+// commonPrimeName returns the name of a common prime, empty if unknown
+func commonPrimeName(prime []byte) string {
+	encoded := hex.EncodeToString(prime)
+	for _, common := range commonPrimes {
+		if common.prime == encoded {
+			return common.name
+		}
+	}
+	return ""
+}
+
+// serverKeyExchangePrime returns the prime of a dhe server key exchange
+// message including its header, nil if it is malformed.
+func serverKeyExchangePrime(message []byte) []byte {
+	if len(message) < 4+2 {
+		return nil
+	}
+	data := message[4:]
+	length := int(data[0])<<8 | int(data[1])
+	if length == 0 || len(data) < 2+length {
+		return nil
+	}
+	return data[2 : 2+length]
+}
+
+// dheCandidateSuites returns the suites using ephemeral dh key exchange
+func dheCandidateSuites() []uint16 {
+	var suites []uint16
+	for _, suite := range candidateSuites(tls.VersionTLS12) {
+		if strings.Contains(suiteName(suite), "_DHE_") {
+			suites = append(suites, suite)
+		}
+	}
+	if len(suites) > maxOfferedSuites {
+		suites = suites[:maxOfferedSuites]
+	}
+	return suites
+}
@@ -0,0 +1,200 @@
+package ciphers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"hash"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ech"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	echTypeOuter = 0
+	echTypeInner = 1
+	// echGreasePayloadLength is the length of grease ech payloads
+	echGreasePayloadLength = 176
+	// echPaddingLength is the multiple encoded inner hello messages are padded to
+	echPaddingLength = 32
+)
+
+// helloRetryRandom is the random of HelloRetryRequest messages
+var helloRetryRandom = []byte{
+	0xcf, 0x21, 0xad, 0x74, 0xe5, 0x9a, 0x61, 0x11, 0xbe, 0x1d, 0x8c, 0x02, 0x1e, 0x65, 0xb8, 0x91,
+	0xc2, 0xa2, 0x11, 0x16, 0x7a, 0xbb, 0x8c, 0x5e, 0x07, 0x9e, 0x09, 0xe2, 0xc8, 0xa8, 0x33, 0x9c,
+}
+
+// DetectECH returns the encrypted client hello support of the server.
+// The ech configuration is looked up in the https dns record of the
+// server name and used for an ech handshake, and a grease ech extension
+// is sent to check servers tolerate it. Nil is returned if the server
+// does not support tls 1.3.
+//
+// follows: https://datatracker.ietf.org/doc/draft-ietf-tls-esni/
+func DetectECH(options *clients.Options, hostname, port string) (*clients.ECHResponse, error) {
+	e := newEnumerator(options, hostname, port)
+
+	response := &clients.ECHResponse{}
+	grease, err := greaseECHExtension()
+	if err != nil {
+		return nil, err
+	}
+	hello, err := e.echHello(grease)
+	if err != nil {
+		return nil, err
+	}
+	if hello == nil {
+		// servers without tls 1.3 cannot support ech
+		if hello, err = e.echHello(nil); err != nil || hello == nil {
+			return nil, err
+		}
+	} else {
+		response.GreaseTolerated = true
+	}
+
+	if e.serverName == "" || iputil.IsIP(e.serverName) {
+		return response, nil
+	}
+	list, err := ech.LookupConfigList(options, e.serverName, port)
+	if err != nil || list == nil {
+		return response, err
+	}
+	configs, err := ech.ParseConfigList(list)
+	if err != nil {
+		return response, errors.Wrap(err, "could not parse ech config list")
+	}
+	config, suite, ok := ech.Select(configs)
+	if !ok {
+		return response, errors.New("no supported ech config")
+	}
+	response.Configured = true
+	response.PublicName = config.PublicName
+	response.Supported, err = e.echAccepted(config, suite)
+	return response, err
+}
+
+// echHello offers tls 1.3 with the encoded extension returning the
+// server hello, nil if the hello was refused.
+func (e *enumerator) echHello(extension []byte) (*serverHello, error) {
+	var hello *serverHello
+	err := e.exchange(&clientHello{version: tls.VersionTLS13, suites: tls13Suites, groups: defaultGroups, keyShare: true, serverName: e.serverName, extensions: extension}, func(reader *handshakeReader) error {
+		var err error
+		hello, err = reader.serverHello(tls.VersionTLS13)
+		return err
+	})
+	return hello, err
+}
+
+// echAccepted makes an ech handshake with the configuration returning
+// true if the server confirmed accepting the inner hello.
+func (e *enumerator) echAccepted(config *ech.Config, suite ech.Suite) (bool, error) {
+	sessionID := make([]byte, 32)
+	if _, err := rand.Read(sessionID); err != nil {
+		return false, err
+	}
+
+	// the inner hello is encoded without the session id of the outer hello
+	inner, err := (&clientHello{version: tls.VersionTLS13, suites: tls13Suites, groups: defaultGroups, keyShare: true, serverName: e.serverName, sessionID: []byte{}, extensions: clients.AppendExtension(nil, ech.VersionECH, []byte{echTypeInner})}).message()
+	if err != nil {
+		return false, errors.Wrap(err, "could not build inner client hello")
+	}
+	encoded := append([]byte(nil), inner[4:]...)
+	if padding := len(encoded) % echPaddingLength; padding != 0 {
+		encoded = append(encoded, make([]byte, echPaddingLength-padding)...)
+	}
+	innerBody := append(append([]byte(nil), inner[4:4+2+32]...), byte(len(sessionID)))
+	innerBody = append(append(innerBody, sessionID...), inner[4+2+32+1:]...)
+	inner = append([]byte{handshakeTypeClientHello, byte(len(innerBody) >> 16), byte(len(innerBody) >> 8), byte(len(innerBody))}, innerBody...)
+
+	sender, err := ech.NewSender(config, suite, append([]byte("tls ech\x00"), config.Raw...))
+	if err != nil {
+		return false, errors.Wrap(err, "could not setup hpke context")
+	}
+	// the outer hello is authenticated with a zero payload, which is the
+	// last value of the message as the ech extension is sent last.
+	payloadLength := len(encoded) + 16
+	outer, err := (&clientHello{version: tls.VersionTLS13, suites: tls13Suites, groups: defaultGroups, keyShare: true, serverName: config.PublicName, sessionID: sessionID, extensions: echOuterExtension(config.ID, suite, sender.Enc, make([]byte, payloadLength))}).message()
+	if err != nil {
+		return false, errors.Wrap(err, "could not build outer client hello")
+	}
+	payload := sender.Seal(outer[4:], encoded)
+	if len(payload) != payloadLength {
+		return false, errors.New("unexpected hpke payload length")
+	}
+	copy(outer[len(outer)-payloadLength:], payload)
+
+	var accepted bool
+	err = e.send(helloRecord(tls.VersionTLS13, outer), func(reader *handshakeReader) error {
+		hello, err := reader.serverHello(tls.VersionTLS13)
+		if err != nil || hello == nil {
+			return errors.New("server refused ech client hello")
+		}
+		random := hello.raw[4+2 : 4+2+32]
+		if bytes.Equal(random, helloRetryRandom) {
+			return errors.New("server requested hello retry")
+		}
+		accepted = hmac.Equal(random[24:], echConfirmation(hello, inner))
+		return nil
+	})
+	return accepted, err
+}
+
+// echConfirmation returns the confirmation of accepted inner hello
+// messages expected in the last 8 bytes of the server random.
+func echConfirmation(hello *serverHello, inner []byte) []byte {
+	newHash := sha256.New
+	if hello.suite == tls.TLS_AES_256_GCM_SHA384 {
+		newHash = sha512.New384
+	}
+	confirmationHello := append([]byte(nil), hello.raw...)
+	copy(confirmationHello[4+2+24:4+2+32], make([]byte, 8))
+	transcript := newHash()
+	transcript.Write(inner)
+	transcript.Write(confirmationHello)
+
+	secret := hkdf.Extract(newHash, inner[4+2:4+2+32], nil)
+	return expandLabel(newHash, secret, "ech accept confirmation", transcript.Sum(nil), 8)
+}
+
+// expandLabel is the HKDF-Expand-Label function of tls 1.3
+func expandLabel(newHash func() hash.Hash, secret []byte, label string, context []byte, length int) []byte {
+	label = "tls13 " + label
+	info := append(clients.Uint16Bytes(length), byte(len(label)))
+	info = append(info, label...)
+	info = append(info, byte(len(context)))
+	info = append(info, context...)
+	out := make([]byte, length)
+	// reading less than 255 hash sizes never fails
+	_, _ = hkdf.Expand(newHash, secret, info).Read(out)
+	return out
+}
+
+// echOuterExtension returns the encoded outer ech extension
+func echOuterExtension(configID uint8, suite ech.Suite, enc, payload []byte) []byte {
+	data := []byte{echTypeOuter}
+	data = append(data, clients.Uint16Bytes(int(suite.KDF))...)
+	data = append(data, clients.Uint16Bytes(int(suite.AEAD))...)
+	data = append(data, configID)
+	data = append(data, clients.Uint16Bytes(len(enc))...)
+	data = append(data, enc...)
+	data = append(data, clients.Uint16Bytes(len(payload))...)
+	data = append(data, payload...)
+	return clients.AppendExtension(nil, ech.VersionECH, data)
+}
+
+// greaseECHExtension returns an outer ech extension with random values
+// as sent by clients without an ech configuration.
+func greaseECHExtension() ([]byte, error) {
+	random := make([]byte, 1+32+echGreasePayloadLength)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	return echOuterExtension(random[0], ech.Suite{KDF: 0x0001, AEAD: 0x0001}, random[1:33], random[33:]), nil
+}
@@ -0,0 +1,60 @@
+package ciphers
+
+import (
+	"crypto/tls"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// DetectExtendedMasterSecret returns whether the server negotiates the
+// extended_master_secret extension for the highest version up to tls 1.2
+// it supports, and whether it refuses handshakes without the extension.
+// Nil is returned if the server supports tls 1.3 only as the master
+// secret is always bound to the handshake there.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc7627
+func DetectExtendedMasterSecret(options *clients.Options, hostname, port string) (*clients.ExtendedMasterSecretResponse, error) {
+	e := newEnumerator(options, hostname, port)
+
+	var lastErr error
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i]
+		// ssl 3.0 has no extensions and tls 1.3 always binds the handshake
+		if version.value == tls.VersionSSL30 || version.value == tls.VersionTLS13 {
+			continue
+		}
+		hello, err := e.emsHello(version.value, true)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if hello == nil {
+			continue
+		}
+		response := &clients.ExtendedMasterSecretResponse{Version: version.name}
+		if _, ok := hello.extensions[extensionExtendedMasterSecret]; !ok {
+			return response, nil
+		}
+		response.Negotiated = true
+
+		hello, err = e.emsHello(version.value, false)
+		if err != nil {
+			return nil, err
+		}
+		response.Required = hello == nil
+		return response, nil
+	}
+	return nil, lastErr
+}
+
+// emsHello offers version with or without the extended_master_secret
+// extension, returning the server hello or nil if the hello is refused.
+func (e *enumerator) emsHello(version uint16, ems bool) (*serverHello, error) {
+	var hello *serverHello
+	err := e.exchange(&clientHello{version: version, suites: compressionSuites(), groups: defaultGroups, serverName: e.serverName, extendedMasterSecret: ems}, func(reader *handshakeReader) error {
+		var err error
+		hello, err = reader.serverHello(version)
+		return err
+	})
+	return hello, err
+}
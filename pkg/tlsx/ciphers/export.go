@@ -0,0 +1,52 @@
+package ciphers
+
+import (
+	"crypto/tls"
+	"strings"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// exportVersions is the list of versions probed for export suites
+var exportVersions = []uint16{tls.VersionTLS12, tls.VersionTLS11, tls.VersionTLS10, tls.VersionSSL30}
+
+// DetectExportSuites returns true if the server negotiates an export
+// grade rsa or dhe suite when offering only those, which allows
+// factoring the 512 bit export keys in the freak and logjam attacks.
+//
+// follows: https://nvd.nist.gov/vuln/detail/CVE-2015-0204
+func DetectExportSuites(options *clients.Options, hostname, port string) (bool, error) {
+	e := newEnumerator(options, hostname, port)
+	suites := exportCandidateSuites()
+
+	var lastErr error
+	for _, version := range exportVersions {
+		version := version
+		var hello *serverHello
+		err := e.exchange(&clientHello{version: version, suites: suites, groups: defaultGroups, serverName: e.serverName}, func(reader *handshakeReader) error {
+			var err error
+			hello, err = reader.serverHello(version)
+			return err
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if hello != nil && containsValue(suites, hello.suite) {
+			return true, nil
+		}
+	}
+	return false, lastErr
+}
+
+// exportCandidateSuites returns the export grade rsa and dhe suites
+func exportCandidateSuites() []uint16 {
+	var suites []uint16
+	for _, suite := range candidateSuites(tls.VersionTLS12) {
+		name := suiteName(suite)
+		if strings.HasPrefix(name, "TLS_RSA_EXPORT") || (strings.HasPrefix(name, "TLS_DHE_") && strings.Contains(name, "_EXPORT")) {
+			suites = append(suites, suite)
+		}
+	}
+	return suites
+}
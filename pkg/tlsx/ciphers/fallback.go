@@ -0,0 +1,78 @@
+package ciphers
+
+import (
+	"crypto/tls"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+const (
+	// suiteFallbackSCSV is the signaling suite of downgraded hello messages
+	suiteFallbackSCSV = 0x5600
+	// alertInappropriateFallback is sent by servers rejecting downgrades
+	alertInappropriateFallback = 86
+)
+
+// DetectFallbackSCSV returns whether the server rejects a hello with the
+// fallback signaling suite for a version below its highest supported one.
+// Nil is returned if the server supports a single version as no downgrade
+// is possible.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc7507
+func DetectFallbackSCSV(options *clients.Options, hostname, port string) (*clients.FallbackSCSVResponse, error) {
+	e := newEnumerator(options, hostname, port)
+
+	var lastErr error
+	highest := -1
+	for i := len(versions) - 1; i >= 0; i-- {
+		accepted, _, err := e.fallbackHello(versions[i].value, false)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if accepted {
+			highest = i
+			break
+		}
+	}
+	if highest == -1 {
+		return nil, lastErr
+	}
+	// lower versions disabled by the server are rejected with other alerts
+	for i := highest - 1; i >= 0; i-- {
+		accepted, alert, err := e.fallbackHello(versions[i].value, true)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if alert == alertInappropriateFallback {
+			return &clients.FallbackSCSVResponse{Supported: true, Version: versions[i].name}, nil
+		}
+		if accepted {
+			return &clients.FallbackSCSVResponse{Supported: false, Version: versions[i].name}, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// fallbackHello offers version with the fallback signaling suite if scsv
+// is true, returning whether the server accepted the version and the alert
+// sent otherwise.
+func (e *enumerator) fallbackHello(version uint16, scsv bool) (bool, byte, error) {
+	suites := tls13Suites
+	if version != tls.VersionTLS13 {
+		suites = compressionSuites()
+	}
+	if scsv {
+		suites = append(append([]uint16(nil), suites...), suiteFallbackSCSV)
+	}
+
+	var accepted bool
+	var alert byte
+	err := e.exchange(&clientHello{version: version, suites: suites, groups: defaultGroups, keyShare: true, serverName: e.serverName}, func(reader *handshakeReader) error {
+		hello, err := reader.serverHello(version)
+		accepted, alert = hello != nil, reader.alert
+		return err
+	})
+	return accepted, alert, err
+}
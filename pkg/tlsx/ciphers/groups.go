@@ -0,0 +1,183 @@
+package ciphers
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+const (
+	handshakeTypeServerKeyExchange = 12
+	handshakeTypeServerHelloDone   = 14
+	// curveTypeNamedCurve is the ec curve type of named curve parameters
+	curveTypeNamedCurve = 3
+)
+
+// groupNames is the list of named groups with their iana names
+var groupNames = map[uint16]string{
+	1:      "sect163k1",
+	2:      "sect163r1",
+	3:      "sect163r2",
+	4:      "sect193r1",
+	5:      "sect193r2",
+	6:      "sect233k1",
+	7:      "sect233r1",
+	8:      "sect239k1",
+	9:      "sect283k1",
+	10:     "sect283r1",
+	11:     "sect409k1",
+	12:     "sect409r1",
+	13:     "sect571k1",
+	14:     "sect571r1",
+	15:     "secp160k1",
+	16:     "secp160r1",
+	17:     "secp160r2",
+	18:     "secp192k1",
+	19:     "secp192r1",
+	20:     "secp224k1",
+	21:     "secp224r1",
+	22:     "secp256k1",
+	23:     "secp256r1",
+	24:     "secp384r1",
+	25:     "secp521r1",
+	26:     "brainpoolP256r1",
+	27:     "brainpoolP384r1",
+	28:     "brainpoolP512r1",
+	29:     "x25519",
+	30:     "x448",
+	31:     "brainpoolP256r1tls13",
+	32:     "brainpoolP384r1tls13",
+	33:     "brainpoolP512r1tls13",
+	256:    "ffdhe2048",
+	257:    "ffdhe3072",
+	258:    "ffdhe4096",
+	259:    "ffdhe6144",
+	260:    "ffdhe8192",
+	0x11eb: "SecP256r1MLKEM768",
+	0x11ec: "X25519MLKEM768",
+	0x11ed: "SecP384r1MLKEM1024",
+	0x6399: "X25519Kyber768Draft00",
+}
+
+// tls13Groups is the list of groups enumerated with tls 1.3
+var tls13Groups = []uint16{29, 30, 23, 24, 25, 31, 32, 33, 256, 257, 258, 259, 260, 0x11eb, 0x11ec, 0x11ed, 0x6399}
+
+// tls12Groups is the list of elliptic curves enumerated with tls 1.2.
+// Finite field groups are not enumerated as tls 1.2 servers send
+// explicit dh parameters instead of a group identifier.
+var tls12Groups = []uint16{29, 30, 23, 24, 25, 26, 27, 28, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22}
+
+// groupName returns the name of a named group
+func groupName(group uint16) string {
+	if name, ok := groupNames[group]; ok {
+		return name
+	}
+	return "0x" + strconv.FormatUint(uint64(group), 16)
+}
+
+// EnumerateGroups returns the key exchange groups accepted by the server
+// for tls 1.2 and tls 1.3 ordered by server preference if enforced.
+func EnumerateGroups(options *clients.Options, hostname, port string) ([]clients.GroupEnumResponse, error) {
+	e := newEnumerator(options, hostname, port)
+
+	var responses []clients.GroupEnumResponse
+	var lastErr error
+	for _, version := range []struct {
+		name       string
+		value      uint16
+		candidates []uint16
+	}{
+		{"tls12", tls.VersionTLS12, tls12Groups},
+		{"tls13", tls.VersionTLS13, tls13Groups},
+	} {
+		version := version
+		negotiate := func(groups []uint16) (uint16, bool, error) {
+			return e.negotiateGroup(version.value, groups)
+		}
+		accepted, err := acceptedValues(version.candidates, negotiate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(accepted) == 0 {
+			continue
+		}
+		ordered, serverPreference, err := orderValues(accepted, negotiate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		response := clients.GroupEnumResponse{Version: version.name, ServerPreference: serverPreference}
+		for _, group := range ordered {
+			response.Groups = append(response.Groups, groupName(group))
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return responses, nil
+}
+
+var (
+	ecdheSuites     []uint16
+	ecdheSuitesOnce sync.Once
+)
+
+// negotiateGroup offers groups for a version returning the group selected
+// by the server. With tls 1.3 an empty key share is sent so that the
+// server requests its selected group, with tls 1.2 the curve is read from
+// the ecdhe server key exchange.
+func (e *enumerator) negotiateGroup(version uint16, groups []uint16) (uint16, bool, error) {
+	hello := &clientHello{version: version, groups: groups, serverName: e.serverName}
+	if version == tls.VersionTLS13 {
+		hello.suites = tls13Suites
+	} else {
+		hello.suites = ecdheCandidateSuites()
+	}
+
+	var group uint16
+	var ok bool
+	err := e.exchange(hello, func(reader *handshakeReader) error {
+		serverHello, err := reader.serverHello(version)
+		if err != nil || serverHello == nil {
+			return err
+		}
+		if version == tls.VersionTLS13 {
+			// selected group of a HelloRetryRequest or group of the key share
+			if keyShare := serverHello.extensions[extensionKeyShare]; len(keyShare) >= 2 {
+				group, ok = binary.BigEndian.Uint16(keyShare[0:2]), true
+			}
+			return nil
+		}
+		for {
+			message, err := reader.next()
+			if err != nil || message == nil || message[0] == handshakeTypeServerHelloDone {
+				return nil
+			}
+			if message[0] == handshakeTypeServerKeyExchange {
+				if len(message) >= 7 && message[4] == curveTypeNamedCurve {
+					group, ok = binary.BigEndian.Uint16(message[5:7]), true
+				}
+				return nil
+			}
+		}
+	})
+	return group, ok, err
+}
+
+// ecdheCandidateSuites returns the ecdhe suites offered for tls 1.2
+func ecdheCandidateSuites() []uint16 {
+	ecdheSuitesOnce.Do(func() {
+		for _, suite := range candidateSuites(tls.VersionTLS12) {
+			if strings.Contains(suiteName(suite), "_ECDHE_") {
+				ecdheSuites = append(ecdheSuites, suite)
+			}
+		}
+	})
+	return ecdheSuites
+}
@@ -0,0 +1,272 @@
+package ciphers
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+const (
+	recordTypeChangeCipherSpec = 20
+	recordTypeAlert            = 21
+	recordTypeHandshake        = 22
+	handshakeTypeClientHello   = 1
+	handshakeTypeServerHello   = 2
+	maxRecordLength            = 16384 + 2048
+	compressionNull            = 0
+	compressionDeflate         = 1
+)
+
+// list of extensions sent by probes or parsed from server messages
+const (
+	extensionExtendedMasterSecret = 23
+	extensionSessionTicket        = 35
+	extensionSupportedVersions    = 43
+	extensionKeyShare             = 51
+)
+
+// defaultGroups is the list of groups offered during cipher enumeration:
+// x25519, secp256r1, secp384r1, secp521r1, ffdhe2048, ffdhe3072
+var defaultGroups = []uint16{29, 23, 24, 25, 256, 257}
+
+// defaultSignatureAlgorithms is the list of signature algorithms offered
+// with tls 1.2 and tls 1.3 unless enumerating signature algorithms
+var defaultSignatureAlgorithms = []uint16{0x0403, 0x0503, 0x0603, 0x0804, 0x0805, 0x0806, 0x0401, 0x0501, 0x0601, 0x0201, 0x0203, 0x0202}
+
+// clientHello is a ClientHello offering a single protocol version
+type clientHello struct {
+	version    uint16
+	suites     []uint16
+	groups     []uint16
+	serverName string
+	// signatureAlgorithms overrides the default signature algorithms
+	signatureAlgorithms []uint16
+	// keyShare sends a x25519 key share with tls 1.3 hello messages,
+	// otherwise an empty key share is sent to request a group.
+	keyShare bool
+	// compressionMethods overrides the offered null compression method
+	compressionMethods []byte
+	// sessionID overrides the random 32 byte session id
+	sessionID []byte
+	// sessionTicket is sent in the session_ticket extension if not nil
+	sessionTicket []byte
+	// extendedMasterSecret sends the extended_master_secret extension
+	extendedMasterSecret bool
+	// extensions are encoded extensions sent after all other extensions
+	extensions []byte
+}
+
+// marshal builds the ClientHello record
+func (h *clientHello) marshal() ([]byte, error) {
+	message, err := h.message()
+	if err != nil {
+		return nil, err
+	}
+	return helloRecord(h.version, message), nil
+}
+
+// message builds the ClientHello handshake message including its header
+func (h *clientHello) message() ([]byte, error) {
+	random := make([]byte, 32+32+32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	var extensions []byte
+	if h.version != tls.VersionSSL30 {
+		if h.serverName != "" {
+			extensions = clients.AppendExtension(extensions, 0, clients.ServerNameExtension(h.serverName))
+		}
+		// supported_groups
+		extensions = clients.AppendExtension(extensions, 10, appendValues(nil, h.groups))
+		// ec_point_formats: uncompressed
+		extensions = clients.AppendExtension(extensions, 11, []byte{1, 0})
+		if h.version >= tls.VersionTLS12 {
+			signatureAlgorithms := h.signatureAlgorithms
+			if signatureAlgorithms == nil {
+				signatureAlgorithms = defaultSignatureAlgorithms
+			}
+			extensions = clients.AppendExtension(extensions, 13, appendValues(nil, signatureAlgorithms))
+		}
+		if h.version == tls.VersionTLS13 {
+			// supported_versions: tls13
+			extensions = clients.AppendExtension(extensions, extensionSupportedVersions, []byte{2, 3, 4})
+			keyShare := []byte{0, 0}
+			if h.keyShare {
+				// x25519 with a random key
+				keyShare = append([]byte{0, 36, 0, 29, 0, 32}, random[64:96]...)
+			}
+			extensions = clients.AppendExtension(extensions, extensionKeyShare, keyShare)
+		}
+		if h.sessionTicket != nil {
+			extensions = clients.AppendExtension(extensions, extensionSessionTicket, h.sessionTicket)
+		}
+		if h.extendedMasterSecret {
+			extensions = clients.AppendExtension(extensions, extensionExtendedMasterSecret, nil)
+		}
+		// renegotiation_info
+		extensions = clients.AppendExtension(extensions, 65281, []byte{0})
+		extensions = append(extensions, h.extensions...)
+	}
+
+	helloVersion := h.version
+	if h.version == tls.VersionTLS13 {
+		helloVersion = tls.VersionTLS12
+	}
+	body := clients.Uint16Bytes(int(helloVersion))
+	body = append(body, random[:32]...)
+	sessionID := h.sessionID
+	if sessionID == nil {
+		sessionID = random[32:64]
+	}
+	body = append(body, byte(len(sessionID)))
+	body = append(body, sessionID...)
+	body = appendValues(body, h.suites)
+	compressionMethods := h.compressionMethods
+	if compressionMethods == nil {
+		compressionMethods = []byte{compressionNull}
+	}
+	body = append(body, byte(len(compressionMethods)))
+	body = append(body, compressionMethods...)
+	if len(extensions) > 0 {
+		body = append(body, clients.Uint16Bytes(len(extensions))...)
+		body = append(body, extensions...)
+	}
+
+	return clients.HandshakeMessage(handshakeTypeClientHello, body), nil
+}
+
+// helloRecord returns the record of a ClientHello message for version
+func helloRecord(version uint16, message []byte) []byte {
+	recordVersion := uint16(tls.VersionTLS10)
+	if version == tls.VersionSSL30 {
+		recordVersion = tls.VersionSSL30
+	}
+	return clients.HandshakeRecord(recordVersion, message)
+}
+
+// serverHello is the parsed ServerHello or HelloRetryRequest
+type serverHello struct {
+	// raw is the message including its header
+	raw         []byte
+	version     uint16
+	suite       uint16
+	sessionID   []byte
+	compression byte
+	extensions  map[uint16][]byte
+}
+
+// handshakeReader reads handshake messages sent by the server
+type handshakeReader struct {
+	// conn is the connection to the server, probes continuing
+	// the handshake write their messages to it.
+	conn      net.Conn
+	handshake []byte
+	// alert is the description of the alert sent by the server if any
+	alert byte
+}
+
+// next returns the next handshake message including its header.
+// Nil is returned if the server sent an alert.
+func (r *handshakeReader) next() ([]byte, error) {
+	header := make([]byte, 5)
+	for {
+		if len(r.handshake) >= 4 {
+			length := int(r.handshake[1])<<16 | int(r.handshake[2])<<8 | int(r.handshake[3])
+			if len(r.handshake) >= 4+length {
+				message := r.handshake[:4+length]
+				r.handshake = r.handshake[4+length:]
+				return message, nil
+			}
+		}
+		if _, err := io.ReadFull(r.conn, header); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[3:5]))
+		if header[1] != 3 || length > maxRecordLength {
+			return nil, errors.New("service does not speak tls")
+		}
+		record := make([]byte, length)
+		if _, err := io.ReadFull(r.conn, record); err != nil {
+			return nil, err
+		}
+		switch header[0] {
+		case recordTypeAlert:
+			if len(record) >= 2 {
+				r.alert = record[1]
+			}
+			return nil, nil
+		case recordTypeHandshake:
+			r.handshake = append(r.handshake, record...)
+		case recordTypeChangeCipherSpec:
+		default:
+			return nil, errors.New("unexpected record type")
+		}
+	}
+}
+
+// serverHello reads the ServerHello returning nil if the server rejected
+// the hello or negotiated a version other than version.
+func (r *handshakeReader) serverHello(version uint16) (*serverHello, error) {
+	message, err := r.next()
+	if err != nil || message == nil || message[0] != handshakeTypeServerHello {
+		// servers close the connection or alert on unsupported parameters
+		return nil, nil
+	}
+	hello := parseServerHello(message)
+	if hello == nil {
+		return nil, nil
+	}
+	if supportedVersion, ok := hello.extensions[extensionSupportedVersions]; ok && len(supportedVersion) == 2 {
+		hello.version = binary.BigEndian.Uint16(supportedVersion)
+	}
+	if hello.version != version {
+		return nil, nil
+	}
+	return hello, nil
+}
+
+// parseServerHello parses a ServerHello message including its header
+func parseServerHello(message []byte) *serverHello {
+	if len(message) < 4+2+32+1 {
+		return nil
+	}
+	hello := &serverHello{raw: message, version: binary.BigEndian.Uint16(message[4:6]), extensions: make(map[uint16][]byte)}
+	data := message[4+2+32:]
+	if len(data) < 1+int(data[0])+3 {
+		return nil
+	}
+	hello.sessionID = data[1 : 1+int(data[0])]
+	data = data[1+int(data[0]):]
+	hello.suite = binary.BigEndian.Uint16(data[0:2])
+	hello.compression = data[2]
+	data = data[3:]
+	if len(data) < 2 {
+		return hello
+	}
+	data = data[2:]
+	for len(data) >= 4 {
+		id := binary.BigEndian.Uint16(data[0:2])
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if len(data) < 4+length {
+			break
+		}
+		hello.extensions[id] = data[4 : 4+length]
+		data = data[4+length:]
+	}
+	return hello
+}
+
+// appendValues appends a two byte length prefixed list of values
+func appendValues(data []byte, values []uint16) []byte {
+	data = append(data, clients.Uint16Bytes(len(values)*2)...)
+	for _, value := range values {
+		data = append(data, clients.Uint16Bytes(int(value))...)
+	}
+	return data
+}
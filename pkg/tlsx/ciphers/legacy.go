@@ -0,0 +1,123 @@
+package ciphers
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// sslv2 protocol values
+const (
+	sslv2Version           = 0x0002
+	sslv2ClientHello       = 1
+	sslv2ServerHello       = 4
+	sslv2ChallengeLength   = 16
+	sslv2ServerHelloLength = 11
+)
+
+// sslv2CipherSpecs is the list of sslv2 cipher kinds offered
+var sslv2CipherSpecs = []uint32{
+	0x010080, // SSL_CK_RC4_128_WITH_MD5
+	0x020080, // SSL_CK_RC4_128_EXPORT40_WITH_MD5
+	0x030080, // SSL_CK_RC2_128_CBC_WITH_MD5
+	0x040080, // SSL_CK_RC2_128_CBC_EXPORT40_WITH_MD5
+	0x050080, // SSL_CK_IDEA_128_CBC_WITH_MD5
+	0x060040, // SSL_CK_DES_64_CBC_WITH_MD5
+	0x0700c0, // SSL_CK_DES_192_EDE3_CBC_WITH_MD5
+}
+
+// DetectSSLv2 returns true if the server answers a sslv2 client hello with
+// a sslv2 server hello. Servers supporting sslv2 expose their rsa key to
+// the drown attack, even if no sslv2 cipher kinds are enabled.
+//
+// follows: https://drownattack.com/
+func DetectSSLv2(options *clients.Options, hostname, port string) (bool, error) {
+	e := newEnumerator(options, hostname, port)
+
+	hello, err := sslv2ClientHelloRecord()
+	if err != nil {
+		return false, errors.Wrap(err, "could not build client hello")
+	}
+	var supported bool
+	err = e.send(hello, func(reader *handshakeReader) error {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(reader.conn, header); err != nil {
+			// servers close the connection on unsupported versions
+			return nil
+		}
+		// tls records and sslv2 records with a three byte header are not
+		// server hello messages
+		if header[0]&0x80 == 0 {
+			return nil
+		}
+		length := int(header[0]&0x7f)<<8 | int(header[1])
+		if length < sslv2ServerHelloLength {
+			return nil
+		}
+		message := make([]byte, sslv2ServerHelloLength)
+		if _, err := io.ReadFull(reader.conn, message); err != nil {
+			return nil
+		}
+		supported = message[0] == sslv2ServerHello && binary.BigEndian.Uint16(message[3:5]) == sslv2Version
+		return nil
+	})
+	return supported, err
+}
+
+// sslv2ClientHelloRecord returns a sslv2 client hello record offering
+// every sslv2 cipher kind.
+func sslv2ClientHelloRecord() ([]byte, error) {
+	challenge := make([]byte, sslv2ChallengeLength)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	message := []byte{sslv2ClientHello}
+	message = append(message, clients.Uint16Bytes(sslv2Version)...)
+	message = append(message, clients.Uint16Bytes(len(sslv2CipherSpecs)*3)...)
+	// no session id
+	message = append(message, 0, 0)
+	message = append(message, clients.Uint16Bytes(len(challenge))...)
+	for _, spec := range sslv2CipherSpecs {
+		message = append(message, byte(spec>>16), byte(spec>>8), byte(spec))
+	}
+	message = append(message, challenge...)
+
+	record := []byte{0x80 | byte(len(message)>>8), byte(len(message))}
+	return append(record, message...), nil
+}
+
+// DetectPOODLE returns true if the server negotiates a cbc cipher suite
+// over sslv3, whose padding is not covered by the mac making it
+// vulnerable to the poodle attack.
+//
+// follows: https://www.openssl.org/~bodo/ssl-poodle.pdf
+func DetectPOODLE(options *clients.Options, hostname, port string) (bool, error) {
+	e := newEnumerator(options, hostname, port)
+
+	var hello *serverHello
+	err := e.exchange(&clientHello{version: tls.VersionSSL30, suites: cbcSuites(), serverName: e.serverName}, func(reader *handshakeReader) error {
+		var err error
+		hello, err = reader.serverHello(tls.VersionSSL30)
+		return err
+	})
+	if err != nil || hello == nil {
+		return false, err
+	}
+	return strings.Contains(suiteName(hello.suite), "_CBC_"), nil
+}
+
+// cbcSuites returns the commonly deployed cbc suites offered with sslv3
+func cbcSuites() []uint16 {
+	var suites []uint16
+	for _, suite := range compressionSuites() {
+		if strings.Contains(suiteName(suite), "_CBC_") {
+			suites = append(suites, suite)
+		}
+	}
+	return suites
+}
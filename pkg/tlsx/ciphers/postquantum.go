@@ -0,0 +1,37 @@
+package ciphers
+
+import (
+	"crypto/tls"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// hybridGroups is the list of post-quantum hybrid key exchange groups
+// in the preference order of current clients.
+var hybridGroups = []uint16{0x11ec, 0x6399, 0x11eb, 0x11ed}
+
+// DetectPostQuantum returns the post-quantum hybrid groups accepted by the
+// server with tls 1.3 and the group negotiated when hybrid groups are
+// offered first along with classical groups, as done by current clients.
+func DetectPostQuantum(options *clients.Options, hostname, port string) (*clients.PostQuantumResponse, error) {
+	e := newEnumerator(options, hostname, port)
+	negotiate := func(groups []uint16) (uint16, bool, error) {
+		return e.negotiateGroup(tls.VersionTLS13, groups)
+	}
+
+	accepted, err := acceptedValues(hybridGroups, negotiate)
+	if err != nil {
+		return nil, err
+	}
+	response := &clients.PostQuantumResponse{}
+	for _, group := range accepted {
+		response.Groups = append(response.Groups, groupName(group))
+	}
+	group, ok, err := negotiate(append(append([]uint16(nil), hybridGroups...), defaultGroups...))
+	if err != nil || !ok {
+		return response, err
+	}
+	response.Negotiated = groupName(group)
+	response.Hybrid = containsValue(hybridGroups, group)
+	return response, nil
+}
@@ -0,0 +1,315 @@
+package ciphers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+const (
+	handshakeTypeCertificate       = 11
+	handshakeTypeClientKeyExchange = 16
+	// premasterSecretLength is the length of rsa premaster secrets
+	premasterSecretLength = 48
+)
+
+// robotVersions is the list of versions probed for rsa key exchange
+var robotVersions = []uint16{tls.VersionTLS12, tls.VersionTLS11, tls.VersionTLS10}
+
+// List of pkcs#1 v1.5 padding variants of the premaster secret sent to
+// the server, following the probes of the ROBOT paper.
+const (
+	robotValidPadding = iota
+	robotWrongFirstBytes
+	robotWrongZeroPosition
+	robotMissingZero
+	robotWrongVersion
+	robotVariants
+)
+
+// errNoRSAKeyExchange is returned when the server stops offering rsa
+// key exchange during probes
+var errNoRSAKeyExchange = errors.New("server did not negotiate rsa key exchange")
+
+// DetectROBOT returns true if the server responds differently to rsa
+// key exchanges with valid and malformed pkcs#1 v1.5 paddings, forming a
+// Bleichenbacher oracle. Servers not offering rsa key exchange are not
+// vulnerable.
+//
+// follows: https://robotattack.org/
+func DetectROBOT(options *clients.Options, hostname, port string) (bool, error) {
+	e := newEnumerator(options, hostname, port)
+
+	version, suite, err := e.rsaKeyExchange()
+	if err != nil || suite == 0 {
+		return false, err
+	}
+	// some servers only reveal the oracle without change cipher spec
+	// and finished messages following the client key exchange
+	for _, shortened := range []bool{false, true} {
+		responses, err := e.robotResponses(version, suite, shortened)
+		if err != nil {
+			return false, err
+		}
+		if !hasDistinctValues(responses) {
+			continue
+		}
+		// oracles must be reproducible to rule out network errors
+		repeated, err := e.robotResponses(version, suite, shortened)
+		if err != nil {
+			return false, err
+		}
+		if strings.Join(responses, ",") != strings.Join(repeated, ",") {
+			return false, errors.Errorf("inconsistent responses to rsa paddings: %s and %s", strings.Join(responses, ","), strings.Join(repeated, ","))
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// rsaKeyExchange returns the highest version and suite negotiated when
+// offering only rsa key exchange suites, zero if none is accepted.
+func (e *enumerator) rsaKeyExchange() (uint16, uint16, error) {
+	suites := rsaCandidateSuites()
+
+	var lastErr error
+	for _, version := range robotVersions {
+		version := version
+		var hello *serverHello
+		err := e.exchange(&clientHello{version: version, suites: suites, groups: defaultGroups, serverName: e.serverName}, func(reader *handshakeReader) error {
+			var err error
+			hello, err = reader.serverHello(version)
+			return err
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if hello != nil {
+			return version, hello.suite, nil
+		}
+	}
+	return 0, 0, lastErr
+}
+
+// robotResponses returns the server responses for every padding variant
+func (e *enumerator) robotResponses(version, suite uint16, shortened bool) ([]string, error) {
+	responses := make([]string, 0, robotVariants)
+	for variant := 0; variant < robotVariants; variant++ {
+		response, err := e.robotProbe(version, suite, variant, shortened)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// robotProbe completes a handshake up to the server hello done and sends
+// a client key exchange with the padding variant, returning the response.
+func (e *enumerator) robotProbe(version, suite uint16, variant int, shortened bool) (string, error) {
+	var response string
+	err := e.exchange(&clientHello{version: version, suites: []uint16{suite}, groups: defaultGroups, serverName: e.serverName}, func(reader *handshakeReader) error {
+		hello, err := reader.serverHello(version)
+		if err != nil {
+			return err
+		}
+		if hello == nil {
+			return errNoRSAKeyExchange
+		}
+		var publicKey *rsa.PublicKey
+		for {
+			message, err := reader.next()
+			if err != nil {
+				return errors.Wrap(err, "could not read server handshake")
+			}
+			if message == nil {
+				return errNoRSAKeyExchange
+			}
+			if message[0] == handshakeTypeCertificate {
+				publicKey = certificatePublicKey(message)
+			}
+			if message[0] == handshakeTypeServerHelloDone {
+				break
+			}
+		}
+		if publicKey == nil {
+			return errNoRSAKeyExchange
+		}
+
+		records, err := robotRecords(publicKey, version, variant, shortened)
+		if err != nil {
+			return err
+		}
+		if _, err := reader.conn.Write(records); err != nil {
+			return errors.Wrap(err, "could not write client key exchange")
+		}
+		response = readAlertResponse(reader.conn)
+		return nil
+	})
+	return response, err
+}
+
+// robotRecords returns the client key exchange with the padding variant
+// encrypted for publicKey, followed by a change cipher spec and a garbage
+// finished message unless shortened.
+func robotRecords(publicKey *rsa.PublicKey, version uint16, variant int, shortened bool) ([]byte, error) {
+	size := (publicKey.N.BitLen() + 7) / 8
+	plaintext, err := robotPlaintext(size, version, variant)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not build premaster secret")
+	}
+	message := new(big.Int).SetBytes(plaintext)
+	ciphertext := message.Exp(message, big.NewInt(int64(publicKey.E)), publicKey.N).FillBytes(make([]byte, size))
+
+	body := append(clients.Uint16Bytes(len(ciphertext)), ciphertext...)
+	handshake := []byte{handshakeTypeClientKeyExchange, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	records := appendRecord(nil, recordTypeHandshake, version, append(handshake, body...))
+	if shortened {
+		return records, nil
+	}
+	records = appendRecord(records, recordTypeChangeCipherSpec, version, []byte{1})
+	finished := make([]byte, 64)
+	if _, err := rand.Read(finished); err != nil {
+		return nil, err
+	}
+	return appendRecord(records, recordTypeHandshake, version, finished), nil
+}
+
+// robotPlaintext returns the size byte pkcs#1 v1.5 block of a premaster
+// secret for version, malformed according to variant.
+func robotPlaintext(size int, version uint16, variant int) ([]byte, error) {
+	if size < premasterSecretLength+11 {
+		return nil, errors.New("rsa modulus too small")
+	}
+	block := make([]byte, size)
+	separator := size - premasterSecretLength - 1
+	// padding and premaster secret are random non-zero bytes so that the
+	// only separator is the one placed by the variant
+	if err := randomNonZero(block[2:]); err != nil {
+		return nil, err
+	}
+	block[0], block[1] = 0x00, 0x02
+	block[separator] = 0x00
+	binary.BigEndian.PutUint16(block[separator+1:], version)
+
+	switch variant {
+	case robotWrongFirstBytes:
+		block[0], block[1] = 0x41, 0x17
+	case robotWrongZeroPosition:
+		block[separator], block[separator+1], block[separator+2] = 0x11, 0x11, 0x11
+		block[size-2], block[size-1] = 0x00, 0x11
+	case robotMissingZero:
+		block[separator], block[separator+1], block[separator+2] = 0x11, 0x11, 0x11
+	case robotWrongVersion:
+		binary.BigEndian.PutUint16(block[separator+1:], 0x0202)
+	}
+	return block, nil
+}
+
+// readAlertResponse returns the alert sent by the server or the reason
+// the connection ended without one.
+func readAlertResponse(conn net.Conn) string {
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return connectionErrorResponse(err)
+		}
+		record := make([]byte, binary.BigEndian.Uint16(header[3:5]))
+		if _, err := io.ReadFull(conn, record); err != nil {
+			return connectionErrorResponse(err)
+		}
+		if header[0] == recordTypeAlert && len(record) >= 2 {
+			return fmt.Sprintf("alert-%d", record[1])
+		}
+	}
+}
+
+// connectionErrorResponse returns the response for a connection error
+func connectionErrorResponse(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "eof"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "reset"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	}
+	return "error"
+}
+
+// certificatePublicKey returns the rsa public key of the leaf certificate
+// in a certificate message, nil if it is not an rsa key.
+func certificatePublicKey(message []byte) *rsa.PublicKey {
+	// header, certificate list length and leaf length
+	if len(message) < 4+3+3 {
+		return nil
+	}
+	data := message[4+3:]
+	length := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+	if len(data) < 3+length {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(data[3 : 3+length])
+	if err != nil {
+		return nil
+	}
+	publicKey, _ := cert.PublicKey.(*rsa.PublicKey)
+	return publicKey
+}
+
+// rsaCandidateSuites returns the suites using rsa key exchange
+func rsaCandidateSuites() []uint16 {
+	var suites []uint16
+	for _, suite := range candidateSuites(tls.VersionTLS12) {
+		if strings.HasPrefix(suiteName(suite), "TLS_RSA_WITH_") {
+			suites = append(suites, suite)
+		}
+	}
+	return suites
+}
+
+// appendRecord appends a record of type with data
+func appendRecord(records []byte, recordType byte, version uint16, data []byte) []byte {
+	records = append(records, recordType)
+	records = append(records, clients.Uint16Bytes(int(version))...)
+	records = append(records, clients.Uint16Bytes(len(data))...)
+	return append(records, data...)
+}
+
+// randomNonZero fills data with random non-zero bytes
+func randomNonZero(data []byte) error {
+	if _, err := rand.Read(data); err != nil {
+		return err
+	}
+	for i := range data {
+		for data[i] == 0 {
+			if _, err := rand.Read(data[i : i+1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasDistinctValues returns true if values are not all equal
+func hasDistinctValues(values []string) bool {
+	for _, value := range values[1:] {
+		if value != values[0] {
+			return true
+		}
+	}
+	return false
+}
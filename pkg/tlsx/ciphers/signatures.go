@@ -0,0 +1,220 @@
+package ciphers
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// signatureAlgorithmNames is the list of signature schemes with their iana names
+var signatureAlgorithmNames = map[uint16]string{
+	0x0101: "rsa_pkcs1_md5",
+	0x0201: "rsa_pkcs1_sha1",
+	0x0202: "dsa_sha1",
+	0x0203: "ecdsa_sha1",
+	0x0301: "rsa_pkcs1_sha224",
+	0x0302: "dsa_sha224",
+	0x0303: "ecdsa_sha224",
+	0x0401: "rsa_pkcs1_sha256",
+	0x0402: "dsa_sha256",
+	0x0403: "ecdsa_secp256r1_sha256",
+	0x0501: "rsa_pkcs1_sha384",
+	0x0502: "dsa_sha384",
+	0x0503: "ecdsa_secp384r1_sha384",
+	0x0601: "rsa_pkcs1_sha512",
+	0x0602: "dsa_sha512",
+	0x0603: "ecdsa_secp521r1_sha512",
+	0x0804: "rsa_pss_rsae_sha256",
+	0x0805: "rsa_pss_rsae_sha384",
+	0x0806: "rsa_pss_rsae_sha512",
+	0x0807: "ed25519",
+	0x0808: "ed448",
+	0x0809: "rsa_pss_pss_sha256",
+	0x080a: "rsa_pss_pss_sha384",
+	0x080b: "rsa_pss_pss_sha512",
+	0x081a: "ecdsa_brainpoolP256r1tls13_sha256",
+	0x081b: "ecdsa_brainpoolP384r1tls13_sha384",
+	0x081c: "ecdsa_brainpoolP512r1tls13_sha512",
+	0x0904: "mldsa44",
+	0x0905: "mldsa65",
+	0x0906: "mldsa87",
+}
+
+// signatureAlgorithms is the list of enumerated signature algorithms
+var signatureAlgorithms = []uint16{
+	0x0807, 0x0808, 0x0403, 0x0503, 0x0603, 0x081a, 0x081b, 0x081c,
+	0x0804, 0x0805, 0x0806, 0x0809, 0x080a, 0x080b,
+	0x0401, 0x0501, 0x0601, 0x0402, 0x0502, 0x0602,
+	0x0904, 0x0905, 0x0906,
+	0x0301, 0x0302, 0x0303, 0x0201, 0x0202, 0x0203, 0x0101,
+}
+
+// signatureAlgorithmName returns the name of a signature scheme
+func signatureAlgorithmName(algorithm uint16) string {
+	if name, ok := signatureAlgorithmNames[algorithm]; ok {
+		return name
+	}
+	return "0x" + strconv.FormatUint(uint64(algorithm), 16)
+}
+
+// EnumerateSignatureAlgorithms returns the signature algorithms accepted
+// by the server. With tls 1.2 the algorithm used is read from the server
+// key exchange so the server preference order is known, with tls 1.3 the
+// handshake signature is encrypted and each algorithm is offered alone.
+func EnumerateSignatureAlgorithms(options *clients.Options, hostname, port string) ([]clients.SignatureEnumResponse, error) {
+	e := newEnumerator(options, hostname, port)
+
+	var responses []clients.SignatureEnumResponse
+	var lastErr error
+
+	negotiate := func(algorithms []uint16) (uint16, bool, error) {
+		return e.negotiateSignatureAlgorithm(algorithms)
+	}
+	accepted, err := acceptedValues(signatureAlgorithms, negotiate)
+	if err != nil {
+		lastErr = err
+	}
+	if len(accepted) > 0 {
+		ordered, serverPreference, err := orderValues(accepted, negotiate)
+		if err != nil {
+			lastErr = err
+		}
+		responses = append(responses, newSignatureEnumResponse("tls12", ordered, serverPreference))
+	}
+
+	var acceptedTLS13 []uint16
+	if ok, err := e.acceptsSignatureAlgorithms(signatureAlgorithms); err != nil {
+		lastErr = err
+	} else if ok {
+		for _, algorithm := range signatureAlgorithms {
+			ok, err := e.acceptsSignatureAlgorithms([]uint16{algorithm})
+			if err != nil {
+				lastErr = err
+				break
+			}
+			if ok {
+				acceptedTLS13 = append(acceptedTLS13, algorithm)
+			}
+		}
+	}
+	if len(acceptedTLS13) > 0 {
+		responses = append(responses, newSignatureEnumResponse("tls13", acceptedTLS13, false))
+	}
+	if len(responses) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return responses, nil
+}
+
+func newSignatureEnumResponse(version string, algorithms []uint16, serverPreference bool) clients.SignatureEnumResponse {
+	response := clients.SignatureEnumResponse{Version: version, ServerPreference: serverPreference}
+	for _, algorithm := range algorithms {
+		response.Algorithms = append(response.Algorithms, signatureAlgorithmName(algorithm))
+	}
+	return response
+}
+
+// negotiateSignatureAlgorithm offers signature algorithms with tls 1.2
+// forward secret suites returning the algorithm used to sign the server
+// key exchange.
+func (e *enumerator) negotiateSignatureAlgorithm(algorithms []uint16) (uint16, bool, error) {
+	hello := &clientHello{
+		version:             tls.VersionTLS12,
+		suites:              forwardSecretCandidateSuites(),
+		groups:              defaultGroups,
+		signatureAlgorithms: algorithms,
+		serverName:          e.serverName,
+	}
+
+	var algorithm uint16
+	var ok bool
+	err := e.exchange(hello, func(reader *handshakeReader) error {
+		serverHello, err := reader.serverHello(tls.VersionTLS12)
+		if err != nil || serverHello == nil {
+			return err
+		}
+		ecdhe := strings.Contains(suiteName(serverHello.suite), "_ECDHE_")
+		for {
+			message, err := reader.next()
+			if err != nil || message == nil || message[0] == handshakeTypeServerHelloDone {
+				return nil
+			}
+			if message[0] == handshakeTypeServerKeyExchange {
+				algorithm, ok = serverKeyExchangeSignatureAlgorithm(message[4:], ecdhe)
+				return nil
+			}
+		}
+	})
+	return algorithm, ok, err
+}
+
+// serverKeyExchangeSignatureAlgorithm returns the signature algorithm of
+// an ecdhe or dhe server key exchange message body.
+func serverKeyExchangeSignatureAlgorithm(body []byte, ecdhe bool) (uint16, bool) {
+	var offset int
+	if ecdhe {
+		// curve type, named group and public key
+		if len(body) < 4 || body[0] != curveTypeNamedCurve {
+			return 0, false
+		}
+		offset = 4 + int(body[3])
+	} else {
+		// prime, generator and public value
+		for i := 0; i < 3; i++ {
+			if len(body) < offset+2 {
+				return 0, false
+			}
+			offset += 2 + int(binary.BigEndian.Uint16(body[offset:offset+2]))
+		}
+	}
+	if len(body) < offset+2 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(body[offset : offset+2]), true
+}
+
+// acceptsSignatureAlgorithms returns true if the server continues a tls 1.3
+// handshake offering only algorithms.
+func (e *enumerator) acceptsSignatureAlgorithms(algorithms []uint16) (bool, error) {
+	hello := &clientHello{
+		version:             tls.VersionTLS13,
+		suites:              tls13Suites,
+		groups:              defaultGroups,
+		keyShare:            true,
+		signatureAlgorithms: algorithms,
+		serverName:          e.serverName,
+	}
+	var ok bool
+	err := e.exchange(hello, func(reader *handshakeReader) error {
+		serverHello, err := reader.serverHello(tls.VersionTLS13)
+		ok = serverHello != nil
+		return err
+	})
+	return ok, err
+}
+
+var (
+	forwardSecretSuites     []uint16
+	forwardSecretSuitesOnce sync.Once
+)
+
+// forwardSecretCandidateSuites returns the ecdhe and dhe suites offered
+// for tls 1.2 so that the server signs its key exchange.
+func forwardSecretCandidateSuites() []uint16 {
+	forwardSecretSuitesOnce.Do(func() {
+		for _, suite := range candidateSuites(tls.VersionTLS12) {
+			name := suiteName(suite)
+			if (strings.Contains(name, "_ECDHE_") || strings.Contains(name, "_DHE_")) && !strings.Contains(name, "_PSK_") {
+				forwardSecretSuites = append(forwardSecretSuites, suite)
+			}
+		}
+		if len(forwardSecretSuites) > maxOfferedSuites {
+			forwardSecretSuites = forwardSecretSuites[:maxOfferedSuites]
+		}
+	})
+	return forwardSecretSuites
+}
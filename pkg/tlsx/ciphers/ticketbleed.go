@@ -0,0 +1,43 @@
+package ciphers
+
+import (
+	"bytes"
+	"crypto/rand"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// ticketbleedSessionIDLength is the length of the session id sent with the
+// ticket. Affected servers echo a 32 byte session id starting with it, the
+// remaining bytes being uninitialized memory.
+const ticketbleedSessionIDLength = 16
+
+// DetectTicketbleed returns true if the server resuming a session from
+// ticket with a session id shorter than 32 bytes pads the echoed session
+// id with process memory. Ticket is a session ticket issued by the server
+// for version. The leaked memory is discarded and never reported.
+//
+// follows: https://filippo.io/Ticketbleed/
+func DetectTicketbleed(options *clients.Options, hostname, port string, version uint16, ticket []byte) (bool, error) {
+	e := newEnumerator(options, hostname, port)
+
+	sessionID := make([]byte, ticketbleedSessionIDLength)
+	if _, err := rand.Read(sessionID); err != nil {
+		return false, errors.Wrap(err, "could not build session id")
+	}
+	var echoed []byte
+	err := e.exchange(&clientHello{version: version, suites: candidateSuites(version), groups: defaultGroups, serverName: e.serverName, sessionID: sessionID, sessionTicket: ticket}, func(reader *handshakeReader) error {
+		hello, err := reader.serverHello(version)
+		if err != nil || hello == nil {
+			return err
+		}
+		echoed = hello.sessionID
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	// servers not resuming the session send a new random session id
+	return len(echoed) == 32 && bytes.HasPrefix(echoed, sessionID), nil
+}
@@ -0,0 +1,90 @@
+// Package clientauth implements detection of servers requesting client
+// certificates along with the certificate authorities they accept.
+package clientauth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+)
+
+// alertTimeout is the time to wait for an alert rejecting the missing
+// client certificate after a tls 1.3 handshake.
+const alertTimeout = 2 * time.Second
+
+// Probe makes a handshake without a client certificate and returns whether
+// the server sent a CertificateRequest, nil if it did not. Servers aborting
+// the handshake without a certificate are reported as requiring it.
+func Probe(options *clients.Options, hostname, port string) (*clients.CertificateRequestResponse, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial address")
+	}
+	defer rawConn.Close()
+
+	if err := starttls.Negotiate(ctx, rawConn, starttls.Protocol(options, port), starttls.ServerName(options, hostname)); err != nil {
+		return nil, errors.Wrap(err, "could not negotiate starttls")
+	}
+	var response *clients.CertificateRequestResponse
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         options.ServerName,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			response = &clients.CertificateRequestResponse{AcceptableCAs: distinguishedNames(info.AcceptableCAs)}
+			// an empty certificate message is sent
+			return &tls.Certificate{}, nil
+		},
+	}
+	if config.ServerName == "" && !iputil.IsIP(hostname) {
+		config.ServerName = hostname
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		if response != nil {
+			response.Required = true
+			return response, nil
+		}
+		return nil, errors.Wrap(err, "could not do handshake")
+	}
+	if response == nil || conn.ConnectionState().Version != tls.VersionTLS13 {
+		return response, nil
+	}
+
+	// tls 1.3 servers reject the certificate after the client finished
+	_ = conn.SetReadDeadline(time.Now().Add(alertTimeout))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && strings.Contains(err.Error(), "remote error") {
+		response.Required = true
+	}
+	return response, nil
+}
+
+// distinguishedNames returns the string form of der encoded names
+func distinguishedNames(raw [][]byte) []string {
+	names := make([]string, 0, len(raw))
+	for _, data := range raw {
+		var sequence pkix.RDNSequence
+		if _, err := asn1.Unmarshal(data, &sequence); err != nil {
+			continue
+		}
+		var name pkix.Name
+		name.FillFromRDNSequence(&sequence)
+		names = append(names, name.String())
+	}
+	return names
+}
@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"strconv"
+	"strings"
+
+	zdsa "github.com/zmap/zcrypto/dsa"
+	zx509 "github.com/zmap/zcrypto/x509"
+)
+
+// weakCipherMarkers are substrings of cipher suite names which indicate
+// a weak or broken cipher suite.
+var weakCipherMarkers = []string{"NULL", "EXPORT", "ANON", "RC4", "RC2", "DES_CBC", "3DES", "IDEA", "MD5"}
+
+// IsWeakCipher returns true if the cipher suite name is considered weak
+func IsWeakCipher(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range weakCipherMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWeakSignatureAlgorithm returns true if the signature algorithm name
+// uses sha1 or md5 such as rsa_pkcs1_sha1 or SHA1-RSA.
+func IsWeakSignatureAlgorithm(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "sha1") || strings.Contains(lower, "md5")
+}
+
+// PublicKeySize returns the size in bits of a public key parsed by
+// crypto/x509 or zcrypto/x509. Zero is returned for unknown key types.
+func PublicKeySize(key interface{}) int {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case *dsa.PublicKey:
+		return k.P.BitLen()
+	case ed25519.PublicKey:
+		return 256
+	case *zx509.AugmentedECDSA:
+		return PublicKeySize(k.Pub)
+	case *zdsa.PublicKey:
+		return k.P.BitLen()
+	}
+	return 0
+}
+
+// PublicKeyType returns the algorithm of a public key with its size or
+// curve such as RSA-2048, ECDSA-P256 or Ed25519.
+func PublicKeyType(algorithm string, key interface{}) string {
+	if augmented, ok := key.(*zx509.AugmentedECDSA); ok {
+		key = augmented.Pub
+	}
+	if k, ok := key.(*ecdsa.PublicKey); ok {
+		return algorithm + "-" + strings.ReplaceAll(k.Curve.Params().Name, "-", "")
+	}
+	switch algorithm {
+	case "RSA", "DSA":
+		if size := PublicKeySize(key); size > 0 {
+			return algorithm + "-" + strconv.Itoa(size)
+		}
+	}
+	return algorithm
+}
+
+// IsWeakKey returns true for rsa and dsa keys shorter than 2048 bits
+// and ecdsa keys shorter than 256 bits.
+func IsWeakKey(algorithm string, size int) bool {
+	if size <= 0 {
+		return false
+	}
+	switch algorithm {
+	case "RSA", "DSA":
+		return size < 2048
+	case "ECDSA":
+		return size < 256
+	}
+	return false
+}
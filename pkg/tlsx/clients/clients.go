@@ -1,14 +1,20 @@
 package clients
 
 import (
-	"bytes"
+	"context"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"math"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/projectdiscovery/fastdialer/fastdialer"
 	"github.com/projectdiscovery/goflags"
 )
@@ -19,26 +25,128 @@ type Implementation interface {
 	Connect(hostname, port string) (*Response, error)
 }
 
+// Dialer is an interface implemented by dialers connecting to hosts
+type Dialer interface {
+	// Dial connects to the address on the named network
+	Dial(ctx context.Context, network, address string) (net.Conn, error)
+}
+
 // Options contains configuration options for tlsx client
 type Options struct {
 	// OutputFile is the file to write output to
 	OutputFile string
+	// OutputTargets is the list of additional output targets ([policy:]format:destination)
+	OutputTargets goflags.StringSlice
+	// NoStdout disables writing results to stdout besides the other outputs
+	NoStdout bool
+	// Table aligns standard output to stdout into columns
+	Table bool
+	// AuditCSV is the file to write auditor inventory csv to
+	AuditCSV string
+	// ChainBundleDir is the directory to write deduplicated chain pem bundles to
+	ChainBundleDir string
+	// ReportPDF is the file to write pdf executive summary to
+	ReportPDF string
+	// ReportLocale is the locale name or catalog file to render the pdf report with
+	ReportLocale string
+	// Package is the zip archive to package scan artifacts into
+	Package string
+	// FlushEvery is the number of results after which stdout and output file are flushed
+	FlushEvery int
+	// Unbuffered flushes stdout and output file after every result
+	Unbuffered bool
+	// Lock enables a lock file preventing concurrent scans writing the same output
+	Lock bool
+	// StatsdAddress is the statsd collector address to emit metrics to
+	StatsdAddress string
+	// StatsdPrefix is the prefix for emitted statsd metrics
+	StatsdPrefix string
+	// StatsdTags is a list of dogstatsd tags for emitted metrics
+	StatsdTags goflags.StringSlice
 	// Inputs is a list of inputs to process
 	Inputs goflags.StringSlice
 	// InputList is the list of inputs to process
 	InputList string
+	// DryRun prints the targets which would be scanned without connecting
+	DryRun bool
+	// Sample is the rate of targets to scan as a percentage (1%) or interval (1/100)
+	Sample string
+	// SampleSeed is the seed selecting sampled targets
+	SampleSeed int
+	// Shuffle scans targets in random order interleaving subnets
+	Shuffle bool
+	// ShuffleSeed is the seed of the random target order
+	ShuffleSeed int
+	// RetryFile is a retry file from a previous scan to process
+	RetryFile string
+	// RetryOutput is the file to write permanently failed targets to
+	RetryOutput string
+	// InputReport is the file to write skipped invalid input lines to
+	InputReport string
 	// ServerName is the optional server-name for tls connection
 	ServerName string
+	// ServerNames is the list of server-names to connect with, one connection each
+	ServerNames goflags.StringSlice
 	// Verbose enables display of verbose output
 	Verbose bool
 	// Version shows the version of the program
 	Version bool
 	// JSON enables display of JSON output
 	JSON bool
+	// Plain enables display of plain key=value output
+	Plain bool
 	// TLSChain enables printing TLS chain information to output
 	TLSChain bool
+	// ChainVerbosity is the verbosity of tls chain entries (full, brief)
+	ChainVerbosity string
+	// ChainValidation enables diagnosing problems of the presented chain
+	ChainValidation bool
+	// AIAFetch enables fetching missing issuers of incomplete chains from aia urls
+	AIAFetch bool
+	// Revocation is the list of revocation checking methods (ocsp, crl)
+	Revocation goflags.StringSlice
+	// CRLCacheDir is the directory downloaded crls are cached in
+	CRLCacheDir string
+	// CT enables parsing signed certificate timestamps and checking ct policy compliance
+	CT bool
+	// CTLogList is the file of the chrome ct log list used instead of the cached or embedded list
+	CTLogList string
+	// CTAppleLogList is the file of the apple ct log list used instead of the cached or embedded list
+	CTAppleLogList string
+	// CTSearch enables searching ct logs for other certificates issued for the hostname
+	CTSearch bool
+	// CTSearchURL is the url of the crt.sh compatible json search the hostname is appended to
+	CTSearchURL string
+	// CTSearchDays is the number of days before the scan certificates are searched for
+	CTSearchDays int
 	// CertsOnly enables early SSL termination using ztls flag
 	CertsOnly bool
+	// CheckOnly only checks whether the host speaks tls
+	CheckOnly bool
+	// DetectService detects plaintext service for failed handshakes
+	DetectService bool
+	// ErrorResults writes a result with the error for failed handshakes
+	ErrorResults bool
+	// DetectDuplicates flags serial numbers and public keys shared across subjects
+	DetectDuplicates bool
+	// SNIMatrix writes the certificates presented for each server name per ip
+	SNIMatrix bool
+	// OIDFile is a json file mapping private object identifiers to names
+	OIDFile string
+	// RootStoreFile is a pem file of a hypothetical root store to simulate
+	RootStoreFile string
+	// RemediationHints attaches findings with remediation hints to responses
+	RemediationHints bool
+	// RemediationFile is an optional file overriding remediation hints
+	RemediationFile string
+	// StartTLS is the starttls protocol to negotiate before handshake
+	StartTLS string
+	// StartTLSPorts is a list of port:protocol starttls protocol mappings
+	StartTLSPorts goflags.StringSlice
+	// AutoStartTLS negotiates the default starttls protocol of ports
+	AutoStartTLS bool
+	// QUIC enables tls probing over quic on udp
+	QUIC bool
 	// RespOnly displays TLS respones only in CLI output
 	RespOnly bool
 	// Silent enables silent output display
@@ -49,22 +157,73 @@ type Options struct {
 	Timeout int
 	// Concurrency is the number of concurrent threads to process
 	Concurrency int
+	// MaxPerSubnet is the max number of targets of a /24 (/56 for ipv6) processed at once
+	MaxPerSubnet int
+	// DiscoverPorts is the name of the port list probed on responsive hosts
+	DiscoverPorts string
 	// Port is the ports to make request to
 	Ports goflags.StringSlice
 	// Ciphers is a list of custom ciphers to use for connection
 	Ciphers goflags.StringSlice
+	// ALPN is a list of application protocols to offer for connection
+	ALPN goflags.StringSlice
 	// CACertificate is the CA certificate for connection
 	CACertificate string
+	// CAFile is the pem bundle or der file of roots to trust instead of the system roots
+	CAFile string
+	// CADir is the directory of root files to trust instead of the system roots
+	CADir string
+	// TrustStoreFiles is the list of named trust stores (name=path or system)
+	// chains are also validated against for a per store trust matrix
+	TrustStoreFiles goflags.StringSlice
+	// ClientCertificate is the pem client certificate file for mtls
+	ClientCertificate string
+	// ClientKey is the pem private key file of the client certificate
+	ClientKey string
+	// ClientPKCS12 is the pkcs12 client certificate file for mtls
+	ClientPKCS12 string
+	// ClientPKCS12Password is the password of the pkcs12 file
+	ClientPKCS12Password string
+	// ClientPKCS11 is the pkcs11 uri of the client certificate for mtls
+	ClientPKCS11 string
+	// ClientCertStore is the system store certificate for mtls (windows)
+	ClientCertStore string
+	// CACertStore is the system store to load trust anchors from (windows)
+	CACertStore string
 	// MinVersion is the minimum tls version that is acceptable
 	MinVersion string
 	// MaxVersion is the maximum tls version that is acceptable
 	MaxVersion string
 	// Resolvers contains custom resolvers for the tlsx client
 	Resolvers goflags.StringSlice
+	// Proxy is the http proxy url to tunnel connections through
+	Proxy string
 	// ScanMode is the tls connection mode to use
 	ScanMode string
+	// OpenSSLBinary is the path to openssl binary for openssl scan mode
+	OpenSSLBinary string
 	// VerifyServerCertificate enables optional verification of server certificates
 	VerifyServerCertificate bool
+	// ValidationTime is the date to evaluate certificate validity at
+	ValidationTime string
+	// ValidationAt is the parsed validation time, zero for the current time
+	ValidationAt time.Time
+	// CaptureHello enables capturing raw ClientHello and ServerHello messages
+	CaptureHello bool
+	// JARM enables the jarm active fingerprint of the server
+	JARM bool
+	// MatchFingerprint enables matching responses against known fingerprints
+	MatchFingerprint bool
+	// FingerprintFile is an optional file with additional fingerprints
+	FingerprintFile string
+	// PreviousResults is a json results file from a previous scan
+	PreviousResults string
+	// RescanChangedOnly only runs full scan for hosts whose certificate changed
+	RescanChangedOnly bool
+	// Preflight validates egress before scanning
+	Preflight bool
+	// PreflightTarget is the known-good host:port used for preflight checks
+	PreflightTarget string
 
 	// Begin List of probes for tlsx
 
@@ -80,13 +239,97 @@ type Options struct {
 	Cipher bool
 	// Expired displays validity of TLS certificate
 	Expired bool
+	// ExpiringWithin is the window certificates are tagged as expiring in (30d, 12h)
+	ExpiringWithin string
+	// ExpiringWindow is the parsed expiring window, zero if disabled
+	ExpiringWindow time.Duration
+	// ExpiringOnly only writes results expiring within the window or expired
+	ExpiringOnly bool
 	// SelfSigned displays if cert is self-signed
 	SelfSigned bool
+	// KeyType displays the public key type and size of the certificate
+	KeyType bool
+	// WeakKey displays if the certificate has a weak public key
+	WeakKey bool
+	// WeakKeyOnly only writes results with weak public keys
+	WeakKeyOnly bool
+	// SignatureAlgorithm displays the signature algorithms of the presented chain
+	SignatureAlgorithm bool
+	// WeakSignature displays presented certificates with md5 or sha1 signatures
+	WeakSignature bool
+	// HostnameCoverage displays how the input hostname is covered by the certificate names
+	HostnameCoverage bool
+	// Hosting displays hosting panel and shared hosting classification of the certificate
+	Hosting bool
+	// DefaultCertificate enables comparing the certificate presented without sni
+	DefaultCertificate bool
+	// Attribute displays only certificate names attributed to the scanned organization
+	Attribute bool
+	// AttributionScope is the list of in scope domains for name attribution
+	AttributionScope goflags.StringSlice
+	// ExcludeHosting excludes results classified as hosting panel or shared hosting
+	ExcludeHosting bool
 	// Hash is the hash to display for certificate
 	Hash string
+	// PinSHA256 displays the spki pin-sha256 of certificate
+	PinSHA256 bool
+	// ACME enables probing for acme tls-alpn-01 challenge endpoints
+	ACME bool
+	// CipherEnum enables enumeration of accepted cipher suites per version
+	CipherEnum bool
+	// GroupEnum enables enumeration of accepted key exchange groups per version
+	GroupEnum bool
+	// SignatureEnum enables enumeration of accepted signature algorithms per version
+	SignatureEnum bool
+	// ALPNEnum enables enumeration of application protocols selected by the server
+	ALPNEnum bool
+	// EarlyData enables probing for tls 1.3 early data acceptance on resumption
+	EarlyData bool
+	// Renegotiation enables probing for secure and client-initiated renegotiation
+	Renegotiation bool
+	// OCSP enables inspection of the stapled ocsp response
+	OCSP bool
+	// Compression enables probing for tls compression acceptance (crime)
+	Compression bool
+	// DHParams enables probing for dh parameters of dhe suites (logjam)
+	DHParams bool
+	// FallbackSCSV enables probing for tls_fallback_scsv downgrade protection
+	FallbackSCSV bool
+	// ExtendedMasterSecret enables probing for extended_master_secret support
+	ExtendedMasterSecret bool
+	// ECH enables probing for encrypted client hello support
+	ECH bool
+	// PostQuantum enables probing for post-quantum hybrid key exchange groups
+	PostQuantum bool
+	// SIPSRV enables looking up sips servers of domain inputs from srv records
+	SIPSRV bool
+	// HTTP2Confirm enables confirming http/2 and grpc services negotiating h2
+	HTTP2Confirm bool
+	// BrokerConfirm enables confirming mqtt and amqp brokers after the handshake
+	BrokerConfirm bool
+	// CertificateRequest enables probing for client certificate requests
+	CertificateRequest bool
+	// Heartbleed enables the heartbleed vulnerability check
+	Heartbleed bool
+	// ROBOT enables the ROBOT rsa padding oracle vulnerability check
+	ROBOT bool
+	// Ticketbleed enables the ticketbleed session id memory leak check
+	Ticketbleed bool
+	// DROWN enables the sslv2 support check for the DROWN vulnerability
+	DROWN bool
+	// POODLE enables the sslv3 cbc support check for the POODLE vulnerability
+	POODLE bool
+	// FREAK enables the export grade cipher suite check for the FREAK vulnerability
+	FREAK bool
 
 	// Fastdialer is a fastdialer dialer instance
 	Fastdialer *fastdialer.Dialer
+	// ProxyDialer is the dialer tunneling connections through proxy
+	ProxyDialer Dialer
+	// OIDRegistry is the registry used to name object identifiers
+	OIDRegistry *OIDRegistry
+	// RootStore is the root store simulation for presented chains
+	RootStore *RootStore
 }
 
 // Response is the response returned for a TLS grab event
@@ -103,19 +346,476 @@ type Response struct {
 	Version string `json:"tls-version"`
 	// Cipher is the cipher for the tls request
 	Cipher string `json:"cipher,omitempty"`
+	// Discovered is true if the port was found by companion port discovery
+	Discovered bool `json:"discovered,omitempty"`
+	// SIPDomain is the sip domain validation of the certificate
+	SIPDomain *SIPDomainResponse `json:"sip-domain,omitempty"`
 	// CertificateResponse is the leaf certificate embedded in json
 	CertificateResponse `json:",inline"`
 	// TLSConnection is the client used for TLS connection
 	// when ran using scan-mode auto.
 	TLSConnection string `json:"tls-connection,omitempty"`
-	// Chain is the chain of certificates
+	// SNI is the server name specified for the connection
+	SNI string `json:"sni,omitempty"`
+	// ClientCertificate is true if a client certificate was requested and sent
+	ClientCertificate bool `json:"client-certificate,omitempty"`
+	// Chain is the presented chain of certificates with the leaf first
 	Chain []CertificateResponse `json:"chain,omitempty"`
+	// ChainValidation is the diagnosis of problems of the presented chain
+	ChainValidation *ChainValidationResponse `json:"chain-validation,omitempty"`
+	// Revocation is the revocation status of the leaf certificate
+	Revocation *RevocationResponse `json:"revocation,omitempty"`
+	// CT is the signed certificate timestamps and ct policy compliance of the leaf certificate
+	CT *CTResponse `json:"ct,omitempty"`
+	// CTSearch is the list of other certificates recently logged for the hostname
+	CTSearch *CTSearchResponse `json:"ct-search,omitempty"`
+	// ChainID is the identifier of the pem bundle written for the presented chain
+	ChainID string `json:"chain-id,omitempty"`
+	// RawChain is the raw presented chain with the leaf first
+	RawChain [][]byte `json:"-"`
+	// RawSCTs is the list of signed certificate timestamps sent in the tls extension
+	RawSCTs [][]byte `json:"-"`
+	// RawOCSPStaple is the der encoded stapled ocsp response
+	RawOCSPStaple []byte `json:"-"`
+	// ClientHello is the raw ClientHello sent to the server
+	ClientHello *HelloMessage `json:"client-hello,omitempty"`
+	// ServerHello is the raw ServerHello received from the server
+	ServerHello *HelloMessage `json:"server-hello,omitempty"`
+	// JA3S is the ja3s fingerprint of the server hello
+	JA3S string `json:"ja3s,omitempty"`
+	// JARM is the jarm fingerprint of the server
+	JARM string `json:"jarm,omitempty"`
+	// HostnameCoverage is the coverage of the input hostname by the certificate names
+	HostnameCoverage *HostnameCoverageResponse `json:"hostname-coverage,omitempty"`
+	// DefaultCertificate is the certificate presented without sni
+	DefaultCertificate *DefaultCertificateResponse `json:"default-certificate,omitempty"`
+	// Hosting is the hosting panel and shared hosting classification of the certificate
+	Hosting *HostingResponse `json:"hosting,omitempty"`
+	// Attribution is the attribution of certificate names to the scanned organization
+	Attribution *AttributionResponse `json:"attribution,omitempty"`
+	// Fingerprints is a list of matched known infrastructure labels
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	// ALPN is the application protocol negotiated with the server
+	ALPN string `json:"alpn,omitempty"`
+	// ALPNEnum is the list of application protocols selected by the server
+	ALPNEnum []string `json:"alpn-enum,omitempty"`
+	// QUIC is the negotiated quic connection information
+	QUIC *QUICResponse `json:"quic,omitempty"`
+	// ACME returns true if the server responds to acme tls-alpn-01 protocol
+	ACME bool `json:"acme,omitempty"`
+	// EarlyData is the tls 1.3 early data support of the server
+	EarlyData *EarlyDataResponse `json:"early-data,omitempty"`
+	// Compression is the tls compression method accepted by the server
+	Compression string `json:"compression,omitempty"`
+	// DHParams is the dh parameters sent by the server for dhe suites
+	DHParams *DHParamsResponse `json:"dh-params,omitempty"`
+	// FallbackSCSV is the downgrade protection of the server
+	FallbackSCSV *FallbackSCSVResponse `json:"fallback-scsv,omitempty"`
+	// ExtendedMasterSecret is the extended_master_secret support of the server
+	ExtendedMasterSecret *ExtendedMasterSecretResponse `json:"extended-master-secret,omitempty"`
+	// ECH is the encrypted client hello support of the server
+	ECH *ECHResponse `json:"ech,omitempty"`
+	// PostQuantum is the post-quantum hybrid key exchange support of the server
+	PostQuantum *PostQuantumResponse `json:"post-quantum,omitempty"`
+	// CertificateRequest is the client certificate request of the server
+	CertificateRequest *CertificateRequestResponse `json:"certificate-request,omitempty"`
+	// HTTP2 is the http/2 and grpc service confirmation of the server
+	HTTP2 *HTTP2Response `json:"http2,omitempty"`
+	// Broker is the message broker protocol confirmation of the server
+	Broker *BrokerResponse `json:"broker,omitempty"`
+	// ActiveDirectory is true if the ldap server is an active directory
+	// domain controller
+	ActiveDirectory bool `json:"active-directory,omitempty"`
+	// Renegotiation is the renegotiation support of the server
+	Renegotiation *RenegotiationResponse `json:"renegotiation,omitempty"`
+	// Vulnerabilities is the list of vulnerabilities confirmed by active checks
+	Vulnerabilities []string `json:"vulnerabilities,omitempty"`
+	// RootStore is the trust of the chain with current and simulated roots
+	RootStore *RootStoreResponse `json:"root-store,omitempty"`
+	// CipherEnum is the list of accepted cipher suites per tls version
+	CipherEnum []CipherEnumResponse `json:"cipher-enum,omitempty"`
+	// GroupEnum is the list of accepted key exchange groups per tls version
+	GroupEnum []GroupEnumResponse `json:"group-enum,omitempty"`
+	// SignatureEnum is the list of accepted signature algorithms per tls version
+	SignatureEnum []SignatureEnumResponse `json:"signature-enum,omitempty"`
+	// Duplicates is a list of serials and public keys shared with unrelated subjects
+	Duplicates []Duplicate `json:"duplicates,omitempty"`
+	// SNIMatrix is the certificate presented for each server name tested
+	// against the ip, only set for matrix records written after the scan.
+	SNIMatrix []SNIMatrixEntry `json:"sni-matrix,omitempty"`
+	// Findings is a list of security findings with remediation hints
+	Findings []Finding `json:"findings,omitempty"`
+	// Error is the error for hosts which did not complete tls handshake
+	Error string `json:"error,omitempty"`
+	// Service is the detected plaintext service for failed handshakes
+	Service string `json:"service,omitempty"`
+	// Banner is the plaintext banner returned by the service
+	Banner string `json:"banner,omitempty"`
+}
+
+// GroupEnumResponse is the list of key exchange groups accepted for a tls version
+type GroupEnumResponse struct {
+	// Version is the enumerated tls version
+	Version string `json:"version"`
+	// Groups is the list of accepted groups in preference order
+	Groups []string `json:"groups"`
+	// ServerPreference is true if the server enforces its own preference order
+	ServerPreference bool `json:"server-preference"`
+}
+
+// SignatureEnumResponse is the list of signature algorithms accepted for a tls version
+type SignatureEnumResponse struct {
+	// Version is the enumerated tls version
+	Version string `json:"version"`
+	// Algorithms is the list of accepted signature algorithms in preference order
+	Algorithms []string `json:"algorithms"`
+	// ServerPreference is true if the server enforces its own preference order
+	ServerPreference bool `json:"server-preference"`
+}
+
+// Duplicate is a serial number or public key shared with unrelated subjects
+type Duplicate struct {
+	// Type is the type of the duplicate value (serial or public-key)
+	Type string `json:"type"`
+	// Value is the duplicated serial number or public key pin
+	Value string `json:"value"`
+	// Hosts is the list of addresses presenting the value for other subjects
+	Hosts []string `json:"hosts"`
+}
+
+// CipherEnumResponse is the list of cipher suites accepted for a tls version
+type CipherEnumResponse struct {
+	// Version is the enumerated tls version
+	Version string `json:"version"`
+	// Ciphers is the list of accepted cipher suites in preference order
+	Ciphers []string `json:"ciphers"`
+	// ServerPreference is true if the server enforces its own preference order
+	ServerPreference bool `json:"server-preference"`
+}
+
+// EarlyDataResponse is the tls 1.3 early data (0-rtt) support of a server
+type EarlyDataResponse struct {
+	// MaxEarlyData is the maximum early data size allowed by session tickets
+	MaxEarlyData uint32 `json:"max-early-data"`
+	// Accepted is true if the server accepted early data on resumption
+	Accepted bool `json:"accepted"`
+}
+
+// RenegotiationResponse is the renegotiation support of a server
+type RenegotiationResponse struct {
+	// SecureRenegotiation is true if the server supports the renegotiation_info extension
+	SecureRenegotiation bool `json:"secure-renegotiation"`
+	// ClientInitiated is true if the server permits client-initiated renegotiation
+	ClientInitiated bool `json:"client-initiated"`
+}
+
+// DHParamsResponse is the dh parameters sent by the server for dhe suites
+type DHParamsResponse struct {
+	// Cipher is the negotiated dhe cipher suite
+	Cipher string `json:"cipher"`
+	// Bits is the size of the dh prime
+	Bits int `json:"bits"`
+	// CommonPrime is the name of the prime if it is a widely shared one
+	CommonPrime string `json:"common-prime,omitempty"`
+	// Logjam is true if the prime is under 2048 bits
+	Logjam bool `json:"logjam"`
+}
+
+// FallbackSCSVResponse is the tls_fallback_scsv support of the server
+type FallbackSCSVResponse struct {
+	// Supported is true if the server rejects the downgraded hello
+	Supported bool `json:"supported"`
+	// Version is the downgraded tls version offered
+	Version string `json:"version"`
+}
+
+// ExtendedMasterSecretResponse is the extended_master_secret support of the server
+type ExtendedMasterSecretResponse struct {
+	// Negotiated is true if the server negotiates the extension
+	Negotiated bool `json:"negotiated"`
+	// Required is true if the server refuses handshakes without the extension
+	Required bool `json:"required"`
+	// Version is the tls version the extension was offered with
+	Version string `json:"version"`
+}
+
+// ECHResponse is the encrypted client hello support of the server
+type ECHResponse struct {
+	// Configured is true if an ech configuration is published in dns
+	Configured bool `json:"configured"`
+	// PublicName is the public name of the published ech configuration
+	PublicName string `json:"public-name,omitempty"`
+	// Supported is true if the server accepted an ech handshake
+	Supported bool `json:"supported"`
+	// GreaseTolerated is true if the server accepts hello messages with grease ech
+	GreaseTolerated bool `json:"grease-tolerated"`
+}
+
+// ChainValidationResponse is the diagnosis of a presented chain
+type ChainValidationResponse struct {
+	// Valid is true if no problem was found with the chain
+	Valid bool `json:"valid"`
+	// Problems is the list of problems found with the chain
+	Problems []ChainProblem `json:"problems,omitempty"`
+	// AIAResolvable is true if the incomplete chain becomes trusted with
+	// the issuers fetched from the aia ca issuers urls
+	AIAResolvable bool `json:"aia-resolvable,omitempty"`
+	// AIAURLs is the list of ca issuers urls the missing issuers were fetched from
+	AIAURLs []string `json:"aia-urls,omitempty"`
+	// TrustStore is the name of the trust store the chain ends at a root of
+	TrustStore string `json:"trust-store,omitempty"`
+	// TrustStores is the trust of the chain in each store if multiple
+	// trust stores are specified
+	TrustStores []TrustStoreResult `json:"trust-stores,omitempty"`
+}
+
+// TrustStoreResult is the trust of a chain in a trust store
+type TrustStoreResult struct {
+	// Name is the name of the trust store
+	Name string `json:"name"`
+	// Trusted is true if the chain ends at a root of the store
+	Trusted bool `json:"trusted"`
+}
+
+// ChainProblem is a problem of a presented chain certificate
+type ChainProblem struct {
+	// Type is the problem type (untrusted-root, incomplete-chain, expired,
+	// not-yet-valid, wrong-order, unnecessary, hostname-mismatch, unparseable)
+	Type string `json:"type"`
+	// Index is the index of the offending certificate in the presented chain, the leaf is 0
+	Index int `json:"index"`
+	// Detail is the subject, missing issuer or hostname of the problem
+	Detail string `json:"detail,omitempty"`
+}
+
+// RevocationResponse is the revocation status of a leaf certificate
+// reported by the revocation services of its issuer.
+type RevocationResponse struct {
+	// Revoked is true if any checked service reported the certificate revoked
+	Revoked bool `json:"revoked"`
+	// OCSP is the status reported by the ocsp responder of the certificate
+	OCSP *OCSPRevocationResponse `json:"ocsp,omitempty"`
+	// CRL is the status listed by the crl distribution point of the certificate
+	CRL *CRLRevocationResponse `json:"crl,omitempty"`
+}
+
+// OCSPRevocationResponse is the status reported by an ocsp responder
+type OCSPRevocationResponse struct {
+	// Responder is the url of the queried ocsp responder
+	Responder string `json:"responder,omitempty"`
+	// Status is the certificate status reported (good, revoked or unknown)
+	Status string `json:"status,omitempty"`
+	// ThisUpdate is the start of the response validity window
+	ThisUpdate *time.Time `json:"this-update,omitempty"`
+	// NextUpdate is the end of the response validity window
+	NextUpdate *time.Time `json:"next-update,omitempty"`
+	// RevokedAt is the time the certificate was revoked
+	RevokedAt *time.Time `json:"revoked-at,omitempty"`
+	// Reason is the revocation reason of revoked certificates
+	Reason string `json:"reason,omitempty"`
+	// Expired is true if the response is past its validity window
+	Expired bool `json:"expired,omitempty"`
+	// LatencyMS is the time the responder took to answer in milliseconds
+	LatencyMS int64 `json:"latency-ms,omitempty"`
+	// Error is the error encountered querying the responder
+	Error string `json:"error,omitempty"`
+}
+
+// CRLRevocationResponse is the status listed by a crl distribution point
+type CRLRevocationResponse struct {
+	// URL is the url of the distribution point the crl was retrieved from
+	URL string `json:"url,omitempty"`
+	// Status is the certificate status listed (good or revoked)
+	Status string `json:"status,omitempty"`
+	// ThisUpdate is the issue time of the crl
+	ThisUpdate *time.Time `json:"this-update,omitempty"`
+	// NextUpdate is the time the next crl is issued by
+	NextUpdate *time.Time `json:"next-update,omitempty"`
+	// RevokedAt is the time the certificate was revoked
+	RevokedAt *time.Time `json:"revoked-at,omitempty"`
+	// Reason is the revocation reason of revoked certificates
+	Reason string `json:"reason,omitempty"`
+	// Expired is true if the crl is past its next update
+	Expired bool `json:"expired,omitempty"`
+	// Cached is true if the crl was read from the cache instead of downloaded
+	Cached bool `json:"cached,omitempty"`
+	// LatencyMS is the time the crl download took in milliseconds
+	LatencyMS int64 `json:"latency-ms,omitempty"`
+	// Error is the error encountered retrieving the crl
+	Error string `json:"error,omitempty"`
+}
+
+// CTResponse is the certificate transparency status of a certificate
+type CTResponse struct {
+	// SCTs is the list of signed certificate timestamps presented
+	SCTs []SCTResponse `json:"scts,omitempty"`
+	// Chrome is the compliance with the chrome ct policy (compliant, not-compliant, unknown)
+	Chrome string `json:"chrome"`
+	// Apple is the compliance with the apple ct policy (compliant, not-compliant, unknown)
+	Apple string `json:"apple"`
+}
+
+// SCTResponse is a signed certificate timestamp of a certificate
+type SCTResponse struct {
+	// Source is how the timestamp was delivered (embedded, tls, ocsp)
+	Source string `json:"source"`
+	// LogID is the base64 encoded id of the log issuing the timestamp
+	LogID string `json:"log-id"`
+	// Log is the description of the log, empty if not in the log list
+	Log string `json:"log,omitempty"`
+	// Operator is the operator of the log
+	Operator string `json:"operator,omitempty"`
+	// LogState is the current state of the log (usable, readonly, retired, ...)
+	LogState string `json:"log-state,omitempty"`
+	// Timestamp is the time the timestamp was issued
+	Timestamp time.Time `json:"timestamp"`
+	// Signature is the status of the signature of the timestamp with the
+	// key of its log (valid, invalid, unverified)
+	Signature string `json:"signature"`
+}
+
+// CTSearchResponse is the certificates recently logged for a hostname
+// other than the presented certificate
+type CTSearchResponse struct {
+	// Certificates is the list of logged certificates, most recent first
+	Certificates []CTSearchCertificate `json:"certificates,omitempty"`
+	// Issuers is the list of distinct issuers of the logged certificates
+	Issuers []string `json:"issuers,omitempty"`
+	// OtherIssuers is the number of certificates of another issuer organization
+	OtherIssuers int `json:"other-issuers,omitempty"`
+}
+
+// CTSearchCertificate is a certificate logged for a hostname
+type CTSearchCertificate struct {
+	// ID is the id of the certificate in the search
+	ID int64 `json:"id,omitempty"`
+	// Serial is the hex encoded serial number of the certificate
+	Serial string `json:"serial,omitempty"`
+	// IssuerDN is the distinguished name of the issuer
+	IssuerDN string `json:"issuer-dn,omitempty"`
+	// SubjectCN is the common name of the certificate
+	SubjectCN string `json:"subject-cn,omitempty"`
+	// SubjectAN is the list of names of the certificate
+	SubjectAN []string `json:"subject-an,omitempty"`
+	// NotBefore is the start of the validity of the certificate
+	NotBefore time.Time `json:"not-before,omitempty"`
+	// NotAfter is the end of the validity of the certificate
+	NotAfter time.Time `json:"not-after,omitempty"`
+	// LoggedAt is the time the certificate was logged
+	LoggedAt time.Time `json:"logged-at,omitempty"`
+	// OtherIssuer is true if the issuer organization differs from the presented certificate
+	OtherIssuer bool `json:"other-issuer,omitempty"`
+}
+
+// AttributionResponse is the attribution of certificate names to the
+// organization of the scanned hostname and scope.
+type AttributionResponse struct {
+	// Names is the list of scored certificate names
+	Names []AttributedName `json:"names,omitempty"`
+	// Attributed is the list of names attributed with high confidence
+	Attributed []string `json:"attributed,omitempty"`
+}
+
+// AttributedName is the attribution score of a certificate name
+type AttributedName struct {
+	// Name is the certificate name without wildcard label
+	Name string `json:"name"`
+	// Score is the likelihood of the name belonging to the organization
+	Score float64 `json:"score"`
+	// Reasons is the list of signals contributing to the score (scope, organization, ct)
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// DefaultCertificateResponse is the certificate presented without sni
+type DefaultCertificateResponse struct {
+	// Rejected is true if the server refused handshakes without sni
+	Rejected bool `json:"rejected,omitempty"`
+	// Differs is true if the certificate differs from the one presented with sni
+	Differs bool `json:"differs"`
+	// Certificate is the leaf certificate presented without sni
+	Certificate *CertificateResponse `json:"certificate,omitempty"`
+}
+
+// SIPDomainResponse is the sip domain validation of a certificate
+type SIPDomainResponse struct {
+	// Domain is the sip domain the server was looked up for
+	Domain string `json:"domain"`
+	// Identities is the list of sip domain identities of the certificate
+	Identities []string `json:"identities,omitempty"`
+	// Matched is true if an identity is the sip domain
+	Matched bool `json:"matched"`
+}
+
+// CertificateRequestResponse is the client certificate request of the server
+type CertificateRequestResponse struct {
+	// Required is true if the handshake fails without a client certificate
+	Required bool `json:"required"`
+	// AcceptableCAs is the list of certificate authority names accepted
+	AcceptableCAs []string `json:"acceptable-cas,omitempty"`
+}
+
+// HTTP2Response is the http/2 and grpc service confirmation of the server
+type HTTP2Response struct {
+	// Confirmed is true if the server answered the connection preface
+	Confirmed bool `json:"confirmed"`
+	// GRPC is true if the server answered a grpc request with a grpc status
+	GRPC bool `json:"grpc"`
+}
+
+// BrokerResponse is the message broker protocol confirmation of the server
+type BrokerResponse struct {
+	// Protocol is the broker protocol expected on the port
+	Protocol string `json:"protocol"`
+	// Confirmed is true if the server answered the protocol opening
+	Confirmed bool `json:"confirmed"`
+}
+
+// PostQuantumResponse is the post-quantum hybrid key exchange support of the server
+type PostQuantumResponse struct {
+	// Groups is the list of accepted hybrid groups
+	Groups []string `json:"groups,omitempty"`
+	// Negotiated is the group selected when offering hybrid and classical groups
+	Negotiated string `json:"negotiated,omitempty"`
+	// Hybrid is true if the negotiated group is a hybrid group
+	Hybrid bool `json:"hybrid"`
+}
+
+// SNIMatrixEntry is the certificate presented by an ip for a server name
+type SNIMatrixEntry struct {
+	// SNI is the server name sent in the handshake
+	SNI string `json:"sni"`
+	// FingerprintSHA256 is the sha256 fingerprint of the leaf certificate
+	FingerprintSHA256 string `json:"fingerprint-sha256"`
+}
+
+// QUICResponse is the response for a quic connection
+type QUICResponse struct {
+	// Version is the negotiated quic version
+	Version string `json:"version"`
+	// TransportParameters are the transport parameters sent by the server
+	TransportParameters map[string]uint64 `json:"transport-parameters,omitempty"`
+}
+
+// Finding is a security finding along with its remediation hints
+type Finding struct {
+	// ID is the identifier of the finding type
+	ID string `json:"id"`
+	// Title is the human readable title of the finding
+	Title string `json:"title,omitempty"`
+	// Remediation is the remediation text for the finding
+	Remediation string `json:"remediation,omitempty"`
+	// References is a list of reference links for the finding
+	References []string `json:"references,omitempty"`
 }
 
 // CertificateResponse is the response for a certificate
 type CertificateResponse struct {
 	// Expired specifies whether the certificate has expired
 	Expired bool `json:"expired,omitempty"`
+	// NotYetValid specifies whether the certificate is not valid yet
+	NotYetValid bool `json:"not-yet-valid,omitempty"`
+	// Expiring specifies whether the certificate expires within the expiring window
+	Expiring bool `json:"expiring,omitempty"`
 	// SelfSigned returns true if the certificate is self-signed
 	SelfSigned bool `json:"self-signed,omitempty"`
 	// NotBefore is the not-before time for certificate
@@ -138,8 +838,32 @@ type CertificateResponse struct {
 	IssuerOrg []string `json:"issuer-org,omitempty"`
 	// Emails is a list of Emails for the certificate
 	Emails []string `json:"emails,omitempty"`
+	// KeyAlgorithm is the public key algorithm of the certificate
+	KeyAlgorithm string `json:"key-algorithm,omitempty"`
+	// KeySize is the size of the public key in bits
+	KeySize int `json:"key-size,omitempty"`
+	// KeyType is the public key algorithm with its size or curve (RSA-2048, ECDSA-P256, Ed25519)
+	KeyType string `json:"key-type,omitempty"`
+	// WeakKey is true for rsa and dsa keys below 2048 bits and ecdsa keys below 256 bits
+	WeakKey bool `json:"weak-key,omitempty"`
+	// SignatureAlgorithm is the algorithm used to sign the certificate
+	SignatureAlgorithm string `json:"signature-algorithm,omitempty"`
+	// Serial is the hex encoded serial number of the certificate
+	Serial string `json:"serial,omitempty"`
 	// FingerprintHash is the hashes for certificate
 	FingerprintHash CertificateResponseFingerprintHash `json:"fingerprint-hash"`
+	// PinSHA256 is the base64 sha256 hash of the subject public key info
+	PinSHA256 string `json:"pin-sha256,omitempty"`
+	// Policies is a list of certificate policies
+	Policies []string `json:"policies,omitempty"`
+	// Extensions is a list of certificate extensions
+	Extensions []string `json:"extensions,omitempty"`
+	// ExtKeyUsage is a list of extended key usages of the certificate
+	ExtKeyUsage []string `json:"ext-key-usage,omitempty"`
+	// MustStaple is true if the certificate requires ocsp stapling
+	MustStaple bool `json:"must-staple,omitempty"`
+	// OCSP is the ocsp response stapled by the server for the leaf certificate
+	OCSP *OCSPResponse `json:"ocsp,omitempty"`
 }
 
 // CertificateDistinguishedName is a distinguished certificate name
@@ -161,6 +885,17 @@ type CertificateResponseFingerprintHash struct {
 	SHA1 string `json:"sha1,omitempty"`
 	// SHA256 is the sha256 hash for certificate
 	SHA256 string `json:"sha256,omitempty"`
+	// TLSH is the tlsh fuzzy hash for certificate
+	TLSH string `json:"tlsh,omitempty"`
+}
+
+// Dial connects to address through the proxy dialer if configured,
+// otherwise directly using fastdialer.
+func Dial(ctx context.Context, options *Options, address string) (net.Conn, error) {
+	if options.ProxyDialer != nil {
+		return options.ProxyDialer.Dial(ctx, "tcp", address)
+	}
+	return options.Fastdialer.Dial(ctx, "tcp", address)
 }
 
 // MD5Fingerprint creates a fingerprint of data using the MD5 hash algorithm.
@@ -182,18 +917,133 @@ func SHA256Fingerprint(data []byte) string {
 	return hex.EncodeToString(sum[:])
 }
 
-// IsExpired returns true if the certificate has expired
-func IsExpired(notAfter time.Time) bool {
-	remaining := math.Round(time.Since(notAfter).Seconds())
+// SPKIPinSHA256 creates a hpkp style pin of data which is the
+// base64 encoded sha256 hash of the subject public key info.
+func SPKIPinSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SerialNumber returns the hex encoded serial number of a certificate
+func SerialNumber(serial *big.Int) string {
+	if serial == nil {
+		return ""
+	}
+	return hex.EncodeToString(serial.Bytes())
+}
+
+// IsExpired returns true if the certificate has expired at now
+func IsExpired(notAfter, now time.Time) bool {
+	remaining := math.Round(now.Sub(notAfter).Seconds())
 	return remaining > 0
 }
 
-// IsSelfSigned returns true if the certificate is self-signed
-//
-// follows: https://security.stackexchange.com/a/162263/250973
-func IsSelfSigned(authorityKeyID, subjectKeyID []byte) bool {
-	if len(authorityKeyID) == 0 || bytes.Equal(authorityKeyID, subjectKeyID) {
-		return true
+// IsNotYetValid returns true if the certificate is not valid yet at now
+func IsNotYetValid(notBefore, now time.Time) bool {
+	return now.Before(notBefore)
+}
+
+// HandshakeOptions returns options with the connection settings of
+// options and no probes, for single handshakes done besides a scan.
+func (options *Options) HandshakeOptions() *Options {
+	return &Options{
+		ServerName:              options.ServerName,
+		Verbose:                 options.Verbose,
+		Silent:                  options.Silent,
+		StartTLS:                options.StartTLS,
+		StartTLSPorts:           options.StartTLSPorts,
+		AutoStartTLS:            options.AutoStartTLS,
+		QUIC:                    options.QUIC,
+		Timeout:                 options.Timeout,
+		Ciphers:                 options.Ciphers,
+		ALPN:                    options.ALPN,
+		CACertificate:           options.CACertificate,
+		CAFile:                  options.CAFile,
+		CADir:                   options.CADir,
+		ClientCertificate:       options.ClientCertificate,
+		ClientKey:               options.ClientKey,
+		ClientPKCS12:            options.ClientPKCS12,
+		ClientPKCS12Password:    options.ClientPKCS12Password,
+		ClientPKCS11:            options.ClientPKCS11,
+		ClientCertStore:         options.ClientCertStore,
+		CACertStore:             options.CACertStore,
+		MinVersion:              options.MinVersion,
+		MaxVersion:              options.MaxVersion,
+		Resolvers:               options.Resolvers,
+		Proxy:                   options.Proxy,
+		ScanMode:                options.ScanMode,
+		OpenSSLBinary:           options.OpenSSLBinary,
+		VerifyServerCertificate: options.VerifyServerCertificate,
+		ValidationTime:          options.ValidationTime,
+		ValidationAt:            options.ValidationAt,
+		Fastdialer:              options.Fastdialer,
+		ProxyDialer:             options.ProxyDialer,
+		OIDRegistry:             options.OIDRegistry,
+	}
+}
+
+// List of prefix lengths of the subnets hosts are grouped by
+const (
+	SubnetPrefixIPv4 = 24
+	SubnetPrefixIPv6 = 56
+)
+
+// Subnet returns the /24 network of ipv4 addresses and the /56 network
+// of ipv6 addresses, nil if value is not an ip address.
+func Subnet(value string) *net.IPNet {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		mask := net.CIDRMask(SubnetPrefixIPv4, 32)
+		return &net.IPNet{IP: ipv4.Mask(mask), Mask: mask}
+	}
+	mask := net.CIDRMask(SubnetPrefixIPv6, 128)
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// Now returns the time certificate validity is evaluated at
+func Now(options *Options) time.Time {
+	if !options.ValidationAt.IsZero() {
+		return options.ValidationAt
+	}
+	return time.Now()
+}
+
+// IsExpiring returns true if the certificate has not expired at now
+// and expires within window
+func IsExpiring(notAfter, now time.Time, window time.Duration) bool {
+	return window > 0 && !IsExpired(notAfter, now) && notAfter.Sub(now) <= window
+}
+
+// ParseWindow parses a time window as a number of days (30d), weeks (2w)
+// or a duration (12h)
+func ParseWindow(value string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	default:
+		window, err := time.ParseDuration(value)
+		if err == nil && window <= 0 {
+			err = errors.Errorf("window must be positive: %s", value)
+		}
+		return window, err
+	}
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || count <= 0 {
+		return 0, errors.Errorf("invalid window: %s", value)
+	}
+	return time.Duration(count) * unit, nil
+}
+
+// ParseValidationTime parses a validation time as a date or rfc3339 time
+func ParseValidationTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, nil
 	}
-	return false
+	return time.Parse(time.RFC3339, value)
 }
@@ -0,0 +1,277 @@
+package clients_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/openssl"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/quic"
+	ctls "github.com/projectdiscovery/tlsx/pkg/tlsx/tls"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ztls"
+	ztlslib "github.com/zmap/zcrypto/tls"
+)
+
+// fixtureChain is a leaf and intermediate certificate presented by a server
+type fixtureChain struct {
+	raw    [][]byte
+	parsed []*x509.Certificate
+}
+
+// newFixtureChain creates a leaf certificate with subject alternative
+// names signed by an intermediate of a root certificate
+func newFixtureChain(t *testing.T) *fixtureChain {
+	t.Helper()
+
+	notBefore := time.Now().Add(-time.Hour).Truncate(time.Second)
+	issue := func(template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("could not generate key: %s", err)
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		template.NotBefore = notBefore
+		template.NotAfter = notBefore.Add(90 * 24 * time.Hour)
+		raw, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+		if err != nil {
+			t.Fatalf("could not create certificate: %s", err)
+		}
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			t.Fatalf("could not parse certificate: %s", err)
+		}
+		return cert, key
+	}
+
+	root, rootKey := issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Conformance Root", Organization: []string{"Conformance"}},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	intermediate, intermediateKey := issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Conformance Intermediate", Organization: []string{"Conformance"}},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, root, rootKey)
+	leaf, _ := issue(&x509.Certificate{
+		SerialNumber:   big.NewInt(3),
+		Subject:        pkix.Name{CommonName: "conformance.test", Organization: []string{"Conformance Test"}},
+		DNSNames:       []string{"conformance.test", "www.conformance.test"},
+		EmailAddresses: []string{"admin@conformance.test"},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, intermediate, intermediateKey)
+
+	return &fixtureChain{
+		raw:    [][]byte{leaf.Raw, intermediate.Raw},
+		parsed: []*x509.Certificate{leaf, intermediate},
+	}
+}
+
+// fixtureSuite is a negotiated cipher suite with its name in openssl
+type fixtureSuite struct {
+	id      uint16
+	openssl string
+}
+
+// handshakes returns the handshake of each backend for the same chain,
+// version and cipher suite
+func (f *fixtureChain) handshakes(version uint16, suite fixtureSuite, options *clients.Options) map[string]*clients.Handshake {
+	handshakes := make(map[string]*clients.Handshake)
+
+	state := tls.ConnectionState{
+		Version:            version,
+		CipherSuite:        suite.id,
+		NegotiatedProtocol: "h2",
+		PeerCertificates:   f.parsed,
+	}
+	handshakes["ctls"] = ctls.NewHandshake(state)
+	if version == tls.VersionTLS13 {
+		handshakes["quic"] = quic.NewHandshake(state)
+	} else {
+		// zcrypto does not support tls 1.3
+		hl := &ztlslib.ServerHandshake{
+			ServerHello: &ztlslib.ServerHello{
+				Version:      ztlslib.TLSVersion(version),
+				CipherSuite:  ztlslib.CipherSuite(suite.id),
+				AlpnProtocol: "h2",
+			},
+			ServerCertificates: &ztlslib.Certificates{
+				Certificate: ztlslib.SimpleCertificate{Raw: f.raw[0]},
+			},
+		}
+		for _, raw := range f.raw[1:] {
+			hl.ServerCertificates.Chain = append(hl.ServerCertificates.Chain, ztlslib.SimpleCertificate{Raw: raw})
+		}
+		handshakes["ztls"] = ztls.NewHandshake(hl, nil)
+	}
+	handshakes["openssl"] = openssl.NewHandshake(f.opensslOutput(version, suite), options)
+	return handshakes
+}
+
+// opensslOutput returns the output of s_client -showcerts for the chain
+func (f *fixtureChain) opensslOutput(version uint16, suite fixtureSuite) []byte {
+	protocol := map[uint16]string{
+		tls.VersionTLS10: "TLSv1",
+		tls.VersionTLS11: "TLSv1.1",
+		tls.VersionTLS12: "TLSv1.2",
+		tls.VersionTLS13: "TLSv1.3",
+	}[version]
+
+	output := &bytes.Buffer{}
+	output.WriteString("CONNECTED(00000003)\n---\nCertificate chain\n")
+	for i, cert := range f.parsed {
+		fmt.Fprintf(output, " %d s:CN = %s\n   i:CN = %s\n", i, cert.Subject.CommonName, cert.Issuer.CommonName)
+		_ = pem.Encode(output, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	fmt.Fprintf(output, "---\nNew, %s, Cipher is %s\nALPN protocol: h2\n", protocol, suite.openssl)
+	fmt.Fprintf(output, "SSL-Session:\n    Protocol  : %s\n    Cipher    : %s\n---\nDONE\n", protocol, suite.openssl)
+	return output.Bytes()
+}
+
+// responseFields returns the json fields of a response without those
+// expected to differ between backends
+func responseFields(t *testing.T, response *clients.Response) map[string]interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("could not marshal response: %s", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("could not unmarshal response: %s", err)
+	}
+	delete(fields, "timestamp")
+	delete(fields, "tls-connection")
+	return fields
+}
+
+func TestNewResponseConformance(t *testing.T) {
+	chain := newFixtureChain(t)
+	options := &clients.Options{
+		TLSChain:           true,
+		Hash:               "md5,sha1,sha256",
+		SignatureAlgorithm: true,
+		KeyType:            true,
+	}
+
+	tests := []struct {
+		name    string
+		version uint16
+		suite   fixtureSuite
+		cipher  string
+	}{
+		{"tls12-aes-gcm", tls.VersionTLS12, fixtureSuite{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, "ECDHE-ECDSA-AES128-GCM-SHA256"}, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		{"tls12-chacha20", tls.VersionTLS12, fixtureSuite{tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, "ECDHE-ECDSA-CHACHA20-POLY1305"}, "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256"},
+		{"tls12-aes-cbc", tls.VersionTLS12, fixtureSuite{tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA, "ECDHE-ECDSA-AES256-SHA"}, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA"},
+		{"tls12-3des", tls.VersionTLS12, fixtureSuite{tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA, "DES-CBC3-SHA"}, "TLS_RSA_WITH_3DES_EDE_CBC_SHA"},
+		{"tls13", tls.VersionTLS13, fixtureSuite{tls.TLS_AES_128_GCM_SHA256, "TLS_AES_128_GCM_SHA256"}, "TLS_AES_128_GCM_SHA256"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			handshakes := chain.handshakes(test.version, test.suite, options)
+			var backends []string
+			for backend := range handshakes {
+				backends = append(backends, backend)
+			}
+			sort.Strings(backends)
+
+			responses := make(map[string]*clients.Response)
+			for _, backend := range backends {
+				handshake := handshakes[backend]
+				handshake.Host, handshake.Port = "conformance.test", "443"
+				response, err := clients.NewResponse(options, handshake)
+				if err != nil {
+					t.Fatalf("%s: could not build response: %s", backend, err)
+				}
+				if response.TLSConnection != backend {
+					t.Errorf("%s: got tls connection %q", backend, response.TLSConnection)
+				}
+				responses[backend] = response
+			}
+
+			// values normalized by every backend
+			leafSHA256 := sha256.Sum256(chain.raw[0])
+			expectedVersion := map[uint16]string{tls.VersionTLS12: "tls12", tls.VersionTLS13: "tls13"}[test.version]
+			for backend, response := range responses {
+				if response.Version != expectedVersion {
+					t.Errorf("%s: got version %q, want %q", backend, response.Version, expectedVersion)
+				}
+				if response.Cipher != test.cipher {
+					t.Errorf("%s: got cipher %q, want %q", backend, response.Cipher, test.cipher)
+				}
+				if response.ALPN != "h2" {
+					t.Errorf("%s: got alpn %q, want h2", backend, response.ALPN)
+				}
+				if !reflect.DeepEqual(response.SubjectAN, chain.parsed[0].DNSNames) {
+					t.Errorf("%s: got sans %v, want %v", backend, response.SubjectAN, chain.parsed[0].DNSNames)
+				}
+				if response.FingerprintHash.SHA256 != hex.EncodeToString(leafSHA256[:]) {
+					t.Errorf("%s: got sha256 %q", backend, response.FingerprintHash.SHA256)
+				}
+				if len(response.Chain) != len(chain.raw) {
+					t.Errorf("%s: got chain of %d certificates, want %d", backend, len(response.Chain), len(chain.raw))
+				}
+			}
+
+			// every field is identical across backends
+			reference := backends[0]
+			referenceFields := responseFields(t, responses[reference])
+			for _, backend := range backends[1:] {
+				fields := responseFields(t, responses[backend])
+				for name := range referenceFields {
+					if _, ok := fields[name]; !ok {
+						t.Errorf("%s: field %q of %s is missing", backend, name, reference)
+					}
+				}
+				for name, value := range fields {
+					referenceValue, ok := referenceFields[name]
+					if !ok {
+						t.Errorf("%s: field %q is missing from %s", backend, name, reference)
+						continue
+					}
+					if !reflect.DeepEqual(value, referenceValue) {
+						t.Errorf("%s: field %q is %v, %s has %v", backend, name, value, reference, referenceValue)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewResponseNoCertificates(t *testing.T) {
+	options := &clients.Options{}
+	handshakes := map[string]*clients.Handshake{
+		"ctls":    ctls.NewHandshake(tls.ConnectionState{Version: tls.VersionTLS12}),
+		"quic":    quic.NewHandshake(tls.ConnectionState{}),
+		"ztls":    ztls.NewHandshake(&ztlslib.ServerHandshake{ServerHello: &ztlslib.ServerHello{}}, nil),
+		"openssl": openssl.NewHandshake([]byte("CONNECTED(00000003)\n---\nno peer certificate available\n"), options),
+	}
+	for backend, handshake := range handshakes {
+		if _, err := clients.NewResponse(options, handshake); err == nil {
+			t.Errorf("%s: expected an error for a handshake without certificates", backend)
+		}
+	}
+}
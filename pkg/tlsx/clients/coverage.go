@@ -0,0 +1,101 @@
+package clients
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// List of hostname coverage values
+const (
+	// CoverageSAN is used when a subject alternative name equals the hostname
+	CoverageSAN = "san"
+	// CoverageWildcardSAN is used when only wildcard subject alternative
+	// names match the hostname
+	CoverageWildcardSAN = "wildcard-san"
+	// CoverageCN is used when only the subject common name matches the
+	// hostname, which is ignored by clients if the certificate has
+	// subject alternative names.
+	CoverageCN = "cn"
+	// CoverageNone is used when no name of the certificate matches
+	CoverageNone = "none"
+)
+
+// HostnameCoverageResponse is the coverage of a hostname by a certificate
+type HostnameCoverageResponse struct {
+	// Hostname is the hostname the coverage is reported for
+	Hostname string `json:"hostname"`
+	// Coverage is how the hostname is covered (san, wildcard-san, cn, none)
+	Coverage string `json:"coverage"`
+	// MatchedName is the certificate name matching the hostname
+	MatchedName string `json:"matched-name,omitempty"`
+	// UnrelatedNames is the list of certificate names outside the
+	// registered domain of the hostname
+	UnrelatedNames []string `json:"unrelated-names,omitempty"`
+}
+
+// HostnameCoverage returns how hostname is covered by the names of cert.
+// Exact subject alternative names take precedence over wildcards, and the
+// common name is only used if no subject alternative name matches.
+func HostnameCoverage(hostname string, cert *CertificateResponse) *HostnameCoverageResponse {
+	hostname = NormalizeName(hostname)
+	response := &HostnameCoverageResponse{Hostname: hostname, Coverage: CoverageNone}
+
+	for _, name := range cert.SubjectAN {
+		if NormalizeName(name) == hostname {
+			response.Coverage, response.MatchedName = CoverageSAN, name
+			break
+		}
+		if response.MatchedName == "" && matchWildcard(NormalizeName(name), hostname) {
+			response.Coverage, response.MatchedName = CoverageWildcardSAN, name
+		}
+	}
+	if response.Coverage == CoverageNone && cert.SubjectCN != "" {
+		cn := NormalizeName(cert.SubjectCN)
+		if cn == hostname || matchWildcard(cn, hostname) {
+			response.Coverage, response.MatchedName = CoverageCN, cert.SubjectCN
+		}
+	}
+
+	domain := RegisteredDomain(hostname)
+	seen := make(map[string]struct{})
+	for _, name := range append([]string{cert.SubjectCN}, cert.SubjectAN...) {
+		normalized := NormalizeName(name)
+		if normalized == "" {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		if RegisteredDomain(strings.TrimPrefix(normalized, "*.")) != domain {
+			response.UnrelatedNames = append(response.UnrelatedNames, name)
+		}
+	}
+	return response
+}
+
+// matchWildcard returns true if pattern is a wildcard name matching
+// exactly one leftmost label of hostname.
+func matchWildcard(pattern, hostname string) bool {
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+	index := strings.Index(hostname, ".")
+	return index > 0 && hostname[index+1:] == pattern[2:]
+}
+
+// RegisteredDomain returns the public suffix plus one label of name,
+// or name itself if it has none such as for single label names.
+func RegisteredDomain(name string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return name
+	}
+	return domain
+}
+
+// NormalizeName returns name in lowercase without a trailing dot
+func NormalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
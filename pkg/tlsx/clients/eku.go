@@ -0,0 +1,48 @@
+package clients
+
+import "encoding/asn1"
+
+// extKeyUsageOID is the object identifier of the extended key usage extension
+const extKeyUsageOID = "2.5.29.37"
+
+// List of extended key usage names used by checks
+const (
+	ExtKeyUsageAny        = "any"
+	ExtKeyUsageServerAuth = "server_auth"
+)
+
+// extKeyUsageNames is the list of names of extended key usages
+var extKeyUsageNames = map[string]string{
+	"2.5.29.37.0":            ExtKeyUsageAny,
+	"1.3.6.1.5.5.7.3.1":      ExtKeyUsageServerAuth,
+	"1.3.6.1.5.5.7.3.2":      "client_auth",
+	"1.3.6.1.5.5.7.3.3":      "code_signing",
+	"1.3.6.1.5.5.7.3.4":      "email_protection",
+	"1.3.6.1.5.5.7.3.8":      "time_stamping",
+	"1.3.6.1.5.5.7.3.9":      "ocsp_signing",
+	"1.3.6.1.5.2.3.5":        "kdc_authentication",
+	"1.3.6.1.4.1.311.20.2.2": "smart_card_logon",
+	"1.3.6.1.4.1.311.10.3.4": "encrypting_file_system",
+}
+
+// ExtKeyUsages returns the names of the extended key usages of an
+// extension, nil if it is not an extended key usage extension. Unknown
+// usages are returned as dotted object identifiers.
+func ExtKeyUsages(oid string, value []byte) []string {
+	if oid != extKeyUsageOID {
+		return nil
+	}
+	var usages []asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(value, &usages); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(usages))
+	for _, usage := range usages {
+		if name, ok := extKeyUsageNames[usage.String()]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, usage.String())
+		}
+	}
+	return names
+}
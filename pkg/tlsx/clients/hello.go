@@ -0,0 +1,284 @@
+package clients
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	recordTypeHandshake        = 22
+	handshakeTypeClientHello   = 1
+	handshakeTypeServerHello   = 2
+	maxCapturedHandshakeLength = 64 * 1024
+)
+
+// HelloMessage is a raw ClientHello or ServerHello captured
+// from the wire during a tls handshake.
+type HelloMessage struct {
+	// Raw is the hex encoded raw handshake message
+	Raw string `json:"raw"`
+	// Version is the legacy version field of the hello message
+	Version uint16 `json:"version"`
+	// CipherSuites is the list of cipher suites offered or selected
+	CipherSuites []uint16 `json:"cipher-suites,omitempty"`
+	// Extensions is the list of extensions in the hello message
+	Extensions []HelloExtension `json:"extensions,omitempty"`
+}
+
+// HelloExtension is an extension present in a hello message
+type HelloExtension struct {
+	// ID is the numeric identifier of the extension
+	ID uint16 `json:"id"`
+	// Name is the name of the extension if it is known
+	Name string `json:"name,omitempty"`
+}
+
+// CaptureConn is a net.Conn which records the handshake records
+// written and read over the connection.
+type CaptureConn struct {
+	net.Conn
+
+	mutex   sync.Mutex
+	written []byte
+	read    []byte
+}
+
+// NewCaptureConn returns a new capturing connection wrapping conn
+func NewCaptureConn(conn net.Conn) *CaptureConn {
+	return &CaptureConn{Conn: conn}
+}
+
+// Read reads data from the connection recording it
+func (c *CaptureConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.mutex.Lock()
+		if len(c.read) < maxCapturedHandshakeLength {
+			c.read = append(c.read, b[:n]...)
+		}
+		c.mutex.Unlock()
+	}
+	return n, err
+}
+
+// Write writes data to the connection recording it
+func (c *CaptureConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	if len(c.written) < maxCapturedHandshakeLength {
+		c.written = append(c.written, b...)
+	}
+	c.mutex.Unlock()
+	return c.Conn.Write(b)
+}
+
+// ClientHello returns the captured ClientHello message if any
+func (c *CaptureConn) ClientHello() *HelloMessage {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	message := firstHandshakeMessage(c.written, handshakeTypeClientHello)
+	if message == nil {
+		return nil
+	}
+	return ParseHelloMessage(message)
+}
+
+// ServerHello returns the captured ServerHello message if any
+func (c *CaptureConn) ServerHello() *HelloMessage {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	message := firstHandshakeMessage(c.read, handshakeTypeServerHello)
+	if message == nil {
+		return nil
+	}
+	return ParseHelloMessage(message)
+}
+
+// firstHandshakeMessage reassembles handshake records from data
+// and returns the first handshake message of the requested type.
+func firstHandshakeMessage(data []byte, messageType byte) []byte {
+	var handshake []byte
+	for len(data) >= 5 {
+		length := int(binary.BigEndian.Uint16(data[3:5]))
+		if len(data) < 5+length {
+			break
+		}
+		if data[0] == recordTypeHandshake {
+			handshake = append(handshake, data[5:5+length]...)
+		} else if len(handshake) > 0 {
+			// Records after the first non-handshake record are encrypted
+			break
+		}
+		data = data[5+length:]
+	}
+	for len(handshake) >= 4 {
+		length := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		if len(handshake) < 4+length {
+			return nil
+		}
+		if handshake[0] == messageType {
+			return handshake[:4+length]
+		}
+		handshake = handshake[4+length:]
+	}
+	return nil
+}
+
+// ParseHelloMessage parses a raw ClientHello or ServerHello handshake
+// message including its header. Fields which cannot be parsed are left empty.
+func ParseHelloMessage(message []byte) *HelloMessage {
+	hello := &HelloMessage{Raw: hex.EncodeToString(message)}
+	if len(message) < 4+2+32+1 {
+		return hello
+	}
+	isClientHello := message[0] == handshakeTypeClientHello
+	hello.Version = binary.BigEndian.Uint16(message[4:6])
+
+	data := message[4+2+32:]
+	sessionIDLength := int(data[0])
+	if len(data) < 1+sessionIDLength {
+		return hello
+	}
+	data = data[1+sessionIDLength:]
+
+	if isClientHello {
+		if len(data) < 2 {
+			return hello
+		}
+		suitesLength := int(binary.BigEndian.Uint16(data[0:2]))
+		if len(data) < 2+suitesLength {
+			return hello
+		}
+		for i := 2; i+1 < 2+suitesLength; i += 2 {
+			hello.CipherSuites = append(hello.CipherSuites, binary.BigEndian.Uint16(data[i:i+2]))
+		}
+		data = data[2+suitesLength:]
+		if len(data) < 1 || len(data) < 1+int(data[0]) {
+			return hello
+		}
+		data = data[1+int(data[0]):]
+	} else {
+		if len(data) < 3 {
+			return hello
+		}
+		hello.CipherSuites = append(hello.CipherSuites, binary.BigEndian.Uint16(data[0:2]))
+		data = data[3:]
+	}
+
+	if len(data) < 2 {
+		return hello
+	}
+	extensionsLength := int(binary.BigEndian.Uint16(data[0:2]))
+	data = data[2:]
+	if len(data) < extensionsLength {
+		return hello
+	}
+	data = data[:extensionsLength]
+	for len(data) >= 4 {
+		id := binary.BigEndian.Uint16(data[0:2])
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if len(data) < 4+length {
+			break
+		}
+		hello.Extensions = append(hello.Extensions, HelloExtension{ID: id, Name: ExtensionName(id)})
+		data = data[4+length:]
+	}
+	return hello
+}
+
+// ExtensionName returns the name for a tls extension identifier
+func ExtensionName(id uint16) string {
+	if name, ok := extensionNames[id]; ok {
+		return name
+	}
+	if id&0x0f0f == 0x0a0a {
+		return "grease"
+	}
+	return ""
+}
+
+var extensionNames = map[uint16]string{
+	0:     "server_name",
+	1:     "max_fragment_length",
+	5:     "status_request",
+	10:    "supported_groups",
+	11:    "ec_point_formats",
+	13:    "signature_algorithms",
+	15:    "heartbeat",
+	16:    "application_layer_protocol_negotiation",
+	18:    "signed_certificate_timestamp",
+	21:    "padding",
+	22:    "encrypt_then_mac",
+	23:    "extended_master_secret",
+	27:    "compress_certificate",
+	28:    "record_size_limit",
+	35:    "session_ticket",
+	41:    "pre_shared_key",
+	42:    "early_data",
+	43:    "supported_versions",
+	44:    "cookie",
+	45:    "psk_key_exchange_modes",
+	47:    "certificate_authorities",
+	49:    "post_handshake_auth",
+	50:    "signature_algorithms_cert",
+	51:    "key_share",
+	13172: "next_protocol_negotiation",
+	17513: "application_settings",
+	65037: "encrypted_client_hello",
+	65281: "renegotiation_info",
+}
+
+// JA3S returns the ja3s fingerprint hash for a server hello message
+//
+// follows: https://github.com/salesforce/ja3
+func JA3S(hello *HelloMessage) string {
+	if hello == nil || len(hello.CipherSuites) == 0 {
+		return ""
+	}
+	extensions := make([]string, 0, len(hello.Extensions))
+	for _, extension := range hello.Extensions {
+		extensions = append(extensions, strconv.Itoa(int(extension.ID)))
+	}
+	value := fmt.Sprintf("%d,%d,%s", hello.Version, hello.CipherSuites[0], strings.Join(extensions, "-"))
+	return MD5Fingerprint([]byte(value))
+}
+
+// Uint16Bytes returns the two byte big endian encoding of value
+func Uint16Bytes(value int) []byte {
+	return []byte{byte(value >> 8), byte(value)}
+}
+
+// AppendExtension appends an extension with its id and length to data
+func AppendExtension(data []byte, id uint16, value []byte) []byte {
+	data = append(data, Uint16Bytes(int(id))...)
+	data = append(data, Uint16Bytes(len(value))...)
+	return append(data, value...)
+}
+
+// ServerNameExtension returns the value of the server_name extension
+// with a single host name
+func ServerNameExtension(serverName string) []byte {
+	entry := append([]byte{0}, Uint16Bytes(len(serverName))...)
+	entry = append(entry, serverName...)
+	return append(Uint16Bytes(len(entry)), entry...)
+}
+
+// HandshakeMessage returns a handshake message of type with its header
+func HandshakeMessage(messageType byte, body []byte) []byte {
+	message := []byte{messageType, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	return append(message, body...)
+}
+
+// HandshakeRecord returns a handshake record of version with message
+func HandshakeRecord(version uint16, message []byte) []byte {
+	record := []byte{recordTypeHandshake}
+	record = append(record, Uint16Bytes(int(version))...)
+	record = append(record, Uint16Bytes(len(message))...)
+	return append(record, message...)
+}
@@ -0,0 +1,106 @@
+package clients
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SharedHostingDomains is the number of unrelated registered domains in
+// the names of a certificate from which it is classified as shared hosting.
+// Registered domains with the same label, as example.com and example.de
+// of one organization, count once.
+const SharedHostingDomains = 100
+
+// HostingResponse is the hosting classification of a certificate
+type HostingResponse struct {
+	// Panel is the hosting panel which issued or generated the certificate
+	Panel string `json:"panel,omitempty"`
+	// Shared is true if the names cover many unrelated registered domains
+	Shared bool `json:"shared"`
+	// Domains is the number of registered domains in the names
+	Domains int `json:"domains"`
+}
+
+// hostingPanel is a hosting panel recognized by its certificates
+type hostingPanel struct {
+	name string
+	// subject is matched against the subject common name and organization
+	subject *regexp.Regexp
+	// issuer is matched against the issuer common name and organization
+	issuer *regexp.Regexp
+	// services is the list of service name prefixes added by the panel
+	services []string
+}
+
+// hostingPanels is the list of hosting panels classified
+var hostingPanels = []hostingPanel{
+	{
+		name:     "cpanel",
+		issuer:   regexp.MustCompile(`(?i)^cPanel, Inc\.`),
+		services: []string{"cpanel.", "webdisk.", "cpcalendars.", "cpcontacts.", "whm."},
+	},
+	{
+		name:    "plesk",
+		subject: regexp.MustCompile(`(?i)^(Plesk|Parallels( Panel)?)$`),
+	},
+	{
+		name:    "directadmin",
+		subject: regexp.MustCompile(`(?i)^DirectAdmin`),
+	},
+	{
+		name:    "webmin",
+		subject: regexp.MustCompile(`(?i)^Webmin Webserver on `),
+	},
+}
+
+// Hosting returns the hosting classification of cert, nil if the
+// certificate is neither from a hosting panel nor shared.
+func Hosting(cert *CertificateResponse) *HostingResponse {
+	domains := make(map[string]struct{})
+	labels := make(map[string]struct{})
+	for _, name := range append([]string{cert.SubjectCN}, cert.SubjectAN...) {
+		if normalized := strings.TrimPrefix(NormalizeName(name), "*."); normalized != "" {
+			domain := RegisteredDomain(normalized)
+			domains[domain] = struct{}{}
+			if index := strings.IndexByte(domain, '.'); index > 0 {
+				domain = domain[:index]
+			}
+			labels[domain] = struct{}{}
+		}
+	}
+	response := &HostingResponse{Panel: hostingPanelName(cert), Domains: len(domains)}
+	response.Shared = len(labels) >= SharedHostingDomains
+	if response.Panel == "" && !response.Shared {
+		return nil
+	}
+	return response
+}
+
+// hostingPanelName returns the name of the panel of cert, empty if none
+func hostingPanelName(cert *CertificateResponse) string {
+	for _, panel := range hostingPanels {
+		if panel.subject != nil && matchAnyValue(panel.subject, append([]string{cert.SubjectCN}, cert.SubjectOrg...)) {
+			return panel.name
+		}
+		if panel.issuer != nil && matchAnyValue(panel.issuer, append([]string{cert.IssuerCN}, cert.IssuerOrg...)) {
+			return panel.name
+		}
+		for _, name := range cert.SubjectAN {
+			for _, service := range panel.services {
+				if strings.HasPrefix(NormalizeName(name), service) {
+					return panel.name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func matchAnyValue(regex *regexp.Regexp, values []string) bool {
+	for _, value := range values {
+		if value != "" && regex.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// tlsFeatureOID is the oid of the tls feature certificate extension
+const tlsFeatureOID = "1.3.6.1.5.5.7.1.24"
+
+// statusRequestFeature is the status_request tls feature requiring stapling
+const statusRequestFeature = 5
+
+// OCSPResponse is the stapled ocsp response returned by a server
+type OCSPResponse struct {
+	// Stapled is true if the server stapled an ocsp response
+	Stapled bool `json:"stapled"`
+	// Status is the certificate status reported (good, revoked or unknown)
+	Status string `json:"status,omitempty"`
+	// ProducedAt is the time the response was signed
+	ProducedAt *time.Time `json:"produced-at,omitempty"`
+	// ThisUpdate is the start of the response validity window
+	ThisUpdate *time.Time `json:"this-update,omitempty"`
+	// NextUpdate is the end of the response validity window
+	NextUpdate *time.Time `json:"next-update,omitempty"`
+	// RevokedAt is the time the certificate was revoked
+	RevokedAt *time.Time `json:"revoked-at,omitempty"`
+	// Expired is true if the response is past its validity window
+	Expired bool `json:"expired,omitempty"`
+	// Error is the error encountered parsing the stapled response
+	Error string `json:"error,omitempty"`
+}
+
+// IsMustStapleExtension returns true if a certificate extension is a tls
+// feature extension requiring the status_request feature (must-staple).
+func IsMustStapleExtension(oid string, value []byte) bool {
+	if oid != tlsFeatureOID {
+		return false
+	}
+	var features []int
+	if _, err := asn1.Unmarshal(value, &features); err != nil {
+		return false
+	}
+	for _, feature := range features {
+		if feature == statusRequestFeature {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStapledOCSP parses a stapled ocsp response for the leaf of a raw
+// chain verifying its signature with the issuer if presented.
+func ParseStapledOCSP(staple []byte, rawChain [][]byte, now time.Time) *OCSPResponse {
+	if len(staple) == 0 {
+		return &OCSPResponse{}
+	}
+	var leaf, issuer *x509.Certificate
+	if len(rawChain) > 0 {
+		leaf, _ = x509.ParseCertificate(rawChain[0])
+	}
+	if leaf != nil && len(rawChain) > 1 {
+		issuer = FindIssuer(leaf, rawChain[1:])
+	}
+	parsed, err := ParseOCSPResponse(staple, issuer)
+	if err != nil {
+		return &OCSPResponse{Stapled: true, Error: err.Error()}
+	}
+	if leaf != nil && (parsed.SerialNumber == nil || parsed.SerialNumber.Cmp(leaf.SerialNumber) != 0) {
+		return &OCSPResponse{Stapled: true, Error: "ocsp response for another certificate"}
+	}
+	return NewStapledOCSP(OCSPStatus(parsed.Status), parsed.ProducedAt, parsed.ThisUpdate, parsed.NextUpdate, parsed.RevokedAt, now)
+}
+
+// FindIssuer returns the first presented certificate whose subject is
+// the issuer of cert and whose key identifier matches the authority key
+// identifier of cert if present, or nil if the issuer is not presented.
+func FindIssuer(cert *x509.Certificate, rawIssuers [][]byte) *x509.Certificate {
+	for _, raw := range rawIssuers {
+		issuer, err := x509.ParseCertificate(raw)
+		if err != nil || !bytes.Equal(issuer.RawSubject, cert.RawIssuer) {
+			continue
+		}
+		if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 && !bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId) {
+			continue
+		}
+		return issuer
+	}
+	return nil
+}
+
+// ParseOCSPResponse parses an ocsp response verifying its signature with
+// the issuer if not nil.
+func ParseOCSPResponse(data []byte, issuer *x509.Certificate) (*ocsp.Response, error) {
+	parsed, err := ocsp.ParseResponse(data, issuer)
+	if err != nil && issuer != nil {
+		// responses signed by the issuer may embed the issuer certificate
+		// which is then wrongly verified as a delegated responder.
+		if unverified, parseErr := ocsp.ParseResponse(data, nil); parseErr == nil && unverified.Certificate != nil && bytes.Equal(unverified.Certificate.Raw, issuer.Raw) {
+			parsed, err = unverified, nil
+		}
+	}
+	return parsed, err
+}
+
+// NewStapledOCSP returns a stapled ocsp response from parsed fields
+// checking its validity window at time now.
+func NewStapledOCSP(status string, producedAt, thisUpdate, nextUpdate, revokedAt time.Time, now time.Time) *OCSPResponse {
+	return &OCSPResponse{
+		Stapled:    true,
+		Status:     status,
+		ProducedAt: TimeOrNil(producedAt),
+		ThisUpdate: TimeOrNil(thisUpdate),
+		NextUpdate: TimeOrNil(nextUpdate),
+		RevokedAt:  TimeOrNil(revokedAt),
+		Expired:    !nextUpdate.IsZero() && now.After(nextUpdate),
+	}
+}
+
+// TimeOrNil returns a pointer to value or nil if value is zero
+func TimeOrNil(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	return &value
+}
+
+// OCSPStatus returns the name of an ocsp certificate status
+func OCSPStatus(status int) string {
+	switch status {
+	case ocsp.Good:
+		return "good"
+	case ocsp.Revoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
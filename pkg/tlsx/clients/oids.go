@@ -0,0 +1,92 @@
+package clients
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// OIDRegistry resolves object identifiers of certificate policies
+// and extensions to human readable names.
+type OIDRegistry struct {
+	names map[string]string
+}
+
+// NewOIDRegistry creates a new registry with the default names and
+// optionally names from a json file mapping object identifiers to names.
+func NewOIDRegistry(file string) (*OIDRegistry, error) {
+	registry := &OIDRegistry{names: make(map[string]string, len(defaultOIDNames))}
+	for oid, name := range defaultOIDNames {
+		registry.names[oid] = name
+	}
+	if file == "" {
+		return registry, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read oid file")
+	}
+	var names map[string]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, errors.Wrap(err, "could not parse oid file")
+	}
+	for oid, name := range names {
+		registry.names[oid] = name
+	}
+	return registry, nil
+}
+
+// Name returns the name for an object identifier or the dotted
+// identifier itself if it is not known.
+func (r *OIDRegistry) Name(oid string) string {
+	if r != nil {
+		if name, ok := r.names[oid]; ok {
+			return name
+		}
+	} else if name, ok := defaultOIDNames[oid]; ok {
+		return name
+	}
+	return oid
+}
+
+// Names returns the names for a list of object identifiers
+func (r *OIDRegistry) Names(oids []string) []string {
+	if len(oids) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		names = append(names, r.Name(oid))
+	}
+	return names
+}
+
+var defaultOIDNames = map[string]string{
+	// certificate extensions
+	"2.5.29.14":               "subject_key_identifier",
+	"2.5.29.15":               "key_usage",
+	"2.5.29.17":               "subject_alt_name",
+	"2.5.29.18":               "issuer_alt_name",
+	"2.5.29.19":               "basic_constraints",
+	"2.5.29.30":               "name_constraints",
+	"2.5.29.31":               "crl_distribution_points",
+	"2.5.29.32":               "certificate_policies",
+	"2.5.29.35":               "authority_key_identifier",
+	"2.5.29.37":               "extended_key_usage",
+	"1.3.6.1.5.5.7.1.1":       "authority_info_access",
+	"1.3.6.1.5.5.7.1.24":      "tls_feature",
+	"1.3.6.1.5.5.7.1.31":      "acme_identifier",
+	"1.3.6.1.4.1.11129.2.4.2": "signed_certificate_timestamps",
+	"1.3.6.1.4.1.11129.2.4.3": "precertificate_poison",
+	"1.3.6.1.4.1.311.20.2":    "microsoft_certificate_template_name",
+	"1.3.6.1.4.1.311.21.7":    "microsoft_certificate_template",
+	"1.3.6.1.4.1.311.21.10":   "microsoft_application_policies",
+	// certificate policies
+	"2.5.29.32.0":             "any_policy",
+	"2.23.140.1.1":            "extended_validation",
+	"2.23.140.1.2.1":          "domain_validated",
+	"2.23.140.1.2.2":          "organization_validated",
+	"2.23.140.1.2.3":          "individual_validated",
+	"1.3.6.1.4.1.44947.1.1.1": "isrg_domain_validated",
+}
@@ -0,0 +1,208 @@
+package clients
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	zx509 "github.com/zmap/zcrypto/x509"
+)
+
+// List of tls chain verbosity values
+const (
+	// ChainVerbosityFull includes every field of the chain certificates
+	ChainVerbosityFull = "full"
+	// ChainVerbosityBrief includes the names, validity, key and sha256
+	// fingerprint of the chain certificates
+	ChainVerbosityBrief = "brief"
+)
+
+// Handshake is the result of a completed handshake reported by a backend,
+// which is turned into a response by NewResponse.
+type Handshake struct {
+	// Host is the host the handshake was made with
+	Host string
+	// IP is the resolved address of host if host is not an ip
+	IP string
+	// Port is the port the handshake was made on
+	Port string
+	// Version is the negotiated tls version string
+	Version string
+	// Cipher is the iana name of the negotiated cipher suite
+	Cipher string
+	// ALPN is the negotiated application protocol
+	ALPN string
+	// TLSConnection is the name of the backend making the handshake
+	TLSConnection string
+	// RawChain is the presented der encoded chain with the leaf first
+	RawChain [][]byte
+	// OCSPStaple is the der encoded stapled ocsp response
+	OCSPStaple []byte
+	// OCSP is the stapled ocsp response for backends which do not
+	// expose the der encoded staple, overriding OCSPStaple.
+	OCSP *OCSPResponse
+	// SCTs is the list of signed certificate timestamps sent in the tls extension
+	SCTs [][]byte
+	// ClientCertificate is true if a client certificate was sent
+	ClientCertificate bool
+	// Capture is the connection capturing hello messages if enabled
+	Capture *CaptureConn
+}
+
+// NewResponse builds the response for a handshake. Every backend builds
+// its response here so that the fields are identical across scan modes.
+func NewResponse(options *Options, handshake *Handshake) (*Response, error) {
+	if len(handshake.RawChain) == 0 || len(handshake.RawChain[0]) == 0 {
+		return nil, errors.New("no certificates returned by server")
+	}
+	now := Now(options)
+
+	response := &Response{
+		Timestamp:           time.Now(),
+		Host:                handshake.Host,
+		IP:                  handshake.IP,
+		Port:                handshake.Port,
+		Version:             handshake.Version,
+		Cipher:              handshake.Cipher,
+		TLSConnection:       handshake.TLSConnection,
+		SNI:                 options.ServerName,
+		ClientCertificate:   handshake.ClientCertificate,
+		ALPN:                handshake.ALPN,
+		CertificateResponse: NewCertificateResponse(handshake.RawChain[0], options),
+		RawChain:            handshake.RawChain,
+	}
+	if options.CT {
+		response.RawSCTs = handshake.SCTs
+		response.RawOCSPStaple = handshake.OCSPStaple
+	}
+	if options.OCSP {
+		response.OCSP = handshake.OCSP
+		if response.OCSP == nil {
+			response.OCSP = ParseStapledOCSP(handshake.OCSPStaple, response.RawChain, now)
+		}
+	}
+	if options.RootStore != nil {
+		response.RootStore = options.RootStore.Evaluate(response.RawChain, now)
+	}
+	// signature flags and remediation hints need the brief chain to
+	// report the signatures of intermediates.
+	if options.TLSChain || options.SignatureAlgorithm || options.WeakSignature || options.RemediationHints {
+		for _, raw := range response.RawChain {
+			cert := NewCertificateResponse(raw, options)
+			if !options.TLSChain || options.ChainVerbosity == ChainVerbosityBrief {
+				cert = cert.Brief()
+			}
+			response.Chain = append(response.Chain, cert)
+		}
+	}
+	if handshake.Capture != nil {
+		serverHello := handshake.Capture.ServerHello()
+		response.JA3S = JA3S(serverHello)
+		if options.CaptureHello {
+			response.ClientHello = handshake.Capture.ClientHello()
+			response.ServerHello = serverHello
+		}
+	}
+	return response, nil
+}
+
+// NewCertificateResponse returns the response for a der encoded certificate.
+// Certificates are always parsed with zcrypto which accepts certificates
+// rejected by crypto/x509, an empty response is returned if parsing fails.
+func NewCertificateResponse(raw []byte, options *Options) CertificateResponse {
+	cert, err := zx509.ParseCertificate(raw)
+	if err != nil {
+		return CertificateResponse{}
+	}
+	now := Now(options)
+	response := CertificateResponse{
+		SubjectAN:          cert.DNSNames,
+		Emails:             cert.EmailAddresses,
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		Expired:            IsExpired(cert.NotAfter, now),
+		NotYetValid:        IsNotYetValid(cert.NotBefore, now),
+		Expiring:           IsExpiring(cert.NotAfter, now, options.ExpiringWindow),
+		SelfSigned:         IsSelfSignedCertificate(cert),
+		IssuerDN:           cert.Issuer.String(),
+		IssuerCN:           cert.Issuer.CommonName,
+		IssuerOrg:          cert.Issuer.Organization,
+		SubjectDN:          cert.Subject.String(),
+		SubjectCN:          cert.Subject.CommonName,
+		SubjectOrg:         cert.Subject.Organization,
+		KeyAlgorithm:       cert.PublicKeyAlgorithm.String(),
+		KeySize:            PublicKeySize(cert.PublicKey),
+		KeyType:            PublicKeyType(cert.PublicKeyAlgorithm.String(), cert.PublicKey),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		Serial:             SerialNumber(cert.SerialNumber),
+		FingerprintHash:    fingerprintHashes(cert.Raw, options.Hash),
+	}
+	// the pin identifies keys shared across hosts for duplicates and reports
+	if options.PinSHA256 || options.DetectDuplicates || options.ReportPDF != "" {
+		response.PinSHA256 = SPKIPinSHA256(cert.RawSubjectPublicKeyInfo)
+	}
+	response.WeakKey = IsWeakKey(response.KeyAlgorithm, response.KeySize)
+	for _, policy := range cert.PolicyIdentifiers {
+		response.Policies = append(response.Policies, options.OIDRegistry.Name(policy.String()))
+	}
+	for _, extension := range cert.Extensions {
+		response.Extensions = append(response.Extensions, options.OIDRegistry.Name(extension.Id.String()))
+		if IsMustStapleExtension(extension.Id.String(), extension.Value) {
+			response.MustStaple = true
+		}
+		if usages := ExtKeyUsages(extension.Id.String(), extension.Value); usages != nil {
+			response.ExtKeyUsage = usages
+		}
+	}
+	return response
+}
+
+// fingerprintHashes returns the sha256 fingerprint identifying a certificate
+// and the other fingerprint hashes of the comma separated hashes list.
+func fingerprintHashes(raw []byte, hashes string) CertificateResponseFingerprintHash {
+	fingerprints := CertificateResponseFingerprintHash{SHA256: SHA256Fingerprint(raw)}
+	for _, hash := range strings.Split(hashes, ",") {
+		switch hash {
+		case "md5":
+			fingerprints.MD5 = MD5Fingerprint(raw)
+		case "sha1":
+			fingerprints.SHA1 = SHA1Fingerprint(raw)
+		case "tlsh":
+			fingerprints.TLSH = TLSHFingerprint(raw)
+		}
+	}
+	return fingerprints
+}
+
+// Brief returns the certificate response with only the fields needed to
+// follow the composition of a chain.
+func (c CertificateResponse) Brief() CertificateResponse {
+	return CertificateResponse{
+		Expired:            c.Expired,
+		NotYetValid:        c.NotYetValid,
+		SelfSigned:         c.SelfSigned,
+		NotBefore:          c.NotBefore,
+		NotAfter:           c.NotAfter,
+		SubjectDN:          c.SubjectDN,
+		SubjectCN:          c.SubjectCN,
+		IssuerDN:           c.IssuerDN,
+		IssuerCN:           c.IssuerCN,
+		KeyAlgorithm:       c.KeyAlgorithm,
+		KeySize:            c.KeySize,
+		KeyType:            c.KeyType,
+		WeakKey:            c.WeakKey,
+		SignatureAlgorithm: c.SignatureAlgorithm,
+		Serial:             c.Serial,
+		FingerprintHash:    CertificateResponseFingerprintHash{SHA256: c.FingerprintHash.SHA256},
+	}
+}
+
+// IsSelfSignedCertificate returns true if the certificate is issued by
+// its own subject and its signature is verified with its own key.
+func IsSelfSignedCertificate(cert *zx509.Certificate) bool {
+	if !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+		return false
+	}
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -0,0 +1,95 @@
+package clients
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RootStore evaluates presented chains against the current trust
+// anchors and a hypothetical root store to simulate ca distrust.
+type RootStore struct {
+	current   *x509.CertPool
+	simulated *x509.CertPool
+}
+
+// RootStoreResponse is the result of a root store simulation for a chain
+type RootStoreResponse struct {
+	// Trusted is true if the chain is trusted by the current roots
+	Trusted bool `json:"trusted"`
+	// SimulatedTrusted is true if the chain is trusted by the simulated roots
+	SimulatedTrusted bool `json:"simulated-trusted"`
+	// Untrusted is true if the chain would become untrusted with the simulated roots
+	Untrusted bool `json:"untrusted,omitempty"`
+}
+
+// NewRootStore creates a root store simulation from a pem file of
+// hypothetical roots. The current roots are the system roots, or the
+// roots of the ca certificate, ca file and ca directory options if any
+// is specified.
+func NewRootStore(file string, options *Options) (*RootStore, error) {
+	simulated, err := readCertPool(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read root store")
+	}
+	store := &RootStore{simulated: simulated}
+	if options.CACertificate != "" {
+		if store.current, err = readCertPool(options.CACertificate); err != nil {
+			return nil, errors.Wrap(err, "could not read ca certificate")
+		}
+	}
+	if store.current, err = AppendCustomRoots(store.current, options); err != nil {
+		return nil, err
+	}
+	if store.current == nil {
+		if store.current, err = x509.SystemCertPool(); err != nil {
+			return nil, errors.Wrap(err, "could not load system roots")
+		}
+	}
+	return store, nil
+}
+
+func readCertPool(file string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found")
+	}
+	return pool, nil
+}
+
+// Evaluate verifies a presented chain of raw certificates with the leaf
+// first at time now against both root stores. Nil is returned if the
+// store is nil or the leaf could not be parsed.
+func (s *RootStore) Evaluate(raw [][]byte, now time.Time) *RootStoreResponse {
+	if s == nil || len(raw) == 0 {
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(raw[0])
+	if err != nil {
+		return nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, data := range raw[1:] {
+		if cert, err := x509.ParseCertificate(data); err == nil {
+			intermediates.AddCert(cert)
+		}
+	}
+	verify := func(roots *x509.CertPool) bool {
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   now,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		return err == nil
+	}
+	response := &RootStoreResponse{Trusted: verify(s.current), SimulatedTrusted: verify(s.simulated)}
+	response.Untrusted = response.Trusted && !response.SimulatedTrusted
+	return response
+}
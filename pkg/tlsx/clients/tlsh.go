@@ -0,0 +1,130 @@
+package clients
+
+import (
+	"encoding/hex"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	tlshBuckets       = 128
+	tlshCodeSize      = 32
+	tlshMinDataLength = 50
+)
+
+// tlshPearsonTable is the pearson hashing permutation used by tlsh
+var tlshPearsonTable = [256]byte{
+	1, 87, 49, 12, 176, 178, 102, 166, 121, 193, 6, 84, 249, 230, 44, 163,
+	14, 197, 213, 181, 161, 85, 218, 80, 64, 239, 24, 226, 236, 142, 38, 200,
+	110, 177, 104, 103, 141, 253, 255, 50, 77, 101, 81, 18, 45, 96, 31, 222,
+	25, 107, 190, 70, 86, 237, 240, 34, 72, 242, 20, 214, 244, 227, 149, 235,
+	97, 234, 57, 22, 60, 250, 82, 175, 208, 5, 127, 199, 111, 62, 135, 248,
+	174, 169, 211, 58, 66, 154, 106, 195, 245, 171, 17, 187, 182, 179, 0, 243,
+	132, 56, 148, 75, 128, 133, 158, 100, 130, 126, 91, 13, 153, 246, 216, 219,
+	119, 68, 223, 78, 83, 88, 201, 99, 122, 11, 92, 32, 136, 114, 52, 10,
+	138, 30, 48, 183, 156, 35, 61, 26, 143, 74, 251, 94, 129, 162, 63, 152,
+	170, 7, 115, 167, 241, 206, 3, 150, 55, 59, 151, 220, 90, 53, 23, 131,
+	125, 173, 15, 238, 79, 95, 89, 16, 105, 137, 225, 224, 217, 160, 37, 123,
+	118, 73, 2, 157, 46, 116, 9, 145, 134, 228, 207, 212, 202, 215, 69, 229,
+	27, 188, 67, 124, 168, 252, 42, 4, 29, 108, 21, 247, 19, 205, 39, 203,
+	233, 40, 186, 147, 198, 192, 155, 33, 164, 191, 98, 204, 165, 180, 117, 76,
+	140, 36, 210, 172, 41, 54, 159, 8, 185, 232, 113, 196, 231, 47, 146, 120,
+	51, 65, 28, 144, 254, 221, 93, 189, 194, 139, 112, 43, 71, 109, 184, 209,
+}
+
+// TLSHFingerprint creates a locality sensitive fuzzy hash of data using
+// the tlsh algorithm with 128 buckets and a 1 byte checksum. Similar
+// inputs produce hashes with a small distance. A blank string is
+// returned if data is too short or lacks variation to be hashed.
+//
+// follows: https://github.com/trendmicro/tlsh
+func TLSHFingerprint(data []byte) string {
+	if len(data) < tlshMinDataLength {
+		return ""
+	}
+	var buckets [256]uint32
+	var checksum byte
+
+	for i := 4; i < len(data); i++ {
+		c0, c1, c2, c3, c4 := data[i], data[i-1], data[i-2], data[i-3], data[i-4]
+
+		checksum = tlshMapping(0, c0, c1, checksum)
+		buckets[tlshMapping(2, c0, c1, c2)]++
+		buckets[tlshMapping(3, c0, c1, c3)]++
+		buckets[tlshMapping(5, c0, c2, c3)]++
+		buckets[tlshMapping(7, c0, c2, c4)]++
+		buckets[tlshMapping(11, c0, c1, c4)]++
+		buckets[tlshMapping(13, c0, c3, c4)]++
+	}
+
+	sorted := make([]uint32, tlshBuckets)
+	copy(sorted, buckets[:tlshBuckets])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	q1, q2, q3 := sorted[tlshBuckets/4-1], sorted[tlshBuckets/2-1], sorted[tlshBuckets-tlshBuckets/4-1]
+	if q3 == 0 {
+		return ""
+	}
+	var nonZero int
+	for _, count := range buckets[:tlshBuckets] {
+		if count > 0 {
+			nonZero++
+		}
+	}
+	if nonZero <= tlshBuckets/2 {
+		return ""
+	}
+
+	var code [tlshCodeSize]byte
+	for i := 0; i < tlshCodeSize; i++ {
+		var h byte
+		for j := 0; j < 4; j++ {
+			k := buckets[4*i+j]
+			switch {
+			case q3 < k:
+				h += 3 << (j * 2)
+			case q2 < k:
+				h += 2 << (j * 2)
+			case q1 < k:
+				h += 1 << (j * 2)
+			}
+		}
+		// code body is emitted in reverse order
+		code[tlshCodeSize-1-i] = h
+	}
+
+	q1Ratio := byte(uint32(float32(q1*100)/float32(q3)) % 16)
+	q2Ratio := byte(uint32(float32(q2*100)/float32(q3)) % 16)
+
+	digest := make([]byte, 0, 3+tlshCodeSize)
+	digest = append(digest, swapNibbles(checksum), swapNibbles(tlshLength(len(data))), q1Ratio<<4|q2Ratio)
+	digest = append(digest, code[:]...)
+	return "T1" + strings.ToUpper(hex.EncodeToString(digest))
+}
+
+// tlshMapping is the pearson hash of a salted triplet
+func tlshMapping(salt, i, j, k byte) byte {
+	h := tlshPearsonTable[salt]
+	h = tlshPearsonTable[h^i]
+	h = tlshPearsonTable[h^j]
+	return tlshPearsonTable[h^k]
+}
+
+// tlshLength returns the logarithmic length encoding of tlsh
+func tlshLength(length int) byte {
+	value := math.Log(float64(length))
+	var i int
+	switch {
+	case length <= 656:
+		i = int(math.Floor(value / 0.4054651))
+	case length <= 3199:
+		i = int(math.Floor(value/0.26236426 - 8.72777))
+	default:
+		i = int(math.Floor(value/0.095310180 - 62.5472))
+	}
+	return byte(i & 0xff)
+}
+
+func swapNibbles(value byte) byte {
+	return value<<4 | value>>4
+}
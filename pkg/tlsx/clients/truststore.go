@@ -0,0 +1,202 @@
+package clients
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TrustStoreSystem is the name of the system trust store
+const TrustStoreSystem = "system"
+
+// TrustStore is a pool of trusted roots named by the file or directory
+// it was loaded from.
+type TrustStore struct {
+	// Name is the name reported for chains validated by the store
+	Name string
+	// Roots is the pool of trusted roots
+	Roots *x509.CertPool
+}
+
+// TrustStores returns a store for each of the ca certificate, ca file and
+// ca directory options, or the system store if none is specified.
+func TrustStores(options *Options) ([]*TrustStore, error) {
+	var stores []*TrustStore
+	for _, file := range []string{options.CACertificate, options.CAFile} {
+		if file == "" {
+			continue
+		}
+		certs, err := readCertificates(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read ca file %s", file)
+		}
+		stores = append(stores, &TrustStore{Name: file, Roots: certPool(nil, certs)})
+	}
+	if options.CADir != "" {
+		certs, err := readCertificateDir(options.CADir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read ca directory %s", options.CADir)
+		}
+		stores = append(stores, &TrustStore{Name: options.CADir, Roots: certPool(nil, certs)})
+	}
+	if len(stores) == 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load system roots")
+		}
+		stores = append(stores, &TrustStore{Name: TrustStoreSystem, Roots: roots})
+	}
+	return stores, nil
+}
+
+// NamedTrustStores returns the stores of the trust store files option
+// given as name=path, path named by itself, or system for the system roots.
+func NamedTrustStores(options *Options) ([]*TrustStore, error) {
+	var stores []*TrustStore
+	for _, value := range options.TrustStoreFiles {
+		name, path := value, value
+		if index := strings.Index(value, "="); index != -1 {
+			name, path = value[:index], value[index+1:]
+		}
+		if name == "" || path == "" {
+			return nil, errors.Errorf("invalid trust store %s", value)
+		}
+		if path == TrustStoreSystem {
+			roots, err := x509.SystemCertPool()
+			if err != nil {
+				return nil, errors.Wrap(err, "could not load system roots")
+			}
+			stores = append(stores, &TrustStore{Name: name, Roots: roots})
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read trust store %s", name)
+		}
+		var certs []*x509.Certificate
+		if info.IsDir() {
+			certs, err = readCertificateDir(path)
+		} else {
+			certs, err = readCertificates(path)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read trust store %s", name)
+		}
+		stores = append(stores, &TrustStore{Name: name, Roots: certPool(nil, certs)})
+	}
+	return stores, nil
+}
+
+// CustomRoots returns the roots of the ca file and ca directory options
+func CustomRoots(options *Options) ([]*x509.Certificate, error) {
+	var roots []*x509.Certificate
+	if options.CAFile != "" {
+		certs, err := readCertificates(options.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read ca file %s", options.CAFile)
+		}
+		roots = append(roots, certs...)
+	}
+	if options.CADir != "" {
+		certs, err := readCertificateDir(options.CADir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read ca directory %s", options.CADir)
+		}
+		roots = append(roots, certs...)
+	}
+	return roots, nil
+}
+
+// AppendCustomRoots appends the roots of the ca file and ca directory
+// options to pool, which is created if nil. The pool is returned as is
+// if neither option is specified.
+func AppendCustomRoots(pool *x509.CertPool, options *Options) (*x509.CertPool, error) {
+	roots, err := CustomRoots(options)
+	if err != nil || len(roots) == 0 {
+		return pool, err
+	}
+	return certPool(pool, roots), nil
+}
+
+// certPool adds certs to pool, which is created if nil
+func certPool(pool *x509.CertPool, certs []*x509.Certificate) *x509.CertPool {
+	if pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool
+}
+
+// readCertificates reads the certificates of a pem bundle or der file
+func readCertificates(file string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// readCertificateDir reads the certificates of the files of a directory,
+// files without certificates such as keys or crls are skipped.
+func readCertificateDir(dir string) ([]*x509.Certificate, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fileCerts, err := readCertificates(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		certs = append(certs, fileCerts...)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// parseCertificates parses the certificate blocks of pem data, or the
+// data as der certificates if it is not pem encoded.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	var pemEncoded bool
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		pemEncoded = true
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if pemEncoded {
+		return certs, nil
+	}
+	return x509.ParseCertificates(data)
+}
@@ -0,0 +1,185 @@
+// Package ct parses the signed certificate timestamps of certificates and
+// checks them against the certificate transparency policies of browsers.
+package ct
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	zx509 "github.com/zmap/zcrypto/x509"
+	zct "github.com/zmap/zcrypto/x509/ct"
+	"golang.org/x/crypto/ocsp"
+)
+
+// List of sources signed certificate timestamps are delivered with
+const (
+	// SourceEmbedded is a timestamp embedded in the certificate
+	SourceEmbedded = "embedded"
+	// SourceTLS is a timestamp sent in the tls extension
+	SourceTLS = "tls"
+	// SourceOCSP is a timestamp in the stapled ocsp response
+	SourceOCSP = "ocsp"
+)
+
+// ocspSCTListOID is the oid of the ocsp timestamp list extension
+var ocspSCTListOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 5}
+
+// Checker checks signed certificate timestamps against the log lists
+// of chrome and apple
+type Checker struct {
+	chrome *LogList
+	apple  *LogList
+}
+
+// New creates a ct checker with the log lists of options
+func New(options *clients.Options) (*Checker, error) {
+	chrome, err := LoadLogList(LogListChrome, options.CTLogList)
+	if err != nil {
+		return nil, err
+	}
+	apple, err := LoadLogList(LogListApple, options.CTAppleLogList)
+	if err != nil {
+		return nil, err
+	}
+	if chrome.Len() == 0 && apple.Len() == 0 {
+		gologger.Warning().Msgf("CT log lists are empty, run tlsx ct-update to download them")
+	}
+	return &Checker{chrome: chrome, apple: apple}, nil
+}
+
+// Check returns the timestamps of the leaf of a presented chain embedded
+// in the certificate, sent in the tls extension or stapled ocsp response
+// and the compliance of the leaf with the browser policies.
+func (c *Checker) Check(rawChain, tlsSCTs [][]byte, ocspStaple []byte) (*clients.CTResponse, error) {
+	leaf, err := zx509.ParseCertificate(rawChain[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse leaf certificate")
+	}
+
+	// embedded timestamps sign the key of the issuer of the leaf
+	var issuerSPKI []byte
+	for _, raw := range rawChain[1:] {
+		if cert, err := zx509.ParseCertificate(raw); err == nil && bytes.Equal(cert.RawSubject, leaf.RawIssuer) {
+			issuerSPKI = cert.RawSubjectPublicKeyInfo
+			break
+		}
+	}
+
+	response := &clients.CTResponse{}
+	for _, sct := range leaf.SignedCertificateTimestampList {
+		response.SCTs = append(response.SCTs, c.sctResponse(SourceEmbedded, sct, leaf, issuerSPKI))
+	}
+	for _, raw := range tlsSCTs {
+		sct, err := zct.DeserializeSCT(bytes.NewReader(raw))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse tls timestamp")
+		}
+		response.SCTs = append(response.SCTs, c.sctResponse(SourceTLS, sct, leaf, issuerSPKI))
+	}
+	if len(ocspStaple) > 0 {
+		scts, err := ocspSCTs(ocspStaple)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse ocsp timestamps")
+		}
+		for _, sct := range scts {
+			response.SCTs = append(response.SCTs, c.sctResponse(SourceOCSP, sct, leaf, issuerSPKI))
+		}
+	}
+
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	response.Chrome = ctPolicy.evaluate(response.SCTs, c.chrome, lifetime)
+	response.Apple = ctPolicy.evaluate(response.SCTs, c.apple, lifetime)
+	return response, nil
+}
+
+// sctResponse returns the response for a timestamp with its log and the
+// status of its signature
+func (c *Checker) sctResponse(source string, sct *zct.SignedCertificateTimestamp, leaf *zx509.Certificate, issuerSPKI []byte) clients.SCTResponse {
+	logID := base64.StdEncoding.EncodeToString(sct.LogID[:])
+	response := clients.SCTResponse{
+		Source:    source,
+		LogID:     logID,
+		Timestamp: time.Unix(0, int64(sct.Timestamp)*int64(time.Millisecond)).UTC(),
+		Signature: SignatureUnverified,
+	}
+	log := c.chrome.Log(logID)
+	if log == nil {
+		log = c.apple.Log(logID)
+	}
+	if log == nil {
+		return response
+	}
+	response.Log = log.Description
+	response.Operator = log.Operator
+	response.LogState = log.State
+
+	embedded := source == SourceEmbedded
+	if log.Key == nil || (embedded && issuerSPKI == nil) {
+		return response
+	}
+	data, err := signedData(sct, leaf.Raw, leaf.RawTBSCertificate, issuerSPKI, embedded)
+	if err == nil {
+		err = verifySignature(log.Key, sct, data)
+	}
+	if err != nil {
+		response.Signature = SignatureInvalid
+	} else {
+		response.Signature = SignatureValid
+	}
+	return response
+}
+
+// ocspSCTs returns the timestamps of the single response extension
+// of a der encoded ocsp response.
+func ocspSCTs(data []byte) ([]*zct.SignedCertificateTimestamp, error) {
+	// the signature is not verified as only extensions are read
+	response, err := ocsp.ParseResponse(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, extension := range response.Extensions {
+		if !extension.Id.Equal(ocspSCTListOID) {
+			continue
+		}
+		var list []byte
+		if _, err := asn1.Unmarshal(extension.Value, &list); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal timestamp list")
+		}
+		return parseSCTList(list)
+	}
+	return nil, nil
+}
+
+// parseSCTList parses a tls encoded list of timestamps
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc6962#section-3.3
+func parseSCTList(data []byte) ([]*zct.SignedCertificateTimestamp, error) {
+	if len(data) < 2 || int(binary.BigEndian.Uint16(data)) != len(data)-2 {
+		return nil, errors.New("invalid timestamp list length")
+	}
+	data = data[2:]
+
+	var scts []*zct.SignedCertificateTimestamp
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errors.New("incomplete timestamp length")
+		}
+		length := int(binary.BigEndian.Uint16(data))
+		if len(data)-2 < length {
+			return nil, errors.New("incomplete timestamp")
+		}
+		sct, err := zct.DeserializeSCT(bytes.NewReader(data[2 : 2+length]))
+		if err != nil {
+			return nil, err
+		}
+		scts = append(scts, sct)
+		data = data[2+length:]
+	}
+	return scts, nil
+}
@@ -0,0 +1,216 @@
+package ct
+
+import (
+	"crypto"
+	"crypto/x509"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LogListURL is the url of the chrome log list in the v3 schema
+const LogListURL = "https://www.gstatic.com/ct/log_list/v3/log_list.json"
+
+// AppleLogListURL is the url of the apple log list in the v3 schema
+const AppleLogListURL = "https://valid.apple.com/ct/log_list/current_log_list.json"
+
+// List of log lists of browsers
+const (
+	LogListChrome = "chrome"
+	LogListApple  = "apple"
+)
+
+// maxLogListSize is the maximum size of downloaded log lists
+const maxLogListSize = 16 << 20
+
+//go:embed log_list.json
+var defaultLogList []byte
+
+//go:embed apple_log_list.json
+var defaultAppleLogList []byte
+
+// List of log states
+//
+// follows: https://googlechrome.github.io/CertificateTransparency/log_states.html
+const (
+	StatePending   = "pending"
+	StateQualified = "qualified"
+	StateUsable    = "usable"
+	StateReadOnly  = "readonly"
+	StateRetired   = "retired"
+	StateRejected  = "rejected"
+)
+
+// logListSchema is the v3 schema of the chrome and apple log lists
+type logListSchema struct {
+	Version   string `json:"version"`
+	Timestamp string `json:"log_list_timestamp"`
+	Operators []struct {
+		Name string `json:"name"`
+		Logs []struct {
+			Description string `json:"description"`
+			LogID       string `json:"log_id"`
+			Key         string `json:"key"`
+			State       map[string]struct {
+				Time time.Time `json:"timestamp"`
+			} `json:"state"`
+		} `json:"logs"`
+	} `json:"operators"`
+}
+
+// Log is a log of the log list
+type Log struct {
+	// Description is the description of the log
+	Description string
+	// Operator is the name of the operator of the log
+	Operator string
+	// State is the current state of the log
+	State string
+	// StateTime is the time the log entered its current state
+	StateTime time.Time
+	// Key is the public key of the log, nil if not in the list
+	Key crypto.PublicKey
+}
+
+// LogList is a list of logs indexed by the base64 encoded log id
+type LogList struct {
+	// Version is the version of the list
+	Version string
+	// Timestamp is the time the list was published
+	Timestamp string
+
+	logs map[string]*Log
+}
+
+// ParseLogList parses a log list in the v3 schema
+func ParseLogList(data []byte) (*LogList, error) {
+	var schema logListSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal log list")
+	}
+	list := &LogList{Version: schema.Version, Timestamp: schema.Timestamp, logs: make(map[string]*Log)}
+	for _, operator := range schema.Operators {
+		for _, log := range operator.Logs {
+			if id, err := base64.StdEncoding.DecodeString(log.LogID); err != nil || len(id) != 32 {
+				return nil, errors.Errorf("invalid log id %q", log.LogID)
+			}
+			entry := &Log{Description: log.Description, Operator: operator.Name}
+			if log.Key != "" {
+				der, err := base64.StdEncoding.DecodeString(log.Key)
+				if err != nil {
+					return nil, errors.Errorf("invalid key of log %q", log.LogID)
+				}
+				if entry.Key, err = x509.ParsePKIXPublicKey(der); err != nil {
+					return nil, errors.Wrapf(err, "could not parse key of log %q", log.LogID)
+				}
+			}
+			// logs have a single state in the v3 schema
+			for state, value := range log.State {
+				entry.State, entry.StateTime = state, value.Time
+			}
+			list.logs[log.LogID] = entry
+		}
+	}
+	return list, nil
+}
+
+// Log returns the log for a base64 encoded log id or nil if unknown
+func (l *LogList) Log(logID string) *Log {
+	return l.logs[logID]
+}
+
+// Len returns the number of logs in the list
+func (l *LogList) Len() int {
+	return len(l.logs)
+}
+
+// LogListPath returns the path the log list of a browser is cached at
+// by UpdateLogList, which is tlsx/ct in the user cache directory.
+func LogListPath(browser string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "could not get user cache directory")
+	}
+	name := "log_list.json"
+	if browser == LogListApple {
+		name = "apple_log_list.json"
+	}
+	return filepath.Join(dir, "tlsx", "ct", name), nil
+}
+
+// LoadLogList loads the log list of a browser from file if specified,
+// otherwise from the cached list downloaded by UpdateLogList falling
+// back to the list embedded at build time.
+func LoadLogList(browser, file string) (*LogList, error) {
+	if file == "" {
+		if path, err := LogListPath(browser); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				file = path
+			}
+		}
+	}
+	if file == "" {
+		if browser == LogListApple {
+			return ParseLogList(defaultAppleLogList)
+		}
+		return ParseLogList(defaultLogList)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read log list")
+	}
+	list, err := ParseLogList(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse log list %s", file)
+	}
+	return list, nil
+}
+
+// UpdateLogList downloads the log list from url and writes it to path
+// once it has been parsed successfully.
+func UpdateLogList(client *http.Client, url, path string) (*LogList, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not download log list")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxLogListSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read log list")
+	}
+	list, err := ParseLogList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create log list directory")
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), ".log_list-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create log list file")
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "could not write log list")
+	}
+	if err := file.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not write log list")
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return nil, errors.Wrap(err, "could not write log list")
+	}
+	return list, nil
+}
@@ -0,0 +1,121 @@
+package ct
+
+import (
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of policy compliance statuses
+const (
+	StatusCompliant    = "compliant"
+	StatusNotCompliant = "not-compliant"
+	// StatusUnknown is reported for certificates which are not compliant
+	// with timestamps of logs missing from the log list or timestamps
+	// whose signature could not be verified
+	StatusUnknown = "unknown"
+)
+
+// policy is the certificate transparency policy of a browser
+type policy struct {
+	// shortLifetime is the maximum lifetime of certificates requiring
+	// shortLifetimeSCTs embedded timestamps
+	shortLifetime time.Duration
+	// shortLifetimeSCTs is the number of embedded timestamps required for
+	// certificates with a lifetime up to shortLifetime
+	shortLifetimeSCTs int
+	// longLifetimeSCTs is the number of embedded timestamps required for
+	// certificates with a lifetime longer than shortLifetime
+	longLifetimeSCTs int
+	// deliveredSCTs is the number of timestamps required if delivered
+	// with the tls extension or stapled ocsp response
+	deliveredSCTs int
+	// operators is the number of distinct log operators required
+	operators int
+}
+
+// ctPolicy is the certificate transparency policy shared by chrome and
+// apple, which differ in the logs they accept and their states.
+//
+// follows: https://googlechrome.github.io/CertificateTransparency/ct_policy.html
+// and https://support.apple.com/en-us/103214
+var ctPolicy = policy{
+	shortLifetime:     180 * 24 * time.Hour,
+	shortLifetimeSCTs: 2,
+	longLifetimeSCTs:  3,
+	deliveredSCTs:     2,
+	operators:         2,
+}
+
+// evaluate returns the compliance status of a certificate with lifetime
+// presenting the timestamps with the logs of a browser log list. Only
+// timestamps with a valid signature are counted.
+func (p policy) evaluate(scts []clients.SCTResponse, list *LogList, lifetime time.Duration) string {
+	required := p.longLifetimeSCTs
+	if lifetime <= p.shortLifetime {
+		required = p.shortLifetimeSCTs
+	}
+
+	var unknown bool
+	embedded, embeddedCurrent := newLogSet(), false
+	delivered := newLogSet()
+	for _, sct := range scts {
+		log := list.Log(sct.LogID)
+		if log == nil || sct.Signature == SignatureUnverified {
+			unknown = true
+			continue
+		}
+		if sct.Signature != SignatureValid {
+			continue
+		}
+		current := isCurrent(log.State)
+		if sct.Source == SourceEmbedded {
+			// timestamps of logs retired after issuance remain valid
+			if current || (log.State == StateRetired && sct.Timestamp.Before(log.StateTime)) {
+				embedded.add(sct.LogID, log.Operator)
+				embeddedCurrent = embeddedCurrent || current
+			}
+			continue
+		}
+		if current {
+			delivered.add(sct.LogID, log.Operator)
+		}
+	}
+
+	if embedded.satisfies(required, p.operators) && embeddedCurrent {
+		return StatusCompliant
+	}
+	if delivered.satisfies(p.deliveredSCTs, p.operators) {
+		return StatusCompliant
+	}
+	if unknown {
+		return StatusUnknown
+	}
+	return StatusNotCompliant
+}
+
+// isCurrent returns true if timestamps of a log in state are accepted
+// at time of check
+func isCurrent(state string) bool {
+	return state == StateQualified || state == StateUsable || state == StateReadOnly
+}
+
+// logSet is a set of distinct logs and their operators
+type logSet struct {
+	logs      map[string]struct{}
+	operators map[string]struct{}
+}
+
+func newLogSet() *logSet {
+	return &logSet{logs: make(map[string]struct{}), operators: make(map[string]struct{})}
+}
+
+func (s *logSet) add(logID, operator string) {
+	s.logs[logID] = struct{}{}
+	s.operators[operator] = struct{}{}
+}
+
+// satisfies returns true if the set has enough logs and operators
+func (s *logSet) satisfies(logs, operators int) bool {
+	return len(s.logs) >= logs && len(s.operators) >= operators
+}
@@ -0,0 +1,262 @@
+package ct
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// DefaultSearchURL is the json certificate search of crt.sh, the
+// escaped hostname is appended to the url.
+const DefaultSearchURL = "https://crt.sh/?output=json&q="
+
+// maxSearchResponse is the maximum size of search responses read
+const maxSearchResponse = 16 << 20
+
+// searchEntry is a logged certificate returned by a crt.sh compatible search
+type searchEntry struct {
+	ID           int64      `json:"id"`
+	IssuerName   string     `json:"issuer_name"`
+	CommonName   string     `json:"common_name"`
+	NameValue    string     `json:"name_value"`
+	SerialNumber string     `json:"serial_number"`
+	NotBefore    searchTime `json:"not_before"`
+	NotAfter     searchTime `json:"not_after"`
+	EntryTime    searchTime `json:"entry_timestamp"`
+}
+
+// searchTime is a time returned by crt.sh which omits the time zone
+type searchTime struct {
+	time.Time
+}
+
+// searchTimeLayouts is the list of layouts of search times
+var searchTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"}
+
+func (t *searchTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil || value == "" {
+		return err
+	}
+	for _, layout := range searchTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t.Time = parsed.UTC()
+			return nil
+		}
+	}
+	return errors.Errorf("invalid time %q", value)
+}
+
+// SearchClient queries a crt.sh compatible json search
+type SearchClient struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewSearchClient creates a search client with the search url of options
+// and the given request timeout
+func NewSearchClient(options *clients.Options, timeout time.Duration) *SearchClient {
+	searchURL := options.CTSearchURL
+	if searchURL == "" {
+		searchURL = DefaultSearchURL
+	}
+	return &SearchClient{
+		url: searchURL,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+					return clients.Dial(ctx, options, address)
+				},
+			},
+		},
+	}
+}
+
+// Names returns the names of the certificates logged for query
+func (c *SearchClient) Names(query string) ([]string, error) {
+	entries, err := c.lookup(query)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, strings.Split(entry.NameValue, "\n")...)
+	}
+	return names, nil
+}
+
+// lookup queries the search url for the logged certificates of query
+func (c *SearchClient) lookup(query string) ([]searchEntry, error) {
+	resp, err := c.httpClient.Get(c.url + url.QueryEscape(query))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not search logs")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected search status %d", resp.StatusCode)
+	}
+
+	var entries []searchEntry
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSearchResponse)).Decode(&entries); err != nil {
+		return nil, errors.Wrap(err, "could not decode search response")
+	}
+	return entries, nil
+}
+
+// Searcher searches certificate transparency logs for certificates issued
+// for scanned hostnames caching the results of each hostname.
+type Searcher struct {
+	options *clients.Options
+	client  *SearchClient
+
+	mutex   sync.Mutex
+	entries map[string]*searchCacheEntry
+}
+
+// searchCacheEntry is the search result of a hostname, its mutex makes
+// concurrent scans of the same hostname wait for one search.
+type searchCacheEntry struct {
+	sync.Mutex
+	done    bool
+	entries []searchEntry
+	err     error
+}
+
+// NewSearcher creates a searcher with the search url of options
+func NewSearcher(options *clients.Options) *Searcher {
+	return &Searcher{
+		options: options,
+		client:  NewSearchClient(options, time.Duration(options.Timeout)*time.Second),
+		entries: make(map[string]*searchCacheEntry),
+	}
+}
+
+// Search returns the certificates for hostname other than the presented
+// cert which were issued within the search window before now. Certificates
+// of an issuer organization other than that of cert are marked.
+func (s *Searcher) Search(hostname string, cert *clients.CertificateResponse, now time.Time) (*clients.CTSearchResponse, error) {
+	entries, err := s.search(strings.ToLower(hostname))
+	if err != nil {
+		return nil, err
+	}
+
+	var organization string
+	if len(cert.IssuerOrg) > 0 {
+		organization = cert.IssuerOrg[0]
+	}
+	issuer := issuerOrganization(organization, cert.IssuerCN)
+	since := now.AddDate(0, 0, -s.options.CTSearchDays)
+	response := &clients.CTSearchResponse{}
+	seen := map[string]struct{}{normalizeSerial(cert.Serial): {}}
+	issuers := make(map[string]struct{})
+	for _, entry := range entries {
+		if entry.NotBefore.Before(since) || entry.NotBefore.After(now) {
+			continue
+		}
+		// precertificates are logged with the serial of their certificate
+		serial := normalizeSerial(entry.SerialNumber)
+		if _, ok := seen[serial]; ok {
+			continue
+		}
+		seen[serial] = struct{}{}
+
+		logged := clients.CTSearchCertificate{
+			ID:        entry.ID,
+			Serial:    serial,
+			IssuerDN:  entry.IssuerName,
+			SubjectCN: entry.CommonName,
+			SubjectAN: strings.Split(entry.NameValue, "\n"),
+			NotBefore: entry.NotBefore.Time,
+			NotAfter:  entry.NotAfter.Time,
+			LoggedAt:  entry.EntryTime.Time,
+		}
+		entryIssuer := issuerOrganization(dnAttribute(entry.IssuerName, "O"), dnAttribute(entry.IssuerName, "CN"))
+		if issuer != "" && entryIssuer != issuer {
+			logged.OtherIssuer = true
+			response.OtherIssuers++
+		}
+		issuers[entry.IssuerName] = struct{}{}
+		response.Certificates = append(response.Certificates, logged)
+	}
+	for name := range issuers {
+		response.Issuers = append(response.Issuers, name)
+	}
+	sort.Strings(response.Issuers)
+	sort.Slice(response.Certificates, func(i, j int) bool {
+		return response.Certificates[i].NotBefore.After(response.Certificates[j].NotBefore)
+	})
+	return response, nil
+}
+
+// search returns the logged certificates for hostname from the cache
+// or the search url.
+func (s *Searcher) search(hostname string) ([]searchEntry, error) {
+	s.mutex.Lock()
+	entry, ok := s.entries[hostname]
+	if !ok {
+		entry = &searchCacheEntry{}
+		s.entries[hostname] = entry
+	}
+	s.mutex.Unlock()
+
+	entry.Lock()
+	defer entry.Unlock()
+	if !entry.done {
+		entry.entries, entry.err = s.client.lookup(hostname)
+		entry.done = true
+	}
+	return entry.entries, entry.err
+}
+
+// normalizeSerial returns the lowercase hex serial without leading zeros
+func normalizeSerial(serial string) string {
+	return strings.TrimLeft(strings.ToLower(serial), "0")
+}
+
+// issuerOrganization returns the organization of an issuer falling
+// back to the common name for issuers without organization.
+func issuerOrganization(organization, commonName string) string {
+	if organization != "" {
+		return organization
+	}
+	return commonName
+}
+
+// dnAttribute returns the value of key in a comma separated distinguished
+// name (C=US, O="Example, Inc.", CN=Example CA).
+func dnAttribute(dn, key string) string {
+	var quoted bool
+	start := 0
+	for i := 0; i <= len(dn); i++ {
+		if i < len(dn) {
+			switch dn[i] {
+			case '"':
+				quoted = !quoted
+				continue
+			case ',':
+				if quoted {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		attribute := dn[start:i]
+		start = i + 1
+		if index := strings.IndexByte(attribute, '='); index != -1 && strings.TrimSpace(attribute[:index]) == key {
+			return strings.Trim(strings.TrimSpace(attribute[index+1:]), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package ct
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	zct "github.com/zmap/zcrypto/x509/ct"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// List of signature statuses of timestamps
+const (
+	SignatureValid   = "valid"
+	SignatureInvalid = "invalid"
+	// SignatureUnverified is reported for timestamps of logs missing from
+	// the log lists or embedded timestamps whose issuer was not presented
+	SignatureUnverified = "unverified"
+)
+
+// sctListOID is the oid of the embedded timestamp list extension
+var sctListOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// List of entry types of the signed timestamp data
+const (
+	x509Entry    = 0
+	precertEntry = 1
+)
+
+// signedData returns the data signed by the log for a timestamp of a
+// certificate. Embedded timestamps sign the precertificate, which is the
+// tbs certificate without the timestamp list and the issuer key hash.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc6962#section-3.2
+func signedData(sct *zct.SignedCertificateTimestamp, raw, rawTBS, issuerSPKI []byte, embedded bool) ([]byte, error) {
+	data := &bytes.Buffer{}
+	data.WriteByte(byte(sct.SCTVersion))
+	data.WriteByte(0) // certificate_timestamp
+	_ = binary.Write(data, binary.BigEndian, sct.Timestamp)
+	if embedded {
+		tbs, err := precertTBS(rawTBS)
+		if err != nil {
+			return nil, err
+		}
+		issuerKeyHash := sha256.Sum256(issuerSPKI)
+		_ = binary.Write(data, binary.BigEndian, uint16(precertEntry))
+		data.Write(issuerKeyHash[:])
+		writeUint24Bytes(data, tbs)
+	} else {
+		_ = binary.Write(data, binary.BigEndian, uint16(x509Entry))
+		writeUint24Bytes(data, raw)
+	}
+	_ = binary.Write(data, binary.BigEndian, uint16(len(sct.Extensions)))
+	data.Write(sct.Extensions)
+	return data.Bytes(), nil
+}
+
+// writeUint24Bytes writes data prefixed with its 24 bit length
+func writeUint24Bytes(buffer *bytes.Buffer, data []byte) {
+	buffer.Write([]byte{byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))})
+	buffer.Write(data)
+}
+
+// precertTBS returns a der encoded tbs certificate without the embedded
+// timestamp list extension
+func precertTBS(rawTBS []byte) ([]byte, error) {
+	input := cryptobyte.String(rawTBS)
+	var tbs cryptobyte.String
+	if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("invalid tbs certificate")
+	}
+
+	builder := cryptobyte.NewBuilder(nil)
+	var buildErr error
+	builder.AddASN1(cryptobyte_asn1.SEQUENCE, func(child *cryptobyte.Builder) {
+		extensionsTag := cryptobyte_asn1.Tag(3).Constructed().ContextSpecific()
+		for !tbs.Empty() {
+			var element cryptobyte.String
+			var tag cryptobyte_asn1.Tag
+			if !tbs.ReadAnyASN1Element(&element, &tag) {
+				buildErr = errors.New("invalid tbs certificate element")
+				return
+			}
+			if tag != extensionsTag {
+				child.AddBytes(element)
+				continue
+			}
+			var explicit, extensions cryptobyte.String
+			if !element.ReadASN1(&explicit, extensionsTag) || !explicit.ReadASN1(&extensions, cryptobyte_asn1.SEQUENCE) {
+				buildErr = errors.New("invalid tbs certificate extensions")
+				return
+			}
+			child.AddASN1(extensionsTag, func(explicit *cryptobyte.Builder) {
+				explicit.AddASN1(cryptobyte_asn1.SEQUENCE, func(list *cryptobyte.Builder) {
+					for !extensions.Empty() {
+						var extension, body cryptobyte.String
+						var id asn1.ObjectIdentifier
+						if !extensions.ReadASN1Element(&extension, cryptobyte_asn1.SEQUENCE) {
+							buildErr = errors.New("invalid tbs certificate extension")
+							return
+						}
+						inner := extension
+						if inner.ReadASN1(&body, cryptobyte_asn1.SEQUENCE) && body.ReadASN1ObjectIdentifier(&id) && id.Equal(sctListOID) {
+							continue
+						}
+						list.AddBytes(extension)
+					}
+				})
+			})
+		}
+	})
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	return builder.Bytes()
+}
+
+// verifySignature verifies the signature of a timestamp over data with
+// the public key of its log
+func verifySignature(key crypto.PublicKey, sct *zct.SignedCertificateTimestamp, data []byte) error {
+	if sct.Signature.HashAlgorithm != zct.SHA256 {
+		return errors.Errorf("unsupported hash algorithm %s", sct.Signature.HashAlgorithm)
+	}
+	digest := sha256.Sum256(data)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if sct.Signature.SignatureAlgorithm != zct.ECDSA {
+			return errors.New("signature algorithm does not match log key")
+		}
+		if !ecdsa.VerifyASN1(key, digest[:], sct.Signature.Signature) {
+			return errors.New("invalid ecdsa signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if sct.Signature.SignatureAlgorithm != zct.RSA {
+			return errors.New("signature algorithm does not match log key")
+		}
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sct.Signature.Signature)
+	default:
+		return errors.Errorf("unsupported log key type %T", key)
+	}
+}
@@ -0,0 +1,91 @@
+// Package defaultcert implements retrieval of the default certificate
+// presented by servers to clients not sending a server name.
+package defaultcert
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+)
+
+// versionStringToTLSVersion is the list of versions the probe is limited to
+// with the min and max version options, as for the main scan.
+var versionStringToTLSVersion = map[string]uint16{
+	"tls10": tls.VersionTLS10,
+	"tls11": tls.VersionTLS11,
+	"tls12": tls.VersionTLS12,
+	"tls13": tls.VersionTLS13,
+}
+
+// rejectionAlerts is the list of alerts sent by servers refusing clients
+// without a server name, other handshake errors are not a rejection.
+var rejectionAlerts = map[string]struct{}{
+	"tls: unrecognized name": {},
+	"tls: handshake failure": {},
+	"tls: access denied":     {},
+	"tls: internal error":    {},
+}
+
+// Probe makes a handshake without the server name extension and returns
+// the certificate presented and whether it differs from the certificate
+// with the sha256 fingerprint presented with a server name.
+func Probe(options *clients.Options, hostname, port, fingerprint string) (*clients.DefaultCertificateResponse, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial address")
+	}
+	defer rawConn.Close()
+
+	if err := starttls.Negotiate(ctx, rawConn, starttls.Protocol(options, port), starttls.ServerName(options, hostname)); err != nil {
+		return nil, errors.Wrap(err, "could not negotiate starttls")
+	}
+	// an empty server name omits the extension
+	config := &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS13}
+	if version, ok := versionStringToTLSVersion[options.MinVersion]; ok {
+		config.MinVersion = version
+	}
+	if version, ok := versionStringToTLSVersion[options.MaxVersion]; ok {
+		config.MaxVersion = version
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		// servers without a default certificate refuse the handshake
+		if isRejection(err) {
+			return &clients.DefaultCertificateResponse{Rejected: true}, nil
+		}
+		return nil, errors.Wrap(err, "could not do handshake")
+	}
+	certificates := conn.ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return nil, errors.New("no certificates returned by server")
+	}
+	cert := clients.NewCertificateResponse(certificates[0].Raw, options)
+	return &clients.DefaultCertificateResponse{
+		Differs:     !strings.EqualFold(cert.FingerprintHash.SHA256, fingerprint),
+		Certificate: &cert,
+	}, nil
+}
+
+// isRejection returns true if a handshake failed with an alert of the
+// server refusing the client.
+func isRejection(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "remote error" {
+		return false
+	}
+	_, ok := rejectionAlerts[opErr.Err.Error()]
+	return ok
+}
@@ -0,0 +1,123 @@
+// Package ech implements the parts of encrypted client hello needed to
+// probe servers: configuration lookup in dns and hpke encryption.
+package ech
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// VersionECH is the version of supported ech configurations, which is
+// also the identifier of the encrypted_client_hello extension.
+const VersionECH = 0xfe0d
+
+// Config is a parsed ech configuration supported by the probes
+type Config struct {
+	// Raw is the ECHConfig structure including version and length
+	Raw []byte
+	// ID is the identifier of the configuration
+	ID uint8
+	// KEM is the hpke key encapsulation mechanism
+	KEM uint16
+	// PublicKey is the hpke public key of the server
+	PublicKey []byte
+	// Suites is the list of hpke symmetric cipher suites
+	Suites []Suite
+	// PublicName is the name sent in the outer client hello
+	PublicName string
+}
+
+// Suite is a hpke symmetric cipher suite
+type Suite struct {
+	KDF  uint16
+	AEAD uint16
+}
+
+// ParseConfigList parses an ECHConfigList skipping configurations of
+// unknown versions.
+func ParseConfigList(data []byte) ([]Config, error) {
+	if len(data) < 2 || int(binary.BigEndian.Uint16(data)) != len(data)-2 {
+		return nil, errors.New("invalid ech config list length")
+	}
+	data = data[2:]
+
+	var configs []Config
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("truncated ech config")
+		}
+		version := binary.BigEndian.Uint16(data[0:2])
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if len(data) < 4+length {
+			return nil, errors.New("truncated ech config")
+		}
+		raw := data[:4+length]
+		data = data[4+length:]
+		if version != VersionECH {
+			continue
+		}
+		config, err := parseConfig(raw)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, *config)
+	}
+	return configs, nil
+}
+
+// parseConfig parses an ECHConfig including version and length
+func parseConfig(raw []byte) (*Config, error) {
+	config := &Config{Raw: raw}
+	data := raw[4:]
+	if len(data) < 1+2+2 {
+		return nil, errors.New("truncated ech key config")
+	}
+	config.ID = data[0]
+	config.KEM = binary.BigEndian.Uint16(data[1:3])
+	length := int(binary.BigEndian.Uint16(data[3:5]))
+	data = data[5:]
+	if len(data) < length+2 {
+		return nil, errors.New("truncated ech public key")
+	}
+	config.PublicKey = data[:length]
+	data = data[length:]
+
+	length = int(binary.BigEndian.Uint16(data[0:2]))
+	data = data[2:]
+	if len(data) < length+2 || length%4 != 0 {
+		return nil, errors.New("invalid ech cipher suites")
+	}
+	for i := 0; i < length; i += 4 {
+		config.Suites = append(config.Suites, Suite{KDF: binary.BigEndian.Uint16(data[i : i+2]), AEAD: binary.BigEndian.Uint16(data[i+2 : i+4])})
+	}
+	data = data[length:]
+
+	// maximum name length and public name
+	length = int(data[1])
+	data = data[2:]
+	if len(data) < length {
+		return nil, errors.New("truncated ech public name")
+	}
+	config.PublicName = string(data[:length])
+	return config, nil
+}
+
+// Select returns the first configuration and suite supported by the
+// hpke implementation, false if there is none.
+func Select(configs []Config) (*Config, Suite, bool) {
+	for i := range configs {
+		if configs[i].KEM != kemX25519 {
+			continue
+		}
+		for _, suite := range configs[i].Suites {
+			if _, ok := kdfs[suite.KDF]; !ok {
+				continue
+			}
+			if _, ok := aeadKeySizes[suite.AEAD]; ok {
+				return &configs[i], suite, true
+			}
+		}
+	}
+	return nil, Suite{}, false
+}
@@ -0,0 +1,67 @@
+package ech
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/retryabledns"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// maxAliases is the number of alias mode records followed
+const maxAliases = 4
+
+// LookupConfigList returns the ech configuration list published in the
+// https dns record of the hostname, nil if there is none. Ports other
+// than 443 are looked up with their port prefix name.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc9460
+func LookupConfigList(options *clients.Options, hostname, port string) ([]byte, error) {
+	resolvers := options.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = fastdialer.DefaultResolvers
+	}
+	client := retryabledns.New(resolvers, 3)
+
+	name := dns.Fqdn(hostname)
+	if port != "443" {
+		name = "_" + port + "._https." + name
+	}
+	for i := 0; i <= maxAliases; i++ {
+		msg := new(dns.Msg)
+		msg.SetQuestion(name, dns.TypeHTTPS)
+		response, err := client.Do(msg)
+		if err != nil && strings.HasPrefix(name, "_") {
+			// port prefixed names usually do not exist, which fails
+			// queries the same way as unreachable resolvers.
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "could not query https record")
+		}
+
+		var alias string
+		for _, answer := range response.Answer {
+			record, ok := answer.(*dns.HTTPS)
+			if !ok {
+				continue
+			}
+			if record.Priority == 0 {
+				alias = record.Target
+				continue
+			}
+			for _, value := range record.Value {
+				if config, ok := value.(*dns.SVCBECHConfig); ok {
+					return config.ECH, nil
+				}
+			}
+		}
+		if alias == "" || alias == "." || strings.EqualFold(alias, name) {
+			return nil, nil
+		}
+		name = alias
+	}
+	return nil, errors.New("too many https alias records")
+}
@@ -0,0 +1,142 @@
+package ech
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// kemX25519 is the DHKEM(X25519, HKDF-SHA256) key encapsulation mechanism
+const kemX25519 = 0x0020
+
+// list of hpke aead identifiers
+const (
+	aeadAES128GCM        = 0x0001
+	aeadAES256GCM        = 0x0002
+	aeadChaCha20Poly1305 = 0x0003
+)
+
+// kdfs is the list of supported hpke key derivation functions
+var kdfs = map[uint16]func() hash.Hash{
+	0x0001: sha256.New,
+	0x0002: sha512.New384,
+	0x0003: sha512.New,
+}
+
+// aeadKeySizes is the list of key sizes of supported hpke aeads
+var aeadKeySizes = map[uint16]int{
+	aeadAES128GCM:        16,
+	aeadAES256GCM:        32,
+	aeadChaCha20Poly1305: 32,
+}
+
+// Sender is a hpke base mode sender context for sealing a single message
+type Sender struct {
+	// Enc is the encapsulated key sent to the server
+	Enc   []byte
+	aead  cipher.AEAD
+	nonce []byte
+}
+
+// NewSender creates a sender context for the configuration
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc9180
+func NewSender(config *Config, suite Suite, info []byte) (*Sender, error) {
+	if config.KEM != kemX25519 || len(config.PublicKey) != curve25519.PointSize {
+		return nil, errors.New("unsupported hpke kem")
+	}
+	kdf, ok := kdfs[suite.KDF]
+	if !ok {
+		return nil, errors.New("unsupported hpke kdf")
+	}
+
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return nil, err
+	}
+	enc, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	dh, err := curve25519.X25519(ephemeral, config.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid hpke public key")
+	}
+	kemSuite := append([]byte("KEM"), uint16Bytes(kemX25519)...)
+	kemContext := append(append([]byte(nil), enc...), config.PublicKey...)
+	prk := labeledExtract(sha256.New, kemSuite, nil, "eae_prk", dh)
+	sharedSecret := labeledExpand(sha256.New, kemSuite, prk, "shared_secret", kemContext, sha256.Size)
+
+	suiteID := append([]byte("HPKE"), uint16Bytes(kemX25519)...)
+	suiteID = append(suiteID, uint16Bytes(suite.KDF)...)
+	suiteID = append(suiteID, uint16Bytes(suite.AEAD)...)
+	keyScheduleContext := []byte{0}
+	keyScheduleContext = append(keyScheduleContext, labeledExtract(kdf, suiteID, nil, "psk_id_hash", nil)...)
+	keyScheduleContext = append(keyScheduleContext, labeledExtract(kdf, suiteID, nil, "info_hash", info)...)
+	secret := labeledExtract(kdf, suiteID, sharedSecret, "secret", nil)
+	key := labeledExpand(kdf, suiteID, secret, "key", keyScheduleContext, aeadKeySizes[suite.AEAD])
+	// the nonce of the first and only message is the base nonce
+	nonce := labeledExpand(kdf, suiteID, secret, "base_nonce", keyScheduleContext, 12)
+
+	aead, err := newAEAD(suite.AEAD, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{Enc: enc, aead: aead, nonce: nonce}, nil
+}
+
+// Seal encrypts the first message of the context
+func (s *Sender) Seal(aad, plaintext []byte) []byte {
+	return s.aead.Seal(nil, s.nonce, plaintext, aad)
+}
+
+// newAEAD returns the hpke aead with key
+func newAEAD(id uint16, key []byte) (cipher.AEAD, error) {
+	switch id {
+	case aeadAES128GCM, aeadAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case aeadChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	}
+	return nil, errors.New("unsupported hpke aead")
+}
+
+// labeledExtract is the LabeledExtract function of hpke
+func labeledExtract(kdf func() hash.Hash, suiteID, salt []byte, label string, ikm []byte) []byte {
+	labeled := append([]byte("HPKE-v1"), suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdf.Extract(kdf, labeled, salt)
+}
+
+// labeledExpand is the LabeledExpand function of hpke
+func labeledExpand(kdf func() hash.Hash, suiteID, prk []byte, label string, info []byte, length int) []byte {
+	labeled := uint16Bytes(uint16(length))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	out := make([]byte, length)
+	// reading less than 255 hash sizes never fails
+	_, _ = hkdf.Expand(kdf, prk, labeled).Read(out)
+	return out
+}
+
+func uint16Bytes(value uint16) []byte {
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, value)
+	return data
+}
@@ -0,0 +1,185 @@
+// Package fingerprint implements matching of tls responses against
+// a database of known infrastructure fingerprints.
+package fingerprint
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+//go:embed fingerprints.json
+var defaultDatabase []byte
+
+// Fingerprint is a single known infrastructure fingerprint. A response
+// is tagged with the label if any of the specified values match.
+type Fingerprint struct {
+	// Label is the label to tag matching responses with
+	Label string `json:"label"`
+	// JA3S is a list of ja3s hashes of the server hello
+	JA3S []string `json:"ja3s,omitempty"`
+	// JARM is a list of jarm fingerprints of the server
+	JARM []string `json:"jarm,omitempty"`
+	// SHA256 is a list of sha256 certificate fingerprints
+	SHA256 []string `json:"sha256,omitempty"`
+	// SubjectCN is a list of regexes for the subject common name
+	SubjectCN []string `json:"subject-cn,omitempty"`
+	// SubjectOrg is a list of regexes for the subject organization
+	SubjectOrg []string `json:"subject-org,omitempty"`
+	// IssuerCN is a list of regexes for the issuer common name
+	IssuerCN []string `json:"issuer-cn,omitempty"`
+	// IssuerOrg is a list of regexes for the issuer organization
+	IssuerOrg []string `json:"issuer-org,omitempty"`
+	// SAN is a list of regexes for subject alternative names
+	SAN []string `json:"san,omitempty"`
+
+	subjectCN  []*regexp.Regexp
+	subjectOrg []*regexp.Regexp
+	issuerCN   []*regexp.Regexp
+	issuerOrg  []*regexp.Regexp
+	san        []*regexp.Regexp
+}
+
+// Matcher matches responses against a list of fingerprints
+type Matcher struct {
+	fingerprints []*Fingerprint
+}
+
+// New creates a new matcher from the embedded fingerprint database
+// and optionally a user supplied database file.
+func New(file string) (*Matcher, error) {
+	matcher := &Matcher{}
+	if err := matcher.load(defaultDatabase); err != nil {
+		return nil, errors.Wrap(err, "could not load embedded fingerprints")
+	}
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read fingerprint file")
+		}
+		if err := matcher.load(data); err != nil {
+			return nil, errors.Wrap(err, "could not load fingerprint file")
+		}
+	}
+	return matcher, nil
+}
+
+// load parses and compiles a json list of fingerprints
+func (m *Matcher) load(data []byte) error {
+	var fingerprints []*Fingerprint
+	if err := json.Unmarshal(data, &fingerprints); err != nil {
+		return err
+	}
+	for _, fingerprint := range fingerprints {
+		if fingerprint.Label == "" {
+			return errors.New("fingerprint without label specified")
+		}
+		var err error
+		if fingerprint.subjectCN, err = compileAll(fingerprint.SubjectCN); err != nil {
+			return errors.Wrapf(err, "invalid subject-cn for %s", fingerprint.Label)
+		}
+		if fingerprint.subjectOrg, err = compileAll(fingerprint.SubjectOrg); err != nil {
+			return errors.Wrapf(err, "invalid subject-org for %s", fingerprint.Label)
+		}
+		if fingerprint.issuerCN, err = compileAll(fingerprint.IssuerCN); err != nil {
+			return errors.Wrapf(err, "invalid issuer-cn for %s", fingerprint.Label)
+		}
+		if fingerprint.issuerOrg, err = compileAll(fingerprint.IssuerOrg); err != nil {
+			return errors.Wrapf(err, "invalid issuer-org for %s", fingerprint.Label)
+		}
+		if fingerprint.san, err = compileAll(fingerprint.SAN); err != nil {
+			return errors.Wrapf(err, "invalid san for %s", fingerprint.Label)
+		}
+		m.fingerprints = append(m.fingerprints, fingerprint)
+	}
+	return nil
+}
+
+// Match returns the unique labels of all fingerprints matching the response
+func (m *Matcher) Match(response *clients.Response) []string {
+	var labels []string
+	seen := make(map[string]struct{})
+	for _, fingerprint := range m.fingerprints {
+		if _, ok := seen[fingerprint.Label]; ok {
+			continue
+		}
+		if fingerprint.matches(response) {
+			seen[fingerprint.Label] = struct{}{}
+			labels = append(labels, fingerprint.Label)
+		}
+	}
+	return labels
+}
+
+// HasJARM returns true if any fingerprint matches jarm fingerprints, which
+// are then computed for every response to match.
+func (m *Matcher) HasJARM() bool {
+	for _, fingerprint := range m.fingerprints {
+		if len(fingerprint.JARM) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if any value of the fingerprint matches the response
+func (f *Fingerprint) matches(response *clients.Response) bool {
+	cert := response.CertificateResponse
+
+	if response.JA3S != "" && containsFold(f.JA3S, response.JA3S) {
+		return true
+	}
+	if response.JARM != "" && containsFold(f.JARM, response.JARM) {
+		return true
+	}
+	if cert.FingerprintHash.SHA256 != "" && containsFold(f.SHA256, cert.FingerprintHash.SHA256) {
+		return true
+	}
+	if matchAny(f.subjectCN, cert.SubjectCN) || matchAny(f.issuerCN, cert.IssuerCN) {
+		return true
+	}
+	if matchAny(f.subjectOrg, cert.SubjectOrg...) || matchAny(f.issuerOrg, cert.IssuerOrg...) {
+		return true
+	}
+	return matchAny(f.san, cert.SubjectAN...)
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, regex)
+	}
+	return compiled, nil
+}
+
+func matchAny(regexes []*regexp.Regexp, values ...string) bool {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, regex := range regexes {
+			if regex.MatchString(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(items []string, value string) bool {
+	for _, item := range items {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
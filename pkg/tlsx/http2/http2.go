@@ -0,0 +1,152 @@
+// Package http2 implements confirmation of http/2 and grpc services on
+// servers negotiating the h2 application protocol.
+package http2
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// ALPNProtocol is the application protocol of http/2 over tls
+const ALPNProtocol = "h2"
+
+// probePath is the path of the grpc method requested, which grpc servers
+// answer with an unimplemented status.
+const probePath = "/tlsx.Probe/Check"
+
+// maxFrames is the number of frames read waiting for the response
+const maxFrames = 32
+
+// Confirm negotiates h2 and returns whether the server speaks http/2 and
+// grpc, nil if h2 is not negotiated. Servers answering the connection
+// preface with their settings are http/2 services, and servers answering
+// a grpc request with a grpc status are grpc services.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc9113#section-3.4
+func Confirm(options *clients.Options, hostname, port string) (*clients.HTTP2Response, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial address")
+	}
+	defer rawConn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = rawConn.SetDeadline(deadline)
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{ALPNProtocol},
+		ServerName:         options.ServerName,
+	}
+	if config.ServerName == "" && !iputil.IsIP(hostname) {
+		config.ServerName = hostname
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, errors.Wrap(err, "could not do handshake")
+	}
+	if conn.ConnectionState().NegotiatedProtocol != ALPNProtocol {
+		return nil, nil
+	}
+
+	response := &clients.HTTP2Response{}
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		return response, errors.Wrap(err, "could not write preface")
+	}
+	framer := http2.NewFramer(conn, conn)
+	if err := framer.WriteSettings(); err != nil {
+		return response, errors.Wrap(err, "could not write settings")
+	}
+	frame, err := framer.ReadFrame()
+	if err != nil {
+		// proxies advertising h2 without speaking it close the connection
+		return response, nil
+	}
+	if settings, ok := frame.(*http2.SettingsFrame); !ok || settings.IsAck() {
+		return response, nil
+	}
+	response.Confirmed = true
+
+	authority := config.ServerName
+	if authority == "" {
+		authority = hostname
+	}
+	if err := writeGRPCRequest(framer, authority); err != nil {
+		return response, err
+	}
+	for i := 0; i < maxFrames; i++ {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return response, nil
+		}
+		switch frame := frame.(type) {
+		case *http2.SettingsFrame:
+			if !frame.IsAck() {
+				_ = framer.WriteSettingsAck()
+			}
+		case *http2.HeadersFrame:
+			if frame.StreamID != 1 {
+				continue
+			}
+			fields, err := hpack.NewDecoder(4096, nil).DecodeFull(frame.HeaderBlockFragment())
+			if err != nil {
+				return response, errors.Wrap(err, "could not decode response headers")
+			}
+			response.GRPC = isGRPC(fields)
+			return response, nil
+		case *http2.RSTStreamFrame, *http2.GoAwayFrame:
+			return response, nil
+		}
+	}
+	return response, nil
+}
+
+// writeGRPCRequest writes the headers of a grpc request without message
+func writeGRPCRequest(framer *http2.Framer, authority string) error {
+	var block bytes.Buffer
+	encoder := hpack.NewEncoder(&block)
+	for _, field := range []hpack.HeaderField{
+		{Name: ":method", Value: "POST"},
+		{Name: ":scheme", Value: "https"},
+		{Name: ":path", Value: probePath},
+		{Name: ":authority", Value: authority},
+		{Name: "content-type", Value: "application/grpc"},
+		{Name: "te", Value: "trailers"},
+	} {
+		if err := encoder.WriteField(field); err != nil {
+			return err
+		}
+	}
+	err := framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: block.Bytes(), EndStream: true, EndHeaders: true})
+	return errors.Wrap(err, "could not write request headers")
+}
+
+// isGRPC returns true if response headers include a grpc status or content type
+func isGRPC(fields []hpack.HeaderField) bool {
+	for _, field := range fields {
+		switch {
+		case field.Name == "grpc-status":
+			return true
+		case field.Name == "content-type" && strings.HasPrefix(field.Value, "application/grpc"):
+			return true
+		}
+	}
+	return false
+}
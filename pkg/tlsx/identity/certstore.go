@@ -0,0 +1,44 @@
+package identity
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CertStoreSpec identifies a certificate in a system certificate store
+type CertStoreSpec struct {
+	// Location is the store location (CurrentUser or LocalMachine)
+	Location string
+	// Store is the name of the system store such as MY or ROOT
+	Store string
+	// Selector is the sha1 thumbprint or subject common name of the certificate
+	Selector string
+}
+
+// ParseCertStoreSpec parses a certificate store specification of the
+// form [location/]store/selector, eg. CurrentUser/MY/3a1c...
+func ParseCertStoreSpec(value string) (*CertStoreSpec, error) {
+	parts := strings.Split(value, "/")
+	spec := &CertStoreSpec{Location: "CurrentUser"}
+	switch len(parts) {
+	case 2:
+		spec.Store, spec.Selector = parts[0], parts[1]
+	case 3:
+		spec.Location, spec.Store, spec.Selector = parts[0], parts[1], parts[2]
+	default:
+		return nil, errors.Errorf("invalid certificate store specification: %s", value)
+	}
+	if !strings.EqualFold(spec.Location, "CurrentUser") && !strings.EqualFold(spec.Location, "LocalMachine") {
+		return nil, errors.Errorf("invalid certificate store location: %s", spec.Location)
+	}
+	if spec.Store == "" || spec.Selector == "" {
+		return nil, errors.Errorf("invalid certificate store specification: %s", value)
+	}
+	return spec, nil
+}
+
+// ParseStoreName parses a trust store name of the form [location/]store
+func ParseStoreName(value string) (*CertStoreSpec, error) {
+	return ParseCertStoreSpec(value + "/*")
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package identity
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+)
+
+// errCertStoreUnsupported is returned on platforms without a system certificate store
+var errCertStoreUnsupported = errors.New("system certificate store is only supported on windows")
+
+// LoadCertStore loads a client certificate and its cng private key
+// from the windows system certificate store.
+func LoadCertStore(value string) (*tls.Certificate, error) {
+	if _, err := ParseCertStoreSpec(value); err != nil {
+		return nil, err
+	}
+	return nil, errCertStoreUnsupported
+}
+
+// LoadCertStoreRoots loads all certificates from a windows system
+// certificate store as trust anchors.
+func LoadCertStoreRoots(value string) (*x509.CertPool, error) {
+	if _, err := ParseStoreName(value); err != nil {
+		return nil, err
+	}
+	return nil, errCertStoreUnsupported
+}
@@ -0,0 +1,238 @@
+//go:build windows
+// +build windows
+
+package identity
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"strings"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	bcryptPadPKCS1 = 0x00000002
+	bcryptPadPSS   = 0x00000008
+)
+
+var (
+	ncrypt             = windows.NewLazySystemDLL("ncrypt.dll")
+	procNCryptSignHash = ncrypt.NewProc("NCryptSignHash")
+)
+
+// bcryptPKCS1PaddingInfo is the BCRYPT_PKCS1_PADDING_INFO structure
+type bcryptPKCS1PaddingInfo struct {
+	algorithm *uint16
+}
+
+// bcryptPSSPaddingInfo is the BCRYPT_PSS_PADDING_INFO structure
+type bcryptPSSPaddingInfo struct {
+	algorithm *uint16
+	salt      uint32
+}
+
+// LoadCertStore loads a client certificate and its cng private key
+// from the windows system certificate store.
+func LoadCertStore(value string) (*tls.Certificate, error) {
+	spec, err := ParseCertStoreSpec(value)
+	if err != nil {
+		return nil, err
+	}
+	store, err := openStore(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CertCloseStore(store, 0)
+
+	var context *windows.CertContext
+	var leaf *x509.Certificate
+	for {
+		context, err = windows.CertEnumCertificatesInStore(store, context)
+		if err != nil || context == nil {
+			return nil, errors.New("no matching certificate found in store")
+		}
+		cert, parseErr := x509.ParseCertificate(contextBytes(context))
+		if parseErr != nil {
+			continue
+		}
+		if matchesSelector(cert, spec.Selector) {
+			leaf = cert
+			break
+		}
+	}
+	// the key handle is cached by the duplicated context for process lifetime
+	context = windows.CertDuplicateCertificateContext(context)
+
+	var key windows.Handle
+	var keySpec uint32
+	var callerFree bool
+	if err := windows.CryptAcquireCertificatePrivateKey(context, windows.CRYPT_ACQUIRE_CACHE_FLAG|windows.CRYPT_ACQUIRE_ONLY_NCRYPT_KEY_FLAG, nil, &key, &keySpec, &callerFree); err != nil {
+		return nil, errors.Wrap(err, "could not acquire certificate private key")
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  &cngSigner{key: key, public: leaf.PublicKey},
+		Leaf:        leaf,
+	}, nil
+}
+
+// LoadCertStoreRoots loads all certificates from a windows system
+// certificate store as trust anchors.
+func LoadCertStoreRoots(value string) (*x509.CertPool, error) {
+	spec, err := ParseStoreName(value)
+	if err != nil {
+		return nil, err
+	}
+	store, err := openStore(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CertCloseStore(store, 0)
+
+	pool := x509.NewCertPool()
+	var context *windows.CertContext
+	for {
+		context, err = windows.CertEnumCertificatesInStore(store, context)
+		if err != nil || context == nil {
+			break
+		}
+		if cert, parseErr := x509.ParseCertificate(contextBytes(context)); parseErr == nil {
+			pool.AddCert(cert)
+		}
+	}
+	return pool, nil
+}
+
+func openStore(spec *CertStoreSpec) (windows.Handle, error) {
+	location := uint32(windows.CERT_SYSTEM_STORE_CURRENT_USER)
+	if strings.EqualFold(spec.Location, "LocalMachine") {
+		location = windows.CERT_SYSTEM_STORE_LOCAL_MACHINE
+	}
+	name, err := windows.UTF16PtrFromString(spec.Store)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid store name")
+	}
+	store, err := windows.CertOpenStore(windows.CERT_STORE_PROV_SYSTEM_W, 0, 0, location|windows.CERT_STORE_OPEN_EXISTING_FLAG|windows.CERT_STORE_READONLY_FLAG, uintptr(unsafe.Pointer(name)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not open certificate store %s", spec.Store)
+	}
+	return store, nil
+}
+
+func contextBytes(context *windows.CertContext) []byte {
+	return append([]byte{}, unsafe.Slice(context.EncodedCert, context.Length)...)
+}
+
+// matchesSelector returns true if the sha1 thumbprint or subject
+// common name of the certificate matches selector.
+func matchesSelector(cert *x509.Certificate, selector string) bool {
+	thumbprint := sha1.Sum(cert.Raw)
+	normalized := strings.ToLower(strings.NewReplacer(" ", "", ":", "").Replace(selector))
+	if hex.EncodeToString(thumbprint[:]) == normalized {
+		return true
+	}
+	return strings.EqualFold(cert.Subject.CommonName, selector)
+}
+
+// cngSigner is a crypto.Signer using a cng private key handle
+type cngSigner struct {
+	key    windows.Handle
+	public crypto.PublicKey
+}
+
+// Public returns the public key of the signer
+func (s *cngSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest using the cng private key
+func (s *cngSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var padding unsafe.Pointer
+	var flags uint32
+
+	switch s.public.(type) {
+	case *rsa.PublicKey:
+		algorithm, ok := cngHashAlgorithms[opts.HashFunc()]
+		if !ok && opts.HashFunc() != crypto.MD5SHA1 {
+			return nil, errors.New("unsupported hash function")
+		}
+		var algorithmPtr *uint16
+		if ok {
+			algorithmPtr, _ = windows.UTF16PtrFromString(algorithm)
+		}
+		if pss, isPSS := opts.(*rsa.PSSOptions); isPSS {
+			salt := pss.SaltLength
+			if salt == rsa.PSSSaltLengthEqualsHash || salt == rsa.PSSSaltLengthAuto {
+				salt = pss.HashFunc().Size()
+			}
+			padding = unsafe.Pointer(&bcryptPSSPaddingInfo{algorithm: algorithmPtr, salt: uint32(salt)})
+			flags = bcryptPadPSS
+		} else {
+			padding = unsafe.Pointer(&bcryptPKCS1PaddingInfo{algorithm: algorithmPtr})
+			flags = bcryptPadPKCS1
+		}
+	case *ecdsa.PublicKey:
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+
+	var size uint32
+	if err := ncryptSignHash(s.key, padding, digest, nil, &size, flags); err != nil {
+		return nil, errors.Wrap(err, "could not get signature size")
+	}
+	signature := make([]byte, size)
+	if err := ncryptSignHash(s.key, padding, digest, signature, &size, flags); err != nil {
+		return nil, errors.Wrap(err, "could not sign with cng key")
+	}
+	signature = signature[:size]
+
+	if _, ok := s.public.(*ecdsa.PublicKey); ok {
+		// cng returns raw r || s which is asn1 encoded for tls
+		half := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(signature[:half]),
+			S: new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+func ncryptSignHash(key windows.Handle, padding unsafe.Pointer, digest, signature []byte, size *uint32, flags uint32) error {
+	var signaturePtr *byte
+	if len(signature) > 0 {
+		signaturePtr = &signature[0]
+	}
+	status, _, _ := procNCryptSignHash.Call(
+		uintptr(key),
+		uintptr(padding),
+		uintptr(unsafe.Pointer(&digest[0])),
+		uintptr(len(digest)),
+		uintptr(unsafe.Pointer(signaturePtr)),
+		uintptr(len(signature)),
+		uintptr(unsafe.Pointer(size)),
+		uintptr(flags),
+	)
+	if status != 0 {
+		return errors.Errorf("NCryptSignHash failed with status 0x%x", status)
+	}
+	return nil
+}
+
+// cngHashAlgorithms are the cng algorithm identifiers for hash functions
+var cngHashAlgorithms = map[crypto.Hash]string{
+	crypto.SHA1:   "SHA1",
+	crypto.SHA256: "SHA256",
+	crypto.SHA384: "SHA384",
+	crypto.SHA512: "SHA512",
+}
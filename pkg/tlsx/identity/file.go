@@ -0,0 +1,88 @@
+package identity
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// LoadFile loads a client certificate and private key from pem files.
+// The certificate file can include intermediates after the leaf.
+func LoadFile(certFile, keyFile string) (*tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// LoadPKCS12 loads a client certificate and private key from a pkcs12
+// file, placing the certificate matching the private key first.
+func LoadPKCS12(file, password string) (*tls.Certificate, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := pkcs12.ToPEM(data, password)
+	if _, ok := err.(pkcs12.NotImplementedError); ok {
+		// aes encrypted files as exported by default since openssl 3 are not
+		// supported by x/crypto/pkcs12 until go-pkcs12 can be vendored
+		return nil, errors.Wrap(err, "could not decode pkcs12 file, re-export it with legacy encryption (openssl pkcs12 -export -legacy)")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode pkcs12 file")
+	}
+
+	certificate := &tls.Certificate{}
+	var certificates []*x509.Certificate
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not parse pkcs12 certificate")
+			}
+			certificates = append(certificates, cert)
+		case "PRIVATE KEY":
+			if certificate.PrivateKey, err = parsePrivateKey(block); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if certificate.PrivateKey == nil {
+		return nil, errors.New("no private key in pkcs12 file")
+	}
+
+	public := certificate.PrivateKey.(crypto.Signer).Public().(interface{ Equal(crypto.PublicKey) bool })
+	for i, cert := range certificates {
+		if public.Equal(cert.PublicKey) {
+			certificate.Leaf = cert
+			certificates[0], certificates[i] = certificates[i], certificates[0]
+			break
+		}
+	}
+	if certificate.Leaf == nil {
+		return nil, errors.New("no certificate matching private key in pkcs12 file")
+	}
+	for _, cert := range certificates {
+		certificate.Certificate = append(certificate.Certificate, cert.Raw)
+	}
+	return certificate, nil
+}
+
+// parsePrivateKey parses the pkcs1 or sec1 encoded private key blocks
+// returned for pkcs12 files.
+func parsePrivateKey(block *pem.Block) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("could not parse pkcs12 private key")
+}
@@ -0,0 +1,94 @@
+// Package identity implements loading of client certificate identities
+// used for mutual tls authentication from pkcs12 files, hardware tokens
+// and system certificate stores.
+package identity
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PKCS11URI is a parsed pkcs11 uri identifying a token object
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc7512
+type PKCS11URI struct {
+	// Token is the label of the token
+	Token string
+	// Object is the label of the certificate and private key objects
+	Object string
+	// ID is the identifier of the certificate and private key objects
+	ID []byte
+	// SlotID is the optional slot identifier of the token
+	SlotID string
+	// ModulePath is the path to the pkcs11 module library
+	ModulePath string
+	// PIN is the user pin to login to the token with
+	PIN string
+}
+
+// ParsePKCS11URI parses a pkcs11 uri of the form
+// pkcs11:token=name;object=label?module-path=/path/module.so&pin-value=1234
+func ParsePKCS11URI(value string) (*PKCS11URI, error) {
+	if !strings.HasPrefix(value, "pkcs11:") {
+		return nil, errors.New("pkcs11 uri must start with pkcs11:")
+	}
+	value = strings.TrimPrefix(value, "pkcs11:")
+
+	var query string
+	if index := strings.Index(value, "?"); index != -1 {
+		value, query = value[:index], value[index+1:]
+	}
+	uri := &PKCS11URI{}
+	for _, attribute := range strings.Split(value, ";") {
+		if attribute == "" {
+			continue
+		}
+		name, attributeValue, err := splitAttribute(attribute)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "token":
+			uri.Token = attributeValue
+		case "object":
+			uri.Object = attributeValue
+		case "id":
+			uri.ID = []byte(attributeValue)
+		case "slot-id":
+			uri.SlotID = attributeValue
+		}
+	}
+	for _, attribute := range strings.Split(query, "&") {
+		if attribute == "" {
+			continue
+		}
+		name, attributeValue, err := splitAttribute(attribute)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "module-path":
+			uri.ModulePath = attributeValue
+		case "pin-value":
+			uri.PIN = attributeValue
+		}
+	}
+	if uri.ModulePath == "" {
+		return nil, errors.New("pkcs11 uri must specify module-path")
+	}
+	return uri, nil
+}
+
+func splitAttribute(attribute string) (string, string, error) {
+	parts := strings.SplitN(attribute, "=", 2)
+	if len(parts) != 2 {
+		return "", "", errors.Errorf("invalid pkcs11 uri attribute: %s", attribute)
+	}
+	value, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid pkcs11 uri attribute value: %s", parts[0])
+	}
+	return parts[0], value, nil
+}
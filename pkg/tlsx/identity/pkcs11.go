@@ -0,0 +1,210 @@
+//go:build cgo
+// +build cgo
+
+package identity
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+// LoadPKCS11 loads a client certificate whose private key is held on
+// a pkcs11 token identified by uri.
+func LoadPKCS11(value string) (*tls.Certificate, error) {
+	uri, err := ParsePKCS11URI(value)
+	if err != nil {
+		return nil, err
+	}
+	ctx := pkcs11.New(uri.ModulePath)
+	if ctx == nil {
+		return nil, errors.Errorf("could not load pkcs11 module %s", uri.ModulePath)
+	}
+	if err := ctx.Initialize(); err != nil {
+		return nil, errors.Wrap(err, "could not initialize pkcs11 module")
+	}
+	slot, err := findSlot(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open pkcs11 session")
+	}
+	if uri.PIN != "" {
+		if err := ctx.Login(session, pkcs11.CKU_USER, uri.PIN); err != nil {
+			return nil, errors.Wrap(err, "could not login to pkcs11 token")
+		}
+	}
+
+	certificateObject, err := findObject(ctx, session, pkcs11.CKO_CERTIFICATE, uri.Object, uri.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find certificate on token")
+	}
+	attributes, err := ctx.GetAttributeValue(session, certificateObject, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read certificate from token")
+	}
+	leaf, err := x509.ParseCertificate(attributes[0].Value)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse certificate from token")
+	}
+
+	// private key is looked up by the identifier of the certificate
+	id := uri.ID
+	if len(id) == 0 {
+		id = attributes[1].Value
+	}
+	keyObject, err := findObject(ctx, session, pkcs11.CKO_PRIVATE_KEY, "", id)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find private key on token")
+	}
+	signer := &pkcs11Signer{ctx: ctx, session: session, key: keyObject, public: leaf.PublicKey}
+	return &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  signer,
+		Leaf:        leaf,
+	}, nil
+}
+
+func findSlot(ctx *pkcs11.Ctx, uri *PKCS11URI) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not list pkcs11 slots")
+	}
+	for _, slot := range slots {
+		if uri.SlotID != "" && strconv.FormatUint(uint64(slot), 10) != uri.SlotID {
+			continue
+		}
+		if uri.Token != "" {
+			info, err := ctx.GetTokenInfo(slot)
+			if err != nil || info.Label != uri.Token {
+				continue
+			}
+		}
+		return slot, nil
+	}
+	return 0, errors.New("no matching pkcs11 token found")
+}
+
+func findObject(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, class uint, label string, id []byte) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}
+	if label != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, label))
+	}
+	if len(id) > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+	}
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := ctx.FindObjects(session, 1)
+	_ = ctx.FindObjectsFinal(session)
+	if err != nil {
+		return 0, err
+	}
+	if len(objects) == 0 {
+		return 0, errors.New("no matching object")
+	}
+	return objects[0], nil
+}
+
+// pkcs11Signer is a crypto.Signer using a private key on a pkcs11 token
+type pkcs11Signer struct {
+	mutex   sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	public  crypto.PublicKey
+}
+
+// Public returns the public key of the signer
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest using the private key on the token
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mechanism *pkcs11.Mechanism
+	message := digest
+
+	switch s.public.(type) {
+	case *rsa.PublicKey:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			hash, mgf, ok := pssMechanisms(pss.HashFunc())
+			if !ok {
+				return nil, errors.New("unsupported pss hash function")
+			}
+			saltLength := pss.SaltLength
+			if saltLength == rsa.PSSSaltLengthEqualsHash || saltLength == rsa.PSSSaltLengthAuto {
+				saltLength = pss.HashFunc().Size()
+			}
+			mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(hash, mgf, uint(saltLength)))
+		} else {
+			prefix, ok := digestInfoPrefixes[opts.HashFunc()]
+			if !ok {
+				return nil, errors.New("unsupported hash function")
+			}
+			message = append(append([]byte{}, prefix...), digest...)
+			mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)
+		}
+	case *ecdsa.PublicKey:
+		mechanism = pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{mechanism}, s.key); err != nil {
+		return nil, errors.Wrap(err, "could not initialize pkcs11 signing")
+	}
+	signature, err := s.ctx.Sign(s.session, message)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign with pkcs11 key")
+	}
+	if _, ok := s.public.(*ecdsa.PublicKey); ok {
+		// pkcs11 returns raw r || s which is asn1 encoded for tls
+		half := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(signature[:half]),
+			S: new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+func pssMechanisms(hash crypto.Hash) (uint, uint, bool) {
+	switch hash {
+	case crypto.SHA256:
+		return pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256, true
+	case crypto.SHA384:
+		return pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384, true
+	case crypto.SHA512:
+		return pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512, true
+	}
+	return 0, 0, false
+}
+
+// digestInfoPrefixes are the der encoded DigestInfo prefixes for pkcs1 v1.5 signatures
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.MD5SHA1: {},
+	crypto.SHA1:    {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA256:  {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384:  {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512:  {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
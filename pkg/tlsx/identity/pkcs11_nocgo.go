@@ -0,0 +1,19 @@
+//go:build !cgo
+// +build !cgo
+
+package identity
+
+import (
+	"crypto/tls"
+
+	"github.com/pkg/errors"
+)
+
+// LoadPKCS11 loads a client certificate whose private key is held on
+// a pkcs11 token identified by uri. It requires a cgo enabled build.
+func LoadPKCS11(value string) (*tls.Certificate, error) {
+	if _, err := ParsePKCS11URI(value); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("pkcs11 support requires tlsx built with cgo enabled")
+}
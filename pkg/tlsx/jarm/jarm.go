@@ -0,0 +1,455 @@
+// Package jarm implements the jarm active tls server fingerprint, which
+// hashes the responses of a server to ten crafted ClientHello messages.
+//
+// follows: https://github.com/salesforce/jarm
+package jarm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+)
+
+// List of orders of cipher suites, alpn protocols and versions
+const (
+	orderForward    = "forward"
+	orderReverse    = "reverse"
+	orderTopHalf    = "top-half"
+	orderBottomHalf = "bottom-half"
+	orderMiddleOut  = "middle-out"
+)
+
+// List of supported_versions extensions sent by probes
+const (
+	supportNone  = ""
+	supportTLS12 = "tls12"
+	supportTLS13 = "tls13"
+)
+
+// maxResponseLength is the length of the response read for each probe
+const maxResponseLength = 1484
+
+// emptyResult is the result of a probe without a ServerHello
+const emptyResult = "|||"
+
+// probe is a crafted ClientHello of the jarm fingerprint
+type probe struct {
+	version uint16
+	// noTLS13Ciphers removes tls 1.3 cipher suites
+	noTLS13Ciphers bool
+	cipherOrder    string
+	grease         bool
+	// rareALPN offers only rarely supported alpn protocols
+	rareALPN bool
+	support  string
+	// extensionOrder is the order of alpn protocols and versions
+	extensionOrder string
+}
+
+// probes are the ten probes of the fingerprint in order
+var probes = []probe{
+	{version: tls.VersionTLS12, cipherOrder: orderForward, support: supportTLS12, extensionOrder: orderReverse},
+	{version: tls.VersionTLS12, cipherOrder: orderReverse, support: supportTLS12, extensionOrder: orderForward},
+	{version: tls.VersionTLS12, cipherOrder: orderTopHalf, extensionOrder: orderForward},
+	{version: tls.VersionTLS12, cipherOrder: orderBottomHalf, rareALPN: true, extensionOrder: orderForward},
+	{version: tls.VersionTLS12, cipherOrder: orderMiddleOut, grease: true, rareALPN: true, extensionOrder: orderReverse},
+	{version: tls.VersionTLS11, cipherOrder: orderForward, extensionOrder: orderForward},
+	{version: tls.VersionTLS13, cipherOrder: orderForward, support: supportTLS13, extensionOrder: orderReverse},
+	{version: tls.VersionTLS13, cipherOrder: orderReverse, support: supportTLS13, extensionOrder: orderForward},
+	{version: tls.VersionTLS13, noTLS13Ciphers: true, cipherOrder: orderForward, support: supportTLS13, extensionOrder: orderForward},
+	{version: tls.VersionTLS13, cipherOrder: orderMiddleOut, grease: true, support: supportTLS13, extensionOrder: orderReverse},
+}
+
+// allCiphers is the list of cipher suites offered by probes in order
+var allCiphers = []uint16{
+	0x0016, 0x0033, 0x0067, 0xc09e, 0xc0a2, 0x009e, 0x0039, 0x006b, 0xc09f, 0xc0a3,
+	0x009f, 0x0045, 0x00be, 0x0088, 0x00c4, 0x009a, 0xc008, 0xc009, 0xc023, 0xc0ac,
+	0xc0ae, 0xc02b, 0xc00a, 0xc024, 0xc0ad, 0xc0af, 0xc02c, 0xc072, 0xc073, 0xcca9,
+	0x1302, 0x1301, 0xcc14, 0xc007, 0xc012, 0xc013, 0xc027, 0xc02f, 0xc014, 0xc028,
+	0xc030, 0xc060, 0xc061, 0xc076, 0xc077, 0xcca8, 0x1305, 0x1304, 0x1303, 0xcc13,
+	0xc011, 0x000a, 0x002f, 0x003c, 0xc09c, 0xc0a0, 0x009c, 0x0035, 0x003d, 0xc09d,
+	0xc0a1, 0x009d, 0x0041, 0x00ba, 0x0084, 0x00c0, 0x0007, 0x0004, 0x0005,
+}
+
+// hashedCiphers is the list of cipher suites whose index is hashed
+var hashedCiphers = []uint16{
+	0x0004, 0x0005, 0x0007, 0x000a, 0x0016, 0x002f, 0x0033, 0x0035, 0x0039, 0x003c,
+	0x003d, 0x0041, 0x0045, 0x0067, 0x006b, 0x0084, 0x0088, 0x009a, 0x009c, 0x009d,
+	0x009e, 0x009f, 0x00ba, 0x00be, 0x00c0, 0x00c4, 0xc007, 0xc008, 0xc009, 0xc00a,
+	0xc011, 0xc012, 0xc013, 0xc014, 0xc023, 0xc024, 0xc027, 0xc028, 0xc02b, 0xc02c,
+	0xc02f, 0xc030, 0xc060, 0xc061, 0xc072, 0xc073, 0xc076, 0xc077, 0xc09c, 0xc09d,
+	0xc09e, 0xc09f, 0xc0a0, 0xc0a1, 0xc0a2, 0xc0a3, 0xc0ac, 0xc0ad, 0xc0ae, 0xc0af,
+	0xcc13, 0xcc14, 0xcca8, 0xcca9, 0x1301, 0x1302, 0x1303, 0x1304, 0x1305,
+}
+
+// alpnProtocols are the alpn protocols offered from weakest to strongest
+var alpnProtocols = []string{"http/0.9", "http/1.0", "http/1.1", "spdy/1", "spdy/2", "spdy/3", "h2", "h2c", "hq"}
+
+// rareALPNProtocols are the alpn protocols offered without h2 and http/1.1
+var rareALPNProtocols = []string{"http/0.9", "http/1.0", "spdy/1", "spdy/2", "spdy/3", "h2c", "hq"}
+
+// Fingerprint returns the jarm fingerprint of host and port. Probes which
+// fail are hashed as empty responses, an error is returned only if no
+// probe could connect.
+func Fingerprint(options *clients.Options, host, port string) (string, error) {
+	serverName := starttls.ServerName(options, host)
+	results := make([]string, len(probes))
+	var connected bool
+	var lastErr error
+	for i, probe := range probes {
+		result, err := send(options, host, port, probe.clientHello(serverName))
+		if err != nil {
+			lastErr = err
+			results[i] = emptyResult
+			continue
+		}
+		connected = true
+		results[i] = result
+	}
+	if !connected {
+		return "", lastErr
+	}
+	return Hash(results), nil
+}
+
+// send writes a ClientHello to a new connection and returns the result
+// of the response
+func send(options *clients.Options, host, port string, hello []byte) (string, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	conn, err := clients.Dial(ctx, options, net.JoinHostPort(host, port))
+	if err != nil {
+		return "", errors.Wrap(err, "could not dial address")
+	}
+	defer conn.Close()
+
+	if err := starttls.Negotiate(ctx, conn, starttls.Protocol(options, port), starttls.ServerName(options, host)); err != nil {
+		return "", errors.Wrap(err, "could not negotiate starttls")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(hello); err != nil {
+		return emptyResult, nil
+	}
+
+	// servers closing the connection or timing out are empty responses
+	data := make([]byte, maxResponseLength)
+	n, _ := io.ReadAtLeast(conn, data, 5)
+	if n >= 5 {
+		length := 5 + int(binary.BigEndian.Uint16(data[3:5]))
+		if length > maxResponseLength {
+			length = maxResponseLength
+		}
+		if n < length {
+			read, _ := io.ReadFull(conn, data[n:length])
+			n += read
+		}
+	}
+	return parseServerHello(data[:n]), nil
+}
+
+// clientHello returns the ClientHello record of a probe
+func (p probe) clientHello(serverName string) []byte {
+	random := make([]byte, 32+32+32)
+	_, _ = rand.Read(random)
+
+	ciphers := allCiphers
+	if p.noTLS13Ciphers {
+		ciphers = nil
+		for _, cipher := range allCiphers {
+			if cipher>>8 != 0x13 {
+				ciphers = append(ciphers, cipher)
+			}
+		}
+	}
+	var ordered []uint16
+	if p.grease {
+		ordered = append(ordered, greaseValue())
+	}
+	for _, i := range mungle(len(ciphers), p.cipherOrder) {
+		ordered = append(ordered, ciphers[i])
+	}
+	ciphers = ordered
+
+	var extensions []byte
+	if p.grease {
+		extensions = clients.AppendExtension(extensions, greaseValue(), nil)
+	}
+	extensions = clients.AppendExtension(extensions, 0, clients.ServerNameExtension(serverName))
+	// extended_master_secret
+	extensions = clients.AppendExtension(extensions, 23, nil)
+	// max_fragment_length: 512
+	extensions = clients.AppendExtension(extensions, 1, []byte{1})
+	// renegotiation_info
+	extensions = clients.AppendExtension(extensions, 65281, []byte{0})
+	// supported_groups: x25519, secp256r1, secp384r1, secp521r1
+	extensions = clients.AppendExtension(extensions, 10, []byte{0, 8, 0, 29, 0, 23, 0, 24, 0, 25})
+	// ec_point_formats: uncompressed
+	extensions = clients.AppendExtension(extensions, 11, []byte{1, 0})
+	// session_ticket
+	extensions = clients.AppendExtension(extensions, 35, nil)
+	extensions = clients.AppendExtension(extensions, 16, p.alpnExtension())
+	// signature_algorithms
+	extensions = clients.AppendExtension(extensions, 13, []byte{0, 18, 4, 3, 8, 4, 4, 1, 5, 3, 8, 5, 5, 1, 8, 6, 6, 1, 2, 1})
+	extensions = clients.AppendExtension(extensions, 51, p.keyShareExtension(random[64:96]))
+	// psk_key_exchange_modes: psk_dhe_ke
+	extensions = clients.AppendExtension(extensions, 45, []byte{1, 1})
+	if p.version == tls.VersionTLS13 || p.support == supportTLS12 {
+		extensions = clients.AppendExtension(extensions, 43, p.supportedVersionsExtension())
+	}
+
+	// tls 1.3 probes use the tls 1.2 hello version in a tls 1.0 record
+	helloVersion, recordVersion := p.version, p.version
+	if p.version == tls.VersionTLS13 {
+		helloVersion, recordVersion = tls.VersionTLS12, tls.VersionTLS10
+	}
+	body := clients.Uint16Bytes(int(helloVersion))
+	body = append(body, random[:32]...)
+	body = append(body, 32)
+	body = append(body, random[32:64]...)
+	body = append(body, clients.Uint16Bytes(len(ciphers)*2)...)
+	for _, cipher := range ciphers {
+		body = append(body, clients.Uint16Bytes(int(cipher))...)
+	}
+	// compression methods: null
+	body = append(body, 1, 0)
+	body = append(body, clients.Uint16Bytes(len(extensions))...)
+	body = append(body, extensions...)
+	return clients.HandshakeRecord(recordVersion, clients.HandshakeMessage(1, body))
+}
+
+// alpnExtension returns the value of the alpn extension of a probe
+func (p probe) alpnExtension() []byte {
+	protocols := alpnProtocols
+	if p.rareALPN {
+		protocols = rareALPNProtocols
+	}
+	var list []byte
+	for _, i := range mungle(len(protocols), p.extensionOrder) {
+		list = append(list, byte(len(protocols[i])))
+		list = append(list, protocols[i]...)
+	}
+	return append(clients.Uint16Bytes(len(list)), list...)
+}
+
+// keyShareExtension returns the value of the key_share extension with an
+// x25519 key, preceded by a grease key share for grease probes
+func (p probe) keyShareExtension(key []byte) []byte {
+	var shares []byte
+	if p.grease {
+		shares = append(clients.Uint16Bytes(int(greaseValue())), 0, 1, 0)
+	}
+	shares = append(shares, 0, 29, 0, 32)
+	shares = append(shares, key...)
+	return append(clients.Uint16Bytes(len(shares)), shares...)
+}
+
+// supportedVersionsExtension returns the value of the supported_versions
+// extension of a probe
+func (p probe) supportedVersionsExtension() []byte {
+	versions := []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12}
+	if p.support != supportTLS12 {
+		versions = append(versions, tls.VersionTLS13)
+	}
+	var ordered []uint16
+	if p.grease {
+		ordered = append(ordered, greaseValue())
+	}
+	for _, i := range mungle(len(versions), p.extensionOrder) {
+		ordered = append(ordered, versions[i])
+	}
+	value := []byte{byte(len(ordered) * 2)}
+	for _, version := range ordered {
+		value = append(value, clients.Uint16Bytes(int(version))...)
+	}
+	return value
+}
+
+// greaseValue returns a random grease value
+func greaseValue() uint16 {
+	value := uint16(mathrand.Intn(16))<<4 | 0x0a
+	return value<<8 | value
+}
+
+// parseServerHello returns the result of a response, which is the
+// selected cipher, version, alpn protocol and extension types of a
+// ServerHello separated by |
+func parseServerHello(data []byte) string {
+	if len(data) < 44 || data[0] != 22 || data[5] != 2 {
+		return emptyResult
+	}
+	serverHelloLength := int(binary.BigEndian.Uint16(data[3:5]))
+	counter := int(data[43])
+	if len(data) < counter+46 {
+		return emptyResult
+	}
+	cipher := hex.EncodeToString(data[counter+44 : counter+46])
+	version := hex.EncodeToString(data[9:11])
+	return cipher + "|" + version + "|" + parseExtensions(data, counter, serverHelloLength)
+}
+
+// parseExtensions returns the alpn protocol and extension types of a
+// ServerHello separated by |
+func parseExtensions(data []byte, counter, serverHelloLength int) string {
+	if len(data) <= counter+47 || data[counter+47] == 11 {
+		return "|"
+	}
+	if bytesAt(data, counter+50, 3) == "\x0e\xac\x0b" || bytesAt(data, 82, 3) == "\x0f\xf0\x0b" {
+		return "|"
+	}
+	if counter+42 >= serverHelloLength || len(data) < counter+49 {
+		return "|"
+	}
+
+	count := counter + 49
+	maximum := int(binary.BigEndian.Uint16(data[counter+47:counter+49])) + count - 1
+	var types []string
+	var alpn string
+	for count < maximum {
+		if len(data) < count+4 {
+			return "|"
+		}
+		extensionType := data[count : count+2]
+		length := int(binary.BigEndian.Uint16(data[count+2 : count+4]))
+		value := data[count+4:]
+		if len(value) > length {
+			value = value[:length]
+		}
+		if extensionType[0] == 0 && extensionType[1] == 16 && alpn == "" && len(value) > 3 {
+			alpn = string(value[3:])
+		}
+		types = append(types, hex.EncodeToString(extensionType))
+		count += length + 4
+	}
+	return alpn + "|" + strings.Join(types, "-")
+}
+
+// bytesAt returns length bytes of data at offset, fewer if data is shorter
+func bytesAt(data []byte, offset, length int) string {
+	if offset >= len(data) {
+		return ""
+	}
+	if offset+length > len(data) {
+		length = len(data) - offset
+	}
+	return string(data[offset : offset+length])
+}
+
+// Hash returns the jarm fingerprint of the results of the ten probes.
+// The fingerprint is the index of the selected cipher and version of each
+// probe followed by the truncated sha256 hash of the alpn protocols and
+// extensions, or 62 zeros if no probe got a ServerHello.
+func Hash(results []string) string {
+	empty := true
+	for _, result := range results {
+		if result != emptyResult {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return strings.Repeat("0", 62)
+	}
+
+	fuzzy := &strings.Builder{}
+	var alpnsAndExtensions string
+	for _, result := range results {
+		components := strings.SplitN(result, "|", 4)
+		for len(components) < 4 {
+			components = append(components, "")
+		}
+		fuzzy.WriteString(cipherBytes(components[0]))
+		fuzzy.WriteString(versionByte(components[1]))
+		alpnsAndExtensions += components[2] + components[3]
+	}
+	sum := sha256.Sum256([]byte(alpnsAndExtensions))
+	fuzzy.WriteString(hex.EncodeToString(sum[:])[:32])
+	return fuzzy.String()
+}
+
+// cipherBytes returns the two hex digit one based index of a selected
+// cipher in the hashed ciphers, 00 if no cipher was selected
+func cipherBytes(cipher string) string {
+	if cipher == "" {
+		return "00"
+	}
+	count := 1
+	for _, hashed := range hashedCiphers {
+		if fmt.Sprintf("%04x", hashed) == cipher {
+			break
+		}
+		count++
+	}
+	return fmt.Sprintf("%02x", count)
+}
+
+// versionByte returns the letter of the minor number of a selected version
+func versionByte(version string) string {
+	if len(version) < 4 || version[3] < '0' || version[3] > '5' {
+		return "0"
+	}
+	return string("abcdef"[version[3]-'0'])
+}
+
+// mungle returns the indices of length values reordered with order
+func mungle(length int, order string) []int {
+	var output []int
+	switch order {
+	case orderReverse:
+		for i := length - 1; i >= 0; i-- {
+			output = append(output, i)
+		}
+	case orderBottomHalf:
+		for i := length / 2; i < length; i++ {
+			if length%2 == 1 && i == length/2 {
+				continue
+			}
+			output = append(output, i)
+		}
+	case orderTopHalf:
+		// the top half in reverse including the middle value
+		if length%2 == 1 {
+			output = append(output, length/2)
+		}
+		reverse := mungle(length, orderReverse)
+		for _, i := range mungle(length, orderBottomHalf) {
+			output = append(output, reverse[i])
+		}
+	case orderMiddleOut:
+		middle := length / 2
+		if length%2 == 1 {
+			output = append(output, middle)
+			for i := 1; i <= middle; i++ {
+				output = append(output, middle+i, middle-i)
+			}
+		} else {
+			for i := 1; i <= middle; i++ {
+				output = append(output, middle-1+i, middle-i)
+			}
+		}
+	default:
+		for i := 0; i < length; i++ {
+			output = append(output, i)
+		}
+	}
+	return output
+}
@@ -0,0 +1,145 @@
+// Package ldap implements confirmation of active directory domain
+// controllers on the ldaps and global catalog over tls ports.
+package ldap
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// domainControllerPorts is the list of active directory ports served over
+// tls: ldaps and global catalog.
+var domainControllerPorts = map[string]struct{}{
+	"636":  {},
+	"3269": {},
+}
+
+// rootDSESearch is an anonymous ldap search of the root dse for the
+// supportedCapabilities attribute with message id 1.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc4511#section-4.5.1
+var rootDSESearch = []byte("\x30\x3c\x02\x01\x01\x63\x37\x04\x00\x0a\x01\x00\x0a\x01\x00\x02\x01\x00\x02\x01\x00\x01\x01\x00" +
+	"\x87\x0bobjectClass\x30\x17\x04\x15supportedCapabilities")
+
+// activeDirectoryCapability is the LDAP_CAP_ACTIVE_DIRECTORY_OID capability
+// advertised by active directory domain services, but not lightweight
+// directory services.
+var activeDirectoryCapability = []byte("1.2.840.113556.1.4.800")
+
+const (
+	// searchResultEntry is the protocol tag of search result entries
+	searchResultEntry = 0x64
+	// maxMessageSize is the maximum size of ldap messages read
+	maxMessageSize = 64 * 1024
+	// maxMessages is the maximum number of ldap messages read
+	maxMessages = 4
+)
+
+// IsDomainControllerPort returns true if port is an active directory port
+func IsDomainControllerPort(port string) bool {
+	_, ok := domainControllerPorts[port]
+	return ok
+}
+
+// IsActiveDirectory makes a handshake with the ldap server of a port and
+// returns true if its root dse advertises active directory domain services.
+func IsActiveDirectory(options *clients.Options, hostname, port string) (bool, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return false, errors.Wrap(err, "could not dial address")
+	}
+	defer rawConn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = rawConn.SetDeadline(deadline)
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		ServerName:         options.ServerName,
+	}
+	if config.ServerName == "" && !iputil.IsIP(hostname) {
+		config.ServerName = hostname
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return false, errors.Wrap(err, "could not do handshake")
+	}
+	if _, err := conn.Write(rootDSESearch); err != nil {
+		return false, errors.Wrap(err, "could not write root dse search")
+	}
+
+	// the entry is followed by the search result done message
+	for i := 0; i < maxMessages; i++ {
+		message, err := readMessage(conn)
+		if err != nil {
+			return false, nil
+		}
+		if protocolTag(message) == searchResultEntry {
+			return bytes.Contains(message, activeDirectoryCapability), nil
+		}
+	}
+	return false, nil
+}
+
+// readMessage reads a ber encoded ldap message and returns its content
+func readMessage(reader io.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0x30 {
+		return nil, errors.New("unexpected ldap message tag")
+	}
+	length := int(header[1])
+	if length&0x80 != 0 {
+		count := length & 0x7f
+		if count == 0 || count > 4 {
+			return nil, errors.New("unsupported ldap message length")
+		}
+		encoded := make([]byte, count)
+		if _, err := io.ReadFull(reader, encoded); err != nil {
+			return nil, err
+		}
+		length = 0
+		for _, b := range encoded {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxMessageSize {
+		return nil, errors.New("ldap message too large")
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(reader, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// protocolTag returns the tag of the protocol operation following the
+// message id of the content of an ldap message.
+func protocolTag(message []byte) byte {
+	if len(message) < 2 || message[0] != 0x02 {
+		return 0
+	}
+	offset := 2 + int(message[1])
+	if offset >= len(message) {
+		return 0
+	}
+	return message[offset]
+}
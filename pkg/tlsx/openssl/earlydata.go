@@ -0,0 +1,80 @@
+package openssl
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// earlyDataPayload is the early data sent on resumption which is
+// harmless for the protocols spoken over tls.
+const earlyDataPayload = "\r\n"
+
+var (
+	maxEarlyDataRegex = regexp.MustCompile(`(?m)^\s*Max Early Data:\s*(\d+)`)
+	earlyDataRegex    = regexp.MustCompile(`(?m)^Early data was (accepted|rejected)`)
+)
+
+// EarlyData returns whether the server accepts tls 1.3 early data on
+// session resumption. Nil is returned if the server does not issue
+// session tickets allowing early data.
+func (c *Client) EarlyData(hostname, port string) (*clients.EarlyDataResponse, error) {
+	args, _, err := c.connectArgs(hostname, port)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "-tls1_3")
+
+	session, err := os.CreateTemp("", "tlsx-session-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create session file")
+	}
+	_ = session.Close()
+	defer os.Remove(session.Name())
+
+	output, err := c.runInteractive(append(args, "-sess_out", session.Name()), "", isTicketLine)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get session ticket")
+	}
+	match := maxEarlyDataRegex.FindSubmatch(output)
+	if match == nil {
+		return nil, nil
+	}
+	maxEarlyData, _ := strconv.ParseUint(string(match[1]), 10, 32)
+	if maxEarlyData == 0 {
+		return nil, nil
+	}
+
+	payload, err := os.CreateTemp("", "tlsx-early-data-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create early data file")
+	}
+	defer os.Remove(payload.Name())
+	_, err = payload.WriteString(earlyDataPayload)
+	if closeErr := payload.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not write early data file")
+	}
+
+	output, err = c.runInteractive(append(args, "-sess_in", session.Name(), "-early_data", payload.Name()), "", isTicketLine)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not resume session")
+	}
+	response := &clients.EarlyDataResponse{MaxEarlyData: uint32(maxEarlyData)}
+	if match := earlyDataRegex.FindSubmatch(output); match != nil {
+		response.Accepted = string(match[1]) == "accepted"
+	}
+	return response, nil
+}
+
+// isTicketLine returns true for the line printed when a session ticket
+// arrives, s_client prints the whole ticket before reading stdin again.
+func isTicketLine(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("Post-Handshake New Session Ticket arrived"))
+}
@@ -0,0 +1,64 @@
+package openssl
+
+import (
+	"crypto/x509"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// ocspTimeLayout is the layout of times printed by s_client -status
+const ocspTimeLayout = "Jan _2 15:04:05 2006 MST"
+
+var (
+	ocspStapledRegex    = regexp.MustCompile(`(?m)^OCSP Response Data:`)
+	ocspStatusRegex     = regexp.MustCompile(`(?m)^\s*OCSP Response Status:\s*(\S+)`)
+	ocspCertStatusRegex = regexp.MustCompile(`(?m)^\s*Cert Status:\s*(\S+)`)
+	ocspProducedRegex   = regexp.MustCompile(`(?m)^\s*Produced At:\s*(.+)$`)
+	ocspThisRegex       = regexp.MustCompile(`(?m)^\s*This Update:\s*(.+)$`)
+	ocspNextRegex       = regexp.MustCompile(`(?m)^\s*Next Update:\s*(.+)$`)
+	ocspRevokedRegex    = regexp.MustCompile(`(?m)^\s*Revocation Time:\s*(.+)$`)
+	ocspSerialRegex     = regexp.MustCompile(`(?m)^\s*Serial Number:\s*([0-9A-Fa-f]+)`)
+)
+
+// parseOCSP parses the stapled ocsp response printed by s_client -status
+// for the leaf of a raw chain.
+func parseOCSP(output []byte, rawChain [][]byte, now time.Time) *clients.OCSPResponse {
+	if !ocspStapledRegex.Match(output) {
+		return &clients.OCSPResponse{}
+	}
+	if status := submatch(ocspStatusRegex, output); status != "successful" {
+		return &clients.OCSPResponse{Stapled: true, Error: "ocsp response status: " + status}
+	}
+	if len(rawChain) > 0 {
+		if leaf, err := x509.ParseCertificate(rawChain[0]); err == nil {
+			serial, ok := new(big.Int).SetString(submatch(ocspSerialRegex, output), 16)
+			if !ok || serial.Cmp(leaf.SerialNumber) != 0 {
+				return &clients.OCSPResponse{Stapled: true, Error: "ocsp response for another certificate"}
+			}
+		}
+	}
+	return clients.NewStapledOCSP(
+		submatch(ocspCertStatusRegex, output),
+		parseOCSPTime(submatch(ocspProducedRegex, output)),
+		parseOCSPTime(submatch(ocspThisRegex, output)),
+		parseOCSPTime(submatch(ocspNextRegex, output)),
+		parseOCSPTime(submatch(ocspRevokedRegex, output)),
+		now,
+	)
+}
+
+func submatch(regex *regexp.Regexp, output []byte) string {
+	if match := regex.FindSubmatch(output); match != nil {
+		return strings.TrimSpace(string(match[1]))
+	}
+	return ""
+}
+
+func parseOCSPTime(value string) time.Time {
+	parsed, _ := time.Parse(ocspTimeLayout, value)
+	return parsed
+}
@@ -0,0 +1,354 @@
+// Package openssl implements a tls grabbing implementation using
+// the openssl s_client binary for servers requiring obsolete protocols
+// and ciphers which cannot be negotiated by go tls libraries.
+package openssl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+	"github.com/rs/xid"
+	"github.com/zmap/zcrypto/x509"
+)
+
+// Client is a TLS grabbing client using openssl s_client
+type Client struct {
+	dialer  *fastdialer.Dialer
+	binary  string
+	args    []string
+	options *clients.Options
+}
+
+// opensslStartTLSProtocols is the list of starttls protocols also
+// supported by s_client under the same name.
+var opensslStartTLSProtocols = map[string]bool{
+	starttls.SMTP:     true,
+	starttls.FTP:      true,
+	starttls.IMAP:     true,
+	starttls.POP3:     true,
+	starttls.LDAP:     true,
+	starttls.XMPP:     true,
+	starttls.POSTGRES: true,
+	starttls.MYSQL:    true,
+}
+
+// versionOrder is the order of versions supported by openssl
+var versionOrder = []string{"ssl30", "tls10", "tls11", "tls12", "tls13"}
+
+// versionToDisableFlag converts tls version string to s_client flag disabling it
+var versionToDisableFlag = map[string]string{
+	"ssl30": "-no_ssl3",
+	"tls10": "-no_tls1",
+	"tls11": "-no_tls1_1",
+	"tls12": "-no_tls1_2",
+	"tls13": "-no_tls1_3",
+}
+
+// protocolToTLSVersionString converts openssl protocol name to version string
+var protocolToTLSVersionString = map[string]string{
+	"SSLv3":   "ssl30",
+	"TLSv1":   "tls10",
+	"TLSv1.1": "tls11",
+	"TLSv1.2": "tls12",
+	"TLSv1.3": "tls13",
+}
+
+var (
+	protocolRegex   = regexp.MustCompile(`(?m)^\s*Protocol\s*:\s*(\S+)`)
+	cipherRegex     = regexp.MustCompile(`(?m)^\s*Cipher\s*:\s*(\S+)`)
+	alpnRegex       = regexp.MustCompile(`(?m)^ALPN protocol:\s*(\S+)`)
+	newSessionRegex = regexp.MustCompile(`(?m)^New, (\S+), Cipher is (\S+)`)
+)
+
+// New creates a new grabbing client using openssl
+func New(options *clients.Options) (*Client, error) {
+	binary := options.OpenSSLBinary
+	if binary == "" {
+		binary = "openssl"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find openssl binary")
+	}
+	c := &Client{
+		dialer:  options.Fastdialer,
+		binary:  path,
+		options: options,
+	}
+
+	if len(options.Ciphers) > 0 {
+		ciphers, suites, err := toOpenSSLCiphers(options.Ciphers)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get openssl ciphers")
+		}
+		if ciphers != "" {
+			c.args = append(c.args, "-cipher", ciphers)
+		}
+		if suites != "" {
+			c.args = append(c.args, "-ciphersuites", suites)
+		}
+	}
+	if options.CACertificate != "" {
+		c.args = append(c.args, "-CAfile", options.CACertificate)
+	}
+	if len(options.ALPN) > 0 {
+		c.args = append(c.args, "-alpn", strings.Join(options.ALPN, ","))
+	}
+	if options.OCSP {
+		c.args = append(c.args, "-status")
+	}
+	minIndex, maxIndex := 0, len(versionOrder)-1
+	if options.MinVersion != "" {
+		if minIndex = indexOfVersion(options.MinVersion); minIndex == -1 {
+			return nil, fmt.Errorf("invalid min version specified: %s", options.MinVersion)
+		}
+	}
+	if options.MaxVersion != "" {
+		if maxIndex = indexOfVersion(options.MaxVersion); maxIndex == -1 {
+			return nil, fmt.Errorf("invalid max version specified: %s", options.MaxVersion)
+		}
+	}
+	for i, version := range versionOrder {
+		if i < minIndex || i > maxIndex {
+			c.args = append(c.args, versionToDisableFlag[version])
+		}
+	}
+	return c, nil
+}
+
+func indexOfVersion(version string) int {
+	for i, value := range versionOrder {
+		if value == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// Connect connects to a host and grabs the response data
+func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
+	ctx := context.Background()
+	if c.options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	args, resolvedIP, err := c.connectArgs(hostname, port)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, c.args...)
+	cmd := exec.CommandContext(ctx, c.binary, args...)
+	cmd.Stdin = strings.NewReader("")
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "could not do handshake")
+	}
+
+	handshake := NewHandshake(output, c.options)
+	if len(handshake.RawChain) == 0 {
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not do handshake: %s", lastLine(output))
+		}
+		return nil, errors.New("no certificates returned by server")
+	}
+	handshake.Host, handshake.IP, handshake.Port = hostname, resolvedIP, port
+	return clients.NewResponse(c.options, handshake)
+}
+
+// NewHandshake returns the handshake of the output of s_client
+func NewHandshake(output []byte, options *clients.Options) *clients.Handshake {
+	tlsVersion, tlsCipher := parseSession(output)
+	handshake := &clients.Handshake{
+		Version:       tlsVersion,
+		Cipher:        tlsCipher,
+		TLSConnection: "openssl",
+		RawChain:      parseCertificates(output),
+	}
+	if match := alpnRegex.FindSubmatch(output); match != nil {
+		handshake.ALPN = string(match[1])
+	}
+	if options.OCSP {
+		// s_client only prints the parsed staple
+		handshake.OCSP = parseOCSP(output, handshake.RawChain, clients.Now(options))
+	}
+	return handshake
+}
+
+// connectArgs returns the s_client arguments to connect to a host along
+// with the resolved address if hostname is not an ip.
+func (c *Client) connectArgs(hostname, port string) ([]string, string, error) {
+	// resolve using the dialer so custom resolvers are respected
+	ip := hostname
+	var resolvedIP string
+	if !iputil.IsIP(hostname) {
+		dnsData, err := c.dialer.GetDNSData(hostname)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "could not resolve host")
+		}
+		if len(dnsData.A) > 0 {
+			ip = dnsData.A[0]
+		} else if len(dnsData.AAAA) > 0 {
+			ip = dnsData.AAAA[0]
+		} else {
+			return nil, "", errors.New("no address found for host")
+		}
+		resolvedIP = ip
+	}
+
+	serverName := c.options.ServerName
+	if serverName == "" {
+		if iputil.IsIP(hostname) {
+			// using a random sni will return the default server certificate
+			serverName = xid.New().String()
+		} else {
+			serverName = hostname
+		}
+	}
+
+	args := []string{"s_client", "-connect", net.JoinHostPort(ip, port), "-servername", serverName, "-showcerts"}
+	if protocol := starttls.Protocol(c.options, port); protocol != "" {
+		if !opensslStartTLSProtocols[protocol] {
+			return nil, "", fmt.Errorf("starttls protocol %s is not supported by openssl", protocol)
+		}
+		args = append(args, "-starttls", protocol)
+		if protocol == starttls.XMPP {
+			args = append(args, "-xmpphost", starttls.ServerName(c.options, hostname))
+		}
+	}
+	return args, resolvedIP, nil
+}
+
+// interactiveWait is the time to keep connections open after the handshake
+// for messages sent by servers afterwards such as tls 1.3 session tickets.
+const interactiveWait = 2 * time.Second
+
+// runInteractive runs s_client writing input after the handshake and keeps
+// the connection open until done returns true for an output line or
+// interactiveWait elapses, returning the output.
+func (c *Client) runInteractive(args []string, input string, done func(line []byte) bool) ([]byte, error) {
+	ctx := context.Background()
+	if c.options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.options.Timeout)*time.Second+interactiveWait)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, c.binary, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	closeStdin := func() { once.Do(func() { _ = stdin.Close() }) }
+	timer := time.AfterFunc(interactiveWait, closeStdin)
+	defer timer.Stop()
+	if input != "" {
+		// s_client reads stdin only once the handshake is done
+		_, _ = io.WriteString(stdin, input)
+	}
+
+	var output bytes.Buffer
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		output.Write(line)
+		if done(line) {
+			closeStdin()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			closeStdin()
+			return nil, err
+		}
+	}
+	closeStdin()
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "could not do handshake")
+	}
+	if err != nil && !strings.Contains(output.String(), "CONNECTED") {
+		return nil, errors.Wrapf(err, "could not do handshake: %s", lastLine(output.Bytes()))
+	}
+	return output.Bytes(), nil
+}
+
+// parseSession returns the negotiated version and cipher from s_client output
+func parseSession(output []byte) (string, string) {
+	var protocol, cipher string
+	if match := protocolRegex.FindSubmatch(output); match != nil {
+		protocol = string(match[1])
+	}
+	if match := cipherRegex.FindSubmatch(output); match != nil {
+		cipher = string(match[1])
+	}
+	if match := newSessionRegex.FindSubmatch(output); match != nil {
+		if protocol == "" {
+			protocol = string(match[1])
+		}
+		if cipher == "" {
+			cipher = string(match[2])
+		}
+	}
+	if cipher == "0000" || cipher == "(NONE)" {
+		cipher = ""
+	}
+	return protocolToTLSVersionString[protocol], toIANACipher(cipher)
+}
+
+// parseCertificates returns the der encoded certificates printed by -showcerts.
+// Certificates embedded in the stapled ocsp response are printed before
+// the chain, so parsing starts at the chain header if present.
+func parseCertificates(output []byte) [][]byte {
+	var certificates [][]byte
+	rest := output
+	if index := bytes.Index(output, []byte("\nCertificate chain\n")); index != -1 {
+		rest = output[index:]
+	}
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certificates = append(certificates, block.Bytes)
+		}
+	}
+	return certificates
+}
+
+// lastLine returns the last non-empty line of output for error context
+func lastLine(output []byte) string {
+	lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+	return string(lines[len(lines)-1])
+}
@@ -0,0 +1,52 @@
+package openssl
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// renegotiateCommand is the s_client command renegotiating the session
+const renegotiateCommand = "R\n"
+
+var secureRenegotiationRegex = regexp.MustCompile(`(?m)^Secure Renegotiation IS (NOT )?supported`)
+
+// Renegotiation returns whether the server supports the secure renegotiation
+// extension and permits client-initiated renegotiation. Nil is returned if
+// the server does not support tls 1.2 or lower which allow renegotiation.
+func (c *Client) Renegotiation(hostname, port string) (*clients.RenegotiationResponse, error) {
+	args, _, err := c.connectArgs(hostname, port)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "-no_tls1_3")
+
+	renegotiating, renegotiated := false, false
+	output, err := c.runInteractive(args, renegotiateCommand, func(line []byte) bool {
+		if bytes.HasPrefix(line, []byte("RENEGOTIATING")) {
+			renegotiating = true
+			return false
+		}
+		// the certificate is verified again once the new handshake completes
+		if renegotiating && bytes.HasPrefix(line, []byte("verify return:")) {
+			renegotiated = true
+		}
+		return renegotiated
+	})
+	if err != nil {
+		return nil, err
+	}
+	// s_client reports no secure renegotiation for failed handshakes
+	if _, cipher := parseSession(output); cipher == "" {
+		return nil, nil
+	}
+	match := secureRenegotiationRegex.FindSubmatch(output)
+	if match == nil {
+		return nil, nil
+	}
+	return &clients.RenegotiationResponse{
+		SecureRenegotiation: len(match[1]) == 0,
+		ClientInitiated:     renegotiated,
+	}, nil
+}
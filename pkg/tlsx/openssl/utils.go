@@ -0,0 +1,110 @@
+package openssl
+
+import "fmt"
+
+// toOpenSSLCiphers converts iana cipher names to openssl names
+// returning tls1.2 and below cipher list and tls1.3 ciphersuites.
+func toOpenSSLCiphers(items []string) (string, string, error) {
+	var ciphers, suites string
+	for _, item := range items {
+		if _, ok := tls13Ciphers[item]; ok {
+			suites = joinCipher(suites, item)
+			continue
+		}
+		name, ok := ianaToOpenSSLCiphers[item]
+		if !ok {
+			return "", "", fmt.Errorf("unsupported cipher suite: %s", item)
+		}
+		ciphers = joinCipher(ciphers, name)
+	}
+	return ciphers, suites, nil
+}
+
+func joinCipher(list, item string) string {
+	if list == "" {
+		return item
+	}
+	return list + ":" + item
+}
+
+// toIANACipher converts an openssl cipher name to the iana name
+// returning the name as-is if it is not known.
+func toIANACipher(name string) string {
+	if iana, ok := openSSLToIANACiphers[name]; ok {
+		return iana
+	}
+	return name
+}
+
+var tls13Ciphers = map[string]struct{}{
+	"TLS_AES_128_GCM_SHA256":       {},
+	"TLS_AES_256_GCM_SHA384":       {},
+	"TLS_CHACHA20_POLY1305_SHA256": {},
+	"TLS_AES_128_CCM_SHA256":       {},
+	"TLS_AES_128_CCM_8_SHA256":     {},
+}
+
+var ianaToOpenSSLCiphers = map[string]string{
+	"TLS_RSA_WITH_NULL_MD5":                         "NULL-MD5",
+	"TLS_RSA_WITH_NULL_SHA":                         "NULL-SHA",
+	"TLS_RSA_EXPORT_WITH_RC4_40_MD5":                "EXP-RC4-MD5",
+	"TLS_RSA_WITH_RC4_128_MD5":                      "RC4-MD5",
+	"TLS_RSA_WITH_RC4_128_SHA":                      "RC4-SHA",
+	"TLS_RSA_EXPORT_WITH_RC2_CBC_40_MD5":            "EXP-RC2-CBC-MD5",
+	"TLS_RSA_WITH_IDEA_CBC_SHA":                     "IDEA-CBC-SHA",
+	"TLS_RSA_EXPORT_WITH_DES40_CBC_SHA":             "EXP-DES-CBC-SHA",
+	"TLS_RSA_WITH_DES_CBC_SHA":                      "DES-CBC-SHA",
+	"TLS_RSA_WITH_3DES_EDE_CBC_SHA":                 "DES-CBC3-SHA",
+	"TLS_DHE_RSA_EXPORT_WITH_DES40_CBC_SHA":         "EXP-EDH-RSA-DES-CBC-SHA",
+	"TLS_DHE_RSA_WITH_DES_CBC_SHA":                  "EDH-RSA-DES-CBC-SHA",
+	"TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA":             "EDH-RSA-DES-CBC3-SHA",
+	"TLS_DHE_DSS_WITH_3DES_EDE_CBC_SHA":             "EDH-DSS-DES-CBC3-SHA",
+	"TLS_DH_ANON_WITH_RC4_128_MD5":                  "ADH-RC4-MD5",
+	"TLS_DH_ANON_WITH_3DES_EDE_CBC_SHA":             "ADH-DES-CBC3-SHA",
+	"TLS_RSA_WITH_AES_128_CBC_SHA":                  "AES128-SHA",
+	"TLS_DHE_DSS_WITH_AES_128_CBC_SHA":              "DHE-DSS-AES128-SHA",
+	"TLS_DHE_RSA_WITH_AES_128_CBC_SHA":              "DHE-RSA-AES128-SHA",
+	"TLS_DH_ANON_WITH_AES_128_CBC_SHA":              "ADH-AES128-SHA",
+	"TLS_RSA_WITH_AES_256_CBC_SHA":                  "AES256-SHA",
+	"TLS_DHE_DSS_WITH_AES_256_CBC_SHA":              "DHE-DSS-AES256-SHA",
+	"TLS_DHE_RSA_WITH_AES_256_CBC_SHA":              "DHE-RSA-AES256-SHA",
+	"TLS_DH_ANON_WITH_AES_256_CBC_SHA":              "ADH-AES256-SHA",
+	"TLS_RSA_WITH_AES_128_CBC_SHA256":               "AES128-SHA256",
+	"TLS_RSA_WITH_AES_256_CBC_SHA256":               "AES256-SHA256",
+	"TLS_DHE_RSA_WITH_AES_128_CBC_SHA256":           "DHE-RSA-AES128-SHA256",
+	"TLS_DHE_RSA_WITH_AES_256_CBC_SHA256":           "DHE-RSA-AES256-SHA256",
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":               "AES128-GCM-SHA256",
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":               "AES256-GCM-SHA384",
+	"TLS_DHE_RSA_WITH_AES_128_GCM_SHA256":           "DHE-RSA-AES128-GCM-SHA256",
+	"TLS_DHE_RSA_WITH_AES_256_GCM_SHA384":           "DHE-RSA-AES256-GCM-SHA384",
+	"TLS_RSA_WITH_CAMELLIA_128_CBC_SHA":             "CAMELLIA128-SHA",
+	"TLS_RSA_WITH_CAMELLIA_256_CBC_SHA":             "CAMELLIA256-SHA",
+	"TLS_RSA_WITH_SEED_CBC_SHA":                     "SEED-SHA",
+	"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA":              "ECDHE-ECDSA-RC4-SHA",
+	"TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA":         "ECDHE-ECDSA-DES-CBC3-SHA",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":          "ECDHE-ECDSA-AES128-SHA",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":          "ECDHE-ECDSA-AES256-SHA",
+	"TLS_ECDHE_RSA_WITH_RC4_128_SHA":                "ECDHE-RSA-RC4-SHA",
+	"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA":           "ECDHE-RSA-DES-CBC3-SHA",
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":            "ECDHE-RSA-AES128-SHA",
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":            "ECDHE-RSA-AES256-SHA",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256":       "ECDHE-ECDSA-AES128-SHA256",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384":       "ECDHE-ECDSA-AES256-SHA384",
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256":         "ECDHE-RSA-AES128-SHA256",
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384":         "ECDHE-RSA-AES256-SHA384",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256":       "ECDHE-ECDSA-AES128-GCM-SHA256",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384":       "ECDHE-ECDSA-AES256-GCM-SHA384",
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":         "ECDHE-RSA-AES128-GCM-SHA256",
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":         "ECDHE-RSA-AES256-GCM-SHA384",
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256":   "ECDHE-RSA-CHACHA20-POLY1305",
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256": "ECDHE-ECDSA-CHACHA20-POLY1305",
+	"TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256":     "DHE-RSA-CHACHA20-POLY1305",
+}
+
+var openSSLToIANACiphers = func() map[string]string {
+	values := make(map[string]string, len(ianaToOpenSSLCiphers))
+	for iana, name := range ianaToOpenSSLCiphers {
+		values[name] = iana
+	}
+	return values
+}()
@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/pkg/errors"
+)
+
+// negotiateToken returns a base64 spnego token for the proxy service.
+// The kerberos client is created once per dialer so its login and
+// service ticket are reused by later connections.
+func (d *Dialer) negotiateToken() (string, error) {
+	kerberosClient, err := d.kerberos()
+	if err != nil {
+		return "", err
+	}
+	negotiator := spnego.SPNEGOClient(kerberosClient, "HTTP/"+d.hostname)
+	if err := negotiator.AcquireCred(); err != nil {
+		return "", errors.Wrap(err, "could not acquire kerberos credentials")
+	}
+	contextToken, err := negotiator.InitSecContext()
+	if err != nil {
+		return "", errors.Wrap(err, "could not initialize security context")
+	}
+	token, err := contextToken.Marshal()
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal spnego token")
+	}
+	return base64.StdEncoding.EncodeToString(token), nil
+}
+
+// kerberos returns the kerberos client of the dialer creating it on first use.
+//
+// Credentials from the proxy url are used if a domain is given, otherwise
+// tickets are read from the kerberos credential cache (KRB5CCNAME).
+func (d *Dialer) kerberos() (*client.Client, error) {
+	d.kerberosMutex.Lock()
+	defer d.kerberosMutex.Unlock()
+
+	if d.kerberosClient != nil {
+		return d.kerberosClient, nil
+	}
+	configPath := os.Getenv("KRB5_CONFIG")
+	if configPath == "" {
+		configPath = "/etc/krb5.conf"
+	}
+	krb5conf, err := config.Load(configPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load kerberos config")
+	}
+
+	var kerberosClient *client.Client
+	if d.username != "" && d.domain != "" {
+		kerberosClient = client.NewWithPassword(d.username, strings.ToUpper(d.domain), d.password, krb5conf, client.DisablePAFXFAST(true))
+	} else {
+		ccache, err := credentials.LoadCCache(credentialCachePath())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load kerberos credential cache")
+		}
+		if kerberosClient, err = client.NewFromCCache(ccache, krb5conf, client.DisablePAFXFAST(true)); err != nil {
+			return nil, errors.Wrap(err, "could not create kerberos client")
+		}
+	}
+	d.kerberosClient = kerberosClient
+	return kerberosClient, nil
+}
+
+// credentialCachePath returns the path of the kerberos credential cache
+func credentialCachePath() string {
+	if path := os.Getenv("KRB5CCNAME"); path != "" {
+		return strings.TrimPrefix(path, "FILE:")
+	}
+	return "/tmp/krb5cc_" + strconv.Itoa(os.Getuid())
+}
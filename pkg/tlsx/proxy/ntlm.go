@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/md4"
+)
+
+// ntlm message negotiation flags
+const (
+	ntlmNegotiateUnicode         = 0x00000001
+	ntlmNegotiateOEM             = 0x00000002
+	ntlmRequestTarget            = 0x00000004
+	ntlmNegotiateNTLM            = 0x00000200
+	ntlmNegotiateAlwaysSign      = 0x00008000
+	ntlmNegotiateExtendedSession = 0x00080000
+	ntlmNegotiateTargetInfo      = 0x00800000
+	ntlmNegotiate128             = 0x20000000
+	ntlmNegotiate56              = 0x80000000
+
+	ntlmNegotiateFlags = ntlmNegotiateUnicode | ntlmNegotiateOEM | ntlmRequestTarget | ntlmNegotiateNTLM |
+		ntlmNegotiateAlwaysSign | ntlmNegotiateExtendedSession | ntlmNegotiateTargetInfo | ntlmNegotiate128 | ntlmNegotiate56
+)
+
+// ntlmAvTimestamp is the target info attribute carrying server time
+const ntlmAvTimestamp = 7
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// ntlmNegotiateMessage returns a NEGOTIATE_MESSAGE without domain or workstation
+//
+// follows: https://docs.microsoft.com/en-us/openspecs/windows_protocols/ms-nlmp
+func ntlmNegotiateMessage() []byte {
+	message := make([]byte, 32)
+	copy(message, ntlmSignature)
+	binary.LittleEndian.PutUint32(message[8:], 1)
+	binary.LittleEndian.PutUint32(message[12:], ntlmNegotiateFlags)
+	return message
+}
+
+// ntlmAuthenticateMessage returns a NTLMv2 AUTHENTICATE_MESSAGE for a
+// CHALLENGE_MESSAGE received from the server.
+func ntlmAuthenticateMessage(challenge []byte, username, domain, password string) ([]byte, error) {
+	if len(challenge) < 32 || !bytes.Equal(challenge[:8], ntlmSignature) || binary.LittleEndian.Uint32(challenge[8:]) != 2 {
+		return nil, errors.New("invalid ntlm challenge message")
+	}
+	flags := binary.LittleEndian.Uint32(challenge[20:])
+	serverChallenge := challenge[24:32]
+
+	var targetInfo []byte
+	if len(challenge) >= 48 {
+		length := int(binary.LittleEndian.Uint16(challenge[40:]))
+		offset := int(binary.LittleEndian.Uint32(challenge[44:]))
+		if offset+length > len(challenge) {
+			return nil, errors.New("invalid ntlm target info")
+		}
+		targetInfo = challenge[offset : offset+length]
+	}
+
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+	timestamp, serverTime := ntlmTimestamp(targetInfo)
+	if !serverTime {
+		timestamp = ntlmFiletime(time.Now())
+	}
+
+	key := ntowfv2(username, domain, password)
+	ntResponse := ntlmv2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo)
+	lmResponse := make([]byte, 24)
+	if !serverTime {
+		lmResponse = append(hmacMD5(key, serverChallenge, clientChallenge), clientChallenge...)
+	}
+
+	encode := func(value string) []byte {
+		if flags&ntlmNegotiateUnicode != 0 {
+			return utf16le(value)
+		}
+		return []byte(value)
+	}
+	payloads := [][]byte{lmResponse, ntResponse, encode(domain), encode(username), nil, nil}
+
+	message := make([]byte, 64)
+	copy(message, ntlmSignature)
+	binary.LittleEndian.PutUint32(message[8:], 3)
+	offset := len(message)
+	for i, payload := range payloads {
+		field := message[12+i*8:]
+		binary.LittleEndian.PutUint16(field, uint16(len(payload)))
+		binary.LittleEndian.PutUint16(field[2:], uint16(len(payload)))
+		binary.LittleEndian.PutUint32(field[4:], uint32(offset))
+		offset += len(payload)
+	}
+	binary.LittleEndian.PutUint32(message[60:], flags&ntlmNegotiateFlags)
+	for _, payload := range payloads {
+		message = append(message, payload...)
+	}
+	return message, nil
+}
+
+// ntowfv2 returns the NTLMv2 response key for the user
+func ntowfv2(username, domain, password string) []byte {
+	hash := md4.New()
+	_, _ = hash.Write(utf16le(password))
+	return hmacMD5(hash.Sum(nil), utf16le(strings.ToUpper(username)+domain))
+}
+
+// ntlmv2Response returns the NTProofStr followed by the client blob
+func ntlmv2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo []byte) []byte {
+	blob := []byte{1, 1, 0, 0, 0, 0, 0, 0}
+	blob = append(blob, timestamp...)
+	blob = append(blob, clientChallenge...)
+	blob = append(blob, 0, 0, 0, 0)
+	blob = append(blob, targetInfo...)
+	blob = append(blob, 0, 0, 0, 0)
+	return append(hmacMD5(key, serverChallenge, blob), blob...)
+}
+
+// ntlmTimestamp returns the server timestamp from target info if present
+func ntlmTimestamp(targetInfo []byte) ([]byte, bool) {
+	for len(targetInfo) >= 4 {
+		id := binary.LittleEndian.Uint16(targetInfo)
+		length := int(binary.LittleEndian.Uint16(targetInfo[2:]))
+		if id == 0 || len(targetInfo) < 4+length {
+			break
+		}
+		if id == ntlmAvTimestamp && length == 8 {
+			return targetInfo[4:12], true
+		}
+		targetInfo = targetInfo[4+length:]
+	}
+	return nil, false
+}
+
+// ntlmFiletime returns t as a windows FILETIME
+func ntlmFiletime(t time.Time) []byte {
+	value := make([]byte, 8)
+	binary.LittleEndian.PutUint64(value, uint64(t.UnixNano()/100+116444736000000000))
+	return value
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(md5.New, key)
+	for _, value := range data {
+		_, _ = mac.Write(value)
+	}
+	return mac.Sum(nil)
+}
+
+func utf16le(value string) []byte {
+	encoded := utf16.Encode([]rune(value))
+	data := make([]byte, len(encoded)*2)
+	for i, char := range encoded {
+		binary.LittleEndian.PutUint16(data[i*2:], char)
+	}
+	return data
+}
@@ -0,0 +1,229 @@
+// Package proxy implements dialing hosts through http proxies using
+// CONNECT tunnels with basic, ntlm and negotiate authentication.
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/gologger"
+)
+
+// maxDrainLength is the maximum length of a response body drained
+// from the proxy before the connection is reused.
+const maxDrainLength = 64 * 1024
+
+// Dialer is a dialer tunneling connections through a http proxy
+type Dialer struct {
+	dialer   *fastdialer.Dialer
+	address  string
+	hostname string
+	username string
+	password string
+	domain   string
+
+	kerberosMutex  sync.Mutex
+	kerberosClient *client.Client
+}
+
+// New creates a new proxy dialer from a proxy url.
+//
+// The proxy url is in the form http://[domain\user:password@]host:port,
+// where the domain may also be given as user@domain.
+func New(proxyURL string, dialer *fastdialer.Dialer) (*Dialer, error) {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse proxy url")
+	}
+	if parsed.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return nil, errors.New("no proxy host specified")
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "8080"
+	}
+	d := &Dialer{
+		dialer:   dialer,
+		address:  net.JoinHostPort(parsed.Hostname(), port),
+		hostname: parsed.Hostname(),
+	}
+	if parsed.User != nil {
+		d.username = parsed.User.Username()
+		d.password, _ = parsed.User.Password()
+		if parts := strings.SplitN(d.username, `\`, 2); len(parts) == 2 {
+			d.domain, d.username = parts[0], parts[1]
+		} else if parts := strings.SplitN(d.username, "@", 2); len(parts) == 2 {
+			d.username, d.domain = parts[0], parts[1]
+		}
+	}
+	return d, nil
+}
+
+// tunnel is a connection to the proxy with its buffered reader
+type tunnel struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads from the buffered reader so no tunnel data is lost
+func (t *tunnel) Read(data []byte) (int, error) {
+	return t.reader.Read(data)
+}
+
+// Dial connects to address through the proxy
+func (d *Dialer) Dial(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.connect(address, "")
+	if err == nil && response.StatusCode == http.StatusProxyAuthRequired {
+		conn, response, err = d.authenticate(ctx, conn, response, address)
+	}
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy returned %s", response.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (d *Dialer) dial(ctx context.Context) (*tunnel, error) {
+	conn, err := d.dialer.Dial(ctx, "tcp", d.address)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial proxy")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return &tunnel{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// connect sends a CONNECT request for address and reads the response
+func (t *tunnel) connect(address, authorization string) (*http.Response, error) {
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	request.Header.Set("Proxy-Connection", "Keep-Alive")
+	if authorization != "" {
+		request.Header.Set("Proxy-Authorization", authorization)
+	}
+	if err := request.Write(t.Conn); err != nil {
+		return nil, errors.Wrap(err, "could not write connect request")
+	}
+	response, err := http.ReadResponse(t.reader, request)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read connect response")
+	}
+	if response.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, maxDrainLength))
+		response.Body.Close()
+	}
+	return response, nil
+}
+
+// authenticate retries the CONNECT request with the strongest
+// authentication scheme offered by the proxy which can be used, falling
+// back to the other offered schemes if negotiate is rejected.
+func (d *Dialer) authenticate(ctx context.Context, conn *tunnel, response *http.Response, address string) (*tunnel, *http.Response, error) {
+	challenges := parseChallenges(response.Header.Values("Proxy-Authenticate"))
+	reconnect := response.Close
+	unsupported := errors.New("no supported proxy authentication scheme")
+
+	if _, ok := challenges["negotiate"]; ok {
+		token, err := d.negotiateToken()
+		if err == nil {
+			var retried *http.Response
+			conn, retried, err = d.retry(ctx, conn, reconnect, address, "Negotiate "+token)
+			if err != nil || retried.StatusCode != http.StatusProxyAuthRequired {
+				return conn, retried, err
+			}
+			reconnect = retried.Close
+			err = errors.New("negotiate token rejected")
+			unsupported = errors.New("proxy rejected negotiate authentication")
+		}
+		gologger.Debug().Msgf("Could not authenticate to proxy with kerberos: %s", err)
+	}
+	if _, ok := challenges["ntlm"]; ok && d.username != "" {
+		conn, response, err := d.retry(ctx, conn, reconnect, address, "NTLM "+base64.StdEncoding.EncodeToString(ntlmNegotiateMessage()))
+		if err != nil || response.StatusCode != http.StatusProxyAuthRequired {
+			return conn, response, err
+		}
+		challenge, err := base64.StdEncoding.DecodeString(parseChallenges(response.Header.Values("Proxy-Authenticate"))["ntlm"])
+		if err != nil {
+			return conn, nil, errors.Wrap(err, "could not decode ntlm challenge")
+		}
+		authenticate, err := ntlmAuthenticateMessage(challenge, d.username, d.domain, d.password)
+		if err != nil {
+			return conn, nil, errors.Wrap(err, "could not create ntlm authenticate message")
+		}
+		if response.Close {
+			return conn, nil, errors.New("proxy closed connection during ntlm authentication")
+		}
+		return d.retry(ctx, conn, false, address, "NTLM "+base64.StdEncoding.EncodeToString(authenticate))
+	}
+	if _, ok := challenges["basic"]; ok && d.username != "" {
+		username := d.username
+		if d.domain != "" {
+			username = d.domain + `\` + d.username
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + d.password))
+		return d.retry(ctx, conn, reconnect, address, "Basic "+credentials)
+	}
+	return conn, nil, unsupported
+}
+
+// retry sends a CONNECT request with authorization, reconnecting to
+// the proxy first if it closed the previous connection.
+func (d *Dialer) retry(ctx context.Context, conn *tunnel, reconnect bool, address, authorization string) (*tunnel, *http.Response, error) {
+	if reconnect {
+		conn.Close()
+		newConn, err := d.dial(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		conn = newConn
+	}
+	response, err := conn.connect(address, authorization)
+	return conn, response, err
+}
+
+// parseChallenges returns a map of lowercase scheme to challenge token
+// from Proxy-Authenticate header values.
+func parseChallenges(values []string) map[string]string {
+	challenges := make(map[string]string)
+	for _, value := range values {
+		parts := strings.SplitN(strings.TrimSpace(value), " ", 2)
+		var token string
+		if len(parts) == 2 {
+			token = strings.TrimSpace(parts[1])
+		}
+		challenges[strings.ToLower(parts[0])] = token
+	}
+	return challenges
+}
@@ -0,0 +1,151 @@
+//go:build go1.21
+// +build go1.21
+
+package quic
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// frame types handled during the handshake
+const (
+	framePadding         = 0x00
+	framePing            = 0x01
+	frameAck             = 0x02
+	frameAckECN          = 0x03
+	frameCrypto          = 0x06
+	frameConnectionClose = 0x1c
+	frameApplicationEnd  = 0x1d
+	frameHandshakeDone   = 0x1e
+)
+
+// cryptoStream reassembles crypto frame data received out of order
+type cryptoStream struct {
+	offset  uint64
+	pending map[uint64][]byte
+}
+
+// push adds data received at offset to the stream
+func (s *cryptoStream) push(offset uint64, data []byte) {
+	if s.pending == nil {
+		s.pending = make(map[uint64][]byte)
+	}
+	s.pending[offset] = append([]byte(nil), data...)
+}
+
+// pop returns the data which is contiguous with what was read so far
+func (s *cryptoStream) pop() []byte {
+	var data []byte
+	for {
+		found := false
+		for offset, chunk := range s.pending {
+			end := offset + uint64(len(chunk))
+			if offset > s.offset {
+				continue
+			}
+			delete(s.pending, offset)
+			if end > s.offset {
+				data = append(data, chunk[s.offset-offset:]...)
+				s.offset = end
+			}
+			found = true
+		}
+		if !found {
+			return data
+		}
+	}
+}
+
+// parseFrames parses frames of a decrypted payload adding crypto data to stream
+func parseFrames(payload []byte, stream *cryptoStream) error {
+	for len(payload) > 0 {
+		frameType, n := readVarint(payload)
+		if n == 0 {
+			return errors.New("invalid frame type")
+		}
+		payload = payload[n:]
+
+		switch frameType {
+		case framePadding, framePing, frameHandshakeDone:
+		case frameAck, frameAckECN:
+			fields := 4 // largest, delay, range count, first range
+			var values [4]uint64
+			for i := 0; i < fields; i++ {
+				if values[i], n = readVarint(payload); n == 0 {
+					return errors.New("invalid ack frame")
+				}
+				payload = payload[n:]
+			}
+			remaining := int(values[2]) * 2
+			if frameType == frameAckECN {
+				remaining += 3
+			}
+			for i := 0; i < remaining; i++ {
+				if _, n = readVarint(payload); n == 0 {
+					return errors.New("invalid ack frame")
+				}
+				payload = payload[n:]
+			}
+		case frameCrypto:
+			offset, n := readVarint(payload)
+			if n == 0 {
+				return errors.New("invalid crypto frame")
+			}
+			payload = payload[n:]
+			length, n := readVarint(payload)
+			if n == 0 || uint64(len(payload)-n) < length {
+				return errors.New("invalid crypto frame")
+			}
+			stream.push(offset, payload[n:n+int(length)])
+			payload = payload[n+int(length):]
+		case frameConnectionClose, frameApplicationEnd:
+			code, _ := readVarint(payload)
+			return errors.Errorf("connection closed by server with error %#x", code)
+		default:
+			return errors.Errorf("unexpected frame type %#x", frameType)
+		}
+	}
+	return nil
+}
+
+// appendAckFrame appends an ack frame for received packet numbers
+func appendAckFrame(data []byte, received map[uint64]struct{}) []byte {
+	numbers := make([]uint64, 0, len(received))
+	for number := range received {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+
+	// ranges of contiguous packet numbers from the largest down
+	type ackRange struct{ largest, smallest uint64 }
+	ranges := []ackRange{{numbers[0], numbers[0]}}
+	for _, number := range numbers[1:] {
+		last := &ranges[len(ranges)-1]
+		if number == last.smallest-1 {
+			last.smallest = number
+		} else {
+			ranges = append(ranges, ackRange{number, number})
+		}
+	}
+
+	data = appendVarint(data, frameAck)
+	data = appendVarint(data, ranges[0].largest)
+	data = appendVarint(data, 0) // ack delay
+	data = appendVarint(data, uint64(len(ranges)-1))
+	data = appendVarint(data, ranges[0].largest-ranges[0].smallest)
+	for i := 1; i < len(ranges); i++ {
+		data = appendVarint(data, ranges[i-1].smallest-ranges[i].largest-2)
+		data = appendVarint(data, ranges[i].largest-ranges[i].smallest)
+	}
+	return data
+}
+
+// appendCryptoFrame appends a crypto frame carrying data at offset
+func appendCryptoFrame(data []byte, offset uint64, value []byte) []byte {
+	data = appendVarint(data, frameCrypto)
+	data = appendVarint(data, offset)
+	data = appendVarint(data, uint64(len(value)))
+	return append(data, value...)
+}
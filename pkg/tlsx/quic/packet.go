@@ -0,0 +1,279 @@
+//go:build go1.21
+// +build go1.21
+
+package quic
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/binary"
+	"hash"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// version1 is the version number of QUIC v1
+const version1 = 0x00000001
+
+// long header packet types
+const (
+	packetTypeInitial   = 0x00
+	packetTypeHandshake = 0x02
+	packetTypeRetry     = 0x03
+)
+
+// initialSalt is the salt used to derive initial secrets for QUIC v1
+//
+// follows: https://www.rfc-editor.org/rfc/rfc9001#section-5.2
+var initialSalt = []byte{
+	0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17,
+	0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a,
+}
+
+// packetKeys are the packet and header protection keys of an encryption level
+type packetKeys struct {
+	aead cipher.AEAD
+	iv   []byte
+	mask func(sample []byte) []byte
+}
+
+// initialKeys returns the client and server initial keys for a
+// destination connection id chosen by the client.
+func initialKeys(connectionID []byte) (*packetKeys, *packetKeys, error) {
+	initialSecret := hkdf.Extract(sha256.New, connectionID, initialSalt)
+	clientKeys, err := newPacketKeys(tls.TLS_AES_128_GCM_SHA256, expandLabel(sha256.New, initialSecret, "client in", sha256.Size))
+	if err != nil {
+		return nil, nil, err
+	}
+	serverKeys, err := newPacketKeys(tls.TLS_AES_128_GCM_SHA256, expandLabel(sha256.New, initialSecret, "server in", sha256.Size))
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientKeys, serverKeys, nil
+}
+
+// newPacketKeys derives packet protection keys from a traffic secret
+func newPacketKeys(suite uint16, secret []byte) (*packetKeys, error) {
+	var hashFunc func() hash.Hash
+	var keyLength int
+	switch suite {
+	case tls.TLS_AES_128_GCM_SHA256:
+		hashFunc, keyLength = sha256.New, 16
+	case tls.TLS_AES_256_GCM_SHA384:
+		hashFunc, keyLength = sha512.New384, 32
+	case tls.TLS_CHACHA20_POLY1305_SHA256:
+		hashFunc, keyLength = sha256.New, 32
+	default:
+		return nil, errors.Errorf("unsupported cipher suite: %x", suite)
+	}
+	key := expandLabel(hashFunc, secret, "quic key", keyLength)
+	headerKey := expandLabel(hashFunc, secret, "quic hp", keyLength)
+	keys := &packetKeys{iv: expandLabel(hashFunc, secret, "quic iv", 12)}
+
+	if suite == tls.TLS_CHACHA20_POLY1305_SHA256 {
+		aead, err := chacha20poly1305.New(key)
+		if err != nil {
+			return nil, err
+		}
+		keys.aead = aead
+		keys.mask = func(sample []byte) []byte {
+			mask := make([]byte, 5)
+			stream, err := chacha20.NewUnauthenticatedCipher(headerKey, sample[4:16])
+			if err != nil {
+				return mask
+			}
+			stream.SetCounter(binary.LittleEndian.Uint32(sample[:4]))
+			stream.XORKeyStream(mask, mask)
+			return mask
+		}
+		return keys, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if keys.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	headerBlock, err := aes.NewCipher(headerKey)
+	if err != nil {
+		return nil, err
+	}
+	keys.mask = func(sample []byte) []byte {
+		mask := make([]byte, aes.BlockSize)
+		headerBlock.Encrypt(mask, sample)
+		return mask
+	}
+	return keys, nil
+}
+
+// expandLabel implements HKDF-Expand-Label from tls 1.3
+func expandLabel(hashFunc func() hash.Hash, secret []byte, label string, length int) []byte {
+	label = "tls13 " + label
+	info := []byte{byte(length >> 8), byte(length), byte(len(label))}
+	info = append(info, label...)
+	info = append(info, 0)
+	output := make([]byte, length)
+	_, _ = io.ReadFull(hkdf.Expand(hashFunc, secret, info), output)
+	return output
+}
+
+func (k *packetKeys) nonce(packetNumber uint64) []byte {
+	nonce := make([]byte, len(k.iv))
+	copy(nonce, k.iv)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(packetNumber >> (8 * i))
+	}
+	return nonce
+}
+
+// sealLongHeader builds a protected long header packet with a four
+// byte packet number carrying payload.
+func (k *packetKeys) sealLongHeader(packetType byte, destination, source []byte, packetNumber uint64, payload []byte) []byte {
+	header := []byte{0xc0 | packetType<<4 | 0x03}
+	header = binary.BigEndian.AppendUint32(header, version1)
+	header = append(header, byte(len(destination)))
+	header = append(header, destination...)
+	header = append(header, byte(len(source)))
+	header = append(header, source...)
+	if packetType == packetTypeInitial {
+		header = append(header, 0) // token length
+	}
+	// length is always encoded as a two byte varint
+	length := 4 + len(payload) + k.aead.Overhead()
+	header = append(header, 0x40|byte(length>>8), byte(length))
+	packetNumberOffset := len(header)
+	header = binary.BigEndian.AppendUint32(header, uint32(packetNumber))
+
+	additionalData := append([]byte(nil), header...)
+	packet := k.aead.Seal(header, k.nonce(packetNumber), payload, additionalData)
+	mask := k.mask(packet[packetNumberOffset+4 : packetNumberOffset+20])
+	packet[0] ^= mask[0] & 0x0f
+	for i := 0; i < 4; i++ {
+		packet[packetNumberOffset+i] ^= mask[1+i]
+	}
+	return packet
+}
+
+// longHeaderLength returns the length of a long header built by sealLongHeader
+func longHeaderLength(packetType byte, destination, source []byte) int {
+	length := 1 + 4 + 1 + len(destination) + 1 + len(source) + 2 + 4
+	if packetType == packetTypeInitial {
+		length++
+	}
+	return length
+}
+
+// longHeader is a parsed long header packet received from the server
+type longHeader struct {
+	packetType  byte
+	version     uint32
+	destination []byte
+	source      []byte
+	// numberOffset is the offset of the packet number in the packet
+	numberOffset int
+	// length is the length of the packet number and payload
+	length int
+}
+
+// parseLongHeader parses the unprotected fields of a long header packet
+func parseLongHeader(data []byte) (*longHeader, error) {
+	if len(data) < 7 || data[0]&0x80 == 0 {
+		return nil, errors.New("not a long header packet")
+	}
+	header := &longHeader{packetType: (data[0] >> 4) & 0x03, version: binary.BigEndian.Uint32(data[1:5])}
+	offset := 5
+	readConnectionID := func() ([]byte, bool) {
+		if offset >= len(data) || offset+1+int(data[offset]) > len(data) {
+			return nil, false
+		}
+		length := int(data[offset])
+		value := data[offset+1 : offset+1+length]
+		offset += 1 + length
+		return value, true
+	}
+	var ok bool
+	if header.destination, ok = readConnectionID(); !ok {
+		return nil, errors.New("invalid destination connection id")
+	}
+	if header.source, ok = readConnectionID(); !ok {
+		return nil, errors.New("invalid source connection id")
+	}
+	if header.version == 0 || header.packetType == packetTypeRetry {
+		return header, nil
+	}
+	if header.packetType == packetTypeInitial {
+		tokenLength, n := readVarint(data[offset:])
+		if n == 0 || uint64(len(data)-offset-n) < tokenLength {
+			return nil, errors.New("invalid token length")
+		}
+		offset += n + int(tokenLength)
+	}
+	length, n := readVarint(data[offset:])
+	if n == 0 || uint64(len(data)-offset-n) < length {
+		return nil, errors.New("invalid packet length")
+	}
+	header.numberOffset = offset + n
+	header.length = int(length)
+	return header, nil
+}
+
+// open removes header and packet protection from a long header packet
+// at the start of data, returning the packet number and payload.
+func (k *packetKeys) open(data []byte, header *longHeader) (uint64, []byte, error) {
+	offset := header.numberOffset
+	if header.length < 20 {
+		return 0, nil, errors.New("packet too short")
+	}
+	mask := k.mask(data[offset+4 : offset+20])
+	data[0] ^= mask[0] & 0x0f
+	numberLength := int(data[0]&0x03) + 1
+	var packetNumber uint64
+	for i := 0; i < numberLength; i++ {
+		data[offset+i] ^= mask[1+i]
+		packetNumber = packetNumber<<8 | uint64(data[offset+i])
+	}
+	end := offset + header.length
+	payload, err := k.aead.Open(nil, k.nonce(packetNumber), data[offset+numberLength:end], data[:offset+numberLength])
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not decrypt packet")
+	}
+	return packetNumber, payload, nil
+}
+
+// appendVarint appends a variable length integer to data
+func appendVarint(data []byte, value uint64) []byte {
+	switch {
+	case value < 1<<6:
+		return append(data, byte(value))
+	case value < 1<<14:
+		return append(data, 0x40|byte(value>>8), byte(value))
+	case value < 1<<30:
+		return binary.BigEndian.AppendUint32(data, 0x80000000|uint32(value))
+	default:
+		return binary.BigEndian.AppendUint64(data, 0xc000000000000000|value)
+	}
+}
+
+// readVarint reads a variable length integer returning zero length on error
+func readVarint(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	length := 1 << (data[0] >> 6)
+	if len(data) < length {
+		return 0, 0
+	}
+	value := uint64(data[0] & 0x3f)
+	for i := 1; i < length; i++ {
+		value = value<<8 | uint64(data[i])
+	}
+	return value, length
+}
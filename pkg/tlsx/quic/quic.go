@@ -0,0 +1,481 @@
+//go:build go1.21
+// +build go1.21
+
+// Package quic implements a tls grabbing implementation over QUIC v1
+// using the QUIC support of the standard package crypto/tls library.
+package quic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/rs/xid"
+)
+
+// ALPNProtocol is the application protocol offered for http/3 by default
+const ALPNProtocol = "h3"
+
+const (
+	// minInitialDatagramSize is the minimum size of datagrams carrying initial packets
+	minInitialDatagramSize = 1200
+	// maxDatagramSize is the maximum size of a received datagram
+	maxDatagramSize = 65535
+	// retransmitInterval is the time after which the client hello is resent
+	retransmitInterval = time.Second
+)
+
+// Client is a TLS grabbing client using QUIC
+type Client struct {
+	dialer    *fastdialer.Dialer
+	tlsConfig *tls.Config
+	options   *clients.Options
+}
+
+// New creates a new grabbing client using QUIC
+func New(options *clients.Options) (*Client, error) {
+	c := &Client{
+		dialer: options.Fastdialer,
+		tlsConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS13,
+			NextProtos:         []string{ALPNProtocol},
+			InsecureSkipVerify: !options.VerifyServerCertificate,
+		},
+		options: options,
+	}
+	if len(options.ALPN) > 0 {
+		c.tlsConfig.NextProtos = options.ALPN
+	}
+	if options.ServerName != "" {
+		c.tlsConfig.ServerName = options.ServerName
+	}
+	if !options.ValidationAt.IsZero() {
+		c.tlsConfig.Time = func() time.Time { return options.ValidationAt }
+	}
+	if options.CACertificate != "" {
+		caCert, err := ioutil.ReadFile(options.CACertificate)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read ca certificate")
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			gologger.Error().Msgf("Could not append parsed ca-cert to config!")
+		}
+		c.tlsConfig.RootCAs = certPool
+	}
+	rootCAs, err := clients.AppendCustomRoots(c.tlsConfig.RootCAs, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load custom roots")
+	}
+	c.tlsConfig.RootCAs = rootCAs
+	return c, nil
+}
+
+// Connect connects to a host and grabs the response data
+func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
+	address := net.JoinHostPort(hostname, port)
+
+	ctx := context.Background()
+	if c.options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	conn, err := c.dialer.Dial(ctx, "udp", address)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial address")
+	}
+	defer conn.Close()
+
+	var resolvedIP string
+	if !iputil.IsIP(hostname) {
+		resolvedIP = c.dialer.GetDialedIP(hostname)
+	}
+
+	config := c.tlsConfig
+	if config.ServerName == "" {
+		c := config.Clone()
+		if iputil.IsIP(hostname) {
+			// using a random sni will return the default server certificate
+			c.ServerName = xid.New().String()
+		} else {
+			c.ServerName = hostname
+		}
+		config = c
+	}
+
+	session, err := newSession(conn)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create quic session")
+	}
+	tlsConn := tls.QUICClient(&tls.QUICConfig{TLSConfig: config})
+	tlsConn.SetTransportParameters(transportParameters(session.source))
+	if err := tlsConn.Start(ctx); err != nil {
+		return nil, errors.Wrap(err, "could not start handshake")
+	}
+	defer tlsConn.Close()
+
+	if err := session.complete(ctx, tlsConn); err != nil {
+		return nil, errors.Wrap(err, "could not do quic handshake")
+	}
+
+	handshake := NewHandshake(tlsConn.ConnectionState())
+	handshake.Host, handshake.IP, handshake.Port = hostname, resolvedIP, port
+	response, err := clients.NewResponse(c.options, handshake)
+	if err != nil {
+		return nil, err
+	}
+	response.QUIC = &clients.QUICResponse{Version: "v1", TransportParameters: parseTransportParameters(session.peerParameters)}
+	return response, nil
+}
+
+// packetSpace is the state of a packet number space
+type packetSpace struct {
+	readKeys  *packetKeys
+	writeKeys *packetKeys
+	stream    cryptoStream
+
+	// outgoing is the crypto data to send and sentOffset its stream offset
+	outgoing   []byte
+	sentOffset uint64
+	// received is the set of received packet numbers and ackPending
+	// whether a new packet was received since the last ack
+	received     map[uint64]struct{}
+	ackPending   bool
+	packetNumber uint64
+}
+
+// session is the client side state of a QUIC connection handshake
+type session struct {
+	conn        net.Conn
+	destination []byte
+	source      []byte
+	initial     *packetSpace
+	handshake   *packetSpace
+
+	// lastInitial is the last sent datagram with the client hello
+	lastInitial    []byte
+	receivedAny    bool
+	done           bool
+	peerParameters []byte
+	// undecryptable are packets received before their keys were available
+	undecryptable [][]byte
+}
+
+func newSession(conn net.Conn) (*session, error) {
+	s := &session{
+		conn:        conn,
+		destination: make([]byte, 8),
+		source:      make([]byte, 8),
+		initial:     &packetSpace{received: make(map[uint64]struct{})},
+		handshake:   &packetSpace{received: make(map[uint64]struct{})},
+	}
+	if _, err := rand.Read(s.destination); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(s.source); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.initial.writeKeys, s.initial.readKeys, err = initialKeys(s.destination); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// space returns the packet number space for a tls encryption level
+func (s *session) space(level tls.QUICEncryptionLevel) *packetSpace {
+	switch level {
+	case tls.QUICEncryptionLevelInitial:
+		return s.initial
+	case tls.QUICEncryptionLevelHandshake:
+		return s.handshake
+	}
+	return nil
+}
+
+// complete exchanges packets until the tls handshake is complete
+func (s *session) complete(ctx context.Context, tlsConn *tls.QUICConn) error {
+	deadline, hasDeadline := ctx.Deadline()
+	buffer := make([]byte, maxDatagramSize)
+	for {
+		done, err := s.handleEvents(tlsConn)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if err := s.flush(); err != nil {
+			return err
+		}
+
+		readDeadline := time.Now().Add(retransmitInterval)
+		if hasDeadline && deadline.Before(readDeadline) {
+			readDeadline = deadline
+		}
+		_ = s.conn.SetReadDeadline(readDeadline)
+		n, err := s.conn.Read(buffer)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			if hasDeadline && !time.Now().Before(deadline) {
+				return errors.New("timed out waiting for server response")
+			}
+			if !s.receivedAny {
+				if _, err := s.conn.Write(s.lastInitial); err != nil {
+					return errors.Wrap(err, "could not resend initial packet")
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not read datagram")
+		}
+		if err := s.handleDatagram(buffer[:n], tlsConn); err != nil {
+			return err
+		}
+	}
+}
+
+// handleEvents processes tls events returning true once the handshake is done
+func (s *session) handleEvents(tlsConn *tls.QUICConn) (bool, error) {
+	for {
+		event := tlsConn.NextEvent()
+		switch event.Kind {
+		case tls.QUICNoEvent:
+			return s.done, nil
+		case tls.QUICHandshakeDone:
+			s.done = true
+		case tls.QUICSetReadSecret, tls.QUICSetWriteSecret:
+			space := s.space(event.Level)
+			if space == nil {
+				continue
+			}
+			keys, err := newPacketKeys(event.Suite, event.Data)
+			if err != nil {
+				return false, err
+			}
+			if event.Kind == tls.QUICSetReadSecret {
+				space.readKeys = keys
+			} else {
+				space.writeKeys = keys
+			}
+		case tls.QUICWriteData:
+			if space := s.space(event.Level); space != nil {
+				space.outgoing = append(space.outgoing, event.Data...)
+			}
+		case tls.QUICTransportParameters:
+			s.peerParameters = append([]byte(nil), event.Data...)
+		}
+	}
+}
+
+// flush sends pending crypto data and acknowledgements. Datagrams with
+// initial packets are padded so the server amplification limit grows.
+func (s *session) flush() error {
+	handshakePacket := s.handshake.packet(packetTypeHandshake, s.destination, s.source, 0)
+	padding := 0
+	if len(handshakePacket) < minInitialDatagramSize {
+		padding = minInitialDatagramSize - len(handshakePacket)
+	}
+	initialPacket := s.initial.packet(packetTypeInitial, s.destination, s.source, padding)
+	if initialPacket == nil && handshakePacket == nil {
+		return nil
+	}
+	datagram := append(initialPacket, handshakePacket...)
+	if !s.receivedAny {
+		s.lastInitial = datagram
+	}
+	if _, err := s.conn.Write(datagram); err != nil {
+		return errors.Wrap(err, "could not write datagram")
+	}
+	return nil
+}
+
+// packet returns a packet with pending crypto data and acknowledgements
+// padded to at least size bytes, or nil if there is nothing to send.
+func (p *packetSpace) packet(packetType byte, destination, source []byte, size int) []byte {
+	if p.writeKeys == nil || (!p.ackPending && len(p.outgoing) == 0) {
+		return nil
+	}
+	var payload []byte
+	if p.ackPending {
+		payload = appendAckFrame(payload, p.received)
+		p.ackPending = false
+	}
+	if len(p.outgoing) > 0 {
+		payload = appendCryptoFrame(payload, p.sentOffset, p.outgoing)
+		p.sentOffset += uint64(len(p.outgoing))
+		p.outgoing = nil
+	}
+	if overhead := longHeaderLength(packetType, destination, source) + p.writeKeys.aead.Overhead(); len(payload)+overhead < size {
+		payload = append(payload, make([]byte, size-len(payload)-overhead)...)
+	}
+	packet := p.writeKeys.sealLongHeader(packetType, destination, source, p.packetNumber, payload)
+	p.packetNumber++
+	return packet
+}
+
+// handleDatagram processes the coalesced packets of a received datagram
+func (s *session) handleDatagram(datagram []byte, tlsConn *tls.QUICConn) error {
+	for len(datagram) > 0 {
+		if datagram[0]&0x80 == 0 {
+			// short header packets are application data which is not needed
+			return nil
+		}
+		header, err := parseLongHeader(datagram)
+		if err != nil {
+			return err
+		}
+		if header.version == 0 {
+			return errors.New("server does not support quic v1")
+		}
+		if header.packetType == packetTypeRetry {
+			return errors.New("server requested address validation retry")
+		}
+		packetLength := header.numberOffset + header.length
+		packet := datagram[:packetLength]
+		datagram = datagram[packetLength:]
+
+		var space *packetSpace
+		var level tls.QUICEncryptionLevel
+		switch header.packetType {
+		case packetTypeInitial:
+			space, level = s.initial, tls.QUICEncryptionLevelInitial
+		case packetTypeHandshake:
+			space, level = s.handshake, tls.QUICEncryptionLevelHandshake
+		default:
+			continue
+		}
+		if space.readKeys == nil {
+			s.undecryptable = append(s.undecryptable, append([]byte(nil), packet...))
+			continue
+		}
+		packetNumber, payload, err := space.readKeys.open(packet, header)
+		if err != nil {
+			gologger.Debug().Msgf("Could not open quic packet: %s\n", err)
+			continue
+		}
+		if !s.receivedAny {
+			// subsequent packets are addressed to the server chosen connection id
+			s.receivedAny = true
+			s.destination = append([]byte(nil), header.source...)
+		}
+		space.received[packetNumber] = struct{}{}
+		space.ackPending = true
+
+		if err := parseFrames(payload, &space.stream); err != nil {
+			return err
+		}
+		if data := space.stream.pop(); len(data) > 0 {
+			if err := tlsConn.HandleData(level, data); err != nil {
+				return errors.Wrap(err, "could not handle handshake data")
+			}
+		}
+		if _, err := s.handleEvents(tlsConn); err != nil {
+			return err
+		}
+		if len(s.undecryptable) > 0 && s.handshake.readKeys != nil {
+			pending := s.undecryptable
+			s.undecryptable = nil
+			for _, packet := range pending {
+				if err := s.handleDatagram(packet, tlsConn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// transport parameter identifiers
+//
+// follows: https://www.rfc-editor.org/rfc/rfc9000#section-18.2
+var transportParameterNames = map[uint64]string{
+	0x01: "max-idle-timeout",
+	0x03: "max-udp-payload-size",
+	0x04: "initial-max-data",
+	0x05: "initial-max-stream-data-bidi-local",
+	0x06: "initial-max-stream-data-bidi-remote",
+	0x07: "initial-max-stream-data-uni",
+	0x08: "initial-max-streams-bidi",
+	0x09: "initial-max-streams-uni",
+	0x0a: "ack-delay-exponent",
+	0x0b: "max-ack-delay",
+	0x0c: "disable-active-migration",
+	0x0e: "active-connection-id-limit",
+}
+
+// transportParameters returns the client transport parameters
+func transportParameters(source []byte) []byte {
+	var data []byte
+	appendParameter := func(id uint64, value []byte) {
+		data = appendVarint(data, id)
+		data = appendVarint(data, uint64(len(value)))
+		data = append(data, value...)
+	}
+	appendParameter(0x01, appendVarint(nil, 30000)) // max_idle_timeout
+	appendParameter(0x04, appendVarint(nil, 1<<20)) // initial_max_data
+	appendParameter(0x08, appendVarint(nil, 100))   // initial_max_streams_bidi
+	appendParameter(0x0f, source)                   // initial_source_connection_id
+	return data
+}
+
+// parseTransportParameters returns the known integer transport
+// parameters of the server by name.
+func parseTransportParameters(data []byte) map[string]uint64 {
+	parameters := make(map[string]uint64)
+	for len(data) > 0 {
+		id, n := readVarint(data)
+		if n == 0 {
+			break
+		}
+		data = data[n:]
+		length, n := readVarint(data)
+		if n == 0 || uint64(len(data)-n) < length {
+			break
+		}
+		value := data[n : n+int(length)]
+		data = data[n+int(length):]
+
+		name, ok := transportParameterNames[id]
+		if !ok {
+			continue
+		}
+		if len(value) == 0 {
+			parameters[name] = 1 // flag parameters have no value
+			continue
+		}
+		if number, n := readVarint(value); n > 0 {
+			parameters[name] = number
+		}
+	}
+	return parameters
+}
+
+// NewHandshake returns the handshake of the state of a quic connection,
+// which always uses tls 1.3
+func NewHandshake(connectionState tls.ConnectionState) *clients.Handshake {
+	handshake := &clients.Handshake{
+		Version:       "tls13",
+		Cipher:        tls.CipherSuiteName(connectionState.CipherSuite),
+		ALPN:          connectionState.NegotiatedProtocol,
+		TLSConnection: "quic",
+		OCSPStaple:    connectionState.OCSPResponse,
+		SCTs:          connectionState.SignedCertificateTimestamps,
+	}
+	for _, cert := range connectionState.PeerCertificates {
+		handshake.RawChain = append(handshake.RawChain, cert.Raw)
+	}
+	return handshake
+}
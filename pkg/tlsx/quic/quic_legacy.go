@@ -0,0 +1,27 @@
+//go:build !go1.21
+// +build !go1.21
+
+// Package quic implements a tls grabbing implementation over QUIC v1
+// using the QUIC support of the standard package crypto/tls library.
+package quic
+
+import (
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// errQUICUnsupported is returned when built with a go version without quic support in crypto/tls
+var errQUICUnsupported = errors.New("quic support requires building with go1.21 or later")
+
+// Client is a TLS grabbing client using QUIC
+type Client struct{}
+
+// New creates a new grabbing client using QUIC
+func New(options *clients.Options) (*Client, error) {
+	return nil, errQUICUnsupported
+}
+
+// Connect connects to a host and grabs the response data
+func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
+	return nil, errQUICUnsupported
+}
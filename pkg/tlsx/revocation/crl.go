@@ -0,0 +1,274 @@
+package revocation
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// maxCRLSize is the maximum size of certificate revocation lists read
+const maxCRLSize = 64 << 20
+
+// maxCRLCacheEntries is the maximum number of lists cached in memory
+const maxCRLCacheEntries = 64
+
+// reasonCodeOID is the oid of the crl entry reason code extension
+var reasonCodeOID = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// crlList is a parsed certificate revocation list with its revoked
+// certificates indexed by serial number.
+type crlList struct {
+	parsed     *pkix.CertificateList
+	thisUpdate time.Time
+	nextUpdate time.Time
+	revoked    map[string]pkix.RevokedCertificate
+	// verified is the set of issuers the signature was verified with
+	verified map[string]struct{}
+}
+
+// crlEntry is the cached list of a distribution point, its mutex makes
+// concurrent lookups of the same distribution point wait for one download.
+type crlEntry struct {
+	sync.Mutex
+	list *crlList
+	// downloaded is true if the list was downloaded during the scan, a
+	// new download would not return a list fresh at the validation time
+	downloaded bool
+}
+
+// crlCache caches the lists of distribution points in memory and on
+// disk in files named by the hash of the distribution point url.
+type crlCache struct {
+	dir string
+
+	mutex   sync.Mutex
+	entries map[string]*crlEntry
+}
+
+// newCRLCache creates a crl cache storing lists in dir, lists are only
+// cached in memory if dir is empty. At most maxCRLCacheEntries lists are
+// kept in memory.
+func newCRLCache(dir string) (*crlCache, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrap(err, "could not create crl cache directory")
+		}
+	}
+	return &crlCache{dir: dir, entries: make(map[string]*crlEntry)}, nil
+}
+
+// entry returns the cache entry of a distribution point
+func (c *crlCache) entry(url string) *crlEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[url]
+	if !ok {
+		// an arbitrary list is evicted, lookups using it keep their entry
+		for evicted := range c.entries {
+			if len(c.entries) < maxCRLCacheEntries {
+				break
+			}
+			delete(c.entries, evicted)
+		}
+		entry = &crlEntry{}
+		c.entries[url] = entry
+	}
+	return entry
+}
+
+// path returns the cache file of a distribution point
+func (c *crlCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".crl")
+}
+
+// read returns the raw list of a distribution point from disk
+func (c *crlCache) read(url string) []byte {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(c.path(url))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// write stores the raw list of a distribution point on disk replacing
+// the previous list only once completely written.
+func (c *crlCache) write(url string, data []byte) error {
+	if c.dir == "" {
+		return nil
+	}
+	file, err := ioutil.TempFile(c.dir, ".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.path(url))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
+
+// checkCRL looks up the leaf in the list of the first distribution point
+// which could be retrieved.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc5280#section-5
+func (c *Checker) checkCRL(leaf, issuer *x509.Certificate, now time.Time) *clients.CRLRevocationResponse {
+	if len(leaf.CRLDistributionPoints) == 0 {
+		return &clients.CRLRevocationResponse{Error: "no crl distribution point in certificate"}
+	}
+	var response *clients.CRLRevocationResponse
+	for _, url := range leaf.CRLDistributionPoints {
+		response = &clients.CRLRevocationResponse{URL: url}
+		list, err := c.crlList(response, url, issuer, now)
+		if err != nil {
+			response.Error = err.Error()
+			continue
+		}
+		response.ThisUpdate = clients.TimeOrNil(list.thisUpdate)
+		response.NextUpdate = clients.TimeOrNil(list.nextUpdate)
+		response.Expired = !list.nextUpdate.IsZero() && now.After(list.nextUpdate)
+		response.Status = "good"
+		if revoked, ok := list.revoked[leaf.SerialNumber.String()]; ok {
+			response.Status = "revoked"
+			response.RevokedAt = clients.TimeOrNil(revoked.RevocationTime)
+			response.Reason = reasonName(crlReasonCode(revoked))
+		}
+		break
+	}
+	return response
+}
+
+// crlList returns the list of a distribution point from the memory or
+// disk cache, downloading it if not cached or past its next update at now.
+// Cached lists are verified with the issuer of each lookup.
+func (c *Checker) crlList(response *clients.CRLRevocationResponse, url string, issuer *x509.Certificate, now time.Time) (*crlList, error) {
+	entry := c.crlCache.entry(url)
+	entry.Lock()
+	defer entry.Unlock()
+
+	if entry.list != nil && (entry.downloaded || isFresh(entry.list, now)) {
+		if err := entry.list.verify(issuer); err != nil {
+			return nil, err
+		}
+		response.Cached = true
+		return entry.list, nil
+	}
+	if data := c.crlCache.read(url); data != nil {
+		if list, err := parseCRL(data, issuer); err == nil && isFresh(list, now) {
+			entry.list = list
+			response.Cached = true
+			return list, nil
+		}
+	}
+
+	started := time.Now()
+	data, err := c.downloadCRL(url)
+	response.LatencyMS = time.Since(started).Milliseconds()
+	if err != nil {
+		return nil, err
+	}
+	list, err := parseCRL(data, issuer)
+	if err != nil {
+		return nil, err
+	}
+	entry.list = list
+	entry.downloaded = true
+	if err := c.crlCache.write(url, data); err != nil {
+		gologger.Warning().Msgf("Could not write crl cache for %s: %s", url, err)
+	}
+	return list, nil
+}
+
+// downloadCRL downloads the raw list of a distribution point
+func (c *Checker) downloadCRL(url string) ([]byte, error) {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not download crl")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected crl status %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCRLSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read crl")
+	}
+	return data, nil
+}
+
+// parseCRL parses a der or pem list verifying its signature with the issuer
+func parseCRL(data []byte, issuer *x509.Certificate) (*crlList, error) {
+	parsed, err := x509.ParseCRL(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse crl")
+	}
+	list := &crlList{
+		parsed:     parsed,
+		thisUpdate: parsed.TBSCertList.ThisUpdate,
+		nextUpdate: parsed.TBSCertList.NextUpdate,
+		revoked:    make(map[string]pkix.RevokedCertificate, len(parsed.TBSCertList.RevokedCertificates)),
+		verified:   make(map[string]struct{}),
+	}
+	if err := list.verify(issuer); err != nil {
+		return nil, err
+	}
+	for _, revoked := range parsed.TBSCertList.RevokedCertificates {
+		list.revoked[revoked.SerialNumber.String()] = revoked
+	}
+	return list, nil
+}
+
+// verify verifies the signature of the list with issuer once per issuer
+func (l *crlList) verify(issuer *x509.Certificate) error {
+	key := string(issuer.Raw)
+	if _, ok := l.verified[key]; ok {
+		return nil
+	}
+	if err := issuer.CheckCRLSignature(l.parsed); err != nil {
+		return errors.Wrap(err, "could not verify crl signature")
+	}
+	l.verified[key] = struct{}{}
+	return nil
+}
+
+// isFresh returns true if the list is not past its next update at now
+func isFresh(list *crlList, now time.Time) bool {
+	return list.nextUpdate.IsZero() || now.Before(list.nextUpdate)
+}
+
+// crlReasonCode returns the reason code extension of a list entry
+func crlReasonCode(revoked pkix.RevokedCertificate) int {
+	for _, extension := range revoked.Extensions {
+		if !extension.Id.Equal(reasonCodeOID) {
+			continue
+		}
+		var code asn1.Enumerated
+		if _, err := asn1.Unmarshal(extension.Value, &code); err == nil {
+			return int(code)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package revocation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"golang.org/x/crypto/ocsp"
+)
+
+// maxOCSPResponse is the maximum size of ocsp responses read
+const maxOCSPResponse = 1 << 20
+
+// checkOCSP queries the first ocsp responder of leaf for its status
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc6960
+func (c *Checker) checkOCSP(leaf, issuer *x509.Certificate, now time.Time) *clients.OCSPRevocationResponse {
+	if len(leaf.OCSPServer) == 0 {
+		return &clients.OCSPRevocationResponse{Error: "no ocsp responder in certificate"}
+	}
+	response := &clients.OCSPRevocationResponse{Responder: leaf.OCSPServer[0]}
+
+	started := time.Now()
+	parsed, err := c.queryOCSP(response.Responder, leaf, issuer)
+	response.LatencyMS = time.Since(started).Milliseconds()
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	response.Status = clients.OCSPStatus(parsed.Status)
+	if parsed.Status == ocsp.Revoked {
+		response.RevokedAt = clients.TimeOrNil(parsed.RevokedAt)
+		response.Reason = reasonName(parsed.RevocationReason)
+	}
+	response.ThisUpdate = clients.TimeOrNil(parsed.ThisUpdate)
+	response.NextUpdate = clients.TimeOrNil(parsed.NextUpdate)
+	response.Expired = !parsed.NextUpdate.IsZero() && now.After(parsed.NextUpdate)
+	return response
+}
+
+// queryOCSP posts an ocsp request for leaf to the responder returning
+// the response verified with the issuer.
+func (c *Checker) queryOCSP(responder string, leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
+	request, err := ocsp.CreateRequest(leaf, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create ocsp request")
+	}
+	resp, err := c.httpClient.Post(responder, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query ocsp responder")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected ocsp responder status %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxOCSPResponse))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read ocsp response")
+	}
+	parsed, err := clients.ParseOCSPResponse(data, issuer)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse ocsp response")
+	}
+	if parsed.SerialNumber == nil || parsed.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		return nil, errors.New("ocsp response for another certificate")
+	}
+	return parsed, nil
+}
@@ -0,0 +1,136 @@
+// Package revocation checks the revocation status of certificates with
+// the revocation services named in the certificates.
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of revocation checking methods
+const (
+	// MethodOCSP queries the ocsp responder of the certificate
+	MethodOCSP = "ocsp"
+	// MethodCRL looks up the certificate in the list of its distribution point
+	MethodCRL = "crl"
+)
+
+// methods is the list of supported revocation checking methods
+var methods = map[string]struct{}{
+	MethodOCSP: {},
+	MethodCRL:  {},
+}
+
+// IsSupported returns true if the revocation checking method is supported
+func IsSupported(method string) bool {
+	_, ok := methods[method]
+	return ok
+}
+
+// reasons is the name of each crl reason code
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc5280#section-5.3.1
+var reasons = map[int]string{
+	0:  "unspecified",
+	1:  "keyCompromise",
+	2:  "cACompromise",
+	3:  "affiliationChanged",
+	4:  "superseded",
+	5:  "cessationOfOperation",
+	6:  "certificateHold",
+	8:  "removeFromCRL",
+	9:  "privilegeWithdrawn",
+	10: "aACompromise",
+}
+
+// Checker checks the revocation status of presented certificates
+type Checker struct {
+	options    *clients.Options
+	httpClient *http.Client
+	crlCache   *crlCache
+}
+
+// New creates a revocation checker for the methods of options
+func New(options *clients.Options) (*Checker, error) {
+	checker := &Checker{
+		options: options,
+		httpClient: &http.Client{
+			Timeout: time.Duration(options.Timeout) * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+					return clients.Dial(ctx, options, address)
+				},
+			},
+		},
+	}
+	for _, method := range options.Revocation {
+		if method != MethodCRL {
+			continue
+		}
+		var err error
+		if checker.crlCache, err = newCRLCache(CRLCacheDir(options)); err != nil {
+			return nil, err
+		}
+	}
+	return checker, nil
+}
+
+// CRLCacheDir returns the directory lists are cached in, which defaults
+// to tlsx/crl in the user cache directory. Lists are only cached in
+// memory if there is no user cache directory.
+func CRLCacheDir(options *clients.Options) string {
+	if options.CRLCacheDir != "" {
+		return options.CRLCacheDir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tlsx", "crl")
+}
+
+// Check returns the revocation status of the leaf of a presented chain
+// with each enabled method. The issuer must be presented to build the
+// requests of the revocation services.
+func (c *Checker) Check(rawChain [][]byte, now time.Time) (*clients.RevocationResponse, error) {
+	if len(rawChain) < 2 {
+		return nil, errors.New("issuer certificate not presented")
+	}
+	leaf, err := x509.ParseCertificate(rawChain[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse leaf certificate")
+	}
+	issuer := clients.FindIssuer(leaf, rawChain[1:])
+	if issuer == nil {
+		return nil, errors.New("issuer certificate not presented")
+	}
+
+	response := &clients.RevocationResponse{}
+	for _, method := range c.options.Revocation {
+		switch method {
+		case MethodOCSP:
+			response.OCSP = c.checkOCSP(leaf, issuer, now)
+			response.Revoked = response.Revoked || response.OCSP.Status == "revoked"
+		case MethodCRL:
+			response.CRL = c.checkCRL(leaf, issuer, now)
+			response.Revoked = response.Revoked || response.CRL.Status == "revoked"
+		}
+	}
+	return response, nil
+}
+
+// reasonName returns the name of a crl reason code
+func reasonName(code int) string {
+	if name, ok := reasons[code]; ok {
+		return name
+	}
+	return "unspecified"
+}
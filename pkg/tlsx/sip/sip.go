@@ -0,0 +1,100 @@
+// Package sip implements discovery of sip over tls (sips) servers of a
+// domain and validation of their certificates as sip domain certificates.
+package sip
+
+import (
+	"crypto/x509"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/retryabledns"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// DefaultPort is the port of sips servers without srv records
+const DefaultPort = "5061"
+
+// Target is a sips server of a domain
+type Target struct {
+	Host string
+	Port string
+}
+
+// LookupTargets returns the sips servers of a domain from its _sips._tcp
+// srv records ordered by priority, nil if there are none.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc3263#section-4.2
+func LookupTargets(options *clients.Options, domain string) ([]Target, error) {
+	resolvers := options.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = fastdialer.DefaultResolvers
+	}
+	client := retryabledns.New(resolvers, 3)
+
+	msg := new(dns.Msg)
+	msg.SetQuestion("_sips._tcp."+dns.Fqdn(domain), dns.TypeSRV)
+	response, err := client.Do(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query srv record")
+	}
+	var records []*dns.SRV
+	for _, answer := range response.Answer {
+		if record, ok := answer.(*dns.SRV); ok && record.Target != "." {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Priority != records[j].Priority {
+			return records[i].Priority < records[j].Priority
+		}
+		return records[i].Weight > records[j].Weight
+	})
+	targets := make([]Target, 0, len(records))
+	for _, record := range records {
+		targets = append(targets, Target{Host: strings.TrimSuffix(record.Target, "."), Port: strconv.Itoa(int(record.Port))})
+	}
+	return targets, nil
+}
+
+// CheckDomain returns the sip domain identities of a der encoded
+// certificate and whether one of them is the domain. Identities are sip
+// uris without user part and dns names of the subject alternative names,
+// or the common name without them. Wildcards never match.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc5922#section-7.1
+func CheckDomain(domain string, raw []byte) (*clients.SIPDomainResponse, error) {
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse certificate")
+	}
+	response := &clients.SIPDomainResponse{Domain: domain}
+	for _, uri := range cert.URIs {
+		if identity := uriIdentity(uri); identity != "" {
+			response.Identities = append(response.Identities, identity)
+		}
+	}
+	response.Identities = append(response.Identities, cert.DNSNames...)
+	if len(cert.URIs) == 0 && len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 0 && len(cert.EmailAddresses) == 0 && cert.Subject.CommonName != "" {
+		response.Identities = append(response.Identities, cert.Subject.CommonName)
+	}
+	for _, identity := range response.Identities {
+		if strings.EqualFold(strings.TrimSuffix(identity, "."), strings.TrimSuffix(domain, ".")) {
+			response.Matched = true
+			break
+		}
+	}
+	return response, nil
+}
+
+// uriIdentity returns the host of sip uris without user part or parameters
+func uriIdentity(uri *url.URL) string {
+	if !strings.EqualFold(uri.Scheme, "sip") || uri.Opaque == "" || strings.ContainsAny(uri.Opaque, "@;?:") {
+		return ""
+	}
+	return uri.Opaque
+}
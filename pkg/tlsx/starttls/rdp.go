@@ -0,0 +1,70 @@
+package starttls
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// rdpConnectionRequest is the x.224 connection request carrying a rdp
+// negotiation request for the tls and credssp security protocols.
+var rdpConnectionRequest = []byte{
+	0x03, 0x00, 0x00, 0x13, // tpkt header
+	0x0e, 0xe0, 0x00, 0x00, 0x00, 0x00, 0x00, // x.224 connection request
+	0x01, 0x00, 0x08, 0x00, 0x03, 0x00, 0x00, 0x00, // rdp negotiation request
+}
+
+// rdp negotiation message types
+const (
+	rdpNegotiationResponse = 0x02
+	rdpNegotiationFailure  = 0x03
+)
+
+// rdpStandardSecurity is the selected protocol for legacy rdp security
+const rdpStandardSecurity = 0
+
+// negotiateRDP requests tls security from a rdp server which starts the
+// tls handshake after the x.224 connection confirm.
+//
+// follows: https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-rdpbcgr/18a27ef9-6f9a-4501-b000-94b1fe3c2c10
+func negotiateRDP(conn net.Conn, reader *bufio.Reader, _ string) error {
+	if _, err := conn.Write(rdpConnectionRequest); err != nil {
+		return errors.Wrap(err, "could not write rdp connection request")
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return errors.Wrap(err, "could not read rdp connection confirm")
+	}
+	length := int(binary.BigEndian.Uint16(header[2:4]))
+	if header[0] != 0x03 || length < 4 || length > maxStreamLength {
+		return errors.New("invalid rdp tpkt header")
+	}
+	confirm := make([]byte, length-4)
+	if _, err := io.ReadFull(reader, confirm); err != nil {
+		return errors.Wrap(err, "could not read rdp connection confirm")
+	}
+	// x.224 connection confirm followed by the negotiation message
+	if len(confirm) < 7 || confirm[1]&0xf0 != 0xd0 {
+		return errors.New("unexpected rdp x.224 response")
+	}
+	negotiation := confirm[7:]
+	if len(negotiation) < 8 {
+		return errors.New("rdp server only supports standard rdp security")
+	}
+	value := binary.LittleEndian.Uint32(negotiation[4:8])
+	switch negotiation[0] {
+	case rdpNegotiationResponse:
+		if value == rdpStandardSecurity {
+			return errors.New("rdp server only supports standard rdp security")
+		}
+		return nil
+	case rdpNegotiationFailure:
+		return fmt.Errorf("rdp tls negotiation failed with code %d", value)
+	default:
+		return fmt.Errorf("unexpected rdp negotiation message type %d", negotiation[0])
+	}
+}
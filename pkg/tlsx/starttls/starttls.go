@@ -0,0 +1,479 @@
+// Package starttls implements plaintext protocol negotiation performed
+// before a tls handshake for services upgrading connections using STARTTLS.
+package starttls
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of built-in starttls protocols
+const (
+	SMTP     = "smtp"
+	FTP      = "ftp"
+	IMAP     = "imap"
+	POP3     = "pop3"
+	LDAP     = "ldap"
+	XMPP     = "xmpp"
+	POSTGRES = "postgres"
+	MYSQL    = "mysql"
+	RDP      = "rdp"
+)
+
+// maxStreamLength is the maximum length of xmpp stream data read
+const maxStreamLength = 16 * 1024
+
+// ldapStartTLSRequest is the ldap extended request for the StartTLS
+// operation (1.3.6.1.4.1.1466.20037) with message id 1.
+var ldapStartTLSRequest = append([]byte{0x30, 0x1d, 0x02, 0x01, 0x01, 0x77, 0x18, 0x80, 0x16}, "1.3.6.1.4.1.1466.20037"...)
+
+// postgresSSLRequest is the postgres SSLRequest startup packet
+var postgresSSLRequest = []byte{0x00, 0x00, 0x00, 0x08, 0x04, 0xd2, 0x16, 0x2f}
+
+// mysql capability flags used for the SSLRequest packet
+const (
+	mysqlClientLongPassword     = 0x00000001
+	mysqlClientProtocol41       = 0x00000200
+	mysqlClientSSL              = 0x00000800
+	mysqlClientSecureConnection = 0x00008000
+)
+
+// Handler performs the plaintext dialog of a protocol over conn leaving
+// it ready for a tls handshake. Data sent by the server must be read from
+// reader which buffers conn. The server name is used by protocols
+// addressing a virtual host.
+type Handler interface {
+	Negotiate(conn net.Conn, reader *bufio.Reader, serverName string) error
+}
+
+// HandlerFunc is a function implementing Handler
+type HandlerFunc func(conn net.Conn, reader *bufio.Reader, serverName string) error
+
+// Negotiate calls f(conn, reader, serverName)
+func (f HandlerFunc) Negotiate(conn net.Conn, reader *bufio.Reader, serverName string) error {
+	return f(conn, reader, serverName)
+}
+
+var (
+	registryMutex sync.RWMutex
+	// handlers is the registry of protocol handlers
+	handlers = map[string]Handler{
+		SMTP:     HandlerFunc(negotiateSMTP),
+		FTP:      HandlerFunc(negotiateFTP),
+		IMAP:     HandlerFunc(negotiateIMAP),
+		POP3:     HandlerFunc(negotiatePOP3),
+		LDAP:     HandlerFunc(negotiateLDAP),
+		XMPP:     HandlerFunc(negotiateXMPP),
+		POSTGRES: HandlerFunc(negotiatePostgres),
+		MYSQL:    HandlerFunc(negotiateMySQL),
+		RDP:      HandlerFunc(negotiateRDP),
+	}
+	// portToProtocol is the list of default protocols of ports used
+	// when automatic starttls detection is enabled.
+	portToProtocol = map[string]string{
+		"21":   FTP,
+		"25":   SMTP,
+		"587":  SMTP,
+		"143":  IMAP,
+		"110":  POP3,
+		"389":  LDAP,
+		"5222": XMPP,
+		"5432": POSTGRES,
+		"3306": MYSQL,
+		"3389": RDP,
+	}
+)
+
+// Register registers a handler for protocol, replacing any handler
+// registered for it, and uses it by default for ports when automatic
+// detection is enabled.
+func Register(protocol string, handler Handler, ports ...string) error {
+	if protocol == "" || strings.ContainsAny(protocol, ",:") {
+		return fmt.Errorf("invalid starttls protocol name: %q", protocol)
+	}
+	if handler == nil {
+		return errors.New("nil starttls handler")
+	}
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	handlers[protocol] = handler
+	for _, port := range ports {
+		portToProtocol[port] = protocol
+	}
+	return nil
+}
+
+// Protocols returns the sorted list of registered protocols
+func Protocols() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	protocols := make([]string, 0, len(handlers))
+	for protocol := range handlers {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+	return protocols
+}
+
+// Protocol returns the starttls protocol to use for a port. The protocol
+// specified in options is used if any, then the protocol mapped to the
+// port in options, otherwise the default protocol of the port if automatic
+// detection is enabled.
+func Protocol(options *clients.Options, port string) string {
+	if options.StartTLS != "" {
+		return options.StartTLS
+	}
+	for _, mapping := range options.StartTLSPorts {
+		if mappedPort, protocol, ok := ParsePortMapping(mapping); ok && mappedPort == port {
+			return protocol
+		}
+	}
+	if !options.AutoStartTLS {
+		return ""
+	}
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	return portToProtocol[port]
+}
+
+// ParsePortMapping parses a port:protocol mapping of a port to a protocol
+func ParsePortMapping(mapping string) (string, string, bool) {
+	parts := strings.SplitN(mapping, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// ServerName returns the server name to use in starttls dialogs
+func ServerName(options *clients.Options, hostname string) string {
+	if options.ServerName != "" {
+		return options.ServerName
+	}
+	return hostname
+}
+
+// IsSupported returns true if a handler is registered for the protocol
+func IsSupported(protocol string) bool {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	_, ok := handlers[protocol]
+	return ok
+}
+
+// Negotiate performs the starttls dialog for protocol over conn leaving
+// it ready for a tls handshake. No dialog is done for empty protocol.
+// The server name is used by protocols addressing a virtual host.
+func Negotiate(ctx context.Context, conn net.Conn, protocol, serverName string) error {
+	if protocol == "" {
+		return nil
+	}
+	registryMutex.RLock()
+	handler, ok := handlers[protocol]
+	registryMutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("unsupported starttls protocol: %s", protocol)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() {
+			_ = conn.SetDeadline(time.Time{})
+		}()
+	}
+	return handler.Negotiate(conn, bufio.NewReader(conn), serverName)
+}
+
+func negotiateSMTP(conn net.Conn, buffered *bufio.Reader, _ string) error {
+	reader := textproto.NewReader(buffered)
+	if _, _, err := reader.ReadResponse(220); err != nil {
+		return errors.Wrap(err, "could not read smtp greeting")
+	}
+	if _, err := conn.Write([]byte("EHLO tlsx\r\n")); err != nil {
+		return errors.Wrap(err, "could not write smtp ehlo")
+	}
+	if _, _, err := reader.ReadResponse(250); err != nil {
+		return errors.Wrap(err, "could not read smtp ehlo response")
+	}
+	if _, err := conn.Write([]byte("STARTTLS\r\n")); err != nil {
+		return errors.Wrap(err, "could not write smtp starttls")
+	}
+	if _, _, err := reader.ReadResponse(220); err != nil {
+		return errors.Wrap(err, "could not read smtp starttls response")
+	}
+	return nil
+}
+
+func negotiateFTP(conn net.Conn, buffered *bufio.Reader, _ string) error {
+	reader := textproto.NewReader(buffered)
+	if _, _, err := reader.ReadResponse(220); err != nil {
+		return errors.Wrap(err, "could not read ftp greeting")
+	}
+	if _, err := conn.Write([]byte("AUTH TLS\r\n")); err != nil {
+		return errors.Wrap(err, "could not write ftp auth tls")
+	}
+	if _, _, err := reader.ReadResponse(234); err != nil {
+		return errors.Wrap(err, "could not read ftp auth tls response")
+	}
+	return nil
+}
+
+func negotiateIMAP(conn net.Conn, buffered *bufio.Reader, _ string) error {
+	reader := textproto.NewReader(buffered)
+	greeting, err := reader.ReadLine()
+	if err != nil {
+		return errors.Wrap(err, "could not read imap greeting")
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected imap greeting: %s", greeting)
+	}
+	if _, err := conn.Write([]byte("a1 STARTTLS\r\n")); err != nil {
+		return errors.Wrap(err, "could not write imap starttls")
+	}
+	// skip untagged responses until the tagged completion result
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return errors.Wrap(err, "could not read imap starttls response")
+		}
+		if strings.HasPrefix(line, "a1 OK") {
+			return nil
+		}
+		if strings.HasPrefix(line, "a1 ") {
+			return fmt.Errorf("imap starttls rejected: %s", line)
+		}
+	}
+}
+
+func negotiatePOP3(conn net.Conn, buffered *bufio.Reader, _ string) error {
+	reader := textproto.NewReader(buffered)
+	greeting, err := reader.ReadLine()
+	if err != nil {
+		return errors.Wrap(err, "could not read pop3 greeting")
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return fmt.Errorf("unexpected pop3 greeting: %s", greeting)
+	}
+	if _, err := conn.Write([]byte("STLS\r\n")); err != nil {
+		return errors.Wrap(err, "could not write pop3 stls")
+	}
+	line, err := reader.ReadLine()
+	if err != nil {
+		return errors.Wrap(err, "could not read pop3 stls response")
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("pop3 stls rejected: %s", line)
+	}
+	return nil
+}
+
+func negotiateLDAP(conn net.Conn, reader *bufio.Reader, _ string) error {
+	if _, err := conn.Write(ldapStartTLSRequest); err != nil {
+		return errors.Wrap(err, "could not write ldap starttls request")
+	}
+	message, err := readBERElement(reader)
+	if err != nil {
+		return errors.Wrap(err, "could not read ldap starttls response")
+	}
+	// LDAPMessage ::= SEQUENCE { messageID INTEGER, protocolOp [APPLICATION 24] ExtendedResponse }
+	// where the ExtendedResponse starts with the resultCode ENUMERATED.
+	if len(message) < 3 || message[0] != 0x02 || len(message) < 2+int(message[1]) {
+		return errors.New("invalid ldap starttls response")
+	}
+	operation := message[2+int(message[1]):]
+	if len(operation) < 2 || operation[0] != 0x78 {
+		return errors.New("unexpected ldap starttls response operation")
+	}
+	_, headerLength, err := berLength(operation[1:])
+	if err != nil {
+		return errors.Wrap(err, "invalid ldap starttls response")
+	}
+	result := operation[1+headerLength:]
+	if len(result) < 3 || result[0] != 0x0a || result[1] != 0x01 {
+		return errors.New("invalid ldap starttls result code")
+	}
+	if result[2] != 0 {
+		return fmt.Errorf("ldap starttls rejected with result code %d", result[2])
+	}
+	return nil
+}
+
+// readBERElement reads a ber encoded sequence returning its contents
+func readBERElement(reader *bufio.Reader) ([]byte, error) {
+	header, err := reader.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != 0x30 {
+		return nil, errors.New("response is not a ber sequence")
+	}
+	lengthBytes := 1
+	if header[1]&0x80 != 0 {
+		lengthBytes += int(header[1] & 0x7f)
+	}
+	header, err = reader.Peek(1 + lengthBytes)
+	if err != nil {
+		return nil, err
+	}
+	length, _, err := berLength(header[1:])
+	if err != nil {
+		return nil, err
+	}
+	if length > maxStreamLength {
+		return nil, errors.New("ber sequence too large")
+	}
+	element := make([]byte, 1+lengthBytes+length)
+	if _, err := io.ReadFull(reader, element); err != nil {
+		return nil, err
+	}
+	return element[1+lengthBytes:], nil
+}
+
+// berLength parses a ber definite length returning the length and
+// the number of bytes used by its encoding.
+func berLength(data []byte) (int, int, error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("missing ber length")
+	}
+	if data[0]&0x80 == 0 {
+		return int(data[0]), 1, nil
+	}
+	count := int(data[0] & 0x7f)
+	if count == 0 || count > 3 || len(data) < 1+count {
+		return 0, 0, errors.New("unsupported ber length")
+	}
+	var length int
+	for _, value := range data[1 : 1+count] {
+		length = length<<8 | int(value)
+	}
+	return length, 1 + count, nil
+}
+
+func negotiateXMPP(conn net.Conn, reader *bufio.Reader, serverName string) error {
+	stream := fmt.Sprintf("<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' to='%s' version='1.0'>", serverName)
+	if _, err := conn.Write([]byte(stream)); err != nil {
+		return errors.Wrap(err, "could not write xmpp stream header")
+	}
+	features, err := readUntil(reader, "</stream:features>")
+	if err != nil {
+		return errors.Wrap(err, "could not read xmpp stream features")
+	}
+	if !strings.Contains(features, "urn:ietf:params:xml:ns:xmpp-tls") {
+		return errors.New("xmpp server does not support starttls")
+	}
+	if _, err := conn.Write([]byte("<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>")); err != nil {
+		return errors.Wrap(err, "could not write xmpp starttls")
+	}
+	response, err := readUntil(reader, "<proceed", "<failure")
+	if err != nil {
+		return errors.Wrap(err, "could not read xmpp starttls response")
+	}
+	if strings.HasSuffix(response, "<failure") {
+		return errors.New("xmpp starttls rejected")
+	}
+	// consume the remainder of the proceed element
+	if _, err := readUntil(reader, ">"); err != nil {
+		return errors.Wrap(err, "could not read xmpp proceed")
+	}
+	return nil
+}
+
+// readUntil reads from reader until data ends with one of the tokens
+func readUntil(reader *bufio.Reader, tokens ...string) (string, error) {
+	var builder strings.Builder
+	for builder.Len() < maxStreamLength {
+		value, err := reader.ReadByte()
+		if err != nil {
+			return builder.String(), err
+		}
+		builder.WriteByte(value)
+		for _, token := range tokens {
+			if strings.HasSuffix(builder.String(), token) {
+				return builder.String(), nil
+			}
+		}
+	}
+	return builder.String(), errors.New("stream data too large")
+}
+
+func negotiatePostgres(conn net.Conn, reader *bufio.Reader, _ string) error {
+	if _, err := conn.Write(postgresSSLRequest); err != nil {
+		return errors.Wrap(err, "could not write postgres ssl request")
+	}
+	response, err := reader.ReadByte()
+	if err != nil {
+		return errors.Wrap(err, "could not read postgres ssl response")
+	}
+	switch response {
+	case 'S':
+		return nil
+	case 'N':
+		return errors.New("postgres server does not support ssl")
+	default:
+		return fmt.Errorf("unexpected postgres ssl response: %q", response)
+	}
+}
+
+func negotiateMySQL(conn net.Conn, reader *bufio.Reader, _ string) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return errors.Wrap(err, "could not read mysql greeting")
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	if length > maxStreamLength {
+		return errors.New("mysql greeting too large")
+	}
+	greeting := make([]byte, length)
+	if _, err := io.ReadFull(reader, greeting); err != nil {
+		return errors.Wrap(err, "could not read mysql greeting")
+	}
+	if len(greeting) > 0 && greeting[0] == 0xff {
+		// error packet with 2 byte code followed by the message
+		if len(greeting) > 3 {
+			return fmt.Errorf("mysql server returned error: %s", strings.TrimPrefix(string(greeting[3:]), "#"))
+		}
+		return errors.New("mysql server returned error")
+	}
+
+	// protocol version, null terminated server version, connection id,
+	// auth plugin data, filler and lower capability flags
+	if len(greeting) < 1 || greeting[0] != 10 {
+		return errors.New("unsupported mysql protocol version")
+	}
+	index := bytes.IndexByte(greeting[1:], 0)
+	if index == -1 || len(greeting) < 1+index+1+4+8+1+2 {
+		return errors.New("invalid mysql greeting")
+	}
+	capabilities := binary.LittleEndian.Uint16(greeting[1+index+1+4+8+1:])
+	if capabilities&mysqlClientSSL == 0 {
+		return errors.New("mysql server does not support ssl")
+	}
+
+	request := make([]byte, 4+32)
+	request[0] = 32
+	request[3] = header[3] + 1
+	binary.LittleEndian.PutUint32(request[4:], mysqlClientLongPassword|mysqlClientProtocol41|mysqlClientSSL|mysqlClientSecureConnection)
+	binary.LittleEndian.PutUint32(request[8:], 16*1024*1024)
+	request[12] = 0x21 // utf8_general_ci
+	if _, err := conn.Write(request); err != nil {
+		return errors.Wrap(err, "could not write mysql ssl request")
+	}
+	return nil
+}
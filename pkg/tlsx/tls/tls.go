@@ -15,11 +15,11 @@ import (
 	"github.com/projectdiscovery/fastdialer/fastdialer"
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/broker"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/identity"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
 	"github.com/rs/xid"
-
-	zasn1 "github.com/zmap/zcrypto/encoding/asn1"
-	zpkix "github.com/zmap/zcrypto/x509/pkix"
 )
 
 // Client is a TLS grabbing client using crypto/tls
@@ -45,6 +45,9 @@ var versionToTLSVersionString = map[uint16]string{
 	tls.VersionTLS13: "tls13",
 }
 
+// errCertsOnly aborts handshakes once the certificates are received
+var errCertsOnly = errors.New("certificates received")
+
 // New creates a new grabbing client using crypto/tls
 func New(options *clients.Options) (*Client, error) {
 	c := &Client{
@@ -60,6 +63,12 @@ func New(options *clients.Options) (*Client, error) {
 	if options.ServerName != "" {
 		c.tlsConfig.ServerName = options.ServerName
 	}
+	if !options.ValidationAt.IsZero() {
+		c.tlsConfig.Time = func() time.Time { return options.ValidationAt }
+	}
+	if len(options.ALPN) > 0 {
+		c.tlsConfig.NextProtos = options.ALPN
+	}
 	if len(options.Ciphers) > 0 {
 		if customCiphers, err := toTLSCiphers(options.Ciphers); err != nil {
 			return nil, errors.Wrap(err, "could not get tls ciphers")
@@ -67,17 +76,60 @@ func New(options *clients.Options) (*Client, error) {
 			c.tlsConfig.CipherSuites = customCiphers
 		}
 	}
+	if options.CACertStore != "" {
+		certPool, err := identity.LoadCertStoreRoots(options.CACertStore)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load ca certificate store")
+		}
+		c.tlsConfig.RootCAs = certPool
+	}
 	if options.CACertificate != "" {
 		caCert, err := ioutil.ReadFile(options.CACertificate)
 		if err != nil {
 			return nil, errors.Wrap(err, "could not read ca certificate")
 		}
-		certPool := x509.NewCertPool()
+		certPool := c.tlsConfig.RootCAs
+		if certPool == nil {
+			certPool = x509.NewCertPool()
+		}
 		if !certPool.AppendCertsFromPEM(caCert) {
 			gologger.Error().Msgf("Could not append parsed ca-cert to config!")
 		}
 		c.tlsConfig.RootCAs = certPool
 	}
+	rootCAs, err := clients.AppendCustomRoots(c.tlsConfig.RootCAs, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load custom roots")
+	}
+	c.tlsConfig.RootCAs = rootCAs
+	if options.ClientCertificate != "" {
+		certificate, err := identity.LoadFile(options.ClientCertificate, options.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client certificate")
+		}
+		c.tlsConfig.Certificates = []tls.Certificate{*certificate}
+	}
+	if options.ClientPKCS12 != "" {
+		certificate, err := identity.LoadPKCS12(options.ClientPKCS12, options.ClientPKCS12Password)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load pkcs12 client certificate")
+		}
+		c.tlsConfig.Certificates = []tls.Certificate{*certificate}
+	}
+	if options.ClientPKCS11 != "" {
+		certificate, err := identity.LoadPKCS11(options.ClientPKCS11)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load pkcs11 client certificate")
+		}
+		c.tlsConfig.Certificates = []tls.Certificate{*certificate}
+	}
+	if options.ClientCertStore != "" {
+		certificate, err := identity.LoadCertStore(options.ClientCertStore)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client certificate from store")
+		}
+		c.tlsConfig.Certificates = []tls.Certificate{*certificate}
+	}
 	if options.MinVersion != "" {
 		version, ok := versionStringToTLSVersion[options.MinVersion]
 		if !ok {
@@ -108,10 +160,19 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 		defer cancel()
 	}
 
-	rawConn, err := c.dialer.Dial(ctx, "tcp", address)
+	rawConn, err := clients.Dial(ctx, c.options, address)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not dial address")
 	}
+	if err := starttls.Negotiate(ctx, rawConn, starttls.Protocol(c.options, port), starttls.ServerName(c.options, hostname)); err != nil {
+		rawConn.Close()
+		return nil, errors.Wrap(err, "could not negotiate starttls")
+	}
+	var captureConn *clients.CaptureConn
+	if c.options.CaptureHello || c.options.MatchFingerprint {
+		captureConn = clients.NewCaptureConn(rawConn)
+		rawConn = captureConn
+	}
 	var resolvedIP string
 	if !iputil.IsIP(hostname) {
 		resolvedIP = c.dialer.GetDialedIP(hostname)
@@ -129,85 +190,73 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 
 		config = c
 	}
-
-	conn := tls.Client(rawConn, config)
-	if err := conn.HandshakeContext(ctx); err != nil {
-		rawConn.Close()
-		return nil, errors.Wrap(err, "could not do handshake")
+	if len(c.options.ALPN) == 0 {
+		if protocols := broker.ALPN(port); len(protocols) > 0 {
+			config = config.Clone()
+			config.NextProtos = protocols
+		}
 	}
-	defer conn.Close()
 
-	connectionState := conn.ConnectionState()
-	if len(connectionState.PeerCertificates) == 0 {
-		return nil, errors.New("no certificates returned by server")
+	var clientCertificate, requested bool
+	if len(config.Certificates) > 0 || c.options.CertificateRequest {
+		// the client certificate is sent through the callback to record
+		// whether the server requested it, an empty one if there is none.
+		certificate := &tls.Certificate{}
+		if len(config.Certificates) > 0 {
+			certificate = &config.Certificates[0]
+		}
+		config = config.Clone()
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			requested = true
+			clientCertificate = len(certificate.Certificate) > 0
+			return certificate, nil
+		}
 	}
-	tlsVersion := versionToTLSVersionString[connectionState.Version]
-	tlsCipher := tls.CipherSuiteName(connectionState.CipherSuite)
-
-	leafCertificate := connectionState.PeerCertificates[0]
-	certificateChain := connectionState.PeerCertificates[1:]
 
-	response := &clients.Response{
-		Timestamp:           time.Now(),
-		Host:                hostname,
-		IP:                  resolvedIP,
-		Port:                port,
-		Version:             tlsVersion,
-		Cipher:              tlsCipher,
-		TLSConnection:       "ctls",
-		CertificateResponse: convertCertificateToResponse(leafCertificate),
-	}
-	if c.options.TLSChain {
-		for _, cert := range certificateChain {
-			response.Chain = append(response.Chain, convertCertificateToResponse(cert))
+	var certsOnlyChain [][]byte
+	if c.options.CertsOnly {
+		// the handshake is aborted once the certificate message is
+		// processed, before the key exchange with tls 1.2 servers.
+		config = config.Clone()
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			certsOnlyChain = rawCerts
+			return errCertsOnly
 		}
 	}
-	return response, nil
-}
 
-func convertCertificateToResponse(cert *x509.Certificate) clients.CertificateResponse {
-	response := clients.CertificateResponse{
-		SubjectAN:  cert.DNSNames,
-		Emails:     cert.EmailAddresses,
-		NotBefore:  cert.NotAfter,
-		NotAfter:   cert.NotAfter,
-		Expired:    clients.IsExpired(cert.NotAfter),
-		SelfSigned: clients.IsSelfSigned(cert.AuthorityKeyId, cert.SubjectKeyId),
-		IssuerCN:   cert.Issuer.CommonName,
-		IssuerOrg:  cert.Issuer.Organization,
-		SubjectCN:  cert.Subject.CommonName,
-		SubjectOrg: cert.Subject.Organization,
-		FingerprintHash: clients.CertificateResponseFingerprintHash{
-			MD5:    clients.MD5Fingerprint(cert.Raw),
-			SHA1:   clients.SHA1Fingerprint(cert.Raw),
-			SHA256: clients.SHA256Fingerprint(cert.Raw),
-		},
-	}
-	if parsedIssuer := parseASN1DNSequenceWithZpkix(cert.RawIssuer); parsedIssuer != "" {
-		response.IssuerDN = parsedIssuer
-	} else {
-		response.IssuerDN = cert.Issuer.String()
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil && err != errCertsOnly {
+		rawConn.Close()
+		// servers aborting the handshake without a client certificate
+		// are reported when probing certificate requests.
+		if !c.options.CertificateRequest || !requested || len(conn.ConnectionState().PeerCertificates) == 0 {
+			return nil, errors.Wrap(err, "could not do handshake")
+		}
 	}
-	if parsedSubject := parseASN1DNSequenceWithZpkix(cert.RawSubject); parsedSubject != "" {
-		response.SubjectDN = parsedSubject
-	} else {
-		response.SubjectDN = cert.Subject.String()
+	defer conn.Close()
+
+	handshake := NewHandshake(conn.ConnectionState())
+	handshake.Host, handshake.IP, handshake.Port = hostname, resolvedIP, port
+	handshake.Capture = captureConn
+	handshake.ClientCertificate = clientCertificate
+	if certsOnlyChain != nil {
+		handshake.RawChain = certsOnlyChain
 	}
-	return response
+	return clients.NewResponse(c.options, handshake)
 }
 
-// parseASN1DNSequenceWithZpkix tries to parse raw ASN1 of a TLS DN with zpkix and
-// zasn1 library which includes additional information not parsed by go standard
-// library which may be useful.
-//
-// If the parsing fails, a blank string is returned and the standard library data is used.
-func parseASN1DNSequenceWithZpkix(data []byte) string {
-	var rdnSequence zpkix.RDNSequence
-	var subject zpkix.Name
-	if _, err := zasn1.Unmarshal(data, &rdnSequence); err != nil {
-		return ""
-	}
-	subject.FillFromRDNSequence(&rdnSequence)
-	dnParsedString := subject.String()
-	return dnParsedString
+// NewHandshake returns the handshake of the state of a connection
+func NewHandshake(connectionState tls.ConnectionState) *clients.Handshake {
+	handshake := &clients.Handshake{
+		Version:       versionToTLSVersionString[connectionState.Version],
+		Cipher:        tls.CipherSuiteName(connectionState.CipherSuite),
+		ALPN:          connectionState.NegotiatedProtocol,
+		TLSConnection: "ctls",
+		OCSPStaple:    connectionState.OCSPResponse,
+		SCTs:          connectionState.SignedCertificateTimestamps,
+	}
+	for _, cert := range connectionState.PeerCertificates {
+		handshake.RawChain = append(handshake.RawChain, cert.Raw)
+	}
+	return handshake
 }
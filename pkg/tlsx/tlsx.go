@@ -2,9 +2,29 @@ package tlsx
 
 import (
 	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/acme"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/alpn"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/attribution"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/auto"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/broker"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/check"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ciphers"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clientauth"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ct"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/defaultcert"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/fingerprint"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/http2"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/jarm"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ldap"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/openssl"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/quic"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/revocation"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/tls"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/validation"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/vulns"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/ztls"
 )
 
@@ -12,6 +32,19 @@ import (
 type Service struct {
 	options *clients.Options
 	client  clients.Implementation
+	matcher *fingerprint.Matcher
+	// attributor scores certificate names for name attribution
+	attributor *attribution.Attributor
+	// validator diagnoses problems of presented chains
+	validator *validation.Validator
+	// revocationChecker checks the revocation status of presented leaves
+	revocationChecker *revocation.Checker
+	// ctChecker checks the signed certificate timestamps of presented leaves
+	ctChecker *ct.Checker
+	// ctSearcher searches ct logs for other certificates of hostnames
+	ctSearcher *ct.Searcher
+	// opensslProbe is the openssl client used by probes requiring openssl
+	opensslProbe *openssl.Client
 }
 
 // New creates a new tlsx service module
@@ -20,13 +53,23 @@ func New(options *clients.Options) (*Service, error) {
 		options: options,
 	}
 	var err error
+	if options.CheckOnly {
+		if service.client, err = check.New(options); err != nil {
+			return nil, errors.Wrap(err, "could not create tls service")
+		}
+		return service, nil
+	}
 	switch options.ScanMode {
 	case "ztls":
 		service.client, err = ztls.New(options)
 	case "ctls":
 		service.client, err = tls.New(options)
+	case "openssl":
+		service.client, err = openssl.New(options)
 	case "auto":
 		service.client, err = auto.New(options)
+	case "quic":
+		service.client, err = quic.New(options)
 	default:
 		// Default mode is TLS
 		service.client, err = tls.New(options)
@@ -34,6 +77,37 @@ func New(options *clients.Options) (*Service, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create tls service")
 	}
+	if options.EarlyData || options.Renegotiation {
+		if service.opensslProbe, err = openssl.New(options); err != nil {
+			return nil, errors.Wrap(err, "could not create openssl probe client")
+		}
+	}
+	if options.MatchFingerprint {
+		if service.matcher, err = fingerprint.New(options.FingerprintFile); err != nil {
+			return nil, errors.Wrap(err, "could not create fingerprint matcher")
+		}
+	}
+	if options.Attribute {
+		service.attributor = attribution.New(options)
+	}
+	if options.ChainValidation {
+		if service.validator, err = validation.New(options); err != nil {
+			return nil, errors.Wrap(err, "could not create chain validator")
+		}
+	}
+	if len(options.Revocation) > 0 {
+		if service.revocationChecker, err = revocation.New(options); err != nil {
+			return nil, errors.Wrap(err, "could not create revocation checker")
+		}
+	}
+	if options.CT {
+		if service.ctChecker, err = ct.New(options); err != nil {
+			return nil, errors.Wrap(err, "could not create ct checker")
+		}
+	}
+	if options.CTSearch {
+		service.ctSearcher = ct.NewSearcher(options)
+	}
 	return service, nil
 }
 
@@ -43,5 +117,150 @@ func (s *Service) Connect(host, port string) (*clients.Response, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "could not connect to host")
 	}
+	// jarm is also computed for matching known jarm fingerprints
+	if s.options.JARM || (s.matcher != nil && s.matcher.HasJARM()) {
+		if resp.JARM, err = jarm.Fingerprint(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe jarm for %s: %s", host, err)
+		}
+	}
+	if s.matcher != nil {
+		resp.Fingerprints = s.matcher.Match(resp)
+	}
+	// the server name is used if specified, ip inputs have no hostname
+	hostname := s.options.ServerName
+	if hostname == "" && !iputil.IsIP(host) {
+		hostname = host
+	}
+	if s.options.HostnameCoverage && hostname != "" {
+		resp.HostnameCoverage = clients.HostnameCoverage(hostname, &resp.CertificateResponse)
+	}
+	if s.attributor != nil {
+		resp.Attribution = s.attributor.Attribute(hostname, &resp.CertificateResponse)
+	}
+	if s.validator != nil {
+		resp.ChainValidation = s.validator.Validate(resp.RawChain, hostname, clients.Now(s.options))
+	}
+	if s.revocationChecker != nil {
+		if resp.Revocation, err = s.revocationChecker.Check(resp.RawChain, clients.Now(s.options)); err != nil {
+			gologger.Verbose().Msgf("Could not check revocation for %s: %s", host, err)
+		}
+	}
+	if s.ctChecker != nil {
+		if resp.CT, err = s.ctChecker.Check(resp.RawChain, resp.RawSCTs, resp.RawOCSPStaple); err != nil {
+			gologger.Verbose().Msgf("Could not check ct for %s: %s", host, err)
+		}
+	}
+	if s.ctSearcher != nil && hostname != "" {
+		if resp.CTSearch, err = s.ctSearcher.Search(hostname, &resp.CertificateResponse, clients.Now(s.options)); err != nil {
+			gologger.Verbose().Msgf("Could not search ct logs for %s: %s", hostname, err)
+		}
+	}
+	if s.options.Hosting || s.options.ExcludeHosting {
+		resp.Hosting = clients.Hosting(&resp.CertificateResponse)
+	}
+	if s.options.DefaultCertificate {
+		if resp.DefaultCertificate, err = defaultcert.Probe(s.options, host, port, resp.FingerprintHash.SHA256); err != nil {
+			gologger.Verbose().Msgf("Could not probe default certificate for %s: %s", host, err)
+		}
+	}
+	if s.options.ACME {
+		if resp.ACME, err = acme.Probe(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe acme for %s: %s", host, err)
+		}
+	}
+	if s.options.EarlyData {
+		if resp.EarlyData, err = s.opensslProbe.EarlyData(host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe early data for %s: %s", host, err)
+		}
+	}
+	if s.options.Renegotiation {
+		if resp.Renegotiation, err = s.opensslProbe.Renegotiation(host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe renegotiation for %s: %s", host, err)
+		}
+	}
+	if s.options.Compression {
+		if resp.Compression, err = ciphers.DetectCompression(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe compression for %s: %s", host, err)
+		}
+	}
+	if s.options.DHParams {
+		if resp.DHParams, err = ciphers.DetectDHParams(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe dh parameters for %s: %s", host, err)
+		}
+	}
+	if s.options.FallbackSCSV {
+		if resp.FallbackSCSV, err = ciphers.DetectFallbackSCSV(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe fallback scsv for %s: %s", host, err)
+		}
+	}
+	if s.options.ExtendedMasterSecret {
+		if resp.ExtendedMasterSecret, err = ciphers.DetectExtendedMasterSecret(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe extended master secret for %s: %s", host, err)
+		}
+	}
+	if s.options.ECH {
+		if resp.ECH, err = ciphers.DetectECH(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe ech for %s: %s", host, err)
+		}
+	}
+	if s.options.PostQuantum {
+		if resp.PostQuantum, err = ciphers.DetectPostQuantum(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe post-quantum groups for %s: %s", host, err)
+		}
+	}
+	if s.options.CertificateRequest {
+		if resp.CertificateRequest, err = clientauth.Probe(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not probe certificate request for %s: %s", host, err)
+		}
+	}
+	if s.options.HTTP2Confirm {
+		if resp.HTTP2, err = http2.Confirm(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not confirm http2 for %s: %s", host, err)
+		}
+	}
+	if s.options.BrokerConfirm {
+		if protocol := broker.Protocol(port); protocol != "" {
+			resp.Broker = &clients.BrokerResponse{Protocol: protocol}
+			if resp.Broker.Confirmed, err = broker.Confirm(s.options, host, port); err != nil {
+				gologger.Verbose().Msgf("Could not confirm %s broker for %s: %s", protocol, host, err)
+			}
+		}
+	}
+	// domain controller findings only apply to active directory servers
+	if (s.options.RemediationHints || s.options.ReportPDF != "") && ldap.IsDomainControllerPort(port) {
+		if resp.ActiveDirectory, err = ldap.IsActiveDirectory(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not confirm active directory for %s: %s", host, err)
+		}
+	}
+	for _, check := range vulns.Enabled(s.options) {
+		vulnerable, err := check.Run(s.options, host, port)
+		if err != nil {
+			gologger.Verbose().Msgf("Could not check %s for %s: %s", check.ID, host, err)
+			continue
+		}
+		if vulnerable {
+			resp.Vulnerabilities = append(resp.Vulnerabilities, check.ID)
+		}
+	}
+	if s.options.CipherEnum {
+		if resp.CipherEnum, err = ciphers.Enumerate(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not enumerate ciphers for %s: %s", host, err)
+		}
+	}
+	if s.options.GroupEnum {
+		if resp.GroupEnum, err = ciphers.EnumerateGroups(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not enumerate groups for %s: %s", host, err)
+		}
+	}
+	if s.options.SignatureEnum {
+		if resp.SignatureEnum, err = ciphers.EnumerateSignatureAlgorithms(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not enumerate signature algorithms for %s: %s", host, err)
+		}
+	}
+	if s.options.ALPNEnum {
+		if resp.ALPNEnum, err = alpn.Enumerate(s.options, host, port); err != nil {
+			gologger.Verbose().Msgf("Could not enumerate alpn for %s: %s", host, err)
+		}
+	}
 	return resp, nil
 }
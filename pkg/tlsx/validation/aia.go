@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxAIAFetches is the maximum number of issuers fetched for a chain
+	maxAIAFetches = 4
+	// maxAIAResponse is the maximum size of fetched issuer responses
+	maxAIAResponse = 1 << 20
+	// maxAIACacheEntries is the maximum number of fetched urls cached
+	maxAIACacheEntries = 256
+)
+
+// aiaEntry is the cached response of a ca issuers url, its mutex makes
+// concurrent lookups of the same url wait for one download.
+type aiaEntry struct {
+	sync.Mutex
+	fetched bool
+	certs   []*x509.Certificate
+	err     error
+}
+
+// aiaEntry returns the cache entry of a ca issuers url
+func (v *Validator) aiaEntry(url string) *aiaEntry {
+	v.aiaMutex.Lock()
+	defer v.aiaMutex.Unlock()
+
+	entry, ok := v.aiaEntries[url]
+	if !ok {
+		// an arbitrary url is evicted, lookups using it keep their entry
+		for evicted := range v.aiaEntries {
+			if len(v.aiaEntries) < maxAIACacheEntries {
+				break
+			}
+			delete(v.aiaEntries, evicted)
+		}
+		entry = &aiaEntry{}
+		v.aiaEntries[url] = entry
+	}
+	return entry
+}
+
+// resolveAIA fetches the missing issuers of cert from the aia ca issuers
+// urls, returning true if the chain then ends at a trusted root. The
+// fetched urls are returned in order.
+func (v *Validator) resolveAIA(cert *x509.Certificate) (bool, []string) {
+	var urls []string
+	for i := 0; i < maxAIAFetches; i++ {
+		issuer, url := v.fetchIssuer(cert)
+		if issuer == nil {
+			return false, urls
+		}
+		urls = append(urls, url)
+		if v.trusted(issuer) != "" {
+			return true, urls
+		}
+		if isSelfSigned(issuer) {
+			return false, urls
+		}
+		cert = issuer
+	}
+	return false, urls
+}
+
+// fetchIssuer returns the first certificate issuing cert fetched from
+// its http ca issuers urls along with the url.
+func (v *Validator) fetchIssuer(cert *x509.Certificate) (*x509.Certificate, string) {
+	for _, url := range cert.IssuingCertificateURL {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+		certs, err := v.cachedCertificates(url)
+		if err != nil {
+			continue
+		}
+		for _, issuer := range certs {
+			if bytes.Equal(cert.RawIssuer, issuer.RawSubject) && cert.CheckSignatureFrom(issuer) == nil {
+				return issuer, url
+			}
+		}
+	}
+	return nil, ""
+}
+
+// cachedCertificates returns the certificates served at url, fetching
+// each url once as the issuers of many hosts are served at the same urls.
+func (v *Validator) cachedCertificates(url string) ([]*x509.Certificate, error) {
+	entry := v.aiaEntry(url)
+	entry.Lock()
+	defer entry.Unlock()
+
+	if !entry.fetched {
+		entry.certs, entry.err = v.fetchCertificates(url)
+		entry.fetched = true
+	}
+	return entry.certs, entry.err
+}
+
+// fetchCertificates returns the certificates served at url, which are
+// der or pem encoded certificates or a der encoded pkcs#7 bundle.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc5280#section-4.2.2.1
+func (v *Validator) fetchCertificates(url string) ([]*x509.Certificate, error) {
+	resp, err := v.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxAIAResponse))
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+	if cert, err := x509.ParseCertificate(data); err == nil {
+		return []*x509.Certificate{cert}, nil
+	}
+	return parsePKCS7Certificates(data)
+}
+
+// pkcs7ContentInfo is the ContentInfo structure of pkcs#7 messages
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// pkcs7SignedData is the SignedData structure up to the certificates
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// parsePKCS7Certificates returns the certificates of a der encoded
+// pkcs#7 signed data bundle as served for .p7c urls.
+//
+// follows: https://datatracker.ietf.org/doc/html/rfc2315#section-9.1
+func parsePKCS7Certificates(data []byte) ([]*x509.Certificate, error) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, errors.Wrap(err, "could not parse pkcs7 content info")
+	}
+	var signedData pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signedData); err != nil {
+		return nil, errors.Wrap(err, "could not parse pkcs7 signed data")
+	}
+	return x509.ParseCertificates(signedData.Certificates.Bytes)
+}
@@ -0,0 +1,215 @@
+// Package validation diagnoses problems of presented certificate chains
+// pointing to the offending certificate of each problem.
+package validation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of chain validation problem types
+const (
+	// ProblemUnparseable is used for certificates which could not be parsed
+	ProblemUnparseable = "unparseable"
+	// ProblemUntrustedRoot is used when the chain ends at a self-signed
+	// certificate which is not a trusted root
+	ProblemUntrustedRoot = "untrusted-root"
+	// ProblemIncompleteChain is used when the issuer of the last chain
+	// certificate is neither presented nor a trusted root
+	ProblemIncompleteChain = "incomplete-chain"
+	// ProblemExpired is used for expired chain certificates
+	ProblemExpired = "expired"
+	// ProblemNotYetValid is used for chain certificates not valid yet
+	ProblemNotYetValid = "not-yet-valid"
+	// ProblemWrongOrder is used for issuers presented out of order
+	ProblemWrongOrder = "wrong-order"
+	// ProblemUnnecessary is used for presented certificates which are
+	// not part of the chain of the leaf
+	ProblemUnnecessary = "unnecessary"
+	// ProblemHostnameMismatch is used when the leaf is not valid for the hostname
+	ProblemHostnameMismatch = "hostname-mismatch"
+)
+
+// Validator validates presented chains against trusted roots
+type Validator struct {
+	stores []*clients.TrustStore
+	// compared is the list of stores the trust of chains is reported in,
+	// empty unless named trust stores are specified
+	compared []*clients.TrustStore
+	// httpClient fetches missing issuers if aia fetching is enabled
+	httpClient *http.Client
+
+	aiaMutex   sync.Mutex
+	aiaEntries map[string]*aiaEntry
+}
+
+// New creates a validator with the system roots, or the roots of the
+// ca certificate, ca file and ca directory options if specified. Named
+// trust stores are only compared and do not make chains valid.
+func New(options *clients.Options) (*Validator, error) {
+	stores, err := clients.TrustStores(options)
+	if err != nil {
+		return nil, err
+	}
+	named, err := clients.NamedTrustStores(options)
+	if err != nil {
+		return nil, err
+	}
+	validator := &Validator{stores: stores}
+	if len(named) > 0 {
+		// stores of the same name as a validating store are skipped
+		seen := make(map[string]struct{})
+		for _, store := range append(stores, named...) {
+			if _, ok := seen[store.Name]; ok {
+				continue
+			}
+			seen[store.Name] = struct{}{}
+			validator.compared = append(validator.compared, store)
+		}
+	}
+	if options.AIAFetch {
+		validator.aiaEntries = make(map[string]*aiaEntry)
+		validator.httpClient = &http.Client{
+			Timeout: time.Duration(options.Timeout) * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+					return clients.Dial(ctx, options, address)
+				},
+			},
+		}
+	}
+	return validator, nil
+}
+
+// Validate diagnoses the chain of der encoded certificates presented with
+// the leaf first at time now. The hostname is not checked if empty.
+func (v *Validator) Validate(raw [][]byte, hostname string, now time.Time) *clients.ChainValidationResponse {
+	response := &clients.ChainValidationResponse{}
+	certs := make([]*x509.Certificate, len(raw))
+	for i, data := range raw {
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			response.Problems = append(response.Problems, clients.ChainProblem{Type: ProblemUnparseable, Index: i})
+			continue
+		}
+		certs[i] = cert
+	}
+	if len(certs) == 0 || certs[0] == nil {
+		return response
+	}
+
+	// the path of the leaf is followed through the presented issuers
+	path := []int{0}
+	used := map[int]struct{}{0: {}}
+	for {
+		current := certs[path[len(path)-1]]
+		if isSelfSigned(current) {
+			break
+		}
+		issuer := -1
+		for i, cert := range certs {
+			if _, ok := used[i]; ok || cert == nil {
+				continue
+			}
+			if bytes.Equal(current.RawIssuer, cert.RawSubject) && current.CheckSignatureFrom(cert) == nil {
+				issuer = i
+				break
+			}
+		}
+		if issuer < 0 {
+			break
+		}
+		path = append(path, issuer)
+		used[issuer] = struct{}{}
+	}
+
+	// the chain ends at the first certificate trusted by a store, later
+	// ones such as cross-signed roots only form alternative paths
+	end := len(path) - 1
+	for position, index := range path {
+		if response.TrustStore = v.trusted(certs[index]); response.TrustStore != "" {
+			end = position
+			break
+		}
+	}
+	if response.TrustStore == "" {
+		last := path[end]
+		problem := ProblemIncompleteChain
+		if isSelfSigned(certs[last]) {
+			problem = ProblemUntrustedRoot
+		}
+		response.Problems = append(response.Problems, clients.ChainProblem{Type: problem, Index: last, Detail: certs[last].Issuer.String()})
+		if problem == ProblemIncompleteChain && v.httpClient != nil {
+			response.AIAResolvable, response.AIAURLs = v.resolveAIA(certs[last])
+		}
+	}
+	for _, store := range v.compared {
+		trusted := false
+		for _, index := range path {
+			if trustedIn(store, certs[index]) {
+				trusted = true
+				break
+			}
+		}
+		response.TrustStores = append(response.TrustStores, clients.TrustStoreResult{Name: store.Name, Trusted: trusted})
+	}
+	for position, index := range path[:end+1] {
+		cert := certs[index]
+		if now.After(cert.NotAfter) {
+			response.Problems = append(response.Problems, clients.ChainProblem{Type: ProblemExpired, Index: index, Detail: cert.Subject.String()})
+		} else if now.Before(cert.NotBefore) {
+			response.Problems = append(response.Problems, clients.ChainProblem{Type: ProblemNotYetValid, Index: index, Detail: cert.Subject.String()})
+		}
+		// issuers are presented after the certificates they issued
+		if position > 0 && index < path[position-1] {
+			response.Problems = append(response.Problems, clients.ChainProblem{Type: ProblemWrongOrder, Index: index, Detail: cert.Subject.String()})
+		}
+	}
+	for i, cert := range certs {
+		if _, ok := used[i]; !ok && cert != nil {
+			response.Problems = append(response.Problems, clients.ChainProblem{Type: ProblemUnnecessary, Index: i, Detail: cert.Subject.String()})
+		}
+	}
+	if hostname != "" {
+		if err := certs[0].VerifyHostname(hostname); err != nil {
+			response.Problems = append(response.Problems, clients.ChainProblem{Type: ProblemHostnameMismatch, Index: 0, Detail: hostname})
+		}
+	}
+	response.Valid = len(response.Problems) == 0
+	return response
+}
+
+// trusted returns the name of the first trust store cert is a trusted
+// root of or issued by, or an empty string if it is not trusted.
+func (v *Validator) trusted(cert *x509.Certificate) string {
+	for _, store := range v.stores {
+		if trustedIn(store, cert) {
+			return store.Name
+		}
+	}
+	return ""
+}
+
+// trustedIn returns true if cert is a trusted root of store or issued by one
+func trustedIn(store *clients.TrustStore, cert *x509.Certificate) bool {
+	// validity is ignored as it is reported for each chain certificate
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:       store.Roots,
+		CurrentTime: cert.NotBefore.Add(time.Second),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// isSelfSigned returns true if cert is signed by its own key, which
+// includes leaf certificates without the ca basic constraint.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -0,0 +1,42 @@
+package vulns
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+	"github.com/zmap/zcrypto/tls"
+)
+
+// dialTLS returns a tls 1.0 to 1.2 connection to the target using config
+// after negotiating starttls, deadlines are taken from ctx.
+func dialTLS(ctx context.Context, options *clients.Options, hostname, port string, config *tls.Config) (*tls.Conn, error) {
+	rawConn, err := clients.Dial(ctx, options, net.JoinHostPort(hostname, port))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not dial address")
+	}
+	if err := starttls.Negotiate(ctx, rawConn, starttls.Protocol(options, port), starttls.ServerName(options, hostname)); err != nil {
+		rawConn.Close()
+		return nil, errors.Wrap(err, "could not negotiate starttls")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = rawConn.SetDeadline(deadline)
+	}
+
+	config.InsecureSkipVerify = true
+	config.MinVersion = tls.VersionTLS10
+	config.MaxVersion = tls.VersionTLS12
+	config.ServerName = options.ServerName
+	if config.ServerName == "" && !iputil.IsIP(hostname) {
+		config.ServerName = hostname
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, errors.Wrap(err, "could not do tls handshake")
+	}
+	return conn, nil
+}
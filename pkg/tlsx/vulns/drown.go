@@ -0,0 +1,17 @@
+package vulns
+
+import (
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ciphers"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// DROWN is the identifier of the DROWN vulnerability
+const DROWN = "drown"
+
+// CheckDROWN returns true if the server supports sslv2, which allows
+// decrypting tls sessions using the same rsa key.
+//
+// follows: https://nvd.nist.gov/vuln/detail/CVE-2016-0800
+func CheckDROWN(options *clients.Options, hostname, port string) (bool, error) {
+	return ciphers.DetectSSLv2(options, hostname, port)
+}
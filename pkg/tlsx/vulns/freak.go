@@ -0,0 +1,17 @@
+package vulns
+
+import (
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ciphers"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// FREAK is the identifier of the FREAK vulnerability
+const FREAK = "freak"
+
+// CheckFREAK returns true if the server accepts export grade rsa or dhe
+// cipher suites.
+//
+// follows: https://nvd.nist.gov/vuln/detail/CVE-2015-0204
+func CheckFREAK(options *clients.Options, hostname, port string) (bool, error) {
+	return ciphers.DetectExportSuites(options, hostname, port)
+}
@@ -0,0 +1,42 @@
+package vulns
+
+import (
+	"context"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/zmap/zcrypto/tls"
+)
+
+// Heartbleed is the identifier of the heartbleed vulnerability
+const Heartbleed = "heartbleed"
+
+// heartbleedTimeout is the time waited for a heartbeat response when no
+// timeout is specified, patched servers silently drop the request.
+const heartbleedTimeout = 5 * time.Second
+
+// CheckHeartbleed returns true if the server answers a heartbeat request
+// whose payload length exceeds the record sent, leaking process memory.
+// The returned memory is discarded and never reported.
+//
+// follows: https://nvd.nist.gov/vuln/detail/CVE-2014-0160
+func CheckHeartbleed(options *clients.Options, hostname, port string) (bool, error) {
+	timeout := heartbleedTimeout
+	if options.Timeout != 0 {
+		timeout = time.Duration(options.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := dialTLS(ctx, options, hostname, port, &tls.Config{HeartbeatEnabled: true})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// the heartbeat response and any leaked memory is discarded, only
+	// the presence of a response is recorded by the connection log.
+	_, _ = conn.CheckHeartbleed(make([]byte, 1))
+	log := conn.GetHeartbleedLog()
+	return log != nil && log.Vulnerable, nil
+}
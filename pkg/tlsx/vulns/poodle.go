@@ -0,0 +1,17 @@
+package vulns
+
+import (
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ciphers"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// POODLE is the identifier of the POODLE vulnerability
+const POODLE = "poodle"
+
+// CheckPOODLE returns true if the server negotiates cbc cipher suites
+// over sslv3, servers not supporting sslv3 are not vulnerable.
+//
+// follows: https://nvd.nist.gov/vuln/detail/CVE-2014-3566
+func CheckPOODLE(options *clients.Options, hostname, port string) (bool, error) {
+	return ciphers.DetectPOODLE(options, hostname, port)
+}
@@ -0,0 +1,18 @@
+package vulns
+
+import (
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ciphers"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// ROBOT is the identifier of the ROBOT vulnerability
+const ROBOT = "robot"
+
+// CheckROBOT returns true if the server is a Bleichenbacher padding oracle
+// for rsa key exchange, servers not offering rsa key exchange are not
+// vulnerable.
+//
+// follows: https://robotattack.org/
+func CheckROBOT(options *clients.Options, hostname, port string) (bool, error) {
+	return ciphers.DetectROBOT(options, hostname, port)
+}
@@ -0,0 +1,40 @@
+package vulns
+
+import (
+	"context"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/ciphers"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/zmap/zcrypto/tls"
+)
+
+// Ticketbleed is the identifier of the ticketbleed vulnerability
+const Ticketbleed = "ticketbleed"
+
+// CheckTicketbleed returns true if the server leaks memory in the session
+// id echoed when resuming a session ticket with a short session id, as
+// done by affected F5 BIG-IP devices. Servers not issuing session tickets
+// are not vulnerable.
+//
+// follows: https://nvd.nist.gov/vuln/detail/CVE-2016-9244
+func CheckTicketbleed(options *clients.Options, hostname, port string) (bool, error) {
+	ctx := context.Background()
+	if options.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	conn, err := dialTLS(ctx, options, hostname, port, &tls.Config{ForceSessionTicketExt: true})
+	if err != nil {
+		return false, err
+	}
+	version := conn.ConnectionState().Version
+	log := conn.GetHandshakeLog()
+	conn.Close()
+	if log == nil || log.SessionTicket == nil || len(log.SessionTicket.Value) == 0 {
+		return false, nil
+	}
+	return ciphers.DetectTicketbleed(options, hostname, port, version, log.SessionTicket.Value)
+}
@@ -0,0 +1,36 @@
+// Package vulns implements active checks for known vulnerabilities of
+// tls implementations. Every check is enabled by its own option.
+package vulns
+
+import "github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+
+// Check is an active vulnerability check run against a target
+type Check struct {
+	// ID is the identifier reported for vulnerable targets
+	ID string
+	// Enabled returns true if the check was requested in options
+	Enabled func(options *clients.Options) bool
+	// Run returns true if the target is vulnerable
+	Run func(options *clients.Options, hostname, port string) (bool, error)
+}
+
+// checks is the list of available vulnerability checks
+var checks = []Check{
+	{ID: Heartbleed, Enabled: func(options *clients.Options) bool { return options.Heartbleed }, Run: CheckHeartbleed},
+	{ID: ROBOT, Enabled: func(options *clients.Options) bool { return options.ROBOT }, Run: CheckROBOT},
+	{ID: Ticketbleed, Enabled: func(options *clients.Options) bool { return options.Ticketbleed }, Run: CheckTicketbleed},
+	{ID: DROWN, Enabled: func(options *clients.Options) bool { return options.DROWN }, Run: CheckDROWN},
+	{ID: POODLE, Enabled: func(options *clients.Options) bool { return options.POODLE }, Run: CheckPOODLE},
+	{ID: FREAK, Enabled: func(options *clients.Options) bool { return options.FREAK }, Run: CheckFREAK},
+}
+
+// Enabled returns the vulnerability checks requested in options
+func Enabled(options *clients.Options) []Check {
+	var enabled []Check
+	for _, check := range checks {
+		if check.Enabled(options) {
+			enabled = append(enabled, check)
+		}
+	}
+	return enabled
+}
@@ -0,0 +1,142 @@
+// Package watch repeatedly handshakes an endpoint and reports changes
+// of its certificate, chain and negotiated parameters.
+package watch
+
+import (
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// List of watched fields
+const (
+	FieldCertificate = "certificate"
+	FieldChain       = "chain"
+	FieldVersion     = "tls-version"
+	FieldCipher      = "cipher"
+	FieldJA3S        = "ja3s"
+	FieldJARM        = "jarm"
+	FieldError       = "error"
+)
+
+// State is the observed state of an endpoint for one handshake
+type State struct {
+	// Certificate is the sha256 fingerprint of the leaf certificate
+	Certificate string `json:"certificate,omitempty"`
+	// SubjectCN is the subject common name of the leaf certificate
+	SubjectCN string `json:"subject-cn,omitempty"`
+	// NotAfter is the expiry of the leaf certificate
+	NotAfter time.Time `json:"not-after,omitempty"`
+	// Chain is the list of sha256 fingerprints of the presented chain
+	// without the leaf
+	Chain []string `json:"chain,omitempty"`
+	// Version is the negotiated tls version
+	Version string `json:"tls-version,omitempty"`
+	// Cipher is the negotiated cipher
+	Cipher string `json:"cipher,omitempty"`
+	// JA3S is the ja3s fingerprint of the server hello
+	JA3S string `json:"ja3s,omitempty"`
+	// JARM is the jarm fingerprint of the server
+	JARM string `json:"jarm,omitempty"`
+	// Error is the handshake error if the handshake failed
+	Error string `json:"error,omitempty"`
+}
+
+// NewState returns the state of a handshake response
+func NewState(response *clients.Response) *State {
+	state := &State{
+		Certificate: response.FingerprintHash.SHA256,
+		SubjectCN:   response.SubjectCN,
+		NotAfter:    response.NotAfter,
+		Version:     response.Version,
+		Cipher:      response.Cipher,
+		JA3S:        response.JA3S,
+		JARM:        response.JARM,
+	}
+	if state.Certificate == "" && len(response.RawChain) > 0 {
+		state.Certificate = clients.SHA256Fingerprint(response.RawChain[0])
+	}
+	for i := 1; i < len(response.RawChain); i++ {
+		state.Chain = append(state.Chain, clients.SHA256Fingerprint(response.RawChain[i]))
+	}
+	return state
+}
+
+// Change is a watched field which differs between two handshakes
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Diff returns the changes of the watched fields of two successful handshakes
+func Diff(old, new *State) []Change {
+	var changes []Change
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, Change{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	add(FieldCertificate, old.Certificate, new.Certificate)
+	add(FieldChain, strings.Join(old.Chain, ","), strings.Join(new.Chain, ","))
+	add(FieldVersion, old.Version, new.Version)
+	add(FieldCipher, old.Cipher, new.Cipher)
+	add(FieldJA3S, old.JA3S, new.JA3S)
+	// jarm probes of a handshake may fail on their own
+	if old.JARM != "" && new.JARM != "" {
+		add(FieldJARM, old.JARM, new.JARM)
+	}
+	return changes
+}
+
+// Event is the result of a handshake which is the first one or changed
+// the state of the endpoint.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Host      string    `json:"host"`
+	Port      string    `json:"port"`
+	// Initial is true for the first handshake of the watch
+	Initial bool     `json:"initial,omitempty"`
+	Changes []Change `json:"changes,omitempty"`
+	State   *State   `json:"state"`
+}
+
+// Watch handshakes host and port every interval calling fn with the first
+// state and every change. Handshake failures and recoveries are changes
+// of the error field, and the state after a recovery is compared with the
+// last successful handshake. Count limits the number of handshakes, zero
+// watches until the process is stopped.
+func Watch(service *tlsx.Service, host, port string, interval time.Duration, count int, fn func(*Event)) {
+	var previous *State
+	var failure string
+	for i := 0; count == 0 || i < count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		event := &Event{Host: host, Port: port, Initial: i == 0}
+		response, err := service.Connect(host, port)
+		event.Timestamp = time.Now().UTC()
+		if err != nil {
+			event.State = &State{Error: err.Error()}
+			if event.State.Error != failure {
+				event.Changes = append(event.Changes, Change{Field: FieldError, Old: failure, New: event.State.Error})
+				failure = event.State.Error
+			}
+		} else {
+			event.State = NewState(response)
+			if failure != "" {
+				event.Changes = append(event.Changes, Change{Field: FieldError, Old: failure})
+				failure = ""
+			}
+			if previous != nil {
+				event.Changes = append(event.Changes, Diff(previous, event.State)...)
+			}
+			previous = event.State
+		}
+		if event.Initial || len(event.Changes) > 0 {
+			fn(event)
+		}
+	}
+}
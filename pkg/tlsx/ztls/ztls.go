@@ -13,7 +13,10 @@ import (
 	"github.com/projectdiscovery/fastdialer/fastdialer"
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/iputil"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/broker"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/starttls"
+	"github.com/rs/xid"
 	"github.com/zmap/zcrypto/tls"
 	"github.com/zmap/zcrypto/x509"
 )
@@ -57,6 +60,15 @@ func New(options *clients.Options) (*Client, error) {
 	if options.ServerName != "" {
 		c.tlsConfig.ServerName = options.ServerName
 	}
+	if !options.ValidationAt.IsZero() {
+		c.tlsConfig.Time = func() time.Time { return options.ValidationAt }
+	}
+	if len(options.ALPN) > 0 {
+		c.tlsConfig.NextProtos = options.ALPN
+	}
+	if options.CT {
+		c.tlsConfig.SignedCertificateTimestampExt = true
+	}
 	if len(options.Ciphers) > 0 {
 		if customCiphers, err := toZTLSCiphers(options.Ciphers); err != nil {
 			return nil, errors.Wrap(err, "could not get ztls ciphers")
@@ -75,6 +87,20 @@ func New(options *clients.Options) (*Client, error) {
 		}
 		c.tlsConfig.RootCAs = certPool
 	}
+	customRoots, err := clients.CustomRoots(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load custom roots")
+	}
+	for _, root := range customRoots {
+		cert, err := x509.ParseCertificate(root.Raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse custom root")
+		}
+		if c.tlsConfig.RootCAs == nil {
+			c.tlsConfig.RootCAs = x509.NewCertPool()
+		}
+		c.tlsConfig.RootCAs.AddCert(cert)
+	}
 	if options.MinVersion != "" {
 		version, ok := versionStringToTLSVersion[options.MinVersion]
 		if !ok {
@@ -120,10 +146,19 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 		defer cancel()
 	}
 
-	conn, err := c.dialer.Dial(ctx, "tcp", address)
+	conn, err := clients.Dial(ctx, c.options, address)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not connect to address")
 	}
+	if err := starttls.Negotiate(ctx, conn, starttls.Protocol(c.options, port), starttls.ServerName(c.options, hostname)); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not negotiate starttls")
+	}
+	var captureConn *clients.CaptureConn
+	if c.options.CaptureHello || c.options.MatchFingerprint {
+		captureConn = clients.NewCaptureConn(conn)
+		conn = captureConn
+	}
 	var resolvedIP string
 	if !iputil.IsIP(hostname) {
 		resolvedIP = c.dialer.GetDialedIP(hostname)
@@ -132,9 +167,24 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 	config := c.tlsConfig
 	if config.ServerName == "" {
 		c := config.Clone()
-		c.ServerName = hostname
+		if iputil.IsIP(hostname) {
+			// using a random sni will return the default server certificate
+			c.ServerName = xid.New().String()
+		} else {
+			c.ServerName = hostname
+		}
 		config = c
 	}
+	if len(c.options.ALPN) == 0 {
+		if protocols := broker.ALPN(port); len(protocols) > 0 {
+			config = config.Clone()
+			config.NextProtos = protocols
+		}
+	}
+	// zcrypto does not copy the sct extension when cloning configs
+	if config != c.tlsConfig {
+		config.SignedCertificateTimestampExt = c.tlsConfig.SignedCertificateTimestampExt
+	}
 
 	tlsConn := tls.Client(conn, config)
 	if timeout == 0 {
@@ -155,54 +205,34 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 	defer tlsConn.Close()
 
 	hl := tlsConn.GetHandshakeLog()
+	if hl.ServerCertificates == nil || len(hl.ServerCertificates.Certificate.Raw) == 0 {
+		return nil, errors.New("no certificates returned by server")
+	}
 
-	tlsVersion := versionToTLSVersionString[uint16(hl.ServerHello.Version)]
-	tlsCipher := hl.ServerHello.CipherSuite.String()
+	handshake := NewHandshake(hl, tlsConn.OCSPResponse())
+	handshake.Host, handshake.IP, handshake.Port = hostname, resolvedIP, port
+	handshake.Capture = captureConn
+	return clients.NewResponse(c.options, handshake)
+}
 
-	response := &clients.Response{
-		Timestamp:           time.Now(),
-		Host:                hostname,
-		IP:                  resolvedIP,
-		Port:                port,
-		Version:             tlsVersion,
-		Cipher:              tlsCipher,
-		TLSConnection:       "ztls",
-		CertificateResponse: convertCertificateToResponse(parseSimpleTLSCertificate(hl.ServerCertificates.Certificate)),
-	}
-	if c.options.TLSChain {
+// NewHandshake returns the handshake of a handshake log and the stapled
+// ocsp response of a connection
+func NewHandshake(hl *tls.ServerHandshake, ocspStaple []byte) *clients.Handshake {
+	handshake := &clients.Handshake{
+		Version:       versionToTLSVersionString[uint16(hl.ServerHello.Version)],
+		Cipher:        hl.ServerHello.CipherSuite.String(),
+		ALPN:          hl.ServerHello.AlpnProtocol,
+		TLSConnection: "ztls",
+		OCSPStaple:    ocspStaple,
+	}
+	if hl.ServerCertificates != nil {
+		handshake.RawChain = [][]byte{hl.ServerCertificates.Certificate.Raw}
 		for _, cert := range hl.ServerCertificates.Chain {
-			response.Chain = append(response.Chain, convertCertificateToResponse(parseSimpleTLSCertificate(cert)))
+			handshake.RawChain = append(handshake.RawChain, cert.Raw)
 		}
 	}
-	return response, nil
-}
-
-func parseSimpleTLSCertificate(cert tls.SimpleCertificate) *x509.Certificate {
-	parsed, _ := x509.ParseCertificate(cert.Raw)
-	return parsed
-}
-
-func convertCertificateToResponse(cert *x509.Certificate) clients.CertificateResponse {
-	if cert == nil {
-		return clients.CertificateResponse{}
-	}
-	return clients.CertificateResponse{
-		SubjectAN:  cert.DNSNames,
-		Emails:     cert.EmailAddresses,
-		NotBefore:  cert.NotAfter,
-		NotAfter:   cert.NotAfter,
-		Expired:    clients.IsExpired(cert.NotAfter),
-		SelfSigned: clients.IsSelfSigned(cert.AuthorityKeyId, cert.SubjectKeyId),
-		IssuerDN:   cert.Issuer.String(),
-		IssuerCN:   cert.Issuer.CommonName,
-		IssuerOrg:  cert.Issuer.Organization,
-		SubjectDN:  cert.Subject.String(),
-		SubjectCN:  cert.Subject.CommonName,
-		SubjectOrg: cert.Subject.Organization,
-		FingerprintHash: clients.CertificateResponseFingerprintHash{
-			MD5:    clients.MD5Fingerprint(cert.Raw),
-			SHA1:   clients.SHA1Fingerprint(cert.Raw),
-			SHA256: clients.SHA256Fingerprint(cert.Raw),
-		},
+	for _, sct := range hl.ServerHello.SignedCertificateTimestamps {
+		handshake.SCTs = append(handshake.SCTs, sct.Raw)
 	}
+	return handshake
 }